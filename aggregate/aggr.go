@@ -49,7 +49,7 @@ func (ac *AggregatorConfigure) PickPoints(category string, pts []*point.Point) m
 
 // SelectPoints filters points based on the rule's selector criteria.
 func (ar *AggregateRule) SelectPoints(pts []*point.Point) []*point.Point {
-	return ar.Selector.doSelect(ar.Groupby, pts)
+	return ar.Selector.doSelect(ar.Groupby, ar.carryFields, pts)
 }
 
 // GroupbyPoints groups points by their hash value calculated from grouping keys.
@@ -73,6 +73,10 @@ func (ar *AggregateRule) GroupbyBatch(ac *AggregatorConfigure, pts []*point.Poin
 			ConfigHash:      ac.hash,
 			PickKey:         pickKey,
 			AggregationOpts: ar.aggregationOpts,
+			Rule:            ar.Name,
+			EventTime:       ar.EventTime,
+			Window:          ar.Window,
+			Expressions:     ar.Expressions,
 			Points:          &point.PBPoints{Arr: []*point.PBPoint{pt.PBPoint()}},
 		}
 		batches = append(batches, b)
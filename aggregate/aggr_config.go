@@ -3,6 +3,7 @@ package aggregate
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -72,14 +73,34 @@ func (ac *AggregatorConfigure) doHash() {
 	}
 }
 
-func (ac *AggregatorConfigure) hashView() any {
-	type aggregateRuleView struct {
-		Name       string                            `json:"name"`
-		Selector   *RuleSelector                     `json:"select"`
-		Groupby    []string                          `json:"group_by"`
-		Algorithms map[string]*AggregationAlgoConfig `json:"algorithms"`
+// aggregateRuleView is the part of AggregateRule hashed.
+type aggregateRuleView struct {
+	Name        string                            `json:"name"`
+	Selector    *RuleSelector                     `json:"select"`
+	Groupby     []string                          `json:"group_by"`
+	Algorithms  map[string]*AggregationAlgoConfig `json:"algorithms"`
+	EventTime   *EventTimeOptions                 `json:"event_time,omitempty"`
+	Window      *WindowOptions                    `json:"window,omitempty"`
+	Expressions []*Expression                     `json:"expressions,omitempty"`
+}
+
+func newAggregateRuleView(rule *AggregateRule) *aggregateRuleView {
+	if rule == nil {
+		return nil
+	}
+
+	return &aggregateRuleView{
+		Name:        rule.Name,
+		Selector:    rule.Selector,
+		Groupby:     rule.Groupby,
+		Algorithms:  rule.Algorithms,
+		EventTime:   rule.EventTime,
+		Window:      rule.Window,
+		Expressions: rule.Expressions,
 	}
+}
 
+func (ac *AggregatorConfigure) hashView() any {
 	type aggregatorConfigureView struct {
 		DefaultWindow    time.Duration        `json:"default_window"`
 		AggregateRules   []*aggregateRuleView `json:"aggregate_rules"`
@@ -94,22 +115,25 @@ func (ac *AggregatorConfigure) hashView() any {
 	}
 
 	for _, rule := range ac.AggregateRules {
-		if rule == nil {
-			view.AggregateRules = append(view.AggregateRules, nil)
-			continue
-		}
-
-		view.AggregateRules = append(view.AggregateRules, &aggregateRuleView{
-			Name:       rule.Name,
-			Selector:   rule.Selector,
-			Groupby:    rule.Groupby,
-			Algorithms: rule.Algorithms,
-		})
+		view.AggregateRules = append(view.AggregateRules, newAggregateRuleView(rule))
 	}
 
 	return view
 }
 
+// doHash hash the rule with the default window, which is the window of
+// algorithms not configured.
+func (ar *AggregateRule) doHash(defaultWindow time.Duration) {
+	view := struct {
+		DefaultWindow time.Duration      `json:"default_window"`
+		Rule          *aggregateRuleView `json:"rule"`
+	}{defaultWindow, newAggregateRuleView(ar)}
+
+	if j, err := json.Marshal(view); err == nil {
+		ar.hash = xxhash.Sum64(j)
+	}
+}
+
 // AggregateRule configured a specific aggregate rule.
 type AggregateRule struct {
 	Name       string                            `toml:"name" json:"name"`
@@ -117,7 +141,22 @@ type AggregateRule struct {
 	Groupby    []string                          `toml:"group_by" json:"group_by"`
 	Algorithms map[string]*AggregationAlgoConfig `toml:"algorithms" json:"algorithms"`
 
+	// EventTime enable event-time windowing on the rule, see windows_event_time.go.
+	EventTime *EventTimeOptions `toml:"event_time" json:"event_time"`
+
+	// Window is the window spec of the rule, see windows_sliding.go.
+	Window *WindowOptions `toml:"window" json:"window"`
+
+	// Expressions computed over outputs of the rule, see aggr_expression.go.
+	Expressions []*Expression `toml:"expressions" json:"expressions"`
+
 	aggregationOpts map[string]*AggregationAlgo
+
+	// carryFields are fields attached to the selected points of source
+	// field, such as the weight field of topk.
+	carryFields map[string][]string
+
+	hash uint64
 }
 
 type AggregationAlgoConfig struct {
@@ -128,6 +167,45 @@ type AggregationAlgoConfig struct {
 	HistogramOpts *HistogramOptions     `toml:"histogram_opts" json:"histogram_opts"`
 	ExpoOpts      *ExpoHistogramOptions `toml:"expo_opts" json:"expo_opts"`
 	QuantileOpts  *QuantileOptions      `toml:"quantile_opts" json:"quantile_opts"`
+	TopKOpts      *TopKOptions          `toml:"topk_opts" json:"topk_opts"`
+
+	DistinctValuesOpts *DistinctValuesOptions `toml:"distinct_values_opts" json:"distinct_values_opts"`
+}
+
+// UnmarshalJSON decode expo_opts of the configure. It records whether
+// max_scale is configured, so max_scale = 0 means scale 0 rather than the
+// default, and takes record_min_max = false as disable_min_max = true.
+func (m *ExpoHistogramOptions) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		MaxScale      *int32 `json:"max_scale"`
+		MaxBuckets    int32  `json:"max_buckets"`
+		RecordMinMax  *bool  `json:"record_min_max"`
+		DisableMinMax bool   `json:"disable_min_max"`
+		HasMaxScale   bool   `json:"has_max_scale"`
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*m = ExpoHistogramOptions{
+		MaxBuckets:    raw.MaxBuckets,
+		DisableMinMax: raw.DisableMinMax,
+		HasMaxScale:   raw.HasMaxScale || raw.MaxScale != nil,
+	}
+
+	if raw.MaxScale != nil {
+		m.MaxScale = *raw.MaxScale
+	}
+
+	if raw.RecordMinMax != nil {
+		m.RecordMinMax = *raw.RecordMinMax
+		if !m.RecordMinMax {
+			m.DisableMinMax = true
+		}
+	}
+
+	return nil
 }
 
 func (cfg *AggregationAlgoConfig) ToAggregationAlgo() *AggregationAlgo {
@@ -155,6 +233,10 @@ func (cfg *AggregationAlgoConfig) ToAggregationAlgo() *AggregationAlgo {
 		algo.Options = &AggregationAlgo_ExpoOpts{ExpoOpts: cfg.ExpoOpts}
 	case cfg.QuantileOpts != nil:
 		algo.Options = &AggregationAlgo_QuantileOpts{QuantileOpts: cfg.QuantileOpts}
+	case cfg.TopKOpts != nil:
+		algo.Options = &AggregationAlgo_TopkOpts{TopkOpts: cfg.TopKOpts}
+	case cfg.DistinctValuesOpts != nil:
+		algo.Options = &AggregationAlgo_DistinctValuesOpts{DistinctValuesOpts: cfg.DistinctValuesOpts}
 	}
 
 	return algo
@@ -178,6 +260,12 @@ func (ac *AggregatorConfigure) Setup() error {
 		if err := ar.Selector.Setup(); err != nil {
 			return err
 		}
+		if err := validateEventTime(ar.EventTime); err != nil {
+			return fmt.Errorf("aggregate rule %q: %w", ar.Name, err)
+		}
+		if err := ar.Window.setup(); err != nil {
+			return fmt.Errorf("aggregate rule %q: %w", ar.Name, err)
+		}
 
 		algorithms, err := ar.setupAlgorithms()
 		if err != nil {
@@ -191,10 +279,18 @@ func (ac *AggregatorConfigure) Setup() error {
 			if algo.Window <= 10 {
 				algo.Window = int64(ac.DefaultWindow)
 			}
+			if x := ar.Window.paneSize(); x > 0 {
+				algo.Window = x
+			}
+		}
+		if err := ar.setupExpressions(algorithms); err != nil {
+			return fmt.Errorf("aggregate rule %q: %w", ar.Name, err)
 		}
 		ar.aggregationOpts = algorithms
+		ar.carryFields = carryFields(algorithms)
 
 		sort.Strings(ar.Groupby)
+		ar.doHash(ac.DefaultWindow)
 	}
 
 	ac.doHash()
@@ -213,9 +309,7 @@ func validateAggregationAlgo(key string, algo *AggregationAlgo) error {
 
 	method := NormalizeAlgoMethod(algo.Method)
 	switch method {
-	case SUM, AVG, COUNT, MIN, MAX, HISTOGRAM, STDEV, QUANTILES, COUNT_DISTINCT, LAST, FIRST:
-	case EXPO_HISTOGRAM:
-		return fmt.Errorf("algorithm %q: method %q is not supported", key, algo.Method)
+	case SUM, AVG, COUNT, MIN, MAX, HISTOGRAM, EXPO_HISTOGRAM, STDEV, QUANTILES, COUNT_DISTINCT, LAST, FIRST, MODE, DISTINCT_VALUES, RATE, INCREASE, IRATE, TOPK:
 	case METHOD_UNSPECIFIED:
 		return fmt.Errorf("algorithm %q missing method", key)
 	default:
@@ -232,11 +326,82 @@ func validateAggregationAlgo(key string, algo *AggregationAlgo) error {
 				return fmt.Errorf("algorithm %q: percentile %v out of range [0,1]", key, percentile)
 			}
 		}
+		if x := opt.QuantileOpts.RelativeAccuracy; x < 0 || x >= 1 {
+			return fmt.Errorf("algorithm %q: relative_accuracy %v should be 0(default %v) or within (0,1)", key, x, quantileDefaultRelativeAccuracy)
+		}
+		if x := opt.QuantileOpts.MaxBins; x == 1 {
+			return fmt.Errorf("algorithm %q: max_bins %d should be 0(default %d) or at least 2", key, x, quantileDefaultMaxBins)
+		}
+	}
+
+	if method == TOPK {
+		if opt, ok := algo.Options.(*AggregationAlgo_TopkOpts); ok && opt != nil && opt.TopkOpts != nil {
+			if x := opt.TopkOpts.K; x < 0 {
+				return fmt.Errorf("algorithm %q: k %d should not be negative", key, x)
+			}
+			if k, x := topkCapacity(opt.TopkOpts); opt.TopkOpts.Capacity != 0 && x < k {
+				return fmt.Errorf("algorithm %q: capacity %d should be 0(default) or at least k(%d)", key, opt.TopkOpts.Capacity, k)
+			}
+		}
+	}
+
+	if method == DISTINCT_VALUES {
+		if opt, ok := algo.Options.(*AggregationAlgo_DistinctValuesOpts); ok && opt != nil && opt.DistinctValuesOpts != nil {
+			if x := opt.DistinctValuesOpts.K; x < 0 || x > topValuesCapacity {
+				return fmt.Errorf("algorithm %q: k %d should be 0(default %d) or within [1,%d]", key, x, distinctValuesDefaultK, topValuesCapacity)
+			}
+		}
+	}
+
+	if method == EXPO_HISTOGRAM {
+		if opt, ok := algo.Options.(*AggregationAlgo_ExpoOpts); ok && opt != nil && opt.ExpoOpts != nil {
+			if x := opt.ExpoOpts.MaxScale; x < expoMinScale || x > expoMaxScale {
+				return fmt.Errorf("algorithm %q: max_scale %d out of range [%d,%d]", key, x, expoMinScale, expoMaxScale)
+			}
+			if x := opt.ExpoOpts.MaxBuckets; x < 0 || x == 1 {
+				return fmt.Errorf("algorithm %q: max_buckets %d should be 0(default %d) or at least 2", key, x, expoDefaultMaxBuckets)
+			}
+		}
 	}
 
 	return nil
 }
 
+// carryFields return fields required by algorithms besides the source field,
+// keyed by the source field.
+func carryFields(algorithms map[string]*AggregationAlgo) map[string][]string {
+	res := map[string][]string{}
+	for key, algo := range algorithms {
+		src := key
+		if f := algo.GetSourceField(); f != "" {
+			src = f
+		}
+
+		if opt, ok := algo.GetOptions().(*AggregationAlgo_TopkOpts); ok {
+			if f := opt.TopkOpts.GetWeightField(); f != "" {
+				res[src] = append(res[src], f)
+			}
+		}
+
+		// counters of rate/increase/irate told apart by hash of all tags.
+		switch NormalizeAlgoMethod(algo.GetMethod()) {
+		case RATE, INCREASE, IRATE:
+			if !slices.Contains(res[src], seriesHashField) {
+				res[src] = append(res[src], seriesHashField)
+			}
+		}
+	}
+
+	if len(res) == 0 {
+		return nil
+	}
+
+	for _, arr := range res {
+		sort.Strings(arr)
+	}
+	return res
+}
+
 func (ar *AggregateRule) setupAlgorithms() (map[string]*AggregationAlgo, error) {
 	if len(ar.Algorithms) == 0 {
 		return nil, nil
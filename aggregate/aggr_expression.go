@@ -0,0 +1,338 @@
+package aggregate
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/GuanceCloud/cliutils/point"
+)
+
+// Expressions of a rule computed over outputs of its algorithms: calculators
+// of the same group(rule, measurement and tags of the selected point) within
+// the window are joined when the window emitted(WindowsToData), and the
+// computed fields output as an extra point of the group.
+
+const (
+	// on_div_zero policies.
+	DivZeroSkip = "skip"
+	DivZeroZero = "zero"
+)
+
+var (
+	errDivZero     = errors.New("divide by zero")
+	errFieldAbsent = errors.New("field absent or not numeric")
+
+	// expr -> compiled AST.
+	compiledExprs sync.Map
+)
+
+// compileExpression parse expr and check it only got numbers, field names,
+// parentheses and + - * /, field names referred returned.
+func compileExpression(expr string) (ast.Expr, []string, error) {
+	e, err := parser.ParseExpr(expr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid expression %q: %w", expr, err)
+	}
+
+	var (
+		idents []string
+		check  func(ast.Expr) error
+	)
+
+	check = func(e ast.Expr) error {
+		switch x := e.(type) {
+		case *ast.Ident:
+			idents = append(idents, x.Name)
+			return nil
+
+		case *ast.BasicLit:
+			if x.Kind != token.INT && x.Kind != token.FLOAT {
+				return fmt.Errorf("literal %s not number", x.Value)
+			}
+			_, err := strconv.ParseFloat(x.Value, 64)
+			return err
+
+		case *ast.ParenExpr:
+			return check(x.X)
+
+		case *ast.UnaryExpr:
+			if x.Op != token.ADD && x.Op != token.SUB {
+				return fmt.Errorf("operator %s not supported", x.Op)
+			}
+			return check(x.X)
+
+		case *ast.BinaryExpr:
+			switch x.Op {
+			case token.ADD, token.SUB, token.MUL, token.QUO:
+			default:
+				return fmt.Errorf("operator %s not supported", x.Op)
+			}
+
+			if err := check(x.X); err != nil {
+				return err
+			}
+			return check(x.Y)
+
+		default:
+			return fmt.Errorf("%T not supported", e)
+		}
+	}
+
+	if err := check(e); err != nil {
+		return nil, nil, fmt.Errorf("invalid expression %q: %w", expr, err)
+	}
+
+	return e, idents, nil
+}
+
+// compiledExpression is compileExpression with compiled AST cached.
+func compiledExpression(expr string) (ast.Expr, error) {
+	if x, ok := compiledExprs.Load(expr); ok {
+		return x.(ast.Expr), nil
+	}
+
+	e, _, err := compileExpression(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	compiledExprs.Store(expr, e)
+	return e, nil
+}
+
+// evalExpression evaluate e on numeric fields.
+func evalExpression(e ast.Expr, fields map[string]float64) (float64, error) {
+	switch x := e.(type) {
+	case *ast.Ident:
+		if v, ok := fields[x.Name]; ok {
+			return v, nil
+		}
+		return 0, fmt.Errorf("%w: %s", errFieldAbsent, x.Name)
+
+	case *ast.BasicLit:
+		return strconv.ParseFloat(x.Value, 64)
+
+	case *ast.ParenExpr:
+		return evalExpression(x.X, fields)
+
+	case *ast.UnaryExpr:
+		v, err := evalExpression(x.X, fields)
+		if err != nil {
+			return 0, err
+		}
+		if x.Op == token.SUB {
+			return -v, nil
+		}
+		return v, nil
+
+	case *ast.BinaryExpr:
+		a, err := evalExpression(x.X, fields)
+		if err != nil {
+			return 0, err
+		}
+
+		b, err := evalExpression(x.Y, fields)
+		if err != nil {
+			return 0, err
+		}
+
+		switch x.Op {
+		case token.ADD:
+			return a + b, nil
+		case token.SUB:
+			return a - b, nil
+		case token.MUL:
+			return a * b, nil
+		case token.QUO:
+			if b == 0 {
+				return 0, errDivZero
+			}
+			return a / b, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%T not supported", e)
+}
+
+// numericOutputs return output fields of the algorithm usable within
+// expressions. Fields of string-able methods(first/last/mode) checked on
+// evaluation.
+func numericOutputs(key string, algo *AggregationAlgo) []string {
+	switch method := NormalizeAlgoMethod(algo.Method); method {
+	case SUM, AVG, COUNT, MIN, MAX, STDEV, COUNT_DISTINCT, FIRST, LAST, RATE, INCREASE, IRATE:
+		return []string{key, key + "_count"}
+
+	case MODE:
+		return []string{key, key + "_frequency", key + "_count"}
+
+	case DISTINCT_VALUES:
+		return []string{key + "_count"}
+
+	case QUANTILES:
+		res := []string{key + "_count"}
+		if opt, ok := algo.Options.(*AggregationAlgo_QuantileOpts); ok {
+			for _, q := range opt.QuantileOpts.GetPercentiles() {
+				res = append(res, fmt.Sprintf("%s_P%.0f", key, q*100))
+			}
+		}
+		return res
+
+	case EXPO_HISTOGRAM:
+		return []string{key + expoSuffixCount, key + expoSuffixSum, key + expoSuffixAvg, key + expoSuffixMin, key + expoSuffixMax}
+
+	default: // histogram/topk got multiple points
+		return nil
+	}
+}
+
+// setupExpressions check expressions of the rule on its algorithms.
+func (ar *AggregateRule) setupExpressions(algorithms map[string]*AggregationAlgo) error {
+	outputs := map[string]bool{}
+	for key, algo := range algorithms {
+		for _, f := range numericOutputs(key, algo) {
+			outputs[f] = true
+		}
+	}
+
+	names := map[string]bool{}
+	for _, e := range ar.Expressions {
+		if e == nil || e.Name == "" {
+			return fmt.Errorf("expression missing name")
+		}
+
+		if names[e.Name] || outputs[e.Name] {
+			return fmt.Errorf("expression %q: name conflict with other expression or algorithm output", e.Name)
+		}
+		names[e.Name] = true
+
+		switch e.OnDivZero {
+		case "", DivZeroSkip, DivZeroZero:
+		default:
+			return fmt.Errorf("expression %q: invalid on_div_zero %q", e.Name, e.OnDivZero)
+		}
+
+		_, idents, err := compileExpression(e.Expr)
+		if err != nil {
+			return fmt.Errorf("expression %q: %w", e.Name, err)
+		}
+
+		for _, ident := range idents {
+			if !outputs[ident] {
+				return fmt.Errorf("expression %q: %q is not a numeric output of algorithms", e.Name, ident)
+			}
+		}
+	}
+
+	return nil
+}
+
+// exprGroup is outputs of calculators within the same group.
+type exprGroup struct {
+	exprs   []*Expression
+	name    string
+	tags    map[string]string
+	fields  map[string]float64
+	maxTime int64
+}
+
+func (g *exprGroup) add(pts []*point.Point) {
+	for _, pt := range pts {
+		if g.tags == nil {
+			g.tags = pt.MapTags()
+		} else { // only tags shared by all outputs kept
+			for k, v := range g.tags {
+				if pt.GetTag(k) != v {
+					delete(g.tags, k)
+				}
+			}
+		}
+
+		for _, kv := range pt.Fields() {
+			switch v := kv.Raw().(type) {
+			case float64:
+				g.fields[kv.Key] = v
+			case int64:
+				g.fields[kv.Key] = float64(v)
+			case uint64:
+				g.fields[kv.Key] = float64(v)
+			}
+		}
+
+		if t := pt.Time().UnixNano(); t > g.maxTime {
+			g.maxTime = t
+		}
+	}
+}
+
+// point evaluate the expressions, nil if none of them computed.
+func (g *exprGroup) point() *point.Point {
+	var kvs point.KVs
+
+	for _, e := range g.exprs {
+		x, err := compiledExpression(e.Expr)
+		if err != nil {
+			l.Warnf("expression %q: %s", e.Name, err)
+			continue
+		}
+
+		v, err := evalExpression(x, g.fields)
+		switch {
+		case err == nil:
+			kvs = kvs.Add(e.Name, v)
+		case errors.Is(err, errDivZero) && e.OnDivZero == DivZeroZero:
+			kvs = kvs.Add(e.Name, 0.0)
+		default:
+			l.Debugf("expression %q skipped: %s", e.Name, err)
+		}
+	}
+
+	if len(kvs) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(g.tags))
+	for k := range g.tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		kvs = kvs.SetTag(k, g.tags[k])
+	}
+
+	return point.NewPoint(g.name, kvs, point.WithTimestamp(g.maxTime))
+}
+
+// exprGroups collect outputs of calculators with expressions.
+type exprGroups map[uint64]*exprGroup
+
+func (gs exprGroups) add(calc Calculator, pts []*point.Point) {
+	mb := calc.Base()
+	if len(mb.exprs) == 0 || len(pts) == 0 {
+		return
+	}
+
+	key := HashCombine(mb.group, uint64(mb.nextWallTime))
+	g, ok := gs[key]
+	if !ok {
+		g = &exprGroup{exprs: mb.exprs, name: mb.name, fields: map[string]float64{}}
+		gs[key] = g
+	}
+
+	g.add(pts)
+}
+
+func (gs exprGroups) points() (res []*point.Point) {
+	for _, g := range gs {
+		if pt := g.point(); pt != nil {
+			res = append(res, pt)
+		}
+	}
+	return res
+}
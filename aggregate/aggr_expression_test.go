@@ -0,0 +1,170 @@
+package aggregate
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exprTestConfig(exprs ...*Expression) *AggregatorConfigure {
+	return &AggregatorConfigure{
+		DefaultWindow: time.Hour,
+		AggregateRules: []*AggregateRule{
+			{
+				Name:     "slo",
+				Selector: &RuleSelector{Category: point.Metric.String(), MetricName: []string{"errors", "latency"}},
+				Groupby:  []string{"host"},
+				Algorithms: map[string]*AggregationAlgoConfig{
+					"errors": {Method: string(SUM), AddTags: map[string]string{"algo": "errors"}},
+					"latency": {
+						Method:       string(QUANTILES),
+						QuantileOpts: &QuantileOptions{Percentiles: []float64{0.5}},
+						AddTags:      map[string]string{"algo": "latency"},
+					},
+				},
+				Expressions: exprs,
+			},
+		},
+	}
+}
+
+func TestExpressionSetup(t *testing.T) {
+	require.NoError(t, exprTestConfig(
+		&Expression{Name: "error_rate", Expr: "errors / latency_count"},
+		&Expression{Name: "x", Expr: "-(errors + 1.5) * 2 - latency_P50", OnDivZero: DivZeroZero},
+	).Setup())
+
+	for _, e := range []*Expression{
+		{Expr: "errors"},
+		{Name: "errors", Expr: "errors_count"},
+		{Name: "x", Expr: "unknown / errors"},
+		{Name: "x", Expr: "latency / errors"},
+		{Name: "x", Expr: "errors % 2"},
+		{Name: "x", Expr: "max(errors, 1)"},
+		{Name: "x", Expr: `errors + "1"`},
+		{Name: "x", Expr: "errors > 1"},
+		{Name: "x", Expr: "errors +"},
+		{Name: "x", Expr: "errors", OnDivZero: "bad"},
+	} {
+		assert.Error(t, exprTestConfig(e).Setup(), "%+#v", e)
+	}
+
+	assert.Error(t, exprTestConfig(
+		&Expression{Name: "x", Expr: "errors"},
+		&Expression{Name: "x", Expr: "errors_count"},
+	).Setup())
+
+	var cfg AggregatorConfigure
+	require.NoError(t, cfg.UnmarshalTOML(map[string]any{
+		"aggregate_rules": []any{
+			map[string]any{
+				"name":        "rule",
+				"expressions": []any{map[string]any{"name": "error_rate", "expr": "errors / total", "on_div_zero": "zero"}},
+			},
+		},
+	}))
+	assert.Equal(t, []*Expression{{Name: "error_rate", Expr: "errors / total", OnDivZero: DivZeroZero}},
+		cfg.AggregateRules[0].Expressions)
+}
+
+func exprTestCache(t *testing.T, cfg *AggregatorConfigure, now time.Time) *Cache {
+	t.Helper()
+
+	require.NoError(t, cfg.Setup())
+
+	var pts []*point.Point
+	for _, x := range []struct {
+		host    string
+		errors  float64
+		latency float64
+	}{
+		{"web-1", 1, 10},
+		{"web-1", 0, 20},
+		{"web-1", 1, 30},
+		{"web-1", 0, 40},
+		{"web-2", 0, 50},
+	} {
+		pts = append(pts, point.NewPoint("request",
+			point.NewKVs(map[string]any{"errors": x.errors, "latency": x.latency}).AddTag("host", x.host),
+			point.WithTime(now)))
+	}
+
+	ar := cfg.AggregateRules[0]
+	c := NewCache(time.Hour)
+	for _, b := range ar.GroupbyBatch(cfg, ar.SelectPoints(pts)) {
+		c.AddBatch("token-a", b)
+	}
+	return c
+}
+
+// exprPoints flush all windows and return points with field name.
+func exprPoints(c *Cache, name string) map[string]*point.Point {
+	var windows []*Window
+	for _, ws := range c.WindowsBuckets {
+		windows = append(windows, ws.Close()...)
+	}
+
+	res := map[string]*point.Point{}
+	for _, pd := range WindowsToData(windows) {
+		for _, pt := range pd.PTS {
+			if pt.Get(name) != nil {
+				res[pt.GetTag("host")] = pt
+			}
+		}
+	}
+	return res
+}
+
+func TestExpressionOutput(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	t.Run("basic", func(t *testing.T) {
+		cfg := exprTestConfig(&Expression{Name: "error_rate", Expr: "errors / latency_count"})
+		pts := exprPoints(exprTestCache(t, cfg, now), "error_rate")
+		require.Len(t, pts, 2)
+
+		assert.Equal(t, 0.5, pts["web-1"].Get("error_rate"))
+		assert.Equal(t, 0.0, pts["web-2"].Get("error_rate"))
+
+		pt := pts["web-1"]
+		assert.Equal(t, "request", pt.Name())
+		assert.Empty(t, pt.GetTag("algo"), "tags differ among algorithms dropped")
+		assert.Equal(t, now.UnixNano(), pt.Time().UnixNano())
+		assert.Len(t, pt.Fields(), 1)
+	})
+
+	t.Run("div-zero", func(t *testing.T) {
+		cfg := exprTestConfig(
+			&Expression{Name: "skipped", Expr: "latency_count / errors"},
+			&Expression{Name: "zero", Expr: "latency_count / errors", OnDivZero: DivZeroZero},
+		)
+
+		c := exprTestCache(t, cfg, now)
+		pts := exprPoints(c, "zero")
+		require.Len(t, pts, 2)
+
+		assert.Equal(t, 2.0, pts["web-1"].Get("skipped"))
+		assert.Equal(t, 2.0, pts["web-1"].Get("zero"))
+
+		assert.Nil(t, pts["web-2"].Get("skipped"))
+		assert.Equal(t, 0.0, pts["web-2"].Get("zero"))
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		cfg := exprTestConfig(&Expression{Name: "error_rate", Expr: "errors / latency_count"})
+
+		var buf bytes.Buffer
+		require.NoError(t, exprTestCache(t, cfg, now).Snapshot(&buf))
+
+		restored, _, err := RestoreCache(&buf)
+		require.NoError(t, err)
+
+		pts := exprPoints(restored, "error_rate")
+		require.Len(t, pts, 2)
+		assert.Equal(t, 0.5, pts["web-1"].Get("error_rate"))
+	})
+}
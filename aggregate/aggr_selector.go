@@ -2,6 +2,8 @@ package aggregate
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/GuanceCloud/cliutils"
 	fp "github.com/GuanceCloud/cliutils/filter"
@@ -15,11 +17,91 @@ type RuleSelector struct {
 	MetricName   []string `toml:"metric_name" json:"metric_name"`
 	Condition    string   `toml:"condition" json:"condition"`
 
+	// MetricNameAs rename fields matched by regex or glob metric_name, so
+	// algorithms can refer to them, named capture groups of the regex can be
+	// referred as ${name}.
+	MetricNameAs string `toml:"metric_name_as" json:"metric_name_as,omitempty"`
+
 	measurementsWhitelist []*cliutils.WhiteListItem
 	fieldsWhitelist       []*cliutils.WhiteListItem
+	fieldsRegexp          []*regexp.Regexp // regexp of fieldsWhitelist, nil for exact names
 	conds                 fp.WhereConditions
 }
 
+// selectorItem build whitelist item of measurements/metric_name, pattern
+// prefixed with reg: is regex, pattern with * or ? is glob, others exact name.
+func selectorItem(pattern string) (*cliutils.WhiteListItem, *regexp.Regexp, error) {
+	pattern = strings.TrimSpace(pattern)
+
+	switch {
+	case strings.HasPrefix(pattern, "reg:"):
+		re, err := regexp.Compile(strings.TrimPrefix(pattern, "reg:"))
+		if err != nil {
+			return nil, nil, err
+		}
+		return cliutils.NewWhiteListItem(pattern), re, nil
+
+	case strings.ContainsAny(pattern, "*?"):
+		expr := globRegexp(pattern)
+		return cliutils.NewWhiteListItem("reg:" + expr), regexp.MustCompile(expr), nil
+
+	default:
+		return cliutils.NewWhiteListItem(pattern), nil, nil
+	}
+}
+
+// globRegexp convert glob to anchored regex, * match any characters and ?
+// match a single character.
+func globRegexp(glob string) string {
+	var sb strings.Builder
+	sb.WriteByte('^')
+	for _, r := range glob {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteByte('.')
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteByte('$')
+	return sb.String()
+}
+
+// matchField match field name on metric_name, as is the name of the selected
+// field, captured are named capture groups of the matched regex.
+func (rs *RuleSelector) matchField(name string) (as string, captured [][2]string, ok bool) {
+	for i, item := range rs.fieldsWhitelist {
+		if !item.Match(name) {
+			continue
+		}
+
+		re := rs.fieldsRegexp[i]
+		if re == nil {
+			return name, nil, true
+		}
+
+		m := re.FindStringSubmatchIndex(name)
+		for j, group := range re.SubexpNames() {
+			if group != "" && m[2*j] >= 0 {
+				captured = append(captured, [2]string{group, name[m[2*j]:m[2*j+1]]})
+			}
+		}
+
+		as = name
+		if rs.MetricNameAs != "" {
+			if x := string(re.ExpandString(nil, rs.MetricNameAs, name, m)); x != "" {
+				as = x
+			}
+		}
+
+		return as, captured, true
+	}
+
+	return "", nil, false
+}
+
 // Setup initializes the rule selector with validation and prepares whitelists.
 func (rs *RuleSelector) Setup() error {
 	switch point.CatString(rs.Category) { // category required
@@ -54,22 +136,29 @@ func (rs *RuleSelector) Setup() error {
 		rs.conds = ast
 	}
 
-	if len(rs.Measurements) > 0 {
-		for _, m := range rs.Measurements {
-			rs.measurementsWhitelist = append(rs.measurementsWhitelist, cliutils.NewWhiteListItem(m))
+	rs.measurementsWhitelist = nil
+	for _, m := range rs.Measurements {
+		item, _, err := selectorItem(m)
+		if err != nil {
+			return fmt.Errorf("invalid measurement %q: %w", m, err)
 		}
+		rs.measurementsWhitelist = append(rs.measurementsWhitelist, item)
 	}
 
-	if len(rs.MetricName) > 0 {
-		for _, f := range rs.MetricName {
-			rs.fieldsWhitelist = append(rs.fieldsWhitelist, cliutils.NewWhiteListItem(f))
+	rs.fieldsWhitelist, rs.fieldsRegexp = nil, nil
+	for _, f := range rs.MetricName {
+		item, re, err := selectorItem(f)
+		if err != nil {
+			return fmt.Errorf("invalid metric_name %q: %w", f, err)
 		}
+		rs.fieldsWhitelist = append(rs.fieldsWhitelist, item)
+		rs.fieldsRegexp = append(rs.fieldsRegexp, re)
 	}
 
 	return nil
 }
 
-func (rs *RuleSelector) doSelect(groupby []string, pts []*point.Point) (res []*point.Point) {
+func (rs *RuleSelector) doSelect(groupby []string, carry map[string][]string, pts []*point.Point) (res []*point.Point) {
 	ptwrapper := &ptWrap{}
 
 	for _, pt := range pts {
@@ -88,7 +177,7 @@ func (rs *RuleSelector) doSelect(groupby []string, pts []*point.Point) (res []*p
 			}
 		}
 
-		forkedPts := rs.selectKVS(false, pt)
+		forkedPts := rs.selectKVS(false, pt, groupby)
 		if len(forkedPts) > 0 {
 			for _, tagKey := range groupby {
 				if v := pt.GetTag(tagKey); v != "" {
@@ -104,6 +193,26 @@ func (rs *RuleSelector) doSelect(groupby []string, pts []*point.Point) (res []*p
 				}
 			}
 
+			// carried fields appended after the selected field, so they are
+			// not hashed. Only points of the source field requiring them got
+			// them, or other algorithms on the carried field would aggregate
+			// it more than once. Series hash of rate/increase/irate computed
+			// on the source point with all its tags.
+			if len(carry) > 0 {
+				for _, fpt := range forkedPts {
+					for _, k := range carry[selectedField(fpt)] {
+						if k == seriesHashField {
+							fpt.Add(k, seriesHash(pt))
+							continue
+						}
+
+						if v := pt.Get(k); v != nil && fpt.Get(k) == nil {
+							fpt.Add(k, v)
+						}
+					}
+				}
+			}
+
 			res = append(res, forkedPts...)
 		}
 	}
@@ -111,37 +220,91 @@ func (rs *RuleSelector) doSelect(groupby []string, pts []*point.Point) (res []*p
 	return res
 }
 
-func (rs *RuleSelector) selectKVS(delKey bool, pt *point.Point) []*point.Point {
+// selectKVS fork pt into points with a single selected field, named capture
+// groups of metric_name within groupby attached as tags.
+func (rs *RuleSelector) selectKVS(delKey bool, pt *point.Point, groupby []string) []*point.Point {
 	var pts []*point.Point
 	if len(rs.fieldsWhitelist) > 0 {
+		newPoint := func(kvs point.KVs, captured [][2]string) *point.Point {
+			for _, c := range captured {
+				for _, k := range groupby {
+					if k == c[0] {
+						kvs = kvs.SetTag(c[0], c[1])
+						break
+					}
+				}
+			}
+			return point.NewPoint(pt.Name(), kvs, point.WithTime(pt.Time()))
+		}
+
+		// exponential histogram x selected by x, all fields of x forked within the same point.
+		expoKeys := map[string]bool{}
+		for _, base := range expoHistogramBases(pt) {
+			as, captured, ok := rs.matchField(base)
+			if !ok {
+				continue
+			}
+
+			var kvs point.KVs
+			for _, suffix := range expoHistogramSuffixes {
+				if kv := pt.KVs().Get(base + suffix); kv != nil && !kv.IsTag {
+					expoKeys[kv.Key] = true
+					kvs = kvs.Add(as+suffix, kv.Raw(), point.WithKVType(kv.Type))
+				}
+			}
+
+			if delKey {
+				for _, suffix := range expoHistogramSuffixes {
+					pt.Del(base + suffix)
+				}
+			}
+			pts = append(pts, newPoint(kvs, captured))
+		}
+
 		for _, kv := range pt.KVs() {
-			if !cliutils.WhiteListMatched(kv.Key, rs.fieldsWhitelist) {
+			if expoKeys[kv.Key] {
+				continue
+			}
+
+			// quantile sketch x_sketch and topk sketch x_topk selected by x
+			name := kv.Key
+			if base := quantileSketchBaseOf(pt, kv.Key); base != "" {
+				name = base
+			} else if base := topkSketchBaseOf(pt, kv.Key); base != "" {
+				name = base
+			}
+
+			as, captured, ok := rs.matchField(name)
+			if !ok {
 				continue
 			}
 
 			if kv.IsTag {
 				continue
 			}
+
+			key := as + strings.TrimPrefix(kv.Key, name)
+
 			var kvs point.KVs
 			switch v := kv.Val.(type) {
 			case *point.Field_F:
-				kvs = kvs.Add(kv.Key, v.F)
+				kvs = kvs.Add(key, v.F)
 			case *point.Field_I:
-				kvs = kvs.Add(kv.Key, float64(v.I))
+				kvs = kvs.Add(key, float64(v.I))
 			case *point.Field_U:
-				kvs = kvs.Add(kv.Key, float64(v.U))
+				kvs = kvs.Add(key, float64(v.U))
 			case *point.Field_S:
-				kvs = kvs.Add(kv.Key, v.S)
+				kvs = kvs.Add(key, v.S)
 			case *point.Field_D:
-				kvs = kvs.Add(kv.Key, v.D)
+				kvs = kvs.Add(key, v.D)
 			case *point.Field_B:
-				kvs = kvs.Add(kv.Key, v.B)
+				kvs = kvs.Add(key, v.B)
 			}
 			if len(kvs) > 0 {
 				if delKey {
 					pt.Del(kv.Key)
 				}
-				pts = append(pts, point.NewPoint(pt.Name(), kvs, point.WithTime(pt.Time())))
+				pts = append(pts, newPoint(kvs, captured))
 			}
 		}
 	}
@@ -0,0 +1,116 @@
+package aggregate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectorPatterns(t *testing.T) {
+	now := time.Now()
+	pts := []*point.Point{
+		point.NewPoint("nginx_access", point.NewKVs(map[string]any{"bytes": 1.0}), point.WithTime(now)),
+		point.NewPoint("nginx_error", point.NewKVs(map[string]any{"bytes": 2.0}), point.WithTime(now)),
+		point.NewPoint("apache", point.NewKVs(map[string]any{"bytes": 3.0}), point.WithTime(now)),
+	}
+
+	for _, x := range []struct {
+		measurements []string
+		expect       int
+	}{
+		{[]string{"nginx_*"}, 2},
+		{[]string{"nginx_????"}, 0},
+		{[]string{"nginx_?????"}, 1},
+		{[]string{"reg:^(apache|nginx_error)$"}, 2},
+		{[]string{"nginx.*"}, 0}, // not regex without reg:
+		{[]string{"apache", "nginx_access"}, 2},
+	} {
+		rs := &RuleSelector{Category: point.Metric.String(), Measurements: x.measurements, MetricName: []string{"byte?"}}
+		require.NoError(t, rs.Setup())
+		assert.Len(t, rs.doSelect(nil, nil, pts), x.expect, "%v", x.measurements)
+	}
+
+	for _, rs := range []*RuleSelector{
+		{Category: point.Metric.String(), Measurements: []string{"reg:("}},
+		{Category: point.Metric.String(), MetricName: []string{"reg:[a-"}},
+	} {
+		assert.Error(t, rs.Setup())
+	}
+}
+
+func TestSelectorCaptureGroups(t *testing.T) {
+	now := time.Now()
+	pt := point.NewPoint("api", point.NewKVs(map[string]any{
+		"latency_p99_login":  10.0,
+		"latency_p99_search": 20.0,
+		"latency_avg_login":  1.0,
+		"errors":             int64(3),
+	}).AddTag("host", "web-1"), point.WithTime(now))
+
+	t.Run("rename-and-tag", func(t *testing.T) {
+		rs := &RuleSelector{
+			Category:     point.Metric.String(),
+			MetricName:   []string{`reg:^latency_(?P<quantile>p\d+)_(?P<endpoint>\w+)$`, "errors"},
+			MetricNameAs: "latency_${quantile}",
+		}
+		require.NoError(t, rs.Setup())
+
+		res := map[string]*point.Point{}
+		for _, x := range rs.doSelect([]string{"endpoint", "host"}, nil, []*point.Point{pt}) {
+			res[x.GetTag("endpoint")+"/"+x.Fields()[0].Key] = x
+		}
+
+		require.Len(t, res, 3)
+		assert.Equal(t, 10.0, res["login/latency_p99"].Get("latency_p99"))
+		assert.Equal(t, 20.0, res["search/latency_p99"].Get("latency_p99"))
+		assert.Equal(t, "web-1", res["search/latency_p99"].GetTag("host"))
+		assert.Empty(t, res["login/latency_p99"].GetTag("quantile"), "capture not in group_by dropped")
+
+		// exact names not renamed
+		assert.Equal(t, 3.0, res["/errors"].Get("errors"))
+	})
+
+	t.Run("aggregate", func(t *testing.T) {
+		cfg := &AggregatorConfigure{
+			DefaultWindow: time.Hour,
+			AggregateRules: []*AggregateRule{
+				{
+					Name: "latency",
+					Selector: &RuleSelector{
+						Category:     point.Metric.String(),
+						MetricName:   []string{"reg:^latency_p99_(?P<endpoint>.+)$"},
+						MetricNameAs: "latency_p99",
+					},
+					Groupby:    []string{"endpoint"},
+					Algorithms: map[string]*AggregationAlgoConfig{"latency_p99": {Method: string(MAX)}},
+				},
+			},
+		}
+		require.NoError(t, cfg.Setup())
+
+		ar := cfg.AggregateRules[0]
+		c := NewCache(time.Hour)
+		for i := 0; i < 2; i++ {
+			for _, b := range ar.GroupbyBatch(cfg, ar.SelectPoints([]*point.Point{pt})) {
+				c.AddBatch("token-a", b)
+			}
+		}
+
+		var windows []*Window
+		for _, ws := range c.WindowsBuckets {
+			windows = append(windows, ws.Close()...)
+		}
+
+		res := map[string]any{}
+		for _, pd := range WindowsToData(windows) {
+			for _, x := range pd.PTS {
+				res[x.GetTag("endpoint")] = x.Get("latency_p99")
+			}
+		}
+
+		assert.Equal(t, map[string]any{"login": 10.0, "search": 20.0}, res)
+	})
+}
@@ -52,3 +52,47 @@ func TestAggregatorConfigureDecodeFile(t *testing.T) {
 
 	require.NoError(t, cfg.Setup())
 }
+
+func TestAggregatorConfigureDecodeExpoOpts(t *testing.T) {
+	doc := `
+default_window = "10s"
+
+[[aggregate_rules]]
+  name = "expo"
+  group_by = ["service"]
+
+  [aggregate_rules.select]
+  category = "metric"
+  metric_name = ["latency"]
+
+  [aggregate_rules.algorithms.latency]
+  method = "expo_histogram"
+
+    [aggregate_rules.algorithms.latency.expo_opts]
+    max_scale = 0
+    max_buckets = 8
+
+  [aggregate_rules.algorithms.latency_no_min_max]
+  method = "expo_histogram"
+  source_field = "latency"
+
+    [aggregate_rules.algorithms.latency_no_min_max.expo_opts]
+    record_min_max = false
+`
+
+	var cfg AggregatorConfigure
+	_, err := toml.Decode(doc, &cfg)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Setup())
+
+	algos := cfg.AggregateRules[0].Algorithms
+
+	c := newAlgoExpoHistogram(MetricBase{}, 0, algos["latency"].ExpoOpts)
+	require.Equal(t, int32(0), c.maxScale)
+	require.Equal(t, 8, c.maxBuckets)
+	require.True(t, c.recordMinMax)
+
+	c = newAlgoExpoHistogram(MetricBase{}, 0, algos["latency_no_min_max"].ExpoOpts)
+	require.Equal(t, int32(expoMaxScale), c.maxScale)
+	require.False(t, c.recordMinMax)
+}
@@ -9,12 +9,12 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func TestAggregatorConfigureSetupRejectsUnsupportedMethod(t *testing.T) {
+func TestAggregatorConfigureSetupRejectsInvalidExpoOpts(t *testing.T) {
 	cfg := &AggregatorConfigure{
 		DefaultWindow: time.Second * 10,
 		AggregateRules: []*AggregateRule{
 			{
-				Name:    "unsupported",
+				Name:    "invalid-expo",
 				Groupby: []string{"service"},
 				Selector: &RuleSelector{
 					Category:   point.Metric.String(),
@@ -24,6 +24,9 @@ func TestAggregatorConfigureSetupRejectsUnsupportedMethod(t *testing.T) {
 					"latency": {
 						Method:      string(EXPO_HISTOGRAM),
 						SourceField: "latency",
+						ExpoOpts: &ExpoHistogramOptions{
+							MaxScale: -11,
+						},
 					},
 				},
 			},
@@ -32,7 +35,10 @@ func TestAggregatorConfigureSetupRejectsUnsupportedMethod(t *testing.T) {
 
 	err := cfg.Setup()
 	require.Error(t, err)
-	require.ErrorContains(t, err, `method "expo_histogram" is not supported`)
+	require.ErrorContains(t, err, `max_scale -11 out of range [-10,20]`)
+
+	cfg.AggregateRules[0].Algorithms["latency"].ExpoOpts = &ExpoHistogramOptions{MaxScale: 8, MaxBuckets: 80}
+	require.NoError(t, cfg.Setup())
 }
 
 func TestAggregatorConfigureSetupRejectsInvalidQuantiles(t *testing.T) {
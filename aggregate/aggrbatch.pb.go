@@ -87,6 +87,14 @@ type AggregationBatch struct {
 	AggregationOpts map[string]*AggregationAlgo `protobuf:"bytes,4,rep,name=aggregation_opts,json=aggregationOpts,proto3" json:"aggregation_opts,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 	Points          *point.PBPoints             `protobuf:"bytes,5,opt,name=points,proto3" json:"points,omitempty"`
 	PickKey         uint64                      `protobuf:"varint,6,opt,name=pick_key,json=pickKey,proto3" json:"pick_key,omitempty"`
+	// name of the aggregate rule
+	Rule string `protobuf:"bytes,7,opt,name=rule,proto3" json:"rule,omitempty"`
+	// event-time windowing of the rule, windows closed by wall clock if not set.
+	EventTime *EventTimeOptions `protobuf:"bytes,8,opt,name=event_time,json=eventTime,proto3" json:"event_time,omitempty"`
+	// hopping/sliding window of the rule.
+	Window *WindowOptions `protobuf:"bytes,9,opt,name=window,proto3" json:"window,omitempty"`
+	// expressions computed on outputs of the rule.
+	Expressions []*Expression `protobuf:"bytes,10,rep,name=expressions,proto3" json:"expressions,omitempty"`
 }
 
 func (m *AggregationBatch) Reset()      { *m = AggregationBatch{} }
@@ -163,6 +171,222 @@ func (m *AggregationBatch) GetPickKey() uint64 {
 	return 0
 }
 
+func (m *AggregationBatch) GetRule() string {
+	if m != nil {
+		return m.Rule
+	}
+	return ""
+}
+
+func (m *AggregationBatch) GetEventTime() *EventTimeOptions {
+	if m != nil {
+		return m.EventTime
+	}
+	return nil
+}
+
+func (m *AggregationBatch) GetWindow() *WindowOptions {
+	if m != nil {
+		return m.Window
+	}
+	return nil
+}
+
+func (m *AggregationBatch) GetExpressions() []*Expression {
+	if m != nil {
+		return m.Expressions
+	}
+	return nil
+}
+
+// Expression is a computed output field, evaluated over the outputs of
+// algorithms within the same group of the rule when the window emitted.
+type Expression struct {
+	// Name of the computed field.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Arithmetic expression(+ - * / and parentheses) over output fields of the
+	// rule's algorithms, such as "errors / total".
+	Expr string `protobuf:"bytes,2,opt,name=expr,proto3" json:"expr,omitempty"`
+	// On divide by zero: skip(default) the field, or output zero.
+	OnDivZero string `protobuf:"bytes,3,opt,name=on_div_zero,json=onDivZero,proto3" json:"on_div_zero,omitempty"`
+}
+
+func (m *Expression) Reset()      { *m = Expression{} }
+func (*Expression) ProtoMessage() {}
+func (*Expression) Descriptor() ([]byte, []int) {
+	return fileDescriptor_581592ead704e388, []int{2}
+}
+func (m *Expression) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Expression) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Expression.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Expression) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Expression.Merge(m, src)
+}
+func (m *Expression) XXX_Size() int {
+	return m.Size()
+}
+func (m *Expression) XXX_DiscardUnknown() {
+	xxx_messageInfo_Expression.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Expression proto.InternalMessageInfo
+
+func (m *Expression) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Expression) GetExpr() string {
+	if m != nil {
+		return m.Expr
+	}
+	return ""
+}
+
+func (m *Expression) GetOnDivZero() string {
+	if m != nil {
+		return m.OnDivZero
+	}
+	return ""
+}
+
+// WindowOptions is the window spec of aggregate rule.
+type WindowOptions struct {
+	// tumbling/hopping/sliding.
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// Nanoseconds of the window length.
+	Length int64 `protobuf:"varint,2,opt,name=length,proto3" json:"length,omitempty"`
+	// Nanoseconds between windows emitted on hopping window, or the resolution
+	// of sliding window. Calculators are kept on panes of the step and shared
+	// among overlapping windows.
+	Step int64 `protobuf:"varint,3,opt,name=step,proto3" json:"step,omitempty"`
+}
+
+func (m *WindowOptions) Reset()      { *m = WindowOptions{} }
+func (*WindowOptions) ProtoMessage() {}
+func (*WindowOptions) Descriptor() ([]byte, []int) {
+	return fileDescriptor_581592ead704e388, []int{3}
+}
+func (m *WindowOptions) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *WindowOptions) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_WindowOptions.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *WindowOptions) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WindowOptions.Merge(m, src)
+}
+func (m *WindowOptions) XXX_Size() int {
+	return m.Size()
+}
+func (m *WindowOptions) XXX_DiscardUnknown() {
+	xxx_messageInfo_WindowOptions.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WindowOptions proto.InternalMessageInfo
+
+func (m *WindowOptions) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *WindowOptions) GetLength() int64 {
+	if m != nil {
+		return m.Length
+	}
+	return 0
+}
+
+func (m *WindowOptions) GetStep() int64 {
+	if m != nil {
+		return m.Step
+	}
+	return 0
+}
+
+// EventTimeOptions close windows by watermark(max point time observed minus
+// allowed lateness) instead of wall clock.
+type EventTimeOptions struct {
+	// Nanoseconds the point time allowed to be out of order.
+	AllowedLateness int64 `protobuf:"varint,1,opt,name=allowed_lateness,json=allowedLateness,proto3" json:"allowed_lateness,omitempty"`
+	// Policy on points arrived after their window closed:
+	//   drop(default): drop the point
+	//   correction: aggregate late points and emit them as correction points
+	//   side_output: keep the raw point within side output
+	LatePolicy string `protobuf:"bytes,2,opt,name=late_policy,json=latePolicy,proto3" json:"late_policy,omitempty"`
+}
+
+func (m *EventTimeOptions) Reset()      { *m = EventTimeOptions{} }
+func (*EventTimeOptions) ProtoMessage() {}
+func (*EventTimeOptions) Descriptor() ([]byte, []int) {
+	return fileDescriptor_581592ead704e388, []int{4}
+}
+func (m *EventTimeOptions) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *EventTimeOptions) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_EventTimeOptions.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *EventTimeOptions) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EventTimeOptions.Merge(m, src)
+}
+func (m *EventTimeOptions) XXX_Size() int {
+	return m.Size()
+}
+func (m *EventTimeOptions) XXX_DiscardUnknown() {
+	xxx_messageInfo_EventTimeOptions.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EventTimeOptions proto.InternalMessageInfo
+
+func (m *EventTimeOptions) GetAllowedLateness() int64 {
+	if m != nil {
+		return m.AllowedLateness
+	}
+	return 0
+}
+
+func (m *EventTimeOptions) GetLatePolicy() string {
+	if m != nil {
+		return m.LatePolicy
+	}
+	return ""
+}
+
 type AggregationAlgo struct {
 	// 1. which algorithm to apply for specific field?
 	// Use a readable string such as:
@@ -178,6 +402,8 @@ type AggregationAlgo struct {
 	//	*AggregationAlgo_HistogramOpts
 	//	*AggregationAlgo_ExpoOpts
 	//	*AggregationAlgo_QuantileOpts
+	//	*AggregationAlgo_TopkOpts
+	//	*AggregationAlgo_DistinctValuesOpts
 	Options isAggregationAlgo_Options `protobuf_oneof:"options"`
 	AddTags map[string]string         `protobuf:"bytes,8,rep,name=add_tags,json=addTags,proto3" json:"add_tags,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 }
@@ -185,7 +411,7 @@ type AggregationAlgo struct {
 func (m *AggregationAlgo) Reset()      { *m = AggregationAlgo{} }
 func (*AggregationAlgo) ProtoMessage() {}
 func (*AggregationAlgo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_581592ead704e388, []int{2}
+	return fileDescriptor_581592ead704e388, []int{5}
 }
 func (m *AggregationAlgo) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -230,10 +456,18 @@ type AggregationAlgo_ExpoOpts struct {
 type AggregationAlgo_QuantileOpts struct {
 	QuantileOpts *QuantileOptions `protobuf:"bytes,12,opt,name=quantile_opts,json=quantileOpts,proto3,oneof" json:"quantile_opts,omitempty"`
 }
+type AggregationAlgo_TopkOpts struct {
+	TopkOpts *TopKOptions `protobuf:"bytes,13,opt,name=topk_opts,json=topkOpts,proto3,oneof" json:"topk_opts,omitempty"`
+}
+type AggregationAlgo_DistinctValuesOpts struct {
+	DistinctValuesOpts *DistinctValuesOptions `protobuf:"bytes,14,opt,name=distinct_values_opts,json=distinctValuesOpts,proto3,oneof" json:"distinct_values_opts,omitempty"`
+}
 
-func (*AggregationAlgo_HistogramOpts) isAggregationAlgo_Options() {}
-func (*AggregationAlgo_ExpoOpts) isAggregationAlgo_Options()      {}
-func (*AggregationAlgo_QuantileOpts) isAggregationAlgo_Options()  {}
+func (*AggregationAlgo_HistogramOpts) isAggregationAlgo_Options()      {}
+func (*AggregationAlgo_ExpoOpts) isAggregationAlgo_Options()           {}
+func (*AggregationAlgo_QuantileOpts) isAggregationAlgo_Options()       {}
+func (*AggregationAlgo_TopkOpts) isAggregationAlgo_Options()           {}
+func (*AggregationAlgo_DistinctValuesOpts) isAggregationAlgo_Options() {}
 
 func (m *AggregationAlgo) GetOptions() isAggregationAlgo_Options {
 	if m != nil {
@@ -284,6 +518,20 @@ func (m *AggregationAlgo) GetQuantileOpts() *QuantileOptions {
 	return nil
 }
 
+func (m *AggregationAlgo) GetTopkOpts() *TopKOptions {
+	if x, ok := m.GetOptions().(*AggregationAlgo_TopkOpts); ok {
+		return x.TopkOpts
+	}
+	return nil
+}
+
+func (m *AggregationAlgo) GetDistinctValuesOpts() *DistinctValuesOptions {
+	if x, ok := m.GetOptions().(*AggregationAlgo_DistinctValuesOpts); ok {
+		return x.DistinctValuesOpts
+	}
+	return nil
+}
+
 func (m *AggregationAlgo) GetAddTags() map[string]string {
 	if m != nil {
 		return m.AddTags
@@ -297,6 +545,8 @@ func (*AggregationAlgo) XXX_OneofWrappers() []interface{} {
 		(*AggregationAlgo_HistogramOpts)(nil),
 		(*AggregationAlgo_ExpoOpts)(nil),
 		(*AggregationAlgo_QuantileOpts)(nil),
+		(*AggregationAlgo_TopkOpts)(nil),
+		(*AggregationAlgo_DistinctValuesOpts)(nil),
 	}
 }
 
@@ -308,7 +558,7 @@ type HistogramOptions struct {
 func (m *HistogramOptions) Reset()      { *m = HistogramOptions{} }
 func (*HistogramOptions) ProtoMessage() {}
 func (*HistogramOptions) Descriptor() ([]byte, []int) {
-	return fileDescriptor_581592ead704e388, []int{3}
+	return fileDescriptor_581592ead704e388, []int{6}
 }
 func (m *HistogramOptions) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -345,15 +595,17 @@ func (m *HistogramOptions) GetBuckets() []float64 {
 }
 
 type ExpoHistogramOptions struct {
-	MaxScale     int32 `protobuf:"varint,1,opt,name=max_scale,json=maxScale,proto3" json:"max_scale,omitempty"`
-	MaxBuckets   int32 `protobuf:"varint,2,opt,name=max_buckets,json=maxBuckets,proto3" json:"max_buckets,omitempty"`
-	RecordMinMax bool  `protobuf:"varint,3,opt,name=record_min_max,json=recordMinMax,proto3" json:"record_min_max,omitempty"`
+	MaxScale      int32 `protobuf:"varint,1,opt,name=max_scale,json=maxScale,proto3" json:"max_scale,omitempty"`
+	MaxBuckets    int32 `protobuf:"varint,2,opt,name=max_buckets,json=maxBuckets,proto3" json:"max_buckets,omitempty"`
+	RecordMinMax  bool  `protobuf:"varint,3,opt,name=record_min_max,json=recordMinMax,proto3" json:"record_min_max,omitempty"`
+	DisableMinMax bool  `protobuf:"varint,4,opt,name=disable_min_max,json=disableMinMax,proto3" json:"disable_min_max,omitempty"`
+	HasMaxScale   bool  `protobuf:"varint,5,opt,name=has_max_scale,json=hasMaxScale,proto3" json:"has_max_scale,omitempty"`
 }
 
 func (m *ExpoHistogramOptions) Reset()      { *m = ExpoHistogramOptions{} }
 func (*ExpoHistogramOptions) ProtoMessage() {}
 func (*ExpoHistogramOptions) Descriptor() ([]byte, []int) {
-	return fileDescriptor_581592ead704e388, []int{4}
+	return fileDescriptor_581592ead704e388, []int{7}
 }
 func (m *ExpoHistogramOptions) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -403,15 +655,36 @@ func (m *ExpoHistogramOptions) GetRecordMinMax() bool {
 	return false
 }
 
+func (m *ExpoHistogramOptions) GetDisableMinMax() bool {
+	if m != nil {
+		return m.DisableMinMax
+	}
+	return false
+}
+
+func (m *ExpoHistogramOptions) GetHasMaxScale() bool {
+	if m != nil {
+		return m.HasMaxScale
+	}
+	return false
+}
+
 type QuantileOptions struct {
 	// The target percentiles (e.g. 0.50, 0.99)
 	Percentiles []float64 `protobuf:"fixed64,1,rep,packed,name=percentiles,proto3" json:"percentiles,omitempty"`
+	// Relative accuracy of the sketch (accuracy vs memory trade-off), default 0.01.
+	RelativeAccuracy float64 `protobuf:"fixed64,2,opt,name=relative_accuracy,json=relativeAccuracy,proto3" json:"relative_accuracy,omitempty"`
+	// Max bins kept by the sketch, lowest bins collapsed beyond it, default 2048.
+	MaxBins uint32 `protobuf:"varint,3,opt,name=max_bins,json=maxBins,proto3" json:"max_bins,omitempty"`
+	// Output the sketch within field <key>_sketch, so the point can be merged by
+	// another aggregator.
+	EmitSketch bool `protobuf:"varint,4,opt,name=emit_sketch,json=emitSketch,proto3" json:"emit_sketch,omitempty"`
 }
 
 func (m *QuantileOptions) Reset()      { *m = QuantileOptions{} }
 func (*QuantileOptions) ProtoMessage() {}
 func (*QuantileOptions) Descriptor() ([]byte, []int) {
-	return fileDescriptor_581592ead704e388, []int{5}
+	return fileDescriptor_581592ead704e388, []int{8}
 }
 func (m *QuantileOptions) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -447,297 +720,896 @@ func (m *QuantileOptions) GetPercentiles() []float64 {
 	return nil
 }
 
-func init() {
-	proto.RegisterType((*Batchs)(nil), "aggregate.v1.Batchs")
-	proto.RegisterType((*AggregationBatch)(nil), "aggregate.v1.AggregationBatch")
-	proto.RegisterMapType((map[string]*AggregationAlgo)(nil), "aggregate.v1.AggregationBatch.AggregationOptsEntry")
-	proto.RegisterType((*AggregationAlgo)(nil), "aggregate.v1.AggregationAlgo")
-	proto.RegisterMapType((map[string]string)(nil), "aggregate.v1.AggregationAlgo.AddTagsEntry")
-	proto.RegisterType((*HistogramOptions)(nil), "aggregate.v1.HistogramOptions")
-	proto.RegisterType((*ExpoHistogramOptions)(nil), "aggregate.v1.ExpoHistogramOptions")
-	proto.RegisterType((*QuantileOptions)(nil), "aggregate.v1.QuantileOptions")
+func (m *QuantileOptions) GetRelativeAccuracy() float64 {
+	if m != nil {
+		return m.RelativeAccuracy
+	}
+	return 0
 }
 
-func init() { proto.RegisterFile("aggregate/aggrbatch.proto", fileDescriptor_581592ead704e388) }
-
-var fileDescriptor_581592ead704e388 = []byte{
-	// 671 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x54, 0x4d, 0x6f, 0xd3, 0x4a,
-	0x14, 0xcd, 0x24, 0x6d, 0x3e, 0xae, 0xd3, 0x26, 0x6f, 0x54, 0x3d, 0xa5, 0x7d, 0xea, 0xbc, 0x10,
-	0x21, 0x11, 0x21, 0x94, 0x8a, 0x46, 0x42, 0xa8, 0xac, 0x12, 0x28, 0x54, 0xaa, 0xaa, 0x16, 0xc3,
-	0x0a, 0x24, 0xac, 0xa9, 0x3d, 0x75, 0xac, 0xc6, 0x1e, 0xd7, 0xe3, 0x34, 0x0e, 0x2b, 0x7e, 0x02,
-	0x3f, 0x83, 0x1f, 0xc2, 0x82, 0x65, 0x97, 0x5d, 0x52, 0x77, 0xc3, 0xb2, 0x6b, 0x56, 0xc8, 0x33,
-	0x4e, 0xeb, 0x44, 0xa1, 0x9b, 0xc8, 0xf7, 0xdc, 0x7b, 0xce, 0xdc, 0x39, 0xf7, 0x4e, 0x60, 0x9d,
-	0xda, 0x76, 0xc0, 0x6c, 0x1a, 0xb2, 0xad, 0xe4, 0xeb, 0x98, 0x86, 0xe6, 0xa0, 0xe3, 0x07, 0x3c,
-	0xe4, 0xb8, 0x7a, 0x9b, 0xea, 0x9c, 0x3f, 0xdd, 0xf8, 0xc7, 0xe7, 0x8e, 0x17, 0x6e, 0xc9, 0x5f,
-	0x55, 0xd0, 0xfa, 0x08, 0xc5, 0x7e, 0x52, 0x2f, 0xf0, 0x33, 0x28, 0x4a, 0xa6, 0x68, 0xa0, 0x66,
-	0xa1, 0xad, 0x6d, 0x93, 0x4e, 0x96, 0xdb, 0xe9, 0xa5, 0x81, 0xc3, 0x3d, 0x49, 0xd0, 0xd3, 0x6a,
-	0xbc, 0x0e, 0x65, 0xdf, 0x31, 0x4f, 0x8d, 0x53, 0x36, 0x69, 0xe4, 0x9b, 0xa8, 0xbd, 0xa4, 0x97,
-	0x92, 0x78, 0x9f, 0x4d, 0x5a, 0xbf, 0xf3, 0x50, 0x9f, 0xe7, 0xe1, 0xff, 0x41, 0x0b, 0xf8, 0x28,
-	0x74, 0x3c, 0x5b, 0x52, 0x90, 0xa4, 0x40, 0x0a, 0xed, 0xb3, 0x49, 0x52, 0x60, 0x72, 0xef, 0xc4,
-	0xb1, 0x8d, 0x01, 0x15, 0x83, 0x54, 0x13, 0x14, 0xb4, 0x47, 0xc5, 0x00, 0x6f, 0x02, 0x04, 0x74,
-	0x6c, 0x28, 0xa4, 0x51, 0x68, 0xa2, 0x76, 0x55, 0xaf, 0x04, 0x74, 0xfc, 0x52, 0x02, 0xf8, 0x13,
-	0xd4, 0xe9, 0xdd, 0xa1, 0x06, 0xf7, 0x43, 0xd1, 0x58, 0x92, 0x57, 0xea, 0xde, 0x7f, 0xa5, 0x2c,
-	0x70, 0xe8, 0x87, 0x62, 0xd7, 0x0b, 0x83, 0x89, 0x5e, 0xa3, 0xb3, 0x28, 0x7e, 0x04, 0x45, 0xe9,
-	0xa0, 0x68, 0x2c, 0x37, 0x51, 0x5b, 0xdb, 0xae, 0x75, 0x94, 0xa1, 0x47, 0xfd, 0x23, 0x09, 0xeb,
-	0x69, 0x7a, 0xc6, 0x99, 0xe2, 0x8c, 0x33, 0x1b, 0x14, 0xd6, 0x16, 0x1d, 0x86, 0xeb, 0x50, 0x98,
-	0x9a, 0x52, 0xd1, 0x93, 0x4f, 0xdc, 0x85, 0xe5, 0x73, 0x3a, 0x1c, 0x31, 0xe9, 0x83, 0xb6, 0xbd,
-	0xf9, 0xd7, 0x2b, 0xf4, 0x86, 0x36, 0xd7, 0x55, 0xed, 0x4e, 0xfe, 0x39, 0x6a, 0x7d, 0x2f, 0x40,
-	0x6d, 0x2e, 0x8d, 0xff, 0x85, 0xa2, 0xcb, 0xc2, 0x01, 0xb7, 0xd2, 0x13, 0xd2, 0x08, 0x3f, 0x80,
-	0xaa, 0xe0, 0xa3, 0xc0, 0x64, 0xc6, 0x89, 0xc3, 0x86, 0x96, 0x3c, 0xab, 0xa2, 0x6b, 0x0a, 0x7b,
-	0x9d, 0x40, 0x09, 0x75, 0xec, 0x78, 0x16, 0x1f, 0x4b, 0xc3, 0x0b, 0x7a, 0x1a, 0xe1, 0x37, 0xb0,
-	0x3a, 0x70, 0x44, 0xc8, 0xed, 0x80, 0xba, 0xca, 0x6b, 0x90, 0x8d, 0xce, 0xad, 0xcf, 0xde, 0xb4,
-	0xe6, 0xd0, 0x4f, 0xba, 0x11, 0x7b, 0x39, 0x7d, 0x65, 0x90, 0xc1, 0x04, 0xee, 0x41, 0x85, 0x45,
-	0x3e, 0x57, 0x1a, 0x9a, 0xd4, 0x68, 0xcd, 0x6a, 0xec, 0x46, 0x3e, 0x5f, 0xa0, 0x53, 0x4e, 0x68,
-	0x52, 0xe2, 0x15, 0xac, 0x9c, 0x8d, 0xa8, 0x17, 0x3a, 0x43, 0xa6, 0x64, 0xaa, 0x8b, 0x3c, 0x7b,
-	0x9b, 0x96, 0xdc, 0x29, 0x54, 0xcf, 0xee, 0x20, 0x81, 0x77, 0xa1, 0x4c, 0x2d, 0xcb, 0x08, 0xa9,
-	0x2d, 0x1a, 0x65, 0xb9, 0x37, 0x8f, 0xef, 0x35, 0xbd, 0xd3, 0xb3, 0xac, 0xf7, 0xd4, 0x4e, 0xd7,
-	0xa5, 0x44, 0x55, 0xb4, 0xb1, 0x03, 0xd5, 0x6c, 0x62, 0xc1, 0x68, 0xd7, 0xb2, 0xa3, 0xad, 0x64,
-	0x66, 0xd7, 0xaf, 0x40, 0x89, 0xab, 0xee, 0x5a, 0x4f, 0xa0, 0x3e, 0x7f, 0x67, 0xdc, 0x80, 0xd2,
-	0xf1, 0xc8, 0x3c, 0x65, 0xa1, 0x7a, 0xab, 0x48, 0x9f, 0x86, 0xad, 0xcf, 0xb0, 0xb6, 0xc8, 0x25,
-	0xfc, 0x1f, 0x54, 0x5c, 0x1a, 0x19, 0xc2, 0xa4, 0x43, 0x26, 0x5b, 0x58, 0xd6, 0xcb, 0x2e, 0x8d,
-	0xde, 0x25, 0x71, 0xf2, 0xe0, 0x92, 0xe4, 0x54, 0x32, 0x2f, 0xd3, 0xe0, 0xd2, 0xa8, 0xaf, 0x10,
-	0xfc, 0x10, 0x56, 0x03, 0x66, 0xf2, 0xc0, 0x32, 0x5c, 0xc7, 0x33, 0x5c, 0x1a, 0xc9, 0x1d, 0x28,
-	0xeb, 0x55, 0x85, 0x1e, 0x38, 0xde, 0x01, 0x8d, 0x5a, 0x5d, 0xa8, 0xcd, 0x59, 0x8b, 0x9b, 0xa0,
-	0xf9, 0x2c, 0x30, 0x99, 0x04, 0xa7, 0xcd, 0x66, 0xa1, 0x7e, 0xef, 0xe2, 0x8a, 0xe4, 0x2e, 0xaf,
-	0x48, 0xee, 0xe6, 0x8a, 0xa0, 0x2f, 0x31, 0x41, 0xdf, 0x62, 0x82, 0x7e, 0xc4, 0x04, 0x5d, 0xc4,
-	0x04, 0xfd, 0x8c, 0x09, 0xfa, 0x15, 0x93, 0xdc, 0x4d, 0x4c, 0xd0, 0xd7, 0x6b, 0x92, 0xbb, 0xb8,
-	0x26, 0xb9, 0xcb, 0x6b, 0x92, 0xfb, 0xa0, 0x6d, 0xbd, 0xb8, 0x9d, 0xc8, 0x71, 0x51, 0xfe, 0x93,
-	0x75, 0xff, 0x04, 0x00, 0x00, 0xff, 0xff, 0x50, 0x1d, 0xc9, 0xdf, 0x07, 0x05, 0x00, 0x00,
+func (m *QuantileOptions) GetMaxBins() uint32 {
+	if m != nil {
+		return m.MaxBins
+	}
+	return 0
 }
 
-func (this *Batchs) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
+func (m *QuantileOptions) GetEmitSketch() bool {
+	if m != nil {
+		return m.EmitSketch
 	}
+	return false
+}
 
-	that1, ok := that.(*Batchs)
-	if !ok {
-		that2, ok := that.(Batchs)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
+// QuantileSketch is the serialized DDSketch of quantiles.
+type QuantileSketch struct {
+	RelativeAccuracy float64 `protobuf:"fixed64,1,opt,name=relative_accuracy,json=relativeAccuracy,proto3" json:"relative_accuracy,omitempty"`
+	Count            uint64  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Sum              float64 `protobuf:"fixed64,3,opt,name=sum,proto3" json:"sum,omitempty"`
+	Min              float64 `protobuf:"fixed64,4,opt,name=min,proto3" json:"min,omitempty"`
+	Max              float64 `protobuf:"fixed64,5,opt,name=max,proto3" json:"max,omitempty"`
+	ZeroCount        uint64  `protobuf:"varint,6,opt,name=zero_count,json=zeroCount,proto3" json:"zero_count,omitempty"`
+	// Bin i covers values within (gamma^(i-1), gamma^i].
+	PosOffset int32    `protobuf:"varint,7,opt,name=pos_offset,json=posOffset,proto3" json:"pos_offset,omitempty"`
+	PosCounts []uint64 `protobuf:"varint,8,rep,packed,name=pos_counts,json=posCounts,proto3" json:"pos_counts,omitempty"`
+	NegOffset int32    `protobuf:"varint,9,opt,name=neg_offset,json=negOffset,proto3" json:"neg_offset,omitempty"`
+	NegCounts []uint64 `protobuf:"varint,10,rep,packed,name=neg_counts,json=negCounts,proto3" json:"neg_counts,omitempty"`
+}
+
+func (m *QuantileSketch) Reset()      { *m = QuantileSketch{} }
+func (*QuantileSketch) ProtoMessage() {}
+func (*QuantileSketch) Descriptor() ([]byte, []int) {
+	return fileDescriptor_581592ead704e388, []int{9}
+}
+func (m *QuantileSketch) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *QuantileSketch) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_QuantileSketch.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
-	}
-	if len(this.Batchs) != len(that1.Batchs) {
-		return false
+}
+func (m *QuantileSketch) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QuantileSketch.Merge(m, src)
+}
+func (m *QuantileSketch) XXX_Size() int {
+	return m.Size()
+}
+func (m *QuantileSketch) XXX_DiscardUnknown() {
+	xxx_messageInfo_QuantileSketch.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QuantileSketch proto.InternalMessageInfo
+
+func (m *QuantileSketch) GetRelativeAccuracy() float64 {
+	if m != nil {
+		return m.RelativeAccuracy
 	}
-	for i := range this.Batchs {
-		if !this.Batchs[i].Equal(that1.Batchs[i]) {
-			return false
-		}
+	return 0
+}
+
+func (m *QuantileSketch) GetCount() uint64 {
+	if m != nil {
+		return m.Count
 	}
-	if this.PickKey != that1.PickKey {
-		return false
+	return 0
+}
+
+func (m *QuantileSketch) GetSum() float64 {
+	if m != nil {
+		return m.Sum
 	}
-	return true
+	return 0
 }
-func (this *AggregationBatch) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
+
+func (m *QuantileSketch) GetMin() float64 {
+	if m != nil {
+		return m.Min
 	}
+	return 0
+}
 
-	that1, ok := that.(*AggregationBatch)
-	if !ok {
-		that2, ok := that.(AggregationBatch)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
-		}
+func (m *QuantileSketch) GetMax() float64 {
+	if m != nil {
+		return m.Max
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
+	return 0
+}
+
+func (m *QuantileSketch) GetZeroCount() uint64 {
+	if m != nil {
+		return m.ZeroCount
 	}
-	if this.RoutingKey != that1.RoutingKey {
-		return false
+	return 0
+}
+
+func (m *QuantileSketch) GetPosOffset() int32 {
+	if m != nil {
+		return m.PosOffset
 	}
-	if this.ConfigHash != that1.ConfigHash {
-		return false
-	}
-	if !bytes.Equal(this.RawConfig, that1.RawConfig) {
-		return false
-	}
-	if len(this.AggregationOpts) != len(that1.AggregationOpts) {
-		return false
-	}
-	for i := range this.AggregationOpts {
-		if !this.AggregationOpts[i].Equal(that1.AggregationOpts[i]) {
-			return false
-		}
-	}
-	if !this.Points.Equal(that1.Points) {
-		return false
+	return 0
+}
+
+func (m *QuantileSketch) GetPosCounts() []uint64 {
+	if m != nil {
+		return m.PosCounts
 	}
-	if this.PickKey != that1.PickKey {
-		return false
+	return nil
+}
+
+func (m *QuantileSketch) GetNegOffset() int32 {
+	if m != nil {
+		return m.NegOffset
 	}
-	return true
+	return 0
 }
-func (this *AggregationAlgo) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
+
+func (m *QuantileSketch) GetNegCounts() []uint64 {
+	if m != nil {
+		return m.NegCounts
 	}
+	return nil
+}
 
-	that1, ok := that.(*AggregationAlgo)
-	if !ok {
-		that2, ok := that.(AggregationAlgo)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
+type TopKOptions struct {
+	// Top items output, default 10.
+	K int32 `protobuf:"varint,1,opt,name=k,proto3" json:"k,omitempty"`
+	// Numeric field of the point as weight of the item, weighted 1 if not set.
+	WeightField string `protobuf:"bytes,2,opt,name=weight_field,json=weightField,proto3" json:"weight_field,omitempty"`
+	// Counters kept by the sketch, default max(10*k, 100).
+	Capacity int32 `protobuf:"varint,3,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	// Output the sketch within field <key>_topk, so the point can be merged by
+	// another aggregator.
+	EmitSketch bool `protobuf:"varint,4,opt,name=emit_sketch,json=emitSketch,proto3" json:"emit_sketch,omitempty"`
+}
+
+func (m *TopKOptions) Reset()      { *m = TopKOptions{} }
+func (*TopKOptions) ProtoMessage() {}
+func (*TopKOptions) Descriptor() ([]byte, []int) {
+	return fileDescriptor_581592ead704e388, []int{10}
+}
+func (m *TopKOptions) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TopKOptions) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TopKOptions.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
-	}
-	if this.Method != that1.Method {
-		return false
-	}
-	if this.SourceField != that1.SourceField {
-		return false
+}
+func (m *TopKOptions) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TopKOptions.Merge(m, src)
+}
+func (m *TopKOptions) XXX_Size() int {
+	return m.Size()
+}
+func (m *TopKOptions) XXX_DiscardUnknown() {
+	xxx_messageInfo_TopKOptions.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TopKOptions proto.InternalMessageInfo
+
+func (m *TopKOptions) GetK() int32 {
+	if m != nil {
+		return m.K
 	}
-	if this.Window != that1.Window {
-		return false
+	return 0
+}
+
+func (m *TopKOptions) GetWeightField() string {
+	if m != nil {
+		return m.WeightField
 	}
-	if that1.Options == nil {
-		if this.Options != nil {
-			return false
-		}
-	} else if this.Options == nil {
-		return false
-	} else if !this.Options.Equal(that1.Options) {
-		return false
+	return ""
+}
+
+func (m *TopKOptions) GetCapacity() int32 {
+	if m != nil {
+		return m.Capacity
 	}
-	if len(this.AddTags) != len(that1.AddTags) {
-		return false
+	return 0
+}
+
+func (m *TopKOptions) GetEmitSketch() bool {
+	if m != nil {
+		return m.EmitSketch
 	}
-	for i := range this.AddTags {
-		if this.AddTags[i] != that1.AddTags[i] {
-			return false
+	return false
+}
+
+type DistinctValuesOptions struct {
+	// Top values output, default 10, at most 128(counters kept).
+	K int32 `protobuf:"varint,1,opt,name=k,proto3" json:"k,omitempty"`
+}
+
+func (m *DistinctValuesOptions) Reset()      { *m = DistinctValuesOptions{} }
+func (*DistinctValuesOptions) ProtoMessage() {}
+func (*DistinctValuesOptions) Descriptor() ([]byte, []int) {
+	return fileDescriptor_581592ead704e388, []int{11}
+}
+func (m *DistinctValuesOptions) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *DistinctValuesOptions) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_DistinctValuesOptions.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	return true
 }
-func (this *AggregationAlgo_HistogramOpts) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
+func (m *DistinctValuesOptions) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DistinctValuesOptions.Merge(m, src)
+}
+func (m *DistinctValuesOptions) XXX_Size() int {
+	return m.Size()
+}
+func (m *DistinctValuesOptions) XXX_DiscardUnknown() {
+	xxx_messageInfo_DistinctValuesOptions.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DistinctValuesOptions proto.InternalMessageInfo
+
+func (m *DistinctValuesOptions) GetK() int32 {
+	if m != nil {
+		return m.K
 	}
+	return 0
+}
 
-	that1, ok := that.(*AggregationAlgo_HistogramOpts)
-	if !ok {
-		that2, ok := that.(AggregationAlgo_HistogramOpts)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
+// TopKSketch is the serialized Space-Saving sketch of topk.
+type TopKSketch struct {
+	Capacity int32          `protobuf:"varint,1,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	Total    float64        `protobuf:"fixed64,2,opt,name=total,proto3" json:"total,omitempty"`
+	Counters []*TopKCounter `protobuf:"bytes,3,rep,name=counters,proto3" json:"counters,omitempty"`
+}
+
+func (m *TopKSketch) Reset()      { *m = TopKSketch{} }
+func (*TopKSketch) ProtoMessage() {}
+func (*TopKSketch) Descriptor() ([]byte, []int) {
+	return fileDescriptor_581592ead704e388, []int{12}
+}
+func (m *TopKSketch) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TopKSketch) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TopKSketch.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
+}
+func (m *TopKSketch) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TopKSketch.Merge(m, src)
+}
+func (m *TopKSketch) XXX_Size() int {
+	return m.Size()
+}
+func (m *TopKSketch) XXX_DiscardUnknown() {
+	xxx_messageInfo_TopKSketch.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TopKSketch proto.InternalMessageInfo
+
+func (m *TopKSketch) GetCapacity() int32 {
+	if m != nil {
+		return m.Capacity
 	}
-	if !this.HistogramOpts.Equal(that1.HistogramOpts) {
-		return false
+	return 0
+}
+
+func (m *TopKSketch) GetTotal() float64 {
+	if m != nil {
+		return m.Total
 	}
-	return true
+	return 0
 }
-func (this *AggregationAlgo_ExpoOpts) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
+
+func (m *TopKSketch) GetCounters() []*TopKCounter {
+	if m != nil {
+		return m.Counters
 	}
+	return nil
+}
 
-	that1, ok := that.(*AggregationAlgo_ExpoOpts)
-	if !ok {
-		that2, ok := that.(AggregationAlgo_ExpoOpts)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
+type TopKCounter struct {
+	Item   string  `protobuf:"bytes,1,opt,name=item,proto3" json:"item,omitempty"`
+	Weight float64 `protobuf:"fixed64,2,opt,name=weight,proto3" json:"weight,omitempty"`
+	// Max over-estimated weight of the item.
+	Error float64 `protobuf:"fixed64,3,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (m *TopKCounter) Reset()      { *m = TopKCounter{} }
+func (*TopKCounter) ProtoMessage() {}
+func (*TopKCounter) Descriptor() ([]byte, []int) {
+	return fileDescriptor_581592ead704e388, []int{13}
+}
+func (m *TopKCounter) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TopKCounter) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TopKCounter.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
+}
+func (m *TopKCounter) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TopKCounter.Merge(m, src)
+}
+func (m *TopKCounter) XXX_Size() int {
+	return m.Size()
+}
+func (m *TopKCounter) XXX_DiscardUnknown() {
+	xxx_messageInfo_TopKCounter.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TopKCounter proto.InternalMessageInfo
+
+func (m *TopKCounter) GetItem() string {
+	if m != nil {
+		return m.Item
 	}
-	if !this.ExpoOpts.Equal(that1.ExpoOpts) {
-		return false
+	return ""
+}
+
+func (m *TopKCounter) GetWeight() float64 {
+	if m != nil {
+		return m.Weight
 	}
-	return true
+	return 0
 }
-func (this *AggregationAlgo_QuantileOpts) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
+
+func (m *TopKCounter) GetError() float64 {
+	if m != nil {
+		return m.Error
 	}
+	return 0
+}
 
-	that1, ok := that.(*AggregationAlgo_QuantileOpts)
-	if !ok {
-		that2, ok := that.(AggregationAlgo_QuantileOpts)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
+// CalculatorSnapshot is the checkpoint of a calculator cached in windows.
+type CalculatorSnapshot struct {
+	Token string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	// Unix seconds the window expired(the windows bucket within cache).
+	Expire       int64  `protobuf:"varint,2,opt,name=expire,proto3" json:"expire,omitempty"`
+	Method       string `protobuf:"bytes,3,opt,name=method,proto3" json:"method,omitempty"`
+	Key          string `protobuf:"bytes,4,opt,name=key,proto3" json:"key,omitempty"`
+	Hash         uint64 `protobuf:"varint,5,opt,name=hash,proto3" json:"hash,omitempty"`
+	Window       int64  `protobuf:"varint,6,opt,name=window,proto3" json:"window,omitempty"`
+	NextWallTime int64  `protobuf:"varint,7,opt,name=next_wall_time,json=nextWallTime,proto3" json:"next_wall_time,omitempty"`
+	// Name and tags of the aggregated point, and state of the calculator as fields.
+	State *point.PBPoint `protobuf:"bytes,8,opt,name=state,proto3" json:"state,omitempty"`
+	// Rule of the calculator.
+	Rule string `protobuf:"bytes,9,opt,name=rule,proto3" json:"rule,omitempty"`
+	// Within event-time window, expire is the window end.
+	EventTime bool `protobuf:"varint,10,opt,name=event_time,json=eventTime,proto3" json:"event_time,omitempty"`
+	// Window spec of hopping/sliding window.
+	WindowOpts *WindowOptions `protobuf:"bytes,11,opt,name=window_opts,json=windowOpts,proto3" json:"window_opts,omitempty"`
+	// Within panes of hopping/sliding window, expire is the pane end.
+	Pane bool `protobuf:"varint,12,opt,name=pane,proto3" json:"pane,omitempty"`
+	// Window end last emitted on the pane's series.
+	LastEmit int64 `protobuf:"varint,13,opt,name=last_emit,json=lastEmit,proto3" json:"last_emit,omitempty"`
+	// Group(rule, measurement and tags of the point) of the calculator, and
+	// expressions of the rule evaluated over outputs of the group.
+	Group       uint64        `protobuf:"varint,14,opt,name=group,proto3" json:"group,omitempty"`
+	Expressions []*Expression `protobuf:"bytes,15,rep,name=expressions,proto3" json:"expressions,omitempty"`
+	// Correction of late points on event-time window, emitted on next flush.
+	Correction bool `protobuf:"varint,16,opt,name=correction,proto3" json:"correction,omitempty"`
+}
+
+func (m *CalculatorSnapshot) Reset()      { *m = CalculatorSnapshot{} }
+func (*CalculatorSnapshot) ProtoMessage() {}
+func (*CalculatorSnapshot) Descriptor() ([]byte, []int) {
+	return fileDescriptor_581592ead704e388, []int{14}
+}
+func (m *CalculatorSnapshot) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *CalculatorSnapshot) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_CalculatorSnapshot.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
-	}
-	if !this.QuantileOpts.Equal(that1.QuantileOpts) {
-		return false
-	}
-	return true
 }
-func (this *HistogramOptions) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
-	}
+func (m *CalculatorSnapshot) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CalculatorSnapshot.Merge(m, src)
+}
+func (m *CalculatorSnapshot) XXX_Size() int {
+	return m.Size()
+}
+func (m *CalculatorSnapshot) XXX_DiscardUnknown() {
+	xxx_messageInfo_CalculatorSnapshot.DiscardUnknown(m)
+}
 
-	that1, ok := that.(*HistogramOptions)
-	if !ok {
-		that2, ok := that.(HistogramOptions)
-		if ok {
-			that1 = &that2
-		} else {
-			return false
+var xxx_messageInfo_CalculatorSnapshot proto.InternalMessageInfo
+
+func (m *CalculatorSnapshot) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+func (m *CalculatorSnapshot) GetExpire() int64 {
+	if m != nil {
+		return m.Expire
+	}
+	return 0
+}
+
+func (m *CalculatorSnapshot) GetMethod() string {
+	if m != nil {
+		return m.Method
+	}
+	return ""
+}
+
+func (m *CalculatorSnapshot) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *CalculatorSnapshot) GetHash() uint64 {
+	if m != nil {
+		return m.Hash
+	}
+	return 0
+}
+
+func (m *CalculatorSnapshot) GetWindow() int64 {
+	if m != nil {
+		return m.Window
+	}
+	return 0
+}
+
+func (m *CalculatorSnapshot) GetNextWallTime() int64 {
+	if m != nil {
+		return m.NextWallTime
+	}
+	return 0
+}
+
+func (m *CalculatorSnapshot) GetState() *point.PBPoint {
+	if m != nil {
+		return m.State
+	}
+	return nil
+}
+
+func (m *CalculatorSnapshot) GetRule() string {
+	if m != nil {
+		return m.Rule
+	}
+	return ""
+}
+
+func (m *CalculatorSnapshot) GetEventTime() bool {
+	if m != nil {
+		return m.EventTime
+	}
+	return false
+}
+
+func (m *CalculatorSnapshot) GetWindowOpts() *WindowOptions {
+	if m != nil {
+		return m.WindowOpts
+	}
+	return nil
+}
+
+func (m *CalculatorSnapshot) GetPane() bool {
+	if m != nil {
+		return m.Pane
+	}
+	return false
+}
+
+func (m *CalculatorSnapshot) GetLastEmit() int64 {
+	if m != nil {
+		return m.LastEmit
+	}
+	return 0
+}
+
+func (m *CalculatorSnapshot) GetGroup() uint64 {
+	if m != nil {
+		return m.Group
+	}
+	return 0
+}
+
+func (m *CalculatorSnapshot) GetExpressions() []*Expression {
+	if m != nil {
+		return m.Expressions
+	}
+	return nil
+}
+
+func (m *CalculatorSnapshot) GetCorrection() bool {
+	if m != nil {
+		return m.Correction
+	}
+	return false
+}
+
+// EventTimeSnapshot is the checkpoint of event-time windows on rule of token.
+type EventTimeSnapshot struct {
+	Token   string            `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Rule    string            `protobuf:"bytes,2,opt,name=rule,proto3" json:"rule,omitempty"`
+	MaxTime int64             `protobuf:"varint,3,opt,name=max_time,json=maxTime,proto3" json:"max_time,omitempty"`
+	Options *EventTimeOptions `protobuf:"bytes,4,opt,name=options,proto3" json:"options,omitempty"`
+	Late    int64             `protobuf:"varint,5,opt,name=late,proto3" json:"late,omitempty"`
+	Closed  int64             `protobuf:"varint,6,opt,name=closed,proto3" json:"closed,omitempty"`
+}
+
+func (m *EventTimeSnapshot) Reset()      { *m = EventTimeSnapshot{} }
+func (*EventTimeSnapshot) ProtoMessage() {}
+func (*EventTimeSnapshot) Descriptor() ([]byte, []int) {
+	return fileDescriptor_581592ead704e388, []int{15}
+}
+func (m *EventTimeSnapshot) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *EventTimeSnapshot) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_EventTimeSnapshot.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if that1 == nil {
-		return this == nil
-	} else if this == nil {
-		return false
+}
+func (m *EventTimeSnapshot) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EventTimeSnapshot.Merge(m, src)
+}
+func (m *EventTimeSnapshot) XXX_Size() int {
+	return m.Size()
+}
+func (m *EventTimeSnapshot) XXX_DiscardUnknown() {
+	xxx_messageInfo_EventTimeSnapshot.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EventTimeSnapshot proto.InternalMessageInfo
+
+func (m *EventTimeSnapshot) GetToken() string {
+	if m != nil {
+		return m.Token
 	}
-	if len(this.Buckets) != len(that1.Buckets) {
-		return false
+	return ""
+}
+
+func (m *EventTimeSnapshot) GetRule() string {
+	if m != nil {
+		return m.Rule
 	}
-	for i := range this.Buckets {
-		if this.Buckets[i] != that1.Buckets[i] {
-			return false
+	return ""
+}
+
+func (m *EventTimeSnapshot) GetMaxTime() int64 {
+	if m != nil {
+		return m.MaxTime
+	}
+	return 0
+}
+
+func (m *EventTimeSnapshot) GetOptions() *EventTimeOptions {
+	if m != nil {
+		return m.Options
+	}
+	return nil
+}
+
+func (m *EventTimeSnapshot) GetLate() int64 {
+	if m != nil {
+		return m.Late
+	}
+	return 0
+}
+
+func (m *EventTimeSnapshot) GetClosed() int64 {
+	if m != nil {
+		return m.Closed
+	}
+	return 0
+}
+
+// SideOutputSnapshot is the checkpoint of late points kept under late
+// policy side_output on token.
+type SideOutputSnapshot struct {
+	Token  string           `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Points []*point.PBPoint `protobuf:"bytes,2,rep,name=points,proto3" json:"points,omitempty"`
+}
+
+func (m *SideOutputSnapshot) Reset()      { *m = SideOutputSnapshot{} }
+func (*SideOutputSnapshot) ProtoMessage() {}
+func (*SideOutputSnapshot) Descriptor() ([]byte, []int) {
+	return fileDescriptor_581592ead704e388, []int{16}
+}
+func (m *SideOutputSnapshot) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SideOutputSnapshot) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SideOutputSnapshot.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	return true
 }
-func (this *ExpoHistogramOptions) Equal(that interface{}) bool {
+func (m *SideOutputSnapshot) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SideOutputSnapshot.Merge(m, src)
+}
+func (m *SideOutputSnapshot) XXX_Size() int {
+	return m.Size()
+}
+func (m *SideOutputSnapshot) XXX_DiscardUnknown() {
+	xxx_messageInfo_SideOutputSnapshot.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SideOutputSnapshot proto.InternalMessageInfo
+
+func (m *SideOutputSnapshot) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+func (m *SideOutputSnapshot) GetPoints() []*point.PBPoint {
+	if m != nil {
+		return m.Points
+	}
+	return nil
+}
+
+// CacheSnapshot is the checkpoint of all calculators cached.
+type CacheSnapshot struct {
+	Expired     int64                 `protobuf:"varint,1,opt,name=expired,proto3" json:"expired,omitempty"`
+	Calculators []*CalculatorSnapshot `protobuf:"bytes,2,rep,name=calculators,proto3" json:"calculators,omitempty"`
+	EventTimes  []*EventTimeSnapshot  `protobuf:"bytes,3,rep,name=event_times,json=eventTimes,proto3" json:"event_times,omitempty"`
+	SideOutput  []*SideOutputSnapshot `protobuf:"bytes,4,rep,name=side_output,json=sideOutput,proto3" json:"side_output,omitempty"`
+}
+
+func (m *CacheSnapshot) Reset()      { *m = CacheSnapshot{} }
+func (*CacheSnapshot) ProtoMessage() {}
+func (*CacheSnapshot) Descriptor() ([]byte, []int) {
+	return fileDescriptor_581592ead704e388, []int{17}
+}
+func (m *CacheSnapshot) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *CacheSnapshot) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_CacheSnapshot.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *CacheSnapshot) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CacheSnapshot.Merge(m, src)
+}
+func (m *CacheSnapshot) XXX_Size() int {
+	return m.Size()
+}
+func (m *CacheSnapshot) XXX_DiscardUnknown() {
+	xxx_messageInfo_CacheSnapshot.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CacheSnapshot proto.InternalMessageInfo
+
+func (m *CacheSnapshot) GetExpired() int64 {
+	if m != nil {
+		return m.Expired
+	}
+	return 0
+}
+
+func (m *CacheSnapshot) GetCalculators() []*CalculatorSnapshot {
+	if m != nil {
+		return m.Calculators
+	}
+	return nil
+}
+
+func (m *CacheSnapshot) GetEventTimes() []*EventTimeSnapshot {
+	if m != nil {
+		return m.EventTimes
+	}
+	return nil
+}
+
+func (m *CacheSnapshot) GetSideOutput() []*SideOutputSnapshot {
+	if m != nil {
+		return m.SideOutput
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Batchs)(nil), "aggregate.v1.Batchs")
+	proto.RegisterType((*AggregationBatch)(nil), "aggregate.v1.AggregationBatch")
+	proto.RegisterMapType((map[string]*AggregationAlgo)(nil), "aggregate.v1.AggregationBatch.AggregationOptsEntry")
+	proto.RegisterType((*Expression)(nil), "aggregate.v1.Expression")
+	proto.RegisterType((*WindowOptions)(nil), "aggregate.v1.WindowOptions")
+	proto.RegisterType((*EventTimeOptions)(nil), "aggregate.v1.EventTimeOptions")
+	proto.RegisterType((*AggregationAlgo)(nil), "aggregate.v1.AggregationAlgo")
+	proto.RegisterMapType((map[string]string)(nil), "aggregate.v1.AggregationAlgo.AddTagsEntry")
+	proto.RegisterType((*HistogramOptions)(nil), "aggregate.v1.HistogramOptions")
+	proto.RegisterType((*ExpoHistogramOptions)(nil), "aggregate.v1.ExpoHistogramOptions")
+	proto.RegisterType((*QuantileOptions)(nil), "aggregate.v1.QuantileOptions")
+	proto.RegisterType((*QuantileSketch)(nil), "aggregate.v1.QuantileSketch")
+	proto.RegisterType((*TopKOptions)(nil), "aggregate.v1.TopKOptions")
+	proto.RegisterType((*DistinctValuesOptions)(nil), "aggregate.v1.DistinctValuesOptions")
+	proto.RegisterType((*TopKSketch)(nil), "aggregate.v1.TopKSketch")
+	proto.RegisterType((*TopKCounter)(nil), "aggregate.v1.TopKCounter")
+	proto.RegisterType((*CalculatorSnapshot)(nil), "aggregate.v1.CalculatorSnapshot")
+	proto.RegisterType((*EventTimeSnapshot)(nil), "aggregate.v1.EventTimeSnapshot")
+	proto.RegisterType((*SideOutputSnapshot)(nil), "aggregate.v1.SideOutputSnapshot")
+	proto.RegisterType((*CacheSnapshot)(nil), "aggregate.v1.CacheSnapshot")
+}
+
+func init() { proto.RegisterFile("aggregate/aggrbatch.proto", fileDescriptor_581592ead704e388) }
+
+var fileDescriptor_581592ead704e388 = []byte{
+	// 1593 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x57, 0x4f, 0x8f, 0x1b, 0x49,
+	0x15, 0x9f, 0x1e, 0xff, 0xed, 0xd7, 0xf6, 0x8c, 0x53, 0x1a, 0x50, 0x27, 0xab, 0xf4, 0x9a, 0x26,
+	0x2c, 0xc3, 0x1f, 0x4d, 0x44, 0x22, 0x50, 0x14, 0x40, 0x62, 0x66, 0x12, 0x88, 0x14, 0xa2, 0x09,
+	0x95, 0x88, 0x48, 0x8b, 0xb4, 0xad, 0x4a, 0x77, 0xc5, 0x6e, 0xb9, 0xdd, 0xd5, 0xdb, 0x55, 0x1e,
+	0x7b, 0xe0, 0xc2, 0x47, 0xe0, 0x03, 0x70, 0xe4, 0xc0, 0xa7, 0xd8, 0x23, 0xe2, 0x98, 0x1b, 0x7b,
+	0x24, 0x93, 0x0b, 0xc7, 0x88, 0x13, 0x47, 0x54, 0xaf, 0xaa, 0xed, 0xb6, 0xc7, 0x49, 0xb4, 0x17,
+	0xab, 0xde, 0xef, 0xfd, 0xa9, 0x57, 0xaf, 0x7f, 0x55, 0xef, 0x19, 0xae, 0xb3, 0xd1, 0xa8, 0xe4,
+	0x23, 0xa6, 0xf8, 0x6d, 0xbd, 0x7a, 0xc9, 0x54, 0x3c, 0x3e, 0x2a, 0x4a, 0xa1, 0x04, 0xe9, 0x2d,
+	0x55, 0x47, 0xe7, 0x3f, 0xb9, 0x71, 0xad, 0x10, 0x69, 0xae, 0x6e, 0xe3, 0xaf, 0x31, 0x08, 0xff,
+	0x00, 0xed, 0x13, 0x6d, 0x2f, 0xc9, 0xcf, 0xa0, 0x8d, 0x9e, 0xd2, 0x77, 0x86, 0x8d, 0x43, 0xef,
+	0x4e, 0x70, 0x54, 0xf7, 0x3d, 0x3a, 0xb6, 0x42, 0x2a, 0x72, 0x74, 0xa0, 0xd6, 0x9a, 0x5c, 0x87,
+	0x6e, 0x91, 0xc6, 0x93, 0x68, 0xc2, 0x2f, 0xfc, 0xdd, 0xa1, 0x73, 0xd8, 0xa4, 0x1d, 0x2d, 0x3f,
+	0xe6, 0x17, 0xe1, 0x57, 0x4d, 0x18, 0x6c, 0xfa, 0x91, 0x4f, 0xc1, 0x2b, 0xc5, 0x4c, 0xa5, 0xf9,
+	0x08, 0x5d, 0x1c, 0x74, 0x01, 0x0b, 0x3d, 0xe6, 0x17, 0xda, 0x20, 0x16, 0xf9, 0xab, 0x74, 0x14,
+	0x8d, 0x99, 0x1c, 0xdb, 0x98, 0x60, 0xa0, 0x47, 0x4c, 0x8e, 0xc9, 0x4d, 0x80, 0x92, 0xcd, 0x23,
+	0x83, 0xf8, 0x8d, 0xa1, 0x73, 0xd8, 0xa3, 0x6e, 0xc9, 0xe6, 0xa7, 0x08, 0x90, 0x2f, 0x60, 0xc0,
+	0x56, 0x9b, 0x46, 0xa2, 0x50, 0xd2, 0x6f, 0xe2, 0x91, 0xee, 0x7e, 0xf8, 0x48, 0x75, 0xe0, 0xac,
+	0x50, 0xf2, 0x61, 0xae, 0xca, 0x0b, 0xba, 0xcf, 0xd6, 0x51, 0xf2, 0x7d, 0x68, 0x63, 0x05, 0xa5,
+	0xdf, 0x1a, 0x3a, 0x87, 0xde, 0x9d, 0xfd, 0x23, 0x53, 0xd0, 0xa7, 0x27, 0x4f, 0x11, 0xa6, 0x56,
+	0xbd, 0x56, 0x99, 0xf6, 0x5a, 0x65, 0x08, 0x81, 0x66, 0x39, 0xcb, 0xb8, 0xdf, 0x19, 0x3a, 0x87,
+	0x2e, 0xc5, 0x35, 0xf9, 0x25, 0x00, 0x3f, 0xe7, 0xb9, 0x8a, 0x54, 0x3a, 0xe5, 0x7e, 0x77, 0xe8,
+	0x5c, 0xfd, 0x08, 0x0f, 0xb5, 0xfe, 0x79, 0x3a, 0xe5, 0x67, 0x85, 0x4e, 0x47, 0x52, 0x97, 0x57,
+	0x08, 0xb9, 0x0b, 0xed, 0x79, 0x9a, 0x27, 0x62, 0xee, 0xbb, 0xe8, 0xfa, 0xc9, 0xba, 0xeb, 0x0b,
+	0xd4, 0x55, 0x7e, 0xd6, 0x94, 0xdc, 0x07, 0x8f, 0x2f, 0x8a, 0x92, 0x4b, 0xa9, 0x61, 0x1f, 0xb0,
+	0x4c, 0xfe, 0xc6, 0xa6, 0x4b, 0x03, 0x5a, 0x37, 0xbe, 0xc1, 0xe0, 0x60, 0x5b, 0xc1, 0xc8, 0x00,
+	0x1a, 0xd5, 0x87, 0x75, 0xa9, 0x5e, 0x92, 0xbb, 0xd0, 0x3a, 0x67, 0xd9, 0x8c, 0xe3, 0xb7, 0xf4,
+	0xee, 0xdc, 0x7c, 0xef, 0x67, 0x38, 0xce, 0x46, 0x82, 0x1a, 0xdb, 0xfb, 0xbb, 0xf7, 0x9c, 0xf0,
+	0x39, 0xc0, 0x6a, 0x77, 0x5d, 0xb4, 0x9c, 0x4d, 0xb9, 0x8d, 0x8c, 0x6b, 0x8d, 0xe9, 0x9c, 0x30,
+	0xb2, 0x4b, 0x71, 0x4d, 0x02, 0xf0, 0x44, 0x1e, 0x25, 0xe9, 0x79, 0xf4, 0x47, 0x5e, 0x0a, 0x24,
+	0x88, 0x4b, 0x5d, 0x91, 0x3f, 0x48, 0xcf, 0x3f, 0xe7, 0xa5, 0x08, 0xcf, 0xa0, 0xbf, 0x56, 0x0d,
+	0x1d, 0x44, 0x5d, 0x14, 0xcb, 0xc0, 0x7a, 0x4d, 0xbe, 0x0d, 0xed, 0x8c, 0xe7, 0x23, 0x65, 0x08,
+	0xd8, 0xa0, 0x56, 0xd2, 0xb6, 0x52, 0xf1, 0x02, 0xa3, 0x36, 0x28, 0xae, 0xc3, 0x2f, 0x60, 0xb0,
+	0xf9, 0x65, 0xc8, 0x0f, 0x60, 0xc0, 0xb2, 0x4c, 0xcc, 0x79, 0x12, 0x65, 0x4c, 0xf1, 0x9c, 0x4b,
+	0x89, 0xf1, 0x1b, 0x74, 0xdf, 0xe2, 0xbf, 0xb5, 0xb0, 0x26, 0xbc, 0x36, 0x89, 0x0a, 0x91, 0xa5,
+	0xf1, 0x85, 0x3d, 0x0a, 0x68, 0xe8, 0x29, 0x22, 0xe1, 0xbf, 0x9a, 0xb0, 0xbf, 0x51, 0x25, 0x9d,
+	0xdf, 0x94, 0xab, 0xb1, 0x48, 0x6c, 0xd6, 0x56, 0x22, 0xdf, 0x81, 0x9e, 0x14, 0xb3, 0x32, 0xe6,
+	0xd1, 0xab, 0x94, 0x67, 0x89, 0x8d, 0xe6, 0x19, 0xec, 0xd7, 0x1a, 0xd2, 0xae, 0x96, 0x29, 0xe6,
+	0x10, 0x56, 0x22, 0xbf, 0x81, 0xbd, 0x71, 0x2a, 0x95, 0x18, 0x95, 0x6c, 0x6a, 0xae, 0x0d, 0x6c,
+	0x23, 0xe1, 0xa3, 0xca, 0xc6, 0x1e, 0xf5, 0xd1, 0x0e, 0xed, 0x8f, 0x6b, 0x98, 0x24, 0xc7, 0xe0,
+	0xf2, 0x45, 0x21, 0x4c, 0x0c, 0x0f, 0x63, 0x84, 0x57, 0x38, 0x25, 0xb6, 0xc4, 0xe9, 0x6a, 0x37,
+	0x0c, 0xf1, 0x00, 0xfa, 0x5f, 0xce, 0x58, 0xae, 0xd2, 0x8c, 0x9b, 0x30, 0xbd, 0x6d, 0xd4, 0xf9,
+	0x9d, 0x35, 0x59, 0x45, 0xe8, 0x7d, 0xb9, 0x82, 0x24, 0xb9, 0x07, 0xae, 0x12, 0xc5, 0xc4, 0x44,
+	0xe8, 0x63, 0x84, 0xeb, 0xeb, 0x11, 0x9e, 0x8b, 0xe2, 0x71, 0x6d, 0x7f, 0x6d, 0x8d, 0x9e, 0x2f,
+	0xe0, 0x20, 0x49, 0xa5, 0x4a, 0xf3, 0x58, 0x45, 0xc8, 0x47, 0x69, 0x82, 0xec, 0x61, 0x90, 0xef,
+	0xae, 0x07, 0x79, 0x60, 0x2d, 0x7f, 0x8f, 0x86, 0xab, 0x70, 0x24, 0xd9, 0x54, 0x48, 0xf2, 0x10,
+	0xba, 0x2c, 0x49, 0x22, 0xc5, 0x46, 0xd2, 0xef, 0xe2, 0x75, 0xfb, 0xe1, 0x07, 0xaf, 0xc3, 0xd1,
+	0x71, 0x92, 0x3c, 0x67, 0x23, 0xfb, 0x18, 0x75, 0x98, 0x91, 0x6e, 0xdc, 0x87, 0x5e, 0x5d, 0xb1,
+	0xe5, 0xd2, 0x1d, 0xd4, 0x2f, 0x9d, 0x5b, 0xbb, 0x55, 0x27, 0x2e, 0x74, 0x84, 0xc9, 0x31, 0xfc,
+	0x31, 0x0c, 0x36, 0x3f, 0x03, 0xf1, 0xa1, 0xf3, 0x72, 0x16, 0x4f, 0xb8, 0x32, 0x9d, 0xc0, 0xa1,
+	0x95, 0x18, 0xfe, 0xc3, 0x81, 0x83, 0x6d, 0x5f, 0x8e, 0x7c, 0x02, 0xee, 0x94, 0x2d, 0x22, 0x19,
+	0xb3, 0xcc, 0xdc, 0xa2, 0x16, 0xed, 0x4e, 0xd9, 0xe2, 0x99, 0x96, 0x35, 0xbd, 0xb5, 0xb2, 0x8a,
+	0xb9, 0x8b, 0x6a, 0x98, 0xb2, 0xc5, 0x89, 0x41, 0xc8, 0x2d, 0xd8, 0x2b, 0x79, 0x2c, 0xca, 0x24,
+	0x9a, 0xa6, 0x79, 0x34, 0x65, 0x0b, 0xe4, 0x65, 0x97, 0xf6, 0x0c, 0xfa, 0x24, 0xcd, 0x9f, 0xb0,
+	0x05, 0xf9, 0x0c, 0xf6, 0x93, 0x54, 0xb2, 0x97, 0x19, 0x5f, 0x9a, 0x35, 0xd1, 0xac, 0x6f, 0x61,
+	0x6b, 0x17, 0x42, 0x7f, 0xcc, 0x64, 0xb4, 0xca, 0xa7, 0x85, 0x56, 0xde, 0x98, 0xc9, 0x27, 0x36,
+	0xa5, 0xf0, 0xaf, 0x0e, 0xec, 0x6f, 0x70, 0x87, 0x0c, 0xc1, 0x2b, 0x78, 0x19, 0x73, 0x04, 0xab,
+	0xa3, 0xd7, 0x21, 0xf2, 0x23, 0xb8, 0x56, 0xf2, 0x8c, 0xa9, 0xf4, 0x9c, 0x47, 0x2c, 0x8e, 0x67,
+	0x25, 0xb3, 0xb7, 0xd5, 0xa1, 0x83, 0x4a, 0x71, 0x6c, 0x71, 0xfd, 0xf8, 0xe3, 0xa9, 0xd3, 0x5c,
+	0xe2, 0x71, 0xfa, 0xb4, 0xa3, 0x8f, 0x9c, 0xe6, 0x78, 0xdf, 0xf9, 0x34, 0x55, 0x91, 0x9c, 0x70,
+	0x15, 0x8f, 0xed, 0x29, 0x40, 0x43, 0xcf, 0x10, 0x09, 0xff, 0xb6, 0x0b, 0x7b, 0x55, 0x7a, 0x06,
+	0xda, 0xbe, 0xb7, 0xf3, 0x9e, 0xbd, 0x0f, 0xa0, 0x15, 0x8b, 0x59, 0xae, 0x6c, 0xef, 0x34, 0x82,
+	0xa6, 0x88, 0x9c, 0x4d, 0x31, 0x19, 0x87, 0xea, 0xa5, 0x46, 0xa6, 0x69, 0x8e, 0x09, 0x38, 0x54,
+	0x2f, 0x11, 0x61, 0x0b, 0xbf, 0x65, 0x11, 0xb6, 0xd0, 0xcd, 0x56, 0xbf, 0xa2, 0x91, 0x09, 0x68,
+	0xda, 0x98, 0xab, 0x91, 0x53, 0x0c, 0x7a, 0x13, 0xa0, 0x10, 0x32, 0x12, 0xaf, 0x5e, 0x49, 0xae,
+	0xb0, 0x9d, 0xb5, 0xa8, 0x5b, 0x08, 0x79, 0x86, 0x40, 0xa5, 0x46, 0x67, 0xc3, 0xf7, 0x26, 0xaa,
+	0xd1, 0x59, 0x6a, 0x75, 0xce, 0x47, 0x95, 0xb7, 0x6b, 0xbc, 0x73, 0x3e, 0x5a, 0x79, 0x6b, 0xb5,
+	0xf5, 0x06, 0xe3, 0x9d, 0xf3, 0x91, 0xf1, 0x0e, 0xff, 0x04, 0x5e, 0xed, 0xfa, 0x92, 0x1e, 0x38,
+	0x13, 0x4b, 0x3e, 0x67, 0xa2, 0xdf, 0xc1, 0x39, 0x4f, 0x47, 0x63, 0xb5, 0xfe, 0x0e, 0x1a, 0xcc,
+	0xbc, 0x83, 0x37, 0xa0, 0x1b, 0xb3, 0x82, 0xc5, 0xa9, 0xba, 0xc0, 0xaa, 0xb4, 0xe8, 0x52, 0xfe,
+	0xf8, 0x37, 0xfa, 0x1e, 0x7c, 0x6b, 0xeb, 0xb5, 0x5f, 0x4f, 0x23, 0x9c, 0x01, 0xe8, 0x1c, 0xed,
+	0x57, 0xac, 0xef, 0xe8, 0x6c, 0xec, 0x78, 0x00, 0x2d, 0x25, 0x14, 0xcb, 0x2c, 0xa3, 0x8c, 0x40,
+	0x7e, 0x0a, 0x5d, 0x3c, 0x3e, 0x2f, 0x35, 0x8d, 0x1a, 0xdb, 0x1f, 0xb0, 0x53, 0x63, 0x41, 0x97,
+	0xa6, 0xe1, 0x19, 0x78, 0x35, 0x85, 0x6e, 0x5a, 0xa9, 0xe2, 0xd3, 0xaa, 0xc1, 0xe9, 0x35, 0x76,
+	0x01, 0x2c, 0x86, 0xdd, 0xd0, 0x4a, 0x3a, 0x0f, 0x5e, 0x96, 0xa2, 0xb4, 0x44, 0x31, 0x42, 0xf8,
+	0xbf, 0x06, 0x90, 0x53, 0x96, 0xc5, 0xb3, 0x8c, 0x29, 0x51, 0x3e, 0xcb, 0x59, 0x21, 0xc7, 0x42,
+	0x99, 0xa4, 0x27, 0x3c, 0xb7, 0x91, 0x8d, 0xa0, 0x43, 0xf3, 0x45, 0x91, 0x96, 0xbc, 0xea, 0x9d,
+	0x46, 0xaa, 0xf5, 0xac, 0xc6, 0x5a, 0xcf, 0xb2, 0x8f, 0x57, 0x73, 0xf5, 0x78, 0x11, 0x68, 0xe2,
+	0xf0, 0xd7, 0x42, 0xbe, 0xe1, 0xba, 0xd6, 0xb6, 0xda, 0x6b, 0x6d, 0xeb, 0x16, 0xec, 0xe5, 0x7c,
+	0xa1, 0xa2, 0x39, 0xcb, 0x32, 0x33, 0x3b, 0x75, 0x50, 0xdf, 0xd3, 0xe8, 0x0b, 0x96, 0x65, 0x38,
+	0x1e, 0xdd, 0x82, 0x96, 0x54, 0x4c, 0x55, 0x83, 0xd5, 0xde, 0xfa, 0xd0, 0x46, 0x8d, 0x72, 0x39,
+	0x97, 0xb9, 0xb5, 0xb9, 0xec, 0xe6, 0xda, 0x5c, 0x06, 0xc8, 0x84, 0xda, 0xdc, 0xf5, 0x0b, 0xf0,
+	0x4c, 0x22, 0xf5, 0x76, 0xf7, 0xc1, 0xe1, 0x0b, 0xe6, 0x95, 0x88, 0xa3, 0x47, 0xc1, 0x72, 0x8e,
+	0xed, 0xad, 0x4b, 0x71, 0xad, 0x5f, 0xd3, 0x8c, 0x49, 0x15, 0x69, 0xb6, 0x61, 0xd7, 0x6a, 0xd0,
+	0xae, 0x06, 0x1e, 0x4e, 0x53, 0xac, 0xf8, 0xa8, 0x14, 0xb3, 0x02, 0x3b, 0x51, 0x93, 0x1a, 0x61,
+	0x73, 0x8e, 0xdb, 0xff, 0x06, 0x73, 0x1c, 0x09, 0x00, 0x62, 0x51, 0x96, 0x3c, 0xd6, 0xd9, 0xf9,
+	0x03, 0xc3, 0xf4, 0x15, 0x12, 0x7e, 0xe5, 0xc0, 0xb5, 0xe5, 0x78, 0xf3, 0x91, 0x2f, 0x5f, 0xd5,
+	0x6f, 0xb7, 0x56, 0x3f, 0xfb, 0x12, 0x62, 0xf5, 0xcc, 0xc0, 0xa1, 0x5f, 0x42, 0xac, 0xdd, 0xbd,
+	0x65, 0x27, 0xf2, 0x9b, 0xdb, 0x46, 0x8d, 0x2b, 0xf3, 0x6e, 0x65, 0xae, 0x37, 0xd2, 0x03, 0x12,
+	0x12, 0xa4, 0x41, 0x71, 0xad, 0x09, 0x12, 0x67, 0x42, 0xf2, 0xa4, 0x22, 0x88, 0x91, 0x42, 0x0a,
+	0xe4, 0x59, 0x9a, 0xf0, 0xb3, 0x99, 0x2a, 0x66, 0xea, 0x23, 0x07, 0xf8, 0x6c, 0x39, 0xdc, 0xef,
+	0x0e, 0x1b, 0x5b, 0x78, 0x62, 0xb5, 0xe1, 0x7f, 0x1d, 0xe8, 0x9f, 0xb2, 0x78, 0xbc, 0x2a, 0x88,
+	0x0f, 0x1d, 0x43, 0xf3, 0xc4, 0xce, 0x79, 0x95, 0x48, 0x4e, 0xc0, 0x8b, 0x97, 0x57, 0xa7, 0x0a,
+	0x3c, 0x5c, 0x3f, 0xe9, 0xd5, 0xbb, 0x45, 0xeb, 0x4e, 0xe4, 0x57, 0xe0, 0xad, 0x48, 0x58, 0x3d,
+	0x05, 0x9f, 0xbe, 0xa7, 0x5a, 0xcb, 0x10, 0xb0, 0xa4, 0xa9, 0x1e, 0xca, 0x3c, 0x99, 0x26, 0x3c,
+	0x12, 0x58, 0x06, 0xbf, 0xb9, 0x2d, 0x8b, 0xab, 0x65, 0xa2, 0x20, 0x97, 0xd8, 0xc9, 0xf1, 0xeb,
+	0x37, 0xc1, 0xce, 0xd7, 0x6f, 0x82, 0x9d, 0x77, 0x6f, 0x02, 0xe7, 0xcf, 0x97, 0x81, 0xf3, 0xf7,
+	0xcb, 0xc0, 0xf9, 0xe7, 0x65, 0xe0, 0xbc, 0xbe, 0x0c, 0x9c, 0x7f, 0x5f, 0x06, 0xce, 0x7f, 0x2e,
+	0x83, 0x9d, 0x77, 0x97, 0x81, 0xf3, 0x97, 0xb7, 0xc1, 0xce, 0xeb, 0xb7, 0xc1, 0xce, 0xd7, 0x6f,
+	0x83, 0x9d, 0xcf, 0xbd, 0xdb, 0x3f, 0x5f, 0x6e, 0xf2, 0xb2, 0x8d, 0x7f, 0x3b, 0xef, 0xfe, 0x7f,
+	0x00, 0x3d, 0xe3, 0x39, 0x27, 0xb4, 0x0e, 0x00, 0x00,
+}
+
+func (this *Batchs) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*ExpoHistogramOptions)
+	that1, ok := that.(*Batchs)
 	if !ok {
-		that2, ok := that.(ExpoHistogramOptions)
+		that2, ok := that.(Batchs)
 		if ok {
 			that1 = &that2
 		} else {
@@ -749,25 +1621,27 @@ func (this *ExpoHistogramOptions) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if this.MaxScale != that1.MaxScale {
+	if len(this.Batchs) != len(that1.Batchs) {
 		return false
 	}
-	if this.MaxBuckets != that1.MaxBuckets {
-		return false
+	for i := range this.Batchs {
+		if !this.Batchs[i].Equal(that1.Batchs[i]) {
+			return false
+		}
 	}
-	if this.RecordMinMax != that1.RecordMinMax {
+	if this.PickKey != that1.PickKey {
 		return false
 	}
 	return true
 }
-func (this *QuantileOptions) Equal(that interface{}) bool {
+func (this *AggregationBatch) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*QuantileOptions)
+	that1, ok := that.(*AggregationBatch)
 	if !ok {
-		that2, ok := that.(QuantileOptions)
+		that2, ok := that.(AggregationBatch)
 		if ok {
 			that1 = &that2
 		} else {
@@ -779,836 +1653,5347 @@ func (this *QuantileOptions) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if len(this.Percentiles) != len(that1.Percentiles) {
+	if this.RoutingKey != that1.RoutingKey {
 		return false
 	}
-	for i := range this.Percentiles {
-		if this.Percentiles[i] != that1.Percentiles[i] {
+	if this.ConfigHash != that1.ConfigHash {
+		return false
+	}
+	if !bytes.Equal(this.RawConfig, that1.RawConfig) {
+		return false
+	}
+	if len(this.AggregationOpts) != len(that1.AggregationOpts) {
+		return false
+	}
+	for i := range this.AggregationOpts {
+		if !this.AggregationOpts[i].Equal(that1.AggregationOpts[i]) {
 			return false
 		}
 	}
-	return true
-}
-func (this *Batchs) GoString() string {
-	if this == nil {
-		return "nil"
+	if !this.Points.Equal(that1.Points) {
+		return false
 	}
-	s := make([]string, 0, 6)
-	s = append(s, "&aggregate.Batchs{")
-	if this.Batchs != nil {
-		s = append(s, "Batchs: "+fmt.Sprintf("%#v", this.Batchs)+",\n")
+	if this.PickKey != that1.PickKey {
+		return false
 	}
-	s = append(s, "PickKey: "+fmt.Sprintf("%#v", this.PickKey)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *AggregationBatch) GoString() string {
-	if this == nil {
-		return "nil"
+	if this.Rule != that1.Rule {
+		return false
 	}
-	s := make([]string, 0, 10)
-	s = append(s, "&aggregate.AggregationBatch{")
-	s = append(s, "RoutingKey: "+fmt.Sprintf("%#v", this.RoutingKey)+",\n")
-	s = append(s, "ConfigHash: "+fmt.Sprintf("%#v", this.ConfigHash)+",\n")
-	s = append(s, "RawConfig: "+fmt.Sprintf("%#v", this.RawConfig)+",\n")
-	keysForAggregationOpts := make([]string, 0, len(this.AggregationOpts))
-	for k, _ := range this.AggregationOpts {
-		keysForAggregationOpts = append(keysForAggregationOpts, k)
+	if !this.EventTime.Equal(that1.EventTime) {
+		return false
 	}
-	github_com_gogo_protobuf_sortkeys.Strings(keysForAggregationOpts)
-	mapStringForAggregationOpts := "map[string]*AggregationAlgo{"
-	for _, k := range keysForAggregationOpts {
-		mapStringForAggregationOpts += fmt.Sprintf("%#v: %#v,", k, this.AggregationOpts[k])
+	if !this.Window.Equal(that1.Window) {
+		return false
 	}
-	mapStringForAggregationOpts += "}"
-	if this.AggregationOpts != nil {
-		s = append(s, "AggregationOpts: "+mapStringForAggregationOpts+",\n")
+	if len(this.Expressions) != len(that1.Expressions) {
+		return false
 	}
-	if this.Points != nil {
-		s = append(s, "Points: "+fmt.Sprintf("%#v", this.Points)+",\n")
+	for i := range this.Expressions {
+		if !this.Expressions[i].Equal(that1.Expressions[i]) {
+			return false
+		}
 	}
-	s = append(s, "PickKey: "+fmt.Sprintf("%#v", this.PickKey)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
+	return true
 }
-func (this *AggregationAlgo) GoString() string {
-	if this == nil {
-		return "nil"
+func (this *Expression) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	s := make([]string, 0, 11)
-	s = append(s, "&aggregate.AggregationAlgo{")
-	s = append(s, "Method: "+fmt.Sprintf("%#v", this.Method)+",\n")
-	s = append(s, "SourceField: "+fmt.Sprintf("%#v", this.SourceField)+",\n")
-	s = append(s, "Window: "+fmt.Sprintf("%#v", this.Window)+",\n")
-	if this.Options != nil {
-		s = append(s, "Options: "+fmt.Sprintf("%#v", this.Options)+",\n")
+
+	that1, ok := that.(*Expression)
+	if !ok {
+		that2, ok := that.(Expression)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	keysForAddTags := make([]string, 0, len(this.AddTags))
-	for k, _ := range this.AddTags {
-		keysForAddTags = append(keysForAddTags, k)
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	github_com_gogo_protobuf_sortkeys.Strings(keysForAddTags)
-	mapStringForAddTags := "map[string]string{"
-	for _, k := range keysForAddTags {
-		mapStringForAddTags += fmt.Sprintf("%#v: %#v,", k, this.AddTags[k])
+	if this.Name != that1.Name {
+		return false
 	}
-	mapStringForAddTags += "}"
-	if this.AddTags != nil {
-		s = append(s, "AddTags: "+mapStringForAddTags+",\n")
+	if this.Expr != that1.Expr {
+		return false
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *AggregationAlgo_HistogramOpts) GoString() string {
-	if this == nil {
-		return "nil"
+	if this.OnDivZero != that1.OnDivZero {
+		return false
 	}
-	s := strings.Join([]string{`&aggregate.AggregationAlgo_HistogramOpts{` +
-		`HistogramOpts:` + fmt.Sprintf("%#v", this.HistogramOpts) + `}`}, ", ")
-	return s
+	return true
 }
-func (this *AggregationAlgo_ExpoOpts) GoString() string {
-	if this == nil {
-		return "nil"
+func (this *WindowOptions) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	s := strings.Join([]string{`&aggregate.AggregationAlgo_ExpoOpts{` +
-		`ExpoOpts:` + fmt.Sprintf("%#v", this.ExpoOpts) + `}`}, ", ")
-	return s
-}
-func (this *AggregationAlgo_QuantileOpts) GoString() string {
-	if this == nil {
-		return "nil"
+
+	that1, ok := that.(*WindowOptions)
+	if !ok {
+		that2, ok := that.(WindowOptions)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	s := strings.Join([]string{`&aggregate.AggregationAlgo_QuantileOpts{` +
-		`QuantileOpts:` + fmt.Sprintf("%#v", this.QuantileOpts) + `}`}, ", ")
-	return s
-}
-func (this *HistogramOptions) GoString() string {
-	if this == nil {
-		return "nil"
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	s := make([]string, 0, 5)
-	s = append(s, "&aggregate.HistogramOptions{")
-	s = append(s, "Buckets: "+fmt.Sprintf("%#v", this.Buckets)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *ExpoHistogramOptions) GoString() string {
-	if this == nil {
-		return "nil"
+	if this.Type != that1.Type {
+		return false
 	}
-	s := make([]string, 0, 7)
-	s = append(s, "&aggregate.ExpoHistogramOptions{")
-	s = append(s, "MaxScale: "+fmt.Sprintf("%#v", this.MaxScale)+",\n")
-	s = append(s, "MaxBuckets: "+fmt.Sprintf("%#v", this.MaxBuckets)+",\n")
-	s = append(s, "RecordMinMax: "+fmt.Sprintf("%#v", this.RecordMinMax)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *QuantileOptions) GoString() string {
-	if this == nil {
-		return "nil"
+	if this.Length != that1.Length {
+		return false
 	}
-	s := make([]string, 0, 5)
-	s = append(s, "&aggregate.QuantileOptions{")
-	s = append(s, "Percentiles: "+fmt.Sprintf("%#v", this.Percentiles)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func valueToGoStringAggrbatch(v interface{}, typ string) string {
-	rv := reflect.ValueOf(v)
-	if rv.IsNil() {
-		return "nil"
+	if this.Step != that1.Step {
+		return false
 	}
-	pv := reflect.Indirect(rv).Interface()
-	return fmt.Sprintf("func(v %v) *%v { return &v } ( %#v )", typ, typ, pv)
+	return true
 }
-func (m *Batchs) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (this *EventTimeOptions) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	return dAtA[:n], nil
-}
-
-func (m *Batchs) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
-}
 
-func (m *Batchs) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.PickKey != 0 {
-		i = encodeVarintAggrbatch(dAtA, i, uint64(m.PickKey))
-		i--
-		dAtA[i] = 0x10
-	}
-	if len(m.Batchs) > 0 {
-		for iNdEx := len(m.Batchs) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Batchs[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintAggrbatch(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0xa
+	that1, ok := that.(*EventTimeOptions)
+	if !ok {
+		that2, ok := that.(EventTimeOptions)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
 		}
 	}
-	return len(dAtA) - i, nil
-}
-
-func (m *AggregationBatch) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	return dAtA[:n], nil
-}
-
-func (m *AggregationBatch) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	if this.AllowedLateness != that1.AllowedLateness {
+		return false
+	}
+	if this.LatePolicy != that1.LatePolicy {
+		return false
+	}
+	return true
 }
-
-func (m *AggregationBatch) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.PickKey != 0 {
-		i = encodeVarintAggrbatch(dAtA, i, uint64(m.PickKey))
-		i--
-		dAtA[i] = 0x30
+func (this *AggregationAlgo) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	if m.Points != nil {
-		{
-			size, err := m.Points.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintAggrbatch(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x2a
-	}
-	if len(m.AggregationOpts) > 0 {
-		for k := range m.AggregationOpts {
-			v := m.AggregationOpts[k]
-			baseI := i
-			if v != nil {
-				{
-					size, err := v.MarshalToSizedBuffer(dAtA[:i])
-					if err != nil {
-						return 0, err
-					}
-					i -= size
-					i = encodeVarintAggrbatch(dAtA, i, uint64(size))
-				}
-				i--
-				dAtA[i] = 0x12
-			}
-			i -= len(k)
-			copy(dAtA[i:], k)
-			i = encodeVarintAggrbatch(dAtA, i, uint64(len(k)))
-			i--
-			dAtA[i] = 0xa
-			i = encodeVarintAggrbatch(dAtA, i, uint64(baseI-i))
-			i--
-			dAtA[i] = 0x22
+
+	that1, ok := that.(*AggregationAlgo)
+	if !ok {
+		that2, ok := that.(AggregationAlgo)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
 		}
 	}
-	if len(m.RawConfig) > 0 {
-		i -= len(m.RawConfig)
-		copy(dAtA[i:], m.RawConfig)
-		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.RawConfig)))
-		i--
-		dAtA[i] = 0x1a
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	if m.ConfigHash != 0 {
-		i = encodeVarintAggrbatch(dAtA, i, uint64(m.ConfigHash))
-		i--
-		dAtA[i] = 0x10
+	if this.Method != that1.Method {
+		return false
 	}
-	if m.RoutingKey != 0 {
-		i = encodeVarintAggrbatch(dAtA, i, uint64(m.RoutingKey))
-		i--
-		dAtA[i] = 0x8
+	if this.SourceField != that1.SourceField {
+		return false
 	}
-	return len(dAtA) - i, nil
-}
-
-func (m *AggregationAlgo) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+	if this.Window != that1.Window {
+		return false
 	}
-	return dAtA[:n], nil
-}
-
-func (m *AggregationAlgo) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
-}
-
-func (m *AggregationAlgo) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.Options != nil {
-		{
-			size := m.Options.Size()
-			i -= size
-			if _, err := m.Options.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
-			}
+	if that1.Options == nil {
+		if this.Options != nil {
+			return false
 		}
+	} else if this.Options == nil {
+		return false
+	} else if !this.Options.Equal(that1.Options) {
+		return false
 	}
-	if len(m.AddTags) > 0 {
-		for k := range m.AddTags {
-			v := m.AddTags[k]
-			baseI := i
-			i -= len(v)
-			copy(dAtA[i:], v)
-			i = encodeVarintAggrbatch(dAtA, i, uint64(len(v)))
-			i--
-			dAtA[i] = 0x12
-			i -= len(k)
-			copy(dAtA[i:], k)
-			i = encodeVarintAggrbatch(dAtA, i, uint64(len(k)))
-			i--
-			dAtA[i] = 0xa
-			i = encodeVarintAggrbatch(dAtA, i, uint64(baseI-i))
-			i--
-			dAtA[i] = 0x42
+	if len(this.AddTags) != len(that1.AddTags) {
+		return false
+	}
+	for i := range this.AddTags {
+		if this.AddTags[i] != that1.AddTags[i] {
+			return false
 		}
 	}
-	if m.Window != 0 {
-		i = encodeVarintAggrbatch(dAtA, i, uint64(m.Window))
-		i--
-		dAtA[i] = 0x18
+	return true
+}
+func (this *AggregationAlgo_HistogramOpts) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	if len(m.SourceField) > 0 {
-		i -= len(m.SourceField)
-		copy(dAtA[i:], m.SourceField)
-		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.SourceField)))
-		i--
-		dAtA[i] = 0x12
+
+	that1, ok := that.(*AggregationAlgo_HistogramOpts)
+	if !ok {
+		that2, ok := that.(AggregationAlgo_HistogramOpts)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	if len(m.Method) > 0 {
-		i -= len(m.Method)
-		copy(dAtA[i:], m.Method)
-		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.Method)))
-		i--
-		dAtA[i] = 0xa
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	return len(dAtA) - i, nil
+	if !this.HistogramOpts.Equal(that1.HistogramOpts) {
+		return false
+	}
+	return true
 }
+func (this *AggregationAlgo_ExpoOpts) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (m *AggregationAlgo_HistogramOpts) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	that1, ok := that.(*AggregationAlgo_ExpoOpts)
+	if !ok {
+		that2, ok := that.(AggregationAlgo_ExpoOpts)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !this.ExpoOpts.Equal(that1.ExpoOpts) {
+		return false
+	}
+	return true
 }
+func (this *AggregationAlgo_QuantileOpts) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (m *AggregationAlgo_HistogramOpts) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	if m.HistogramOpts != nil {
-		{
-			size, err := m.HistogramOpts.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+	that1, ok := that.(*AggregationAlgo_QuantileOpts)
+	if !ok {
+		that2, ok := that.(AggregationAlgo_QuantileOpts)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
 		}
-		i--
-		dAtA[i] = 0x52
 	}
-	return len(dAtA) - i, nil
-}
-func (m *AggregationAlgo_ExpoOpts) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !this.QuantileOpts.Equal(that1.QuantileOpts) {
+		return false
+	}
+	return true
 }
+func (this *AggregationAlgo_TopkOpts) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (m *AggregationAlgo_ExpoOpts) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	if m.ExpoOpts != nil {
-		{
-			size, err := m.ExpoOpts.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+	that1, ok := that.(*AggregationAlgo_TopkOpts)
+	if !ok {
+		that2, ok := that.(AggregationAlgo_TopkOpts)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
 		}
-		i--
-		dAtA[i] = 0x5a
 	}
-	return len(dAtA) - i, nil
-}
-func (m *AggregationAlgo_QuantileOpts) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !this.TopkOpts.Equal(that1.TopkOpts) {
+		return false
+	}
+	return true
 }
+func (this *AggregationAlgo_DistinctValuesOpts) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (m *AggregationAlgo_QuantileOpts) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	if m.QuantileOpts != nil {
-		{
-			size, err := m.QuantileOpts.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+	that1, ok := that.(*AggregationAlgo_DistinctValuesOpts)
+	if !ok {
+		that2, ok := that.(AggregationAlgo_DistinctValuesOpts)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
 		}
-		i--
-		dAtA[i] = 0x62
 	}
-	return len(dAtA) - i, nil
-}
-func (m *HistogramOptions) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	return dAtA[:n], nil
-}
-
-func (m *HistogramOptions) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
-}
-
-func (m *HistogramOptions) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Buckets) > 0 {
-		for iNdEx := len(m.Buckets) - 1; iNdEx >= 0; iNdEx-- {
-			f6 := math.Float64bits(float64(m.Buckets[iNdEx]))
-			i -= 8
-			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f6))
-		}
-		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.Buckets)*8))
-		i--
-		dAtA[i] = 0xa
+	if !this.DistinctValuesOpts.Equal(that1.DistinctValuesOpts) {
+		return false
 	}
-	return len(dAtA) - i, nil
+	return true
 }
-
-func (m *ExpoHistogramOptions) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (this *HistogramOptions) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	return dAtA[:n], nil
-}
-
-func (m *ExpoHistogramOptions) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
-}
 
-func (m *ExpoHistogramOptions) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.RecordMinMax {
-		i--
-		if m.RecordMinMax {
-			dAtA[i] = 1
+	that1, ok := that.(*HistogramOptions)
+	if !ok {
+		that2, ok := that.(HistogramOptions)
+		if ok {
+			that1 = &that2
 		} else {
-			dAtA[i] = 0
+			return false
 		}
-		i--
-		dAtA[i] = 0x18
 	}
-	if m.MaxBuckets != 0 {
-		i = encodeVarintAggrbatch(dAtA, i, uint64(m.MaxBuckets))
-		i--
-		dAtA[i] = 0x10
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	if m.MaxScale != 0 {
-		i = encodeVarintAggrbatch(dAtA, i, uint64(m.MaxScale))
-		i--
-		dAtA[i] = 0x8
+	if len(this.Buckets) != len(that1.Buckets) {
+		return false
 	}
-	return len(dAtA) - i, nil
-}
-
-func (m *QuantileOptions) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+	for i := range this.Buckets {
+		if this.Buckets[i] != that1.Buckets[i] {
+			return false
+		}
 	}
-	return dAtA[:n], nil
-}
-
-func (m *QuantileOptions) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	return true
 }
+func (this *ExpoHistogramOptions) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (m *QuantileOptions) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if len(m.Percentiles) > 0 {
-		for iNdEx := len(m.Percentiles) - 1; iNdEx >= 0; iNdEx-- {
-			f7 := math.Float64bits(float64(m.Percentiles[iNdEx]))
-			i -= 8
-			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f7))
+	that1, ok := that.(*ExpoHistogramOptions)
+	if !ok {
+		that2, ok := that.(ExpoHistogramOptions)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
 		}
-		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.Percentiles)*8))
-		i--
-		dAtA[i] = 0xa
 	}
-	return len(dAtA) - i, nil
-}
-
-func encodeVarintAggrbatch(dAtA []byte, offset int, v uint64) int {
-	offset -= sovAggrbatch(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	dAtA[offset] = uint8(v)
-	return base
-}
-func (m *Batchs) Size() (n int) {
-	if m == nil {
-		return 0
+	if this.MaxScale != that1.MaxScale {
+		return false
 	}
-	var l int
-	_ = l
-	if len(m.Batchs) > 0 {
-		for _, e := range m.Batchs {
-			l = e.Size()
-			n += 1 + l + sovAggrbatch(uint64(l))
-		}
+	if this.MaxBuckets != that1.MaxBuckets {
+		return false
 	}
-	if m.PickKey != 0 {
-		n += 1 + sovAggrbatch(uint64(m.PickKey))
+	if this.RecordMinMax != that1.RecordMinMax {
+		return false
 	}
-	return n
+	if this.DisableMinMax != that1.DisableMinMax {
+		return false
+	}
+	if this.HasMaxScale != that1.HasMaxScale {
+		return false
+	}
+	return true
 }
-
-func (m *AggregationBatch) Size() (n int) {
-	if m == nil {
-		return 0
+func (this *QuantileOptions) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	var l int
-	_ = l
-	if m.RoutingKey != 0 {
-		n += 1 + sovAggrbatch(uint64(m.RoutingKey))
+
+	that1, ok := that.(*QuantileOptions)
+	if !ok {
+		that2, ok := that.(QuantileOptions)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	if m.ConfigHash != 0 {
-		n += 1 + sovAggrbatch(uint64(m.ConfigHash))
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	l = len(m.RawConfig)
-	if l > 0 {
-		n += 1 + l + sovAggrbatch(uint64(l))
+	if len(this.Percentiles) != len(that1.Percentiles) {
+		return false
 	}
-	if len(m.AggregationOpts) > 0 {
-		for k, v := range m.AggregationOpts {
-			_ = k
-			_ = v
-			l = 0
-			if v != nil {
-				l = v.Size()
-				l += 1 + sovAggrbatch(uint64(l))
-			}
-			mapEntrySize := 1 + len(k) + sovAggrbatch(uint64(len(k))) + l
-			n += mapEntrySize + 1 + sovAggrbatch(uint64(mapEntrySize))
+	for i := range this.Percentiles {
+		if this.Percentiles[i] != that1.Percentiles[i] {
+			return false
 		}
 	}
-	if m.Points != nil {
-		l = m.Points.Size()
-		n += 1 + l + sovAggrbatch(uint64(l))
+	if this.RelativeAccuracy != that1.RelativeAccuracy {
+		return false
 	}
-	if m.PickKey != 0 {
-		n += 1 + sovAggrbatch(uint64(m.PickKey))
+	if this.MaxBins != that1.MaxBins {
+		return false
 	}
-	return n
+	if this.EmitSketch != that1.EmitSketch {
+		return false
+	}
+	return true
 }
+func (this *QuantileSketch) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (m *AggregationAlgo) Size() (n int) {
-	if m == nil {
-		return 0
+	that1, ok := that.(*QuantileSketch)
+	if !ok {
+		that2, ok := that.(QuantileSketch)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	var l int
-	_ = l
-	l = len(m.Method)
-	if l > 0 {
-		n += 1 + l + sovAggrbatch(uint64(l))
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	l = len(m.SourceField)
-	if l > 0 {
-		n += 1 + l + sovAggrbatch(uint64(l))
+	if this.RelativeAccuracy != that1.RelativeAccuracy {
+		return false
 	}
-	if m.Window != 0 {
-		n += 1 + sovAggrbatch(uint64(m.Window))
+	if this.Count != that1.Count {
+		return false
 	}
-	if len(m.AddTags) > 0 {
-		for k, v := range m.AddTags {
-			_ = k
-			_ = v
-			mapEntrySize := 1 + len(k) + sovAggrbatch(uint64(len(k))) + 1 + len(v) + sovAggrbatch(uint64(len(v)))
-			n += mapEntrySize + 1 + sovAggrbatch(uint64(mapEntrySize))
-		}
+	if this.Sum != that1.Sum {
+		return false
 	}
-	if m.Options != nil {
-		n += m.Options.Size()
+	if this.Min != that1.Min {
+		return false
 	}
-	return n
-}
-
-func (m *AggregationAlgo_HistogramOpts) Size() (n int) {
-	if m == nil {
-		return 0
+	if this.Max != that1.Max {
+		return false
 	}
-	var l int
-	_ = l
-	if m.HistogramOpts != nil {
-		l = m.HistogramOpts.Size()
-		n += 1 + l + sovAggrbatch(uint64(l))
+	if this.ZeroCount != that1.ZeroCount {
+		return false
 	}
-	return n
-}
-func (m *AggregationAlgo_ExpoOpts) Size() (n int) {
-	if m == nil {
-		return 0
+	if this.PosOffset != that1.PosOffset {
+		return false
 	}
-	var l int
-	_ = l
-	if m.ExpoOpts != nil {
-		l = m.ExpoOpts.Size()
-		n += 1 + l + sovAggrbatch(uint64(l))
+	if len(this.PosCounts) != len(that1.PosCounts) {
+		return false
 	}
-	return n
-}
-func (m *AggregationAlgo_QuantileOpts) Size() (n int) {
-	if m == nil {
-		return 0
+	for i := range this.PosCounts {
+		if this.PosCounts[i] != that1.PosCounts[i] {
+			return false
+		}
 	}
-	var l int
-	_ = l
-	if m.QuantileOpts != nil {
-		l = m.QuantileOpts.Size()
-		n += 1 + l + sovAggrbatch(uint64(l))
+	if this.NegOffset != that1.NegOffset {
+		return false
 	}
-	return n
-}
-func (m *HistogramOptions) Size() (n int) {
-	if m == nil {
-		return 0
+	if len(this.NegCounts) != len(that1.NegCounts) {
+		return false
 	}
-	var l int
-	_ = l
-	if len(m.Buckets) > 0 {
-		n += 1 + sovAggrbatch(uint64(len(m.Buckets)*8)) + len(m.Buckets)*8
+	for i := range this.NegCounts {
+		if this.NegCounts[i] != that1.NegCounts[i] {
+			return false
+		}
 	}
-	return n
+	return true
 }
+func (this *TopKOptions) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (m *ExpoHistogramOptions) Size() (n int) {
-	if m == nil {
-		return 0
+	that1, ok := that.(*TopKOptions)
+	if !ok {
+		that2, ok := that.(TopKOptions)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	var l int
-	_ = l
-	if m.MaxScale != 0 {
-		n += 1 + sovAggrbatch(uint64(m.MaxScale))
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	if m.MaxBuckets != 0 {
-		n += 1 + sovAggrbatch(uint64(m.MaxBuckets))
+	if this.K != that1.K {
+		return false
 	}
-	if m.RecordMinMax {
-		n += 2
+	if this.WeightField != that1.WeightField {
+		return false
 	}
-	return n
+	if this.Capacity != that1.Capacity {
+		return false
+	}
+	if this.EmitSketch != that1.EmitSketch {
+		return false
+	}
+	return true
 }
+func (this *DistinctValuesOptions) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func (m *QuantileOptions) Size() (n int) {
-	if m == nil {
-		return 0
+	that1, ok := that.(*DistinctValuesOptions)
+	if !ok {
+		that2, ok := that.(DistinctValuesOptions)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	var l int
-	_ = l
-	if len(m.Percentiles) > 0 {
-		n += 1 + sovAggrbatch(uint64(len(m.Percentiles)*8)) + len(m.Percentiles)*8
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	return n
+	if this.K != that1.K {
+		return false
+	}
+	return true
 }
+func (this *TopKSketch) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
 
-func sovAggrbatch(x uint64) (n int) {
-	return (math_bits.Len64(x|1) + 6) / 7
-}
-func sozAggrbatch(x uint64) (n int) {
-	return sovAggrbatch(uint64((x << 1) ^ uint64((int64(x) >> 63))))
-}
-func (this *Batchs) String() string {
-	if this == nil {
-		return "nil"
+	that1, ok := that.(*TopKSketch)
+	if !ok {
+		that2, ok := that.(TopKSketch)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	repeatedStringForBatchs := "[]*AggregationBatch{"
-	for _, f := range this.Batchs {
-		repeatedStringForBatchs += strings.Replace(f.String(), "AggregationBatch", "AggregationBatch", 1) + ","
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	repeatedStringForBatchs += "}"
-	s := strings.Join([]string{`&Batchs{`,
-		`Batchs:` + repeatedStringForBatchs + `,`,
-		`PickKey:` + fmt.Sprintf("%v", this.PickKey) + `,`,
-		`}`,
-	}, "")
-	return s
-}
-func (this *AggregationBatch) String() string {
-	if this == nil {
-		return "nil"
+	if this.Capacity != that1.Capacity {
+		return false
 	}
-	keysForAggregationOpts := make([]string, 0, len(this.AggregationOpts))
-	for k, _ := range this.AggregationOpts {
-		keysForAggregationOpts = append(keysForAggregationOpts, k)
+	if this.Total != that1.Total {
+		return false
 	}
-	github_com_gogo_protobuf_sortkeys.Strings(keysForAggregationOpts)
-	mapStringForAggregationOpts := "map[string]*AggregationAlgo{"
-	for _, k := range keysForAggregationOpts {
-		mapStringForAggregationOpts += fmt.Sprintf("%v: %v,", k, this.AggregationOpts[k])
+	if len(this.Counters) != len(that1.Counters) {
+		return false
 	}
-	mapStringForAggregationOpts += "}"
-	s := strings.Join([]string{`&AggregationBatch{`,
-		`RoutingKey:` + fmt.Sprintf("%v", this.RoutingKey) + `,`,
-		`ConfigHash:` + fmt.Sprintf("%v", this.ConfigHash) + `,`,
-		`RawConfig:` + fmt.Sprintf("%v", this.RawConfig) + `,`,
-		`AggregationOpts:` + mapStringForAggregationOpts + `,`,
-		`Points:` + strings.Replace(fmt.Sprintf("%v", this.Points), "PBPoints", "point.PBPoints", 1) + `,`,
-		`PickKey:` + fmt.Sprintf("%v", this.PickKey) + `,`,
-		`}`,
-	}, "")
-	return s
+	for i := range this.Counters {
+		if !this.Counters[i].Equal(that1.Counters[i]) {
+			return false
+		}
+	}
+	return true
 }
-func (this *AggregationAlgo) String() string {
-	if this == nil {
-		return "nil"
+func (this *TopKCounter) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	keysForAddTags := make([]string, 0, len(this.AddTags))
-	for k, _ := range this.AddTags {
-		keysForAddTags = append(keysForAddTags, k)
+
+	that1, ok := that.(*TopKCounter)
+	if !ok {
+		that2, ok := that.(TopKCounter)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
 	}
-	github_com_gogo_protobuf_sortkeys.Strings(keysForAddTags)
-	mapStringForAddTags := "map[string]string{"
-	for _, k := range keysForAddTags {
-		mapStringForAddTags += fmt.Sprintf("%v: %v,", k, this.AddTags[k])
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	mapStringForAddTags += "}"
-	s := strings.Join([]string{`&AggregationAlgo{`,
-		`Method:` + fmt.Sprintf("%v", this.Method) + `,`,
-		`SourceField:` + fmt.Sprintf("%v", this.SourceField) + `,`,
-		`Window:` + fmt.Sprintf("%v", this.Window) + `,`,
-		`AddTags:` + mapStringForAddTags + `,`,
-		`Options:` + fmt.Sprintf("%v", this.Options) + `,`,
-		`}`,
-	}, "")
-	return s
-}
-func (this *AggregationAlgo_HistogramOpts) String() string {
-	if this == nil {
-		return "nil"
+	if this.Item != that1.Item {
+		return false
 	}
-	s := strings.Join([]string{`&AggregationAlgo_HistogramOpts{`,
-		`HistogramOpts:` + strings.Replace(fmt.Sprintf("%v", this.HistogramOpts), "HistogramOptions", "HistogramOptions", 1) + `,`,
-		`}`,
-	}, "")
-	return s
-}
-func (this *AggregationAlgo_ExpoOpts) String() string {
-	if this == nil {
-		return "nil"
+	if this.Weight != that1.Weight {
+		return false
 	}
-	s := strings.Join([]string{`&AggregationAlgo_ExpoOpts{`,
-		`ExpoOpts:` + strings.Replace(fmt.Sprintf("%v", this.ExpoOpts), "ExpoHistogramOptions", "ExpoHistogramOptions", 1) + `,`,
-		`}`,
-	}, "")
-	return s
+	if this.Error != that1.Error {
+		return false
+	}
+	return true
 }
-func (this *AggregationAlgo_QuantileOpts) String() string {
-	if this == nil {
-		return "nil"
+func (this *CalculatorSnapshot) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	s := strings.Join([]string{`&AggregationAlgo_QuantileOpts{`,
-		`QuantileOpts:` + strings.Replace(fmt.Sprintf("%v", this.QuantileOpts), "QuantileOptions", "QuantileOptions", 1) + `,`,
-		`}`,
-	}, "")
-	return s
+
+	that1, ok := that.(*CalculatorSnapshot)
+	if !ok {
+		that2, ok := that.(CalculatorSnapshot)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Token != that1.Token {
+		return false
+	}
+	if this.Expire != that1.Expire {
+		return false
+	}
+	if this.Method != that1.Method {
+		return false
+	}
+	if this.Key != that1.Key {
+		return false
+	}
+	if this.Hash != that1.Hash {
+		return false
+	}
+	if this.Window != that1.Window {
+		return false
+	}
+	if this.NextWallTime != that1.NextWallTime {
+		return false
+	}
+	if !this.State.Equal(that1.State) {
+		return false
+	}
+	if this.Rule != that1.Rule {
+		return false
+	}
+	if this.EventTime != that1.EventTime {
+		return false
+	}
+	if !this.WindowOpts.Equal(that1.WindowOpts) {
+		return false
+	}
+	if this.Pane != that1.Pane {
+		return false
+	}
+	if this.LastEmit != that1.LastEmit {
+		return false
+	}
+	if this.Group != that1.Group {
+		return false
+	}
+	if len(this.Expressions) != len(that1.Expressions) {
+		return false
+	}
+	for i := range this.Expressions {
+		if !this.Expressions[i].Equal(that1.Expressions[i]) {
+			return false
+		}
+	}
+	if this.Correction != that1.Correction {
+		return false
+	}
+	return true
 }
-func (this *HistogramOptions) String() string {
+func (this *EventTimeSnapshot) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*EventTimeSnapshot)
+	if !ok {
+		that2, ok := that.(EventTimeSnapshot)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Token != that1.Token {
+		return false
+	}
+	if this.Rule != that1.Rule {
+		return false
+	}
+	if this.MaxTime != that1.MaxTime {
+		return false
+	}
+	if !this.Options.Equal(that1.Options) {
+		return false
+	}
+	if this.Late != that1.Late {
+		return false
+	}
+	if this.Closed != that1.Closed {
+		return false
+	}
+	return true
+}
+func (this *SideOutputSnapshot) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*SideOutputSnapshot)
+	if !ok {
+		that2, ok := that.(SideOutputSnapshot)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Token != that1.Token {
+		return false
+	}
+	if len(this.Points) != len(that1.Points) {
+		return false
+	}
+	for i := range this.Points {
+		if !this.Points[i].Equal(that1.Points[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *CacheSnapshot) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*CacheSnapshot)
+	if !ok {
+		that2, ok := that.(CacheSnapshot)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Expired != that1.Expired {
+		return false
+	}
+	if len(this.Calculators) != len(that1.Calculators) {
+		return false
+	}
+	for i := range this.Calculators {
+		if !this.Calculators[i].Equal(that1.Calculators[i]) {
+			return false
+		}
+	}
+	if len(this.EventTimes) != len(that1.EventTimes) {
+		return false
+	}
+	for i := range this.EventTimes {
+		if !this.EventTimes[i].Equal(that1.EventTimes[i]) {
+			return false
+		}
+	}
+	if len(this.SideOutput) != len(that1.SideOutput) {
+		return false
+	}
+	for i := range this.SideOutput {
+		if !this.SideOutput[i].Equal(that1.SideOutput[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *Batchs) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := strings.Join([]string{`&HistogramOptions{`,
-		`Buckets:` + fmt.Sprintf("%v", this.Buckets) + `,`,
-		`}`,
-	}, "")
-	return s
+	s := make([]string, 0, 6)
+	s = append(s, "&aggregate.Batchs{")
+	if this.Batchs != nil {
+		s = append(s, "Batchs: "+fmt.Sprintf("%#v", this.Batchs)+",\n")
+	}
+	s = append(s, "PickKey: "+fmt.Sprintf("%#v", this.PickKey)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-func (this *ExpoHistogramOptions) String() string {
+func (this *AggregationBatch) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := strings.Join([]string{`&ExpoHistogramOptions{`,
-		`MaxScale:` + fmt.Sprintf("%v", this.MaxScale) + `,`,
-		`MaxBuckets:` + fmt.Sprintf("%v", this.MaxBuckets) + `,`,
-		`RecordMinMax:` + fmt.Sprintf("%v", this.RecordMinMax) + `,`,
-		`}`,
-	}, "")
-	return s
+	s := make([]string, 0, 14)
+	s = append(s, "&aggregate.AggregationBatch{")
+	s = append(s, "RoutingKey: "+fmt.Sprintf("%#v", this.RoutingKey)+",\n")
+	s = append(s, "ConfigHash: "+fmt.Sprintf("%#v", this.ConfigHash)+",\n")
+	s = append(s, "RawConfig: "+fmt.Sprintf("%#v", this.RawConfig)+",\n")
+	keysForAggregationOpts := make([]string, 0, len(this.AggregationOpts))
+	for k, _ := range this.AggregationOpts {
+		keysForAggregationOpts = append(keysForAggregationOpts, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForAggregationOpts)
+	mapStringForAggregationOpts := "map[string]*AggregationAlgo{"
+	for _, k := range keysForAggregationOpts {
+		mapStringForAggregationOpts += fmt.Sprintf("%#v: %#v,", k, this.AggregationOpts[k])
+	}
+	mapStringForAggregationOpts += "}"
+	if this.AggregationOpts != nil {
+		s = append(s, "AggregationOpts: "+mapStringForAggregationOpts+",\n")
+	}
+	if this.Points != nil {
+		s = append(s, "Points: "+fmt.Sprintf("%#v", this.Points)+",\n")
+	}
+	s = append(s, "PickKey: "+fmt.Sprintf("%#v", this.PickKey)+",\n")
+	s = append(s, "Rule: "+fmt.Sprintf("%#v", this.Rule)+",\n")
+	if this.EventTime != nil {
+		s = append(s, "EventTime: "+fmt.Sprintf("%#v", this.EventTime)+",\n")
+	}
+	if this.Window != nil {
+		s = append(s, "Window: "+fmt.Sprintf("%#v", this.Window)+",\n")
+	}
+	if this.Expressions != nil {
+		s = append(s, "Expressions: "+fmt.Sprintf("%#v", this.Expressions)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-func (this *QuantileOptions) String() string {
+func (this *Expression) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := strings.Join([]string{`&QuantileOptions{`,
-		`Percentiles:` + fmt.Sprintf("%v", this.Percentiles) + `,`,
-		`}`,
-	}, "")
-	return s
+	s := make([]string, 0, 7)
+	s = append(s, "&aggregate.Expression{")
+	s = append(s, "Name: "+fmt.Sprintf("%#v", this.Name)+",\n")
+	s = append(s, "Expr: "+fmt.Sprintf("%#v", this.Expr)+",\n")
+	s = append(s, "OnDivZero: "+fmt.Sprintf("%#v", this.OnDivZero)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-func valueToStringAggrbatch(v interface{}) string {
-	rv := reflect.ValueOf(v)
-	if rv.IsNil() {
+func (this *WindowOptions) GoString() string {
+	if this == nil {
 		return "nil"
 	}
-	pv := reflect.Indirect(rv).Interface()
-	return fmt.Sprintf("*%v", pv)
+	s := make([]string, 0, 7)
+	s = append(s, "&aggregate.WindowOptions{")
+	s = append(s, "Type: "+fmt.Sprintf("%#v", this.Type)+",\n")
+	s = append(s, "Length: "+fmt.Sprintf("%#v", this.Length)+",\n")
+	s = append(s, "Step: "+fmt.Sprintf("%#v", this.Step)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
 }
-func (m *Batchs) Unmarshal(dAtA []byte) error {
+func (this *EventTimeOptions) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&aggregate.EventTimeOptions{")
+	s = append(s, "AllowedLateness: "+fmt.Sprintf("%#v", this.AllowedLateness)+",\n")
+	s = append(s, "LatePolicy: "+fmt.Sprintf("%#v", this.LatePolicy)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *AggregationAlgo) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 13)
+	s = append(s, "&aggregate.AggregationAlgo{")
+	s = append(s, "Method: "+fmt.Sprintf("%#v", this.Method)+",\n")
+	s = append(s, "SourceField: "+fmt.Sprintf("%#v", this.SourceField)+",\n")
+	s = append(s, "Window: "+fmt.Sprintf("%#v", this.Window)+",\n")
+	if this.Options != nil {
+		s = append(s, "Options: "+fmt.Sprintf("%#v", this.Options)+",\n")
+	}
+	keysForAddTags := make([]string, 0, len(this.AddTags))
+	for k, _ := range this.AddTags {
+		keysForAddTags = append(keysForAddTags, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForAddTags)
+	mapStringForAddTags := "map[string]string{"
+	for _, k := range keysForAddTags {
+		mapStringForAddTags += fmt.Sprintf("%#v: %#v,", k, this.AddTags[k])
+	}
+	mapStringForAddTags += "}"
+	if this.AddTags != nil {
+		s = append(s, "AddTags: "+mapStringForAddTags+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *AggregationAlgo_HistogramOpts) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&aggregate.AggregationAlgo_HistogramOpts{` +
+		`HistogramOpts:` + fmt.Sprintf("%#v", this.HistogramOpts) + `}`}, ", ")
+	return s
+}
+func (this *AggregationAlgo_ExpoOpts) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&aggregate.AggregationAlgo_ExpoOpts{` +
+		`ExpoOpts:` + fmt.Sprintf("%#v", this.ExpoOpts) + `}`}, ", ")
+	return s
+}
+func (this *AggregationAlgo_QuantileOpts) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&aggregate.AggregationAlgo_QuantileOpts{` +
+		`QuantileOpts:` + fmt.Sprintf("%#v", this.QuantileOpts) + `}`}, ", ")
+	return s
+}
+func (this *AggregationAlgo_TopkOpts) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&aggregate.AggregationAlgo_TopkOpts{` +
+		`TopkOpts:` + fmt.Sprintf("%#v", this.TopkOpts) + `}`}, ", ")
+	return s
+}
+func (this *AggregationAlgo_DistinctValuesOpts) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&aggregate.AggregationAlgo_DistinctValuesOpts{` +
+		`DistinctValuesOpts:` + fmt.Sprintf("%#v", this.DistinctValuesOpts) + `}`}, ", ")
+	return s
+}
+func (this *HistogramOptions) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&aggregate.HistogramOptions{")
+	s = append(s, "Buckets: "+fmt.Sprintf("%#v", this.Buckets)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *ExpoHistogramOptions) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 9)
+	s = append(s, "&aggregate.ExpoHistogramOptions{")
+	s = append(s, "MaxScale: "+fmt.Sprintf("%#v", this.MaxScale)+",\n")
+	s = append(s, "MaxBuckets: "+fmt.Sprintf("%#v", this.MaxBuckets)+",\n")
+	s = append(s, "RecordMinMax: "+fmt.Sprintf("%#v", this.RecordMinMax)+",\n")
+	s = append(s, "DisableMinMax: "+fmt.Sprintf("%#v", this.DisableMinMax)+",\n")
+	s = append(s, "HasMaxScale: "+fmt.Sprintf("%#v", this.HasMaxScale)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *QuantileOptions) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 8)
+	s = append(s, "&aggregate.QuantileOptions{")
+	s = append(s, "Percentiles: "+fmt.Sprintf("%#v", this.Percentiles)+",\n")
+	s = append(s, "RelativeAccuracy: "+fmt.Sprintf("%#v", this.RelativeAccuracy)+",\n")
+	s = append(s, "MaxBins: "+fmt.Sprintf("%#v", this.MaxBins)+",\n")
+	s = append(s, "EmitSketch: "+fmt.Sprintf("%#v", this.EmitSketch)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *QuantileSketch) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 14)
+	s = append(s, "&aggregate.QuantileSketch{")
+	s = append(s, "RelativeAccuracy: "+fmt.Sprintf("%#v", this.RelativeAccuracy)+",\n")
+	s = append(s, "Count: "+fmt.Sprintf("%#v", this.Count)+",\n")
+	s = append(s, "Sum: "+fmt.Sprintf("%#v", this.Sum)+",\n")
+	s = append(s, "Min: "+fmt.Sprintf("%#v", this.Min)+",\n")
+	s = append(s, "Max: "+fmt.Sprintf("%#v", this.Max)+",\n")
+	s = append(s, "ZeroCount: "+fmt.Sprintf("%#v", this.ZeroCount)+",\n")
+	s = append(s, "PosOffset: "+fmt.Sprintf("%#v", this.PosOffset)+",\n")
+	s = append(s, "PosCounts: "+fmt.Sprintf("%#v", this.PosCounts)+",\n")
+	s = append(s, "NegOffset: "+fmt.Sprintf("%#v", this.NegOffset)+",\n")
+	s = append(s, "NegCounts: "+fmt.Sprintf("%#v", this.NegCounts)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *TopKOptions) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 8)
+	s = append(s, "&aggregate.TopKOptions{")
+	s = append(s, "K: "+fmt.Sprintf("%#v", this.K)+",\n")
+	s = append(s, "WeightField: "+fmt.Sprintf("%#v", this.WeightField)+",\n")
+	s = append(s, "Capacity: "+fmt.Sprintf("%#v", this.Capacity)+",\n")
+	s = append(s, "EmitSketch: "+fmt.Sprintf("%#v", this.EmitSketch)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *DistinctValuesOptions) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&aggregate.DistinctValuesOptions{")
+	s = append(s, "K: "+fmt.Sprintf("%#v", this.K)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *TopKSketch) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&aggregate.TopKSketch{")
+	s = append(s, "Capacity: "+fmt.Sprintf("%#v", this.Capacity)+",\n")
+	s = append(s, "Total: "+fmt.Sprintf("%#v", this.Total)+",\n")
+	if this.Counters != nil {
+		s = append(s, "Counters: "+fmt.Sprintf("%#v", this.Counters)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *TopKCounter) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&aggregate.TopKCounter{")
+	s = append(s, "Item: "+fmt.Sprintf("%#v", this.Item)+",\n")
+	s = append(s, "Weight: "+fmt.Sprintf("%#v", this.Weight)+",\n")
+	s = append(s, "Error: "+fmt.Sprintf("%#v", this.Error)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *CalculatorSnapshot) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 20)
+	s = append(s, "&aggregate.CalculatorSnapshot{")
+	s = append(s, "Token: "+fmt.Sprintf("%#v", this.Token)+",\n")
+	s = append(s, "Expire: "+fmt.Sprintf("%#v", this.Expire)+",\n")
+	s = append(s, "Method: "+fmt.Sprintf("%#v", this.Method)+",\n")
+	s = append(s, "Key: "+fmt.Sprintf("%#v", this.Key)+",\n")
+	s = append(s, "Hash: "+fmt.Sprintf("%#v", this.Hash)+",\n")
+	s = append(s, "Window: "+fmt.Sprintf("%#v", this.Window)+",\n")
+	s = append(s, "NextWallTime: "+fmt.Sprintf("%#v", this.NextWallTime)+",\n")
+	if this.State != nil {
+		s = append(s, "State: "+fmt.Sprintf("%#v", this.State)+",\n")
+	}
+	s = append(s, "Rule: "+fmt.Sprintf("%#v", this.Rule)+",\n")
+	s = append(s, "EventTime: "+fmt.Sprintf("%#v", this.EventTime)+",\n")
+	if this.WindowOpts != nil {
+		s = append(s, "WindowOpts: "+fmt.Sprintf("%#v", this.WindowOpts)+",\n")
+	}
+	s = append(s, "Pane: "+fmt.Sprintf("%#v", this.Pane)+",\n")
+	s = append(s, "LastEmit: "+fmt.Sprintf("%#v", this.LastEmit)+",\n")
+	s = append(s, "Group: "+fmt.Sprintf("%#v", this.Group)+",\n")
+	if this.Expressions != nil {
+		s = append(s, "Expressions: "+fmt.Sprintf("%#v", this.Expressions)+",\n")
+	}
+	s = append(s, "Correction: "+fmt.Sprintf("%#v", this.Correction)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *EventTimeSnapshot) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 10)
+	s = append(s, "&aggregate.EventTimeSnapshot{")
+	s = append(s, "Token: "+fmt.Sprintf("%#v", this.Token)+",\n")
+	s = append(s, "Rule: "+fmt.Sprintf("%#v", this.Rule)+",\n")
+	s = append(s, "MaxTime: "+fmt.Sprintf("%#v", this.MaxTime)+",\n")
+	if this.Options != nil {
+		s = append(s, "Options: "+fmt.Sprintf("%#v", this.Options)+",\n")
+	}
+	s = append(s, "Late: "+fmt.Sprintf("%#v", this.Late)+",\n")
+	s = append(s, "Closed: "+fmt.Sprintf("%#v", this.Closed)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *SideOutputSnapshot) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&aggregate.SideOutputSnapshot{")
+	s = append(s, "Token: "+fmt.Sprintf("%#v", this.Token)+",\n")
+	if this.Points != nil {
+		s = append(s, "Points: "+fmt.Sprintf("%#v", this.Points)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *CacheSnapshot) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 8)
+	s = append(s, "&aggregate.CacheSnapshot{")
+	s = append(s, "Expired: "+fmt.Sprintf("%#v", this.Expired)+",\n")
+	if this.Calculators != nil {
+		s = append(s, "Calculators: "+fmt.Sprintf("%#v", this.Calculators)+",\n")
+	}
+	if this.EventTimes != nil {
+		s = append(s, "EventTimes: "+fmt.Sprintf("%#v", this.EventTimes)+",\n")
+	}
+	if this.SideOutput != nil {
+		s = append(s, "SideOutput: "+fmt.Sprintf("%#v", this.SideOutput)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func valueToGoStringAggrbatch(v interface{}, typ string) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("func(v %v) *%v { return &v } ( %#v )", typ, typ, pv)
+}
+func (m *Batchs) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Batchs) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Batchs) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.PickKey != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.PickKey))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Batchs) > 0 {
+		for iNdEx := len(m.Batchs) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Batchs[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *AggregationBatch) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *AggregationBatch) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *AggregationBatch) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Expressions) > 0 {
+		for iNdEx := len(m.Expressions) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Expressions[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x52
+		}
+	}
+	if m.Window != nil {
+		{
+			size, err := m.Window.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x4a
+	}
+	if m.EventTime != nil {
+		{
+			size, err := m.EventTime.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x42
+	}
+	if len(m.Rule) > 0 {
+		i -= len(m.Rule)
+		copy(dAtA[i:], m.Rule)
+		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.Rule)))
+		i--
+		dAtA[i] = 0x3a
+	}
+	if m.PickKey != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.PickKey))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.Points != nil {
+		{
+			size, err := m.Points.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x2a
+	}
+	if len(m.AggregationOpts) > 0 {
+		for k := range m.AggregationOpts {
+			v := m.AggregationOpts[k]
+			baseI := i
+			if v != nil {
+				{
+					size, err := v.MarshalToSizedBuffer(dAtA[:i])
+					if err != nil {
+						return 0, err
+					}
+					i -= size
+					i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+				}
+				i--
+				dAtA[i] = 0x12
+			}
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintAggrbatch(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintAggrbatch(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x22
+		}
+	}
+	if len(m.RawConfig) > 0 {
+		i -= len(m.RawConfig)
+		copy(dAtA[i:], m.RawConfig)
+		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.RawConfig)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.ConfigHash != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.ConfigHash))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.RoutingKey != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.RoutingKey))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Expression) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Expression) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Expression) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.OnDivZero) > 0 {
+		i -= len(m.OnDivZero)
+		copy(dAtA[i:], m.OnDivZero)
+		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.OnDivZero)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Expr) > 0 {
+		i -= len(m.Expr)
+		copy(dAtA[i:], m.Expr)
+		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.Expr)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Name) > 0 {
+		i -= len(m.Name)
+		copy(dAtA[i:], m.Name)
+		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.Name)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *WindowOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *WindowOptions) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *WindowOptions) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Step != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.Step))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.Length != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.Length))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Type) > 0 {
+		i -= len(m.Type)
+		copy(dAtA[i:], m.Type)
+		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.Type)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *EventTimeOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *EventTimeOptions) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *EventTimeOptions) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.LatePolicy) > 0 {
+		i -= len(m.LatePolicy)
+		copy(dAtA[i:], m.LatePolicy)
+		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.LatePolicy)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.AllowedLateness != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.AllowedLateness))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *AggregationAlgo) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *AggregationAlgo) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *AggregationAlgo) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Options != nil {
+		{
+			size := m.Options.Size()
+			i -= size
+			if _, err := m.Options.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if len(m.AddTags) > 0 {
+		for k := range m.AddTags {
+			v := m.AddTags[k]
+			baseI := i
+			i -= len(v)
+			copy(dAtA[i:], v)
+			i = encodeVarintAggrbatch(dAtA, i, uint64(len(v)))
+			i--
+			dAtA[i] = 0x12
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintAggrbatch(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintAggrbatch(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x42
+		}
+	}
+	if m.Window != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.Window))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.SourceField) > 0 {
+		i -= len(m.SourceField)
+		copy(dAtA[i:], m.SourceField)
+		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.SourceField)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Method) > 0 {
+		i -= len(m.Method)
+		copy(dAtA[i:], m.Method)
+		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.Method)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *AggregationAlgo_HistogramOpts) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *AggregationAlgo_HistogramOpts) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.HistogramOpts != nil {
+		{
+			size, err := m.HistogramOpts.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x52
+	}
+	return len(dAtA) - i, nil
+}
+func (m *AggregationAlgo_ExpoOpts) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *AggregationAlgo_ExpoOpts) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.ExpoOpts != nil {
+		{
+			size, err := m.ExpoOpts.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x5a
+	}
+	return len(dAtA) - i, nil
+}
+func (m *AggregationAlgo_QuantileOpts) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *AggregationAlgo_QuantileOpts) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.QuantileOpts != nil {
+		{
+			size, err := m.QuantileOpts.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x62
+	}
+	return len(dAtA) - i, nil
+}
+func (m *AggregationAlgo_TopkOpts) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *AggregationAlgo_TopkOpts) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.TopkOpts != nil {
+		{
+			size, err := m.TopkOpts.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x6a
+	}
+	return len(dAtA) - i, nil
+}
+func (m *AggregationAlgo_DistinctValuesOpts) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *AggregationAlgo_DistinctValuesOpts) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.DistinctValuesOpts != nil {
+		{
+			size, err := m.DistinctValuesOpts.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x72
+	}
+	return len(dAtA) - i, nil
+}
+func (m *HistogramOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *HistogramOptions) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *HistogramOptions) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Buckets) > 0 {
+		for iNdEx := len(m.Buckets) - 1; iNdEx >= 0; iNdEx-- {
+			f10 := math.Float64bits(float64(m.Buckets[iNdEx]))
+			i -= 8
+			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f10))
+		}
+		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.Buckets)*8))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ExpoHistogramOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExpoHistogramOptions) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ExpoHistogramOptions) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.HasMaxScale {
+		i--
+		if m.HasMaxScale {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.DisableMinMax {
+		i--
+		if m.DisableMinMax {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.RecordMinMax {
+		i--
+		if m.RecordMinMax {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.MaxBuckets != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.MaxBuckets))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.MaxScale != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.MaxScale))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *QuantileOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *QuantileOptions) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QuantileOptions) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.EmitSketch {
+		i--
+		if m.EmitSketch {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.MaxBins != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.MaxBins))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.RelativeAccuracy != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.RelativeAccuracy))))
+		i--
+		dAtA[i] = 0x11
+	}
+	if len(m.Percentiles) > 0 {
+		for iNdEx := len(m.Percentiles) - 1; iNdEx >= 0; iNdEx-- {
+			f11 := math.Float64bits(float64(m.Percentiles[iNdEx]))
+			i -= 8
+			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f11))
+		}
+		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.Percentiles)*8))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *QuantileSketch) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *QuantileSketch) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QuantileSketch) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.NegCounts) > 0 {
+		dAtA13 := make([]byte, len(m.NegCounts)*10)
+		var j12 int
+		for _, num := range m.NegCounts {
+			for num >= 1<<7 {
+				dAtA13[j12] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j12++
+			}
+			dAtA13[j12] = uint8(num)
+			j12++
+		}
+		i -= j12
+		copy(dAtA[i:], dAtA13[:j12])
+		i = encodeVarintAggrbatch(dAtA, i, uint64(j12))
+		i--
+		dAtA[i] = 0x52
+	}
+	if m.NegOffset != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.NegOffset))
+		i--
+		dAtA[i] = 0x48
+	}
+	if len(m.PosCounts) > 0 {
+		dAtA15 := make([]byte, len(m.PosCounts)*10)
+		var j14 int
+		for _, num := range m.PosCounts {
+			for num >= 1<<7 {
+				dAtA15[j14] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j14++
+			}
+			dAtA15[j14] = uint8(num)
+			j14++
+		}
+		i -= j14
+		copy(dAtA[i:], dAtA15[:j14])
+		i = encodeVarintAggrbatch(dAtA, i, uint64(j14))
+		i--
+		dAtA[i] = 0x42
+	}
+	if m.PosOffset != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.PosOffset))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.ZeroCount != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.ZeroCount))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.Max != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Max))))
+		i--
+		dAtA[i] = 0x29
+	}
+	if m.Min != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Min))))
+		i--
+		dAtA[i] = 0x21
+	}
+	if m.Sum != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Sum))))
+		i--
+		dAtA[i] = 0x19
+	}
+	if m.Count != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.Count))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.RelativeAccuracy != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.RelativeAccuracy))))
+		i--
+		dAtA[i] = 0x9
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TopKOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TopKOptions) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TopKOptions) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.EmitSketch {
+		i--
+		if m.EmitSketch {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Capacity != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.Capacity))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.WeightField) > 0 {
+		i -= len(m.WeightField)
+		copy(dAtA[i:], m.WeightField)
+		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.WeightField)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.K != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.K))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *DistinctValuesOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *DistinctValuesOptions) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *DistinctValuesOptions) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.K != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.K))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TopKSketch) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TopKSketch) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TopKSketch) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Counters) > 0 {
+		for iNdEx := len(m.Counters) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Counters[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if m.Total != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Total))))
+		i--
+		dAtA[i] = 0x11
+	}
+	if m.Capacity != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.Capacity))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TopKCounter) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TopKCounter) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TopKCounter) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Error != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Error))))
+		i--
+		dAtA[i] = 0x19
+	}
+	if m.Weight != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Weight))))
+		i--
+		dAtA[i] = 0x11
+	}
+	if len(m.Item) > 0 {
+		i -= len(m.Item)
+		copy(dAtA[i:], m.Item)
+		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.Item)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *CalculatorSnapshot) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CalculatorSnapshot) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CalculatorSnapshot) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Correction {
+		i--
+		if m.Correction {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0x80
+	}
+	if len(m.Expressions) > 0 {
+		for iNdEx := len(m.Expressions) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Expressions[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x7a
+		}
+	}
+	if m.Group != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.Group))
+		i--
+		dAtA[i] = 0x70
+	}
+	if m.LastEmit != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.LastEmit))
+		i--
+		dAtA[i] = 0x68
+	}
+	if m.Pane {
+		i--
+		if m.Pane {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x60
+	}
+	if m.WindowOpts != nil {
+		{
+			size, err := m.WindowOpts.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x5a
+	}
+	if m.EventTime {
+		i--
+		if m.EventTime {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x50
+	}
+	if len(m.Rule) > 0 {
+		i -= len(m.Rule)
+		copy(dAtA[i:], m.Rule)
+		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.Rule)))
+		i--
+		dAtA[i] = 0x4a
+	}
+	if m.State != nil {
+		{
+			size, err := m.State.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x42
+	}
+	if m.NextWallTime != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.NextWallTime))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.Window != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.Window))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.Hash != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.Hash))
+		i--
+		dAtA[i] = 0x28
+	}
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.Method) > 0 {
+		i -= len(m.Method)
+		copy(dAtA[i:], m.Method)
+		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.Method)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.Expire != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.Expire))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Token) > 0 {
+		i -= len(m.Token)
+		copy(dAtA[i:], m.Token)
+		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.Token)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *EventTimeSnapshot) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *EventTimeSnapshot) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *EventTimeSnapshot) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Closed != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.Closed))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.Late != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.Late))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.Options != nil {
+		{
+			size, err := m.Options.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.MaxTime != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.MaxTime))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Rule) > 0 {
+		i -= len(m.Rule)
+		copy(dAtA[i:], m.Rule)
+		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.Rule)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Token) > 0 {
+		i -= len(m.Token)
+		copy(dAtA[i:], m.Token)
+		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.Token)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *SideOutputSnapshot) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SideOutputSnapshot) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SideOutputSnapshot) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Points) > 0 {
+		for iNdEx := len(m.Points) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Points[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Token) > 0 {
+		i -= len(m.Token)
+		copy(dAtA[i:], m.Token)
+		i = encodeVarintAggrbatch(dAtA, i, uint64(len(m.Token)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *CacheSnapshot) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CacheSnapshot) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CacheSnapshot) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.SideOutput) > 0 {
+		for iNdEx := len(m.SideOutput) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.SideOutput[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x22
+		}
+	}
+	if len(m.EventTimes) > 0 {
+		for iNdEx := len(m.EventTimes) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.EventTimes[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if len(m.Calculators) > 0 {
+		for iNdEx := len(m.Calculators) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Calculators[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintAggrbatch(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.Expired != 0 {
+		i = encodeVarintAggrbatch(dAtA, i, uint64(m.Expired))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintAggrbatch(dAtA []byte, offset int, v uint64) int {
+	offset -= sovAggrbatch(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *Batchs) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Batchs) > 0 {
+		for _, e := range m.Batchs {
+			l = e.Size()
+			n += 1 + l + sovAggrbatch(uint64(l))
+		}
+	}
+	if m.PickKey != 0 {
+		n += 1 + sovAggrbatch(uint64(m.PickKey))
+	}
+	return n
+}
+
+func (m *AggregationBatch) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.RoutingKey != 0 {
+		n += 1 + sovAggrbatch(uint64(m.RoutingKey))
+	}
+	if m.ConfigHash != 0 {
+		n += 1 + sovAggrbatch(uint64(m.ConfigHash))
+	}
+	l = len(m.RawConfig)
+	if l > 0 {
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	if len(m.AggregationOpts) > 0 {
+		for k, v := range m.AggregationOpts {
+			_ = k
+			_ = v
+			l = 0
+			if v != nil {
+				l = v.Size()
+				l += 1 + sovAggrbatch(uint64(l))
+			}
+			mapEntrySize := 1 + len(k) + sovAggrbatch(uint64(len(k))) + l
+			n += mapEntrySize + 1 + sovAggrbatch(uint64(mapEntrySize))
+		}
+	}
+	if m.Points != nil {
+		l = m.Points.Size()
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	if m.PickKey != 0 {
+		n += 1 + sovAggrbatch(uint64(m.PickKey))
+	}
+	l = len(m.Rule)
+	if l > 0 {
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	if m.EventTime != nil {
+		l = m.EventTime.Size()
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	if m.Window != nil {
+		l = m.Window.Size()
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	if len(m.Expressions) > 0 {
+		for _, e := range m.Expressions {
+			l = e.Size()
+			n += 1 + l + sovAggrbatch(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *Expression) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	l = len(m.Expr)
+	if l > 0 {
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	l = len(m.OnDivZero)
+	if l > 0 {
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	return n
+}
+
+func (m *WindowOptions) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Type)
+	if l > 0 {
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	if m.Length != 0 {
+		n += 1 + sovAggrbatch(uint64(m.Length))
+	}
+	if m.Step != 0 {
+		n += 1 + sovAggrbatch(uint64(m.Step))
+	}
+	return n
+}
+
+func (m *EventTimeOptions) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.AllowedLateness != 0 {
+		n += 1 + sovAggrbatch(uint64(m.AllowedLateness))
+	}
+	l = len(m.LatePolicy)
+	if l > 0 {
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	return n
+}
+
+func (m *AggregationAlgo) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Method)
+	if l > 0 {
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	l = len(m.SourceField)
+	if l > 0 {
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	if m.Window != 0 {
+		n += 1 + sovAggrbatch(uint64(m.Window))
+	}
+	if len(m.AddTags) > 0 {
+		for k, v := range m.AddTags {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovAggrbatch(uint64(len(k))) + 1 + len(v) + sovAggrbatch(uint64(len(v)))
+			n += mapEntrySize + 1 + sovAggrbatch(uint64(mapEntrySize))
+		}
+	}
+	if m.Options != nil {
+		n += m.Options.Size()
+	}
+	return n
+}
+
+func (m *AggregationAlgo_HistogramOpts) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.HistogramOpts != nil {
+		l = m.HistogramOpts.Size()
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	return n
+}
+func (m *AggregationAlgo_ExpoOpts) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ExpoOpts != nil {
+		l = m.ExpoOpts.Size()
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	return n
+}
+func (m *AggregationAlgo_QuantileOpts) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.QuantileOpts != nil {
+		l = m.QuantileOpts.Size()
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	return n
+}
+func (m *AggregationAlgo_TopkOpts) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.TopkOpts != nil {
+		l = m.TopkOpts.Size()
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	return n
+}
+func (m *AggregationAlgo_DistinctValuesOpts) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.DistinctValuesOpts != nil {
+		l = m.DistinctValuesOpts.Size()
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	return n
+}
+func (m *HistogramOptions) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Buckets) > 0 {
+		n += 1 + sovAggrbatch(uint64(len(m.Buckets)*8)) + len(m.Buckets)*8
+	}
+	return n
+}
+
+func (m *ExpoHistogramOptions) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.MaxScale != 0 {
+		n += 1 + sovAggrbatch(uint64(m.MaxScale))
+	}
+	if m.MaxBuckets != 0 {
+		n += 1 + sovAggrbatch(uint64(m.MaxBuckets))
+	}
+	if m.RecordMinMax {
+		n += 2
+	}
+	if m.DisableMinMax {
+		n += 2
+	}
+	if m.HasMaxScale {
+		n += 2
+	}
+	return n
+}
+
+func (m *QuantileOptions) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Percentiles) > 0 {
+		n += 1 + sovAggrbatch(uint64(len(m.Percentiles)*8)) + len(m.Percentiles)*8
+	}
+	if m.RelativeAccuracy != 0 {
+		n += 9
+	}
+	if m.MaxBins != 0 {
+		n += 1 + sovAggrbatch(uint64(m.MaxBins))
+	}
+	if m.EmitSketch {
+		n += 2
+	}
+	return n
+}
+
+func (m *QuantileSketch) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.RelativeAccuracy != 0 {
+		n += 9
+	}
+	if m.Count != 0 {
+		n += 1 + sovAggrbatch(uint64(m.Count))
+	}
+	if m.Sum != 0 {
+		n += 9
+	}
+	if m.Min != 0 {
+		n += 9
+	}
+	if m.Max != 0 {
+		n += 9
+	}
+	if m.ZeroCount != 0 {
+		n += 1 + sovAggrbatch(uint64(m.ZeroCount))
+	}
+	if m.PosOffset != 0 {
+		n += 1 + sovAggrbatch(uint64(m.PosOffset))
+	}
+	if len(m.PosCounts) > 0 {
+		l = 0
+		for _, e := range m.PosCounts {
+			l += sovAggrbatch(uint64(e))
+		}
+		n += 1 + sovAggrbatch(uint64(l)) + l
+	}
+	if m.NegOffset != 0 {
+		n += 1 + sovAggrbatch(uint64(m.NegOffset))
+	}
+	if len(m.NegCounts) > 0 {
+		l = 0
+		for _, e := range m.NegCounts {
+			l += sovAggrbatch(uint64(e))
+		}
+		n += 1 + sovAggrbatch(uint64(l)) + l
+	}
+	return n
+}
+
+func (m *TopKOptions) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.K != 0 {
+		n += 1 + sovAggrbatch(uint64(m.K))
+	}
+	l = len(m.WeightField)
+	if l > 0 {
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	if m.Capacity != 0 {
+		n += 1 + sovAggrbatch(uint64(m.Capacity))
+	}
+	if m.EmitSketch {
+		n += 2
+	}
+	return n
+}
+
+func (m *DistinctValuesOptions) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.K != 0 {
+		n += 1 + sovAggrbatch(uint64(m.K))
+	}
+	return n
+}
+
+func (m *TopKSketch) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Capacity != 0 {
+		n += 1 + sovAggrbatch(uint64(m.Capacity))
+	}
+	if m.Total != 0 {
+		n += 9
+	}
+	if len(m.Counters) > 0 {
+		for _, e := range m.Counters {
+			l = e.Size()
+			n += 1 + l + sovAggrbatch(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *TopKCounter) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Item)
+	if l > 0 {
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	if m.Weight != 0 {
+		n += 9
+	}
+	if m.Error != 0 {
+		n += 9
+	}
+	return n
+}
+
+func (m *CalculatorSnapshot) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Token)
+	if l > 0 {
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	if m.Expire != 0 {
+		n += 1 + sovAggrbatch(uint64(m.Expire))
+	}
+	l = len(m.Method)
+	if l > 0 {
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	if m.Hash != 0 {
+		n += 1 + sovAggrbatch(uint64(m.Hash))
+	}
+	if m.Window != 0 {
+		n += 1 + sovAggrbatch(uint64(m.Window))
+	}
+	if m.NextWallTime != 0 {
+		n += 1 + sovAggrbatch(uint64(m.NextWallTime))
+	}
+	if m.State != nil {
+		l = m.State.Size()
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	l = len(m.Rule)
+	if l > 0 {
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	if m.EventTime {
+		n += 2
+	}
+	if m.WindowOpts != nil {
+		l = m.WindowOpts.Size()
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	if m.Pane {
+		n += 2
+	}
+	if m.LastEmit != 0 {
+		n += 1 + sovAggrbatch(uint64(m.LastEmit))
+	}
+	if m.Group != 0 {
+		n += 1 + sovAggrbatch(uint64(m.Group))
+	}
+	if len(m.Expressions) > 0 {
+		for _, e := range m.Expressions {
+			l = e.Size()
+			n += 1 + l + sovAggrbatch(uint64(l))
+		}
+	}
+	if m.Correction {
+		n += 3
+	}
+	return n
+}
+
+func (m *EventTimeSnapshot) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Token)
+	if l > 0 {
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	l = len(m.Rule)
+	if l > 0 {
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	if m.MaxTime != 0 {
+		n += 1 + sovAggrbatch(uint64(m.MaxTime))
+	}
+	if m.Options != nil {
+		l = m.Options.Size()
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	if m.Late != 0 {
+		n += 1 + sovAggrbatch(uint64(m.Late))
+	}
+	if m.Closed != 0 {
+		n += 1 + sovAggrbatch(uint64(m.Closed))
+	}
+	return n
+}
+
+func (m *SideOutputSnapshot) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Token)
+	if l > 0 {
+		n += 1 + l + sovAggrbatch(uint64(l))
+	}
+	if len(m.Points) > 0 {
+		for _, e := range m.Points {
+			l = e.Size()
+			n += 1 + l + sovAggrbatch(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *CacheSnapshot) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Expired != 0 {
+		n += 1 + sovAggrbatch(uint64(m.Expired))
+	}
+	if len(m.Calculators) > 0 {
+		for _, e := range m.Calculators {
+			l = e.Size()
+			n += 1 + l + sovAggrbatch(uint64(l))
+		}
+	}
+	if len(m.EventTimes) > 0 {
+		for _, e := range m.EventTimes {
+			l = e.Size()
+			n += 1 + l + sovAggrbatch(uint64(l))
+		}
+	}
+	if len(m.SideOutput) > 0 {
+		for _, e := range m.SideOutput {
+			l = e.Size()
+			n += 1 + l + sovAggrbatch(uint64(l))
+		}
+	}
+	return n
+}
+
+func sovAggrbatch(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozAggrbatch(x uint64) (n int) {
+	return sovAggrbatch(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (this *Batchs) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForBatchs := "[]*AggregationBatch{"
+	for _, f := range this.Batchs {
+		repeatedStringForBatchs += strings.Replace(f.String(), "AggregationBatch", "AggregationBatch", 1) + ","
+	}
+	repeatedStringForBatchs += "}"
+	s := strings.Join([]string{`&Batchs{`,
+		`Batchs:` + repeatedStringForBatchs + `,`,
+		`PickKey:` + fmt.Sprintf("%v", this.PickKey) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *AggregationBatch) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForExpressions := "[]*Expression{"
+	for _, f := range this.Expressions {
+		repeatedStringForExpressions += strings.Replace(f.String(), "Expression", "Expression", 1) + ","
+	}
+	repeatedStringForExpressions += "}"
+	keysForAggregationOpts := make([]string, 0, len(this.AggregationOpts))
+	for k, _ := range this.AggregationOpts {
+		keysForAggregationOpts = append(keysForAggregationOpts, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForAggregationOpts)
+	mapStringForAggregationOpts := "map[string]*AggregationAlgo{"
+	for _, k := range keysForAggregationOpts {
+		mapStringForAggregationOpts += fmt.Sprintf("%v: %v,", k, this.AggregationOpts[k])
+	}
+	mapStringForAggregationOpts += "}"
+	s := strings.Join([]string{`&AggregationBatch{`,
+		`RoutingKey:` + fmt.Sprintf("%v", this.RoutingKey) + `,`,
+		`ConfigHash:` + fmt.Sprintf("%v", this.ConfigHash) + `,`,
+		`RawConfig:` + fmt.Sprintf("%v", this.RawConfig) + `,`,
+		`AggregationOpts:` + mapStringForAggregationOpts + `,`,
+		`Points:` + strings.Replace(fmt.Sprintf("%v", this.Points), "PBPoints", "point.PBPoints", 1) + `,`,
+		`PickKey:` + fmt.Sprintf("%v", this.PickKey) + `,`,
+		`Rule:` + fmt.Sprintf("%v", this.Rule) + `,`,
+		`EventTime:` + strings.Replace(this.EventTime.String(), "EventTimeOptions", "EventTimeOptions", 1) + `,`,
+		`Window:` + strings.Replace(this.Window.String(), "WindowOptions", "WindowOptions", 1) + `,`,
+		`Expressions:` + repeatedStringForExpressions + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *Expression) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&Expression{`,
+		`Name:` + fmt.Sprintf("%v", this.Name) + `,`,
+		`Expr:` + fmt.Sprintf("%v", this.Expr) + `,`,
+		`OnDivZero:` + fmt.Sprintf("%v", this.OnDivZero) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *WindowOptions) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&WindowOptions{`,
+		`Type:` + fmt.Sprintf("%v", this.Type) + `,`,
+		`Length:` + fmt.Sprintf("%v", this.Length) + `,`,
+		`Step:` + fmt.Sprintf("%v", this.Step) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *EventTimeOptions) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&EventTimeOptions{`,
+		`AllowedLateness:` + fmt.Sprintf("%v", this.AllowedLateness) + `,`,
+		`LatePolicy:` + fmt.Sprintf("%v", this.LatePolicy) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *AggregationAlgo) String() string {
+	if this == nil {
+		return "nil"
+	}
+	keysForAddTags := make([]string, 0, len(this.AddTags))
+	for k, _ := range this.AddTags {
+		keysForAddTags = append(keysForAddTags, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForAddTags)
+	mapStringForAddTags := "map[string]string{"
+	for _, k := range keysForAddTags {
+		mapStringForAddTags += fmt.Sprintf("%v: %v,", k, this.AddTags[k])
+	}
+	mapStringForAddTags += "}"
+	s := strings.Join([]string{`&AggregationAlgo{`,
+		`Method:` + fmt.Sprintf("%v", this.Method) + `,`,
+		`SourceField:` + fmt.Sprintf("%v", this.SourceField) + `,`,
+		`Window:` + fmt.Sprintf("%v", this.Window) + `,`,
+		`AddTags:` + mapStringForAddTags + `,`,
+		`Options:` + fmt.Sprintf("%v", this.Options) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *AggregationAlgo_HistogramOpts) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&AggregationAlgo_HistogramOpts{`,
+		`HistogramOpts:` + strings.Replace(fmt.Sprintf("%v", this.HistogramOpts), "HistogramOptions", "HistogramOptions", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *AggregationAlgo_ExpoOpts) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&AggregationAlgo_ExpoOpts{`,
+		`ExpoOpts:` + strings.Replace(fmt.Sprintf("%v", this.ExpoOpts), "ExpoHistogramOptions", "ExpoHistogramOptions", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *AggregationAlgo_QuantileOpts) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&AggregationAlgo_QuantileOpts{`,
+		`QuantileOpts:` + strings.Replace(fmt.Sprintf("%v", this.QuantileOpts), "QuantileOptions", "QuantileOptions", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *AggregationAlgo_TopkOpts) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&AggregationAlgo_TopkOpts{`,
+		`TopkOpts:` + strings.Replace(fmt.Sprintf("%v", this.TopkOpts), "TopKOptions", "TopKOptions", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *AggregationAlgo_DistinctValuesOpts) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&AggregationAlgo_DistinctValuesOpts{`,
+		`DistinctValuesOpts:` + strings.Replace(fmt.Sprintf("%v", this.DistinctValuesOpts), "DistinctValuesOptions", "DistinctValuesOptions", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *HistogramOptions) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&HistogramOptions{`,
+		`Buckets:` + fmt.Sprintf("%v", this.Buckets) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ExpoHistogramOptions) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&ExpoHistogramOptions{`,
+		`MaxScale:` + fmt.Sprintf("%v", this.MaxScale) + `,`,
+		`MaxBuckets:` + fmt.Sprintf("%v", this.MaxBuckets) + `,`,
+		`RecordMinMax:` + fmt.Sprintf("%v", this.RecordMinMax) + `,`,
+		`DisableMinMax:` + fmt.Sprintf("%v", this.DisableMinMax) + `,`,
+		`HasMaxScale:` + fmt.Sprintf("%v", this.HasMaxScale) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *QuantileOptions) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&QuantileOptions{`,
+		`Percentiles:` + fmt.Sprintf("%v", this.Percentiles) + `,`,
+		`RelativeAccuracy:` + fmt.Sprintf("%v", this.RelativeAccuracy) + `,`,
+		`MaxBins:` + fmt.Sprintf("%v", this.MaxBins) + `,`,
+		`EmitSketch:` + fmt.Sprintf("%v", this.EmitSketch) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *QuantileSketch) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&QuantileSketch{`,
+		`RelativeAccuracy:` + fmt.Sprintf("%v", this.RelativeAccuracy) + `,`,
+		`Count:` + fmt.Sprintf("%v", this.Count) + `,`,
+		`Sum:` + fmt.Sprintf("%v", this.Sum) + `,`,
+		`Min:` + fmt.Sprintf("%v", this.Min) + `,`,
+		`Max:` + fmt.Sprintf("%v", this.Max) + `,`,
+		`ZeroCount:` + fmt.Sprintf("%v", this.ZeroCount) + `,`,
+		`PosOffset:` + fmt.Sprintf("%v", this.PosOffset) + `,`,
+		`PosCounts:` + fmt.Sprintf("%v", this.PosCounts) + `,`,
+		`NegOffset:` + fmt.Sprintf("%v", this.NegOffset) + `,`,
+		`NegCounts:` + fmt.Sprintf("%v", this.NegCounts) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *TopKOptions) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&TopKOptions{`,
+		`K:` + fmt.Sprintf("%v", this.K) + `,`,
+		`WeightField:` + fmt.Sprintf("%v", this.WeightField) + `,`,
+		`Capacity:` + fmt.Sprintf("%v", this.Capacity) + `,`,
+		`EmitSketch:` + fmt.Sprintf("%v", this.EmitSketch) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *DistinctValuesOptions) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&DistinctValuesOptions{`,
+		`K:` + fmt.Sprintf("%v", this.K) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *TopKSketch) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForCounters := "[]*TopKCounter{"
+	for _, f := range this.Counters {
+		repeatedStringForCounters += strings.Replace(f.String(), "TopKCounter", "TopKCounter", 1) + ","
+	}
+	repeatedStringForCounters += "}"
+	s := strings.Join([]string{`&TopKSketch{`,
+		`Capacity:` + fmt.Sprintf("%v", this.Capacity) + `,`,
+		`Total:` + fmt.Sprintf("%v", this.Total) + `,`,
+		`Counters:` + repeatedStringForCounters + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *TopKCounter) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&TopKCounter{`,
+		`Item:` + fmt.Sprintf("%v", this.Item) + `,`,
+		`Weight:` + fmt.Sprintf("%v", this.Weight) + `,`,
+		`Error:` + fmt.Sprintf("%v", this.Error) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *CalculatorSnapshot) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForExpressions := "[]*Expression{"
+	for _, f := range this.Expressions {
+		repeatedStringForExpressions += strings.Replace(f.String(), "Expression", "Expression", 1) + ","
+	}
+	repeatedStringForExpressions += "}"
+	s := strings.Join([]string{`&CalculatorSnapshot{`,
+		`Token:` + fmt.Sprintf("%v", this.Token) + `,`,
+		`Expire:` + fmt.Sprintf("%v", this.Expire) + `,`,
+		`Method:` + fmt.Sprintf("%v", this.Method) + `,`,
+		`Key:` + fmt.Sprintf("%v", this.Key) + `,`,
+		`Hash:` + fmt.Sprintf("%v", this.Hash) + `,`,
+		`Window:` + fmt.Sprintf("%v", this.Window) + `,`,
+		`NextWallTime:` + fmt.Sprintf("%v", this.NextWallTime) + `,`,
+		`State:` + strings.Replace(fmt.Sprintf("%v", this.State), "PBPoint", "point.PBPoint", 1) + `,`,
+		`Rule:` + fmt.Sprintf("%v", this.Rule) + `,`,
+		`EventTime:` + fmt.Sprintf("%v", this.EventTime) + `,`,
+		`WindowOpts:` + strings.Replace(this.WindowOpts.String(), "WindowOptions", "WindowOptions", 1) + `,`,
+		`Pane:` + fmt.Sprintf("%v", this.Pane) + `,`,
+		`LastEmit:` + fmt.Sprintf("%v", this.LastEmit) + `,`,
+		`Group:` + fmt.Sprintf("%v", this.Group) + `,`,
+		`Expressions:` + repeatedStringForExpressions + `,`,
+		`Correction:` + fmt.Sprintf("%v", this.Correction) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *EventTimeSnapshot) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&EventTimeSnapshot{`,
+		`Token:` + fmt.Sprintf("%v", this.Token) + `,`,
+		`Rule:` + fmt.Sprintf("%v", this.Rule) + `,`,
+		`MaxTime:` + fmt.Sprintf("%v", this.MaxTime) + `,`,
+		`Options:` + strings.Replace(this.Options.String(), "EventTimeOptions", "EventTimeOptions", 1) + `,`,
+		`Late:` + fmt.Sprintf("%v", this.Late) + `,`,
+		`Closed:` + fmt.Sprintf("%v", this.Closed) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *SideOutputSnapshot) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForPoints := "[]*PBPoint{"
+	for _, f := range this.Points {
+		repeatedStringForPoints += strings.Replace(fmt.Sprintf("%v", f), "PBPoint", "point.PBPoint", 1) + ","
+	}
+	repeatedStringForPoints += "}"
+	s := strings.Join([]string{`&SideOutputSnapshot{`,
+		`Token:` + fmt.Sprintf("%v", this.Token) + `,`,
+		`Points:` + repeatedStringForPoints + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *CacheSnapshot) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForCalculators := "[]*CalculatorSnapshot{"
+	for _, f := range this.Calculators {
+		repeatedStringForCalculators += strings.Replace(f.String(), "CalculatorSnapshot", "CalculatorSnapshot", 1) + ","
+	}
+	repeatedStringForCalculators += "}"
+	repeatedStringForEventTimes := "[]*EventTimeSnapshot{"
+	for _, f := range this.EventTimes {
+		repeatedStringForEventTimes += strings.Replace(f.String(), "EventTimeSnapshot", "EventTimeSnapshot", 1) + ","
+	}
+	repeatedStringForEventTimes += "}"
+	repeatedStringForSideOutput := "[]*SideOutputSnapshot{"
+	for _, f := range this.SideOutput {
+		repeatedStringForSideOutput += strings.Replace(f.String(), "SideOutputSnapshot", "SideOutputSnapshot", 1) + ","
+	}
+	repeatedStringForSideOutput += "}"
+	s := strings.Join([]string{`&CacheSnapshot{`,
+		`Expired:` + fmt.Sprintf("%v", this.Expired) + `,`,
+		`Calculators:` + repeatedStringForCalculators + `,`,
+		`EventTimes:` + repeatedStringForEventTimes + `,`,
+		`SideOutput:` + repeatedStringForSideOutput + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func valueToStringAggrbatch(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("*%v", pv)
+}
+func (m *Batchs) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAggrbatch
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Batchs: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Batchs: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Batchs", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Batchs = append(m.Batchs, &AggregationBatch{})
+			if err := m.Batchs[len(m.Batchs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PickKey", wireType)
+			}
+			m.PickKey = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PickKey |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAggrbatch(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *AggregationBatch) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAggrbatch
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: AggregationBatch: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: AggregationBatch: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RoutingKey", wireType)
+			}
+			m.RoutingKey = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RoutingKey |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ConfigHash", wireType)
+			}
+			m.ConfigHash = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ConfigHash |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RawConfig", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.RawConfig = append(m.RawConfig[:0], dAtA[iNdEx:postIndex]...)
+			if m.RawConfig == nil {
+				m.RawConfig = []byte{}
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AggregationOpts", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.AggregationOpts == nil {
+				m.AggregationOpts = make(map[string]*AggregationAlgo)
+			}
+			var mapkey string
+			var mapvalue *AggregationAlgo
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAggrbatch
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowAggrbatch
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthAggrbatch
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthAggrbatch
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var mapmsglen int
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowAggrbatch
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapmsglen |= int(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					if mapmsglen < 0 {
+						return ErrInvalidLengthAggrbatch
+					}
+					postmsgIndex := iNdEx + mapmsglen
+					if postmsgIndex < 0 {
+						return ErrInvalidLengthAggrbatch
+					}
+					if postmsgIndex > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = &AggregationAlgo{}
+					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
+						return err
+					}
+					iNdEx = postmsgIndex
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipAggrbatch(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthAggrbatch
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.AggregationOpts[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Points", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Points == nil {
+				m.Points = &point.PBPoints{}
+			}
+			if err := m.Points.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PickKey", wireType)
+			}
+			m.PickKey = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PickKey |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Rule", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Rule = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EventTime", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.EventTime == nil {
+				m.EventTime = &EventTimeOptions{}
+			}
+			if err := m.EventTime.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Window", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Window == nil {
+				m.Window = &WindowOptions{}
+			}
+			if err := m.Window.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Expressions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Expressions = append(m.Expressions, &Expression{})
+			if err := m.Expressions[len(m.Expressions)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAggrbatch(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Expression) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAggrbatch
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Expression: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Expression: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Expr", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Expr = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OnDivZero", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.OnDivZero = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAggrbatch(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *WindowOptions) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAggrbatch
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: WindowOptions: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: WindowOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Type = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Length", wireType)
+			}
+			m.Length = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Length |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Step", wireType)
+			}
+			m.Step = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Step |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAggrbatch(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *EventTimeOptions) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAggrbatch
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: EventTimeOptions: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: EventTimeOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AllowedLateness", wireType)
+			}
+			m.AllowedLateness = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.AllowedLateness |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LatePolicy", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.LatePolicy = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAggrbatch(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *AggregationAlgo) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAggrbatch
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: AggregationAlgo: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: AggregationAlgo: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Method", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Method = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SourceField", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SourceField = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Window", wireType)
+			}
+			m.Window = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Window |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AddTags", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.AddTags == nil {
+				m.AddTags = make(map[string]string)
+			}
+			var mapkey string
+			var mapvalue string
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAggrbatch
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowAggrbatch
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthAggrbatch
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthAggrbatch
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var stringLenmapvalue uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowAggrbatch
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapvalue |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapvalue := int(stringLenmapvalue)
+					if intStringLenmapvalue < 0 {
+						return ErrInvalidLengthAggrbatch
+					}
+					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+					if postStringIndexmapvalue < 0 {
+						return ErrInvalidLengthAggrbatch
+					}
+					if postStringIndexmapvalue > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
+					iNdEx = postStringIndexmapvalue
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipAggrbatch(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthAggrbatch
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.AddTags[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HistogramOpts", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &HistogramOptions{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Options = &AggregationAlgo_HistogramOpts{v}
+			iNdEx = postIndex
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExpoOpts", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &ExpoHistogramOptions{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Options = &AggregationAlgo_ExpoOpts{v}
+			iNdEx = postIndex
+		case 12:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QuantileOpts", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &QuantileOptions{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Options = &AggregationAlgo_QuantileOpts{v}
+			iNdEx = postIndex
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TopkOpts", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &TopKOptions{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Options = &AggregationAlgo_TopkOpts{v}
+			iNdEx = postIndex
+		case 14:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DistinctValuesOpts", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &DistinctValuesOptions{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Options = &AggregationAlgo_DistinctValuesOpts{v}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAggrbatch(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *HistogramOptions) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAggrbatch
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: HistogramOptions: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: HistogramOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType == 1 {
+				var v uint64
+				if (iNdEx + 8) > l {
+					return io.ErrUnexpectedEOF
+				}
+				v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+				iNdEx += 8
+				v2 := float64(math.Float64frombits(v))
+				m.Buckets = append(m.Buckets, v2)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAggrbatch
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthAggrbatch
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthAggrbatch
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				elementCount = packedLen / 8
+				if elementCount != 0 && len(m.Buckets) == 0 {
+					m.Buckets = make([]float64, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v uint64
+					if (iNdEx + 8) > l {
+						return io.ErrUnexpectedEOF
+					}
+					v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+					iNdEx += 8
+					v2 := float64(math.Float64frombits(v))
+					m.Buckets = append(m.Buckets, v2)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field Buckets", wireType)
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAggrbatch(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExpoHistogramOptions) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAggrbatch
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExpoHistogramOptions: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExpoHistogramOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxScale", wireType)
+			}
+			m.MaxScale = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxScale |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxBuckets", wireType)
+			}
+			m.MaxBuckets = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxBuckets |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RecordMinMax", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.RecordMinMax = bool(v != 0)
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DisableMinMax", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.DisableMinMax = bool(v != 0)
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HasMaxScale", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.HasMaxScale = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAggrbatch(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *QuantileOptions) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAggrbatch
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: QuantileOptions: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: QuantileOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType == 1 {
+				var v uint64
+				if (iNdEx + 8) > l {
+					return io.ErrUnexpectedEOF
+				}
+				v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+				iNdEx += 8
+				v2 := float64(math.Float64frombits(v))
+				m.Percentiles = append(m.Percentiles, v2)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAggrbatch
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthAggrbatch
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthAggrbatch
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				elementCount = packedLen / 8
+				if elementCount != 0 && len(m.Percentiles) == 0 {
+					m.Percentiles = make([]float64, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v uint64
+					if (iNdEx + 8) > l {
+						return io.ErrUnexpectedEOF
+					}
+					v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+					iNdEx += 8
+					v2 := float64(math.Float64frombits(v))
+					m.Percentiles = append(m.Percentiles, v2)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field Percentiles", wireType)
+			}
+		case 2:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RelativeAccuracy", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.RelativeAccuracy = float64(math.Float64frombits(v))
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxBins", wireType)
+			}
+			m.MaxBins = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxBins |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EmitSketch", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.EmitSketch = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAggrbatch(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *QuantileSketch) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAggrbatch
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: QuantileSketch: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: QuantileSketch: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RelativeAccuracy", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.RelativeAccuracy = float64(math.Float64frombits(v))
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Count", wireType)
+			}
+			m.Count = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Count |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Sum", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.Sum = float64(math.Float64frombits(v))
+		case 4:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Min", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.Min = float64(math.Float64frombits(v))
+		case 5:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Max", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.Max = float64(math.Float64frombits(v))
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ZeroCount", wireType)
+			}
+			m.ZeroCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ZeroCount |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PosOffset", wireType)
+			}
+			m.PosOffset = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.PosOffset |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType == 0 {
+				var v uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAggrbatch
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.PosCounts = append(m.PosCounts, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAggrbatch
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthAggrbatch
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthAggrbatch
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
+				}
+				elementCount = count
+				if elementCount != 0 && len(m.PosCounts) == 0 {
+					m.PosCounts = make([]uint64, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowAggrbatch
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.PosCounts = append(m.PosCounts, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field PosCounts", wireType)
+			}
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NegOffset", wireType)
+			}
+			m.NegOffset = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.NegOffset |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 10:
+			if wireType == 0 {
+				var v uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAggrbatch
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.NegCounts = append(m.NegCounts, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAggrbatch
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthAggrbatch
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthAggrbatch
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
+				}
+				elementCount = count
+				if elementCount != 0 && len(m.NegCounts) == 0 {
+					m.NegCounts = make([]uint64, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowAggrbatch
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.NegCounts = append(m.NegCounts, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field NegCounts", wireType)
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAggrbatch(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *TopKOptions) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1631,17 +7016,36 @@ func (m *Batchs) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Batchs: wiretype end group for non-group")
+			return fmt.Errorf("proto: TopKOptions: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Batchs: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: TopKOptions: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field K", wireType)
+			}
+			m.K = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.K |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Batchs", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field WeightField", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAggrbatch
@@ -1651,31 +7055,29 @@ func (m *Batchs) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthAggrbatch
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthAggrbatch
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Batchs = append(m.Batchs, &AggregationBatch{})
-			if err := m.Batchs[len(m.Batchs)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.WeightField = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 2:
+		case 3:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field PickKey", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Capacity", wireType)
 			}
-			m.PickKey = 0
+			m.Capacity = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAggrbatch
@@ -1685,11 +7087,31 @@ func (m *Batchs) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.PickKey |= uint64(b&0x7F) << shift
+				m.Capacity |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EmitSketch", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			m.EmitSketch = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAggrbatch(dAtA[iNdEx:])
@@ -1711,7 +7133,7 @@ func (m *Batchs) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *AggregationBatch) Unmarshal(dAtA []byte) error {
+func (m *DistinctValuesOptions) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1734,17 +7156,17 @@ func (m *AggregationBatch) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: AggregationBatch: wiretype end group for non-group")
+			return fmt.Errorf("proto: DistinctValuesOptions: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: AggregationBatch: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: DistinctValuesOptions: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field RoutingKey", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field K", wireType)
 			}
-			m.RoutingKey = 0
+			m.K = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAggrbatch
@@ -1754,35 +7176,66 @@ func (m *AggregationBatch) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.RoutingKey |= uint64(b&0x7F) << shift
+				m.K |= int32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ConfigHash", wireType)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAggrbatch(dAtA[iNdEx:])
+			if err != nil {
+				return err
 			}
-			m.ConfigHash = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowAggrbatch
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.ConfigHash |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthAggrbatch
 			}
-		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field RawConfig", wireType)
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
 			}
-			var byteLen int
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *TopKSketch) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAggrbatch
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: TopKSketch: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: TopKSketch: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Capacity", wireType)
+			}
+			m.Capacity = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAggrbatch
@@ -1792,29 +7245,25 @@ func (m *AggregationBatch) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				m.Capacity |= int32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
-				return ErrInvalidLengthAggrbatch
-			}
-			postIndex := iNdEx + byteLen
-			if postIndex < 0 {
-				return ErrInvalidLengthAggrbatch
+		case 2:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Total", wireType)
 			}
-			if postIndex > l {
+			var v uint64
+			if (iNdEx + 8) > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.RawConfig = append(m.RawConfig[:0], dAtA[iNdEx:postIndex]...)
-			if m.RawConfig == nil {
-				m.RawConfig = []byte{}
-			}
-			iNdEx = postIndex
-		case 4:
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.Total = float64(math.Float64frombits(v))
+		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field AggregationOpts", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Counters", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -1841,111 +7290,66 @@ func (m *AggregationBatch) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.AggregationOpts == nil {
-				m.AggregationOpts = make(map[string]*AggregationAlgo)
+			m.Counters = append(m.Counters, &TopKCounter{})
+			if err := m.Counters[len(m.Counters)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
-			var mapkey string
-			var mapvalue *AggregationAlgo
-			for iNdEx < postIndex {
-				entryPreIndex := iNdEx
-				var wire uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowAggrbatch
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					wire |= uint64(b&0x7F) << shift
-					if b < 0x80 {
-						break
-					}
-				}
-				fieldNum := int32(wire >> 3)
-				if fieldNum == 1 {
-					var stringLenmapkey uint64
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowAggrbatch
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						stringLenmapkey |= uint64(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					intStringLenmapkey := int(stringLenmapkey)
-					if intStringLenmapkey < 0 {
-						return ErrInvalidLengthAggrbatch
-					}
-					postStringIndexmapkey := iNdEx + intStringLenmapkey
-					if postStringIndexmapkey < 0 {
-						return ErrInvalidLengthAggrbatch
-					}
-					if postStringIndexmapkey > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
-					iNdEx = postStringIndexmapkey
-				} else if fieldNum == 2 {
-					var mapmsglen int
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowAggrbatch
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						mapmsglen |= int(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					if mapmsglen < 0 {
-						return ErrInvalidLengthAggrbatch
-					}
-					postmsgIndex := iNdEx + mapmsglen
-					if postmsgIndex < 0 {
-						return ErrInvalidLengthAggrbatch
-					}
-					if postmsgIndex > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapvalue = &AggregationAlgo{}
-					if err := mapvalue.Unmarshal(dAtA[iNdEx:postmsgIndex]); err != nil {
-						return err
-					}
-					iNdEx = postmsgIndex
-				} else {
-					iNdEx = entryPreIndex
-					skippy, err := skipAggrbatch(dAtA[iNdEx:])
-					if err != nil {
-						return err
-					}
-					if (skippy < 0) || (iNdEx+skippy) < 0 {
-						return ErrInvalidLengthAggrbatch
-					}
-					if (iNdEx + skippy) > postIndex {
-						return io.ErrUnexpectedEOF
-					}
-					iNdEx += skippy
-				}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipAggrbatch(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
 			}
-			m.AggregationOpts[mapkey] = mapvalue
-			iNdEx = postIndex
-		case 5:
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *TopKCounter) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowAggrbatch
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: TopKCounter: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: TopKCounter: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Points", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Item", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAggrbatch
@@ -1955,47 +7359,46 @@ func (m *AggregationBatch) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthAggrbatch
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthAggrbatch
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Points == nil {
-				m.Points = &point.PBPoints{}
+			m.Item = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Weight", wireType)
 			}
-			if err := m.Points.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
 			}
-			iNdEx = postIndex
-		case 6:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field PickKey", wireType)
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.Weight = float64(math.Float64frombits(v))
+		case 3:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Error", wireType)
 			}
-			m.PickKey = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowAggrbatch
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.PickKey |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
 			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.Error = float64(math.Float64frombits(v))
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAggrbatch(dAtA[iNdEx:])
@@ -2017,7 +7420,7 @@ func (m *AggregationBatch) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *AggregationAlgo) Unmarshal(dAtA []byte) error {
+func (m *CalculatorSnapshot) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2040,15 +7443,15 @@ func (m *AggregationAlgo) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: AggregationAlgo: wiretype end group for non-group")
+			return fmt.Errorf("proto: CalculatorSnapshot: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: AggregationAlgo: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: CalculatorSnapshot: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Method", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Token", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2076,11 +7479,30 @@ func (m *AggregationAlgo) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Method = string(dAtA[iNdEx:postIndex])
+			m.Token = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Expire", wireType)
+			}
+			m.Expire = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Expire |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field SourceField", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Method", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2108,13 +7530,13 @@ func (m *AggregationAlgo) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.SourceField = string(dAtA[iNdEx:postIndex])
+			m.Method = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Window", wireType)
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
 			}
-			m.Window = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAggrbatch
@@ -2124,16 +7546,29 @@ func (m *AggregationAlgo) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Window |= int64(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 8:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field AddTags", wireType)
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAggrbatch
 			}
-			var msglen int
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hash", wireType)
+			}
+			m.Hash = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAggrbatch
@@ -2143,122 +7578,52 @@ func (m *AggregationAlgo) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.Hash |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthAggrbatch
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthAggrbatch
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Window", wireType)
 			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+			m.Window = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Window |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			if m.AddTags == nil {
-				m.AddTags = make(map[string]string)
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NextWallTime", wireType)
 			}
-			var mapkey string
-			var mapvalue string
-			for iNdEx < postIndex {
-				entryPreIndex := iNdEx
-				var wire uint64
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowAggrbatch
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					wire |= uint64(b&0x7F) << shift
-					if b < 0x80 {
-						break
-					}
+			m.NextWallTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
 				}
-				fieldNum := int32(wire >> 3)
-				if fieldNum == 1 {
-					var stringLenmapkey uint64
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowAggrbatch
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						stringLenmapkey |= uint64(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					intStringLenmapkey := int(stringLenmapkey)
-					if intStringLenmapkey < 0 {
-						return ErrInvalidLengthAggrbatch
-					}
-					postStringIndexmapkey := iNdEx + intStringLenmapkey
-					if postStringIndexmapkey < 0 {
-						return ErrInvalidLengthAggrbatch
-					}
-					if postStringIndexmapkey > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
-					iNdEx = postStringIndexmapkey
-				} else if fieldNum == 2 {
-					var stringLenmapvalue uint64
-					for shift := uint(0); ; shift += 7 {
-						if shift >= 64 {
-							return ErrIntOverflowAggrbatch
-						}
-						if iNdEx >= l {
-							return io.ErrUnexpectedEOF
-						}
-						b := dAtA[iNdEx]
-						iNdEx++
-						stringLenmapvalue |= uint64(b&0x7F) << shift
-						if b < 0x80 {
-							break
-						}
-					}
-					intStringLenmapvalue := int(stringLenmapvalue)
-					if intStringLenmapvalue < 0 {
-						return ErrInvalidLengthAggrbatch
-					}
-					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
-					if postStringIndexmapvalue < 0 {
-						return ErrInvalidLengthAggrbatch
-					}
-					if postStringIndexmapvalue > l {
-						return io.ErrUnexpectedEOF
-					}
-					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
-					iNdEx = postStringIndexmapvalue
-				} else {
-					iNdEx = entryPreIndex
-					skippy, err := skipAggrbatch(dAtA[iNdEx:])
-					if err != nil {
-						return err
-					}
-					if (skippy < 0) || (iNdEx+skippy) < 0 {
-						return ErrInvalidLengthAggrbatch
-					}
-					if (iNdEx + skippy) > postIndex {
-						return io.ErrUnexpectedEOF
-					}
-					iNdEx += skippy
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.NextWallTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
 				}
 			}
-			m.AddTags[mapkey] = mapvalue
-			iNdEx = postIndex
-		case 10:
+		case 8:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field HistogramOpts", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field State", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2285,15 +7650,68 @@ func (m *AggregationAlgo) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			v := &HistogramOptions{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if m.State == nil {
+				m.State = &point.PBPoint{}
+			}
+			if err := m.State.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
-			m.Options = &AggregationAlgo_HistogramOpts{v}
 			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Rule", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Rule = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EventTime", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.EventTime = bool(v != 0)
 		case 11:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ExpoOpts", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field WindowOpts", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2320,15 +7738,74 @@ func (m *AggregationAlgo) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			v := &ExpoHistogramOptions{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if m.WindowOpts == nil {
+				m.WindowOpts = &WindowOptions{}
+			}
+			if err := m.WindowOpts.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
-			m.Options = &AggregationAlgo_ExpoOpts{v}
 			iNdEx = postIndex
 		case 12:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Pane", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Pane = bool(v != 0)
+		case 13:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LastEmit", wireType)
+			}
+			m.LastEmit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.LastEmit |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 14:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Group", wireType)
+			}
+			m.Group = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Group |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 15:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field QuantileOpts", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Expressions", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2355,12 +7832,31 @@ func (m *AggregationAlgo) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			v := &QuantileOptions{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.Expressions = append(m.Expressions, &Expression{})
+			if err := m.Expressions[len(m.Expressions)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
-			m.Options = &AggregationAlgo_QuantileOpts{v}
 			iNdEx = postIndex
+		case 16:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Correction", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Correction = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAggrbatch(dAtA[iNdEx:])
@@ -2382,7 +7878,7 @@ func (m *AggregationAlgo) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *HistogramOptions) Unmarshal(dAtA []byte) error {
+func (m *EventTimeSnapshot) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2405,65 +7901,168 @@ func (m *HistogramOptions) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: HistogramOptions: wiretype end group for non-group")
+			return fmt.Errorf("proto: EventTimeSnapshot: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: HistogramOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: EventTimeSnapshot: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType == 1 {
-				var v uint64
-				if (iNdEx + 8) > l {
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Token", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
 					return io.ErrUnexpectedEOF
 				}
-				v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
-				iNdEx += 8
-				v2 := float64(math.Float64frombits(v))
-				m.Buckets = append(m.Buckets, v2)
-			} else if wireType == 2 {
-				var packedLen int
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowAggrbatch
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					packedLen |= int(b&0x7F) << shift
-					if b < 0x80 {
-						break
-					}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Token = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Rule", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Rule = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxTime", wireType)
+			}
+			m.MaxTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Options", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Options == nil {
+				m.Options = &EventTimeOptions{}
+			}
+			if err := m.Options.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Late", wireType)
+			}
+			m.Late = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
 				}
-				if packedLen < 0 {
-					return ErrInvalidLengthAggrbatch
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
 				}
-				postIndex := iNdEx + packedLen
-				if postIndex < 0 {
-					return ErrInvalidLengthAggrbatch
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Late |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
 				}
-				if postIndex > l {
-					return io.ErrUnexpectedEOF
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Closed", wireType)
+			}
+			m.Closed = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
 				}
-				var elementCount int
-				elementCount = packedLen / 8
-				if elementCount != 0 && len(m.Buckets) == 0 {
-					m.Buckets = make([]float64, 0, elementCount)
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
 				}
-				for iNdEx < postIndex {
-					var v uint64
-					if (iNdEx + 8) > l {
-						return io.ErrUnexpectedEOF
-					}
-					v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
-					iNdEx += 8
-					v2 := float64(math.Float64frombits(v))
-					m.Buckets = append(m.Buckets, v2)
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Closed |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
 				}
-			} else {
-				return fmt.Errorf("proto: wrong wireType = %d for field Buckets", wireType)
 			}
 		default:
 			iNdEx = preIndex
@@ -2486,7 +8085,7 @@ func (m *HistogramOptions) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ExpoHistogramOptions) Unmarshal(dAtA []byte) error {
+func (m *SideOutputSnapshot) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2509,17 +8108,17 @@ func (m *ExpoHistogramOptions) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ExpoHistogramOptions: wiretype end group for non-group")
+			return fmt.Errorf("proto: SideOutputSnapshot: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ExpoHistogramOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: SideOutputSnapshot: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field MaxScale", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Token", wireType)
 			}
-			m.MaxScale = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAggrbatch
@@ -2529,16 +8128,29 @@ func (m *ExpoHistogramOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.MaxScale |= int32(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Token = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field MaxBuckets", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Points", wireType)
 			}
-			m.MaxBuckets = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowAggrbatch
@@ -2548,31 +8160,26 @@ func (m *ExpoHistogramOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.MaxBuckets |= int32(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field RecordMinMax", wireType)
+			if msglen < 0 {
+				return ErrInvalidLengthAggrbatch
 			}
-			var v int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowAggrbatch
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				v |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
 			}
-			m.RecordMinMax = bool(v != 0)
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Points = append(m.Points, &point.PBPoint{})
+			if err := m.Points[len(m.Points)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAggrbatch(dAtA[iNdEx:])
@@ -2594,7 +8201,7 @@ func (m *ExpoHistogramOptions) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *QuantileOptions) Unmarshal(dAtA []byte) error {
+func (m *CacheSnapshot) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2617,66 +8224,133 @@ func (m *QuantileOptions) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: QuantileOptions: wiretype end group for non-group")
+			return fmt.Errorf("proto: CacheSnapshot: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: QuantileOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: CacheSnapshot: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType == 1 {
-				var v uint64
-				if (iNdEx + 8) > l {
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Expired", wireType)
+			}
+			m.Expired = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
 					return io.ErrUnexpectedEOF
 				}
-				v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
-				iNdEx += 8
-				v2 := float64(math.Float64frombits(v))
-				m.Percentiles = append(m.Percentiles, v2)
-			} else if wireType == 2 {
-				var packedLen int
-				for shift := uint(0); ; shift += 7 {
-					if shift >= 64 {
-						return ErrIntOverflowAggrbatch
-					}
-					if iNdEx >= l {
-						return io.ErrUnexpectedEOF
-					}
-					b := dAtA[iNdEx]
-					iNdEx++
-					packedLen |= int(b&0x7F) << shift
-					if b < 0x80 {
-						break
-					}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Expired |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
 				}
-				if packedLen < 0 {
-					return ErrInvalidLengthAggrbatch
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Calculators", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
 				}
-				postIndex := iNdEx + packedLen
-				if postIndex < 0 {
-					return ErrInvalidLengthAggrbatch
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
 				}
-				if postIndex > l {
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Calculators = append(m.Calculators, &CalculatorSnapshot{})
+			if err := m.Calculators[len(m.Calculators)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EventTimes", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
+				}
+				if iNdEx >= l {
 					return io.ErrUnexpectedEOF
 				}
-				var elementCount int
-				elementCount = packedLen / 8
-				if elementCount != 0 && len(m.Percentiles) == 0 {
-					m.Percentiles = make([]float64, 0, elementCount)
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
 				}
-				for iNdEx < postIndex {
-					var v uint64
-					if (iNdEx + 8) > l {
-						return io.ErrUnexpectedEOF
-					}
-					v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
-					iNdEx += 8
-					v2 := float64(math.Float64frombits(v))
-					m.Percentiles = append(m.Percentiles, v2)
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.EventTimes = append(m.EventTimes, &EventTimeSnapshot{})
+			if err := m.EventTimes[len(m.EventTimes)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SideOutput", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAggrbatch
 				}
-			} else {
-				return fmt.Errorf("proto: wrong wireType = %d for field Percentiles", wireType)
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAggrbatch
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SideOutput = append(m.SideOutput, &SideOutputSnapshot{})
+			if err := m.SideOutput[len(m.SideOutput)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAggrbatch(dAtA[iNdEx:])
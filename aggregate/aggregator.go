@@ -0,0 +1,201 @@
+package aggregate
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+)
+
+// Aggregator aggregate points of a workspace on its configure, and the
+// configure can be reloaded with calculators of unchanged rules kept.
+type Aggregator struct {
+	lock  sync.RWMutex
+	cfg   *AggregatorConfigure
+	cache *Cache
+}
+
+// NewAggregator create aggregator on cfg, exp is the Expired of the cache.
+func NewAggregator(cfg *AggregatorConfigure, exp time.Duration) (*Aggregator, error) {
+	if err := cfg.Setup(); err != nil {
+		return nil, err
+	}
+
+	return &Aggregator{cfg: cfg, cache: NewCache(exp)}, nil
+}
+
+// Configure return the configure currently used.
+func (a *Aggregator) Configure() *AggregatorConfigure {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return a.cfg
+}
+
+// Cache return the cache of calculators.
+func (a *Aggregator) Cache() *Cache {
+	return a.cache
+}
+
+// Aggregate pick points of category and add them into cache, n and expN
+// are calculators added and expired.
+func (a *Aggregator) Aggregate(token, category string, pts []*point.Point) (n, expN int) {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	for _, bs := range a.cfg.PickPoints(category, pts) {
+		n1, expN1 := a.cache.AddBatchs(token, bs.Batchs)
+		n += n1
+		expN += expN1
+	}
+
+	return n, expN
+}
+
+// Flush return final points of windows expired.
+func (a *Aggregator) Flush() []*PointsData {
+	return WindowsToData(a.cache.GetExpWidows())
+}
+
+// ReloadDiff is the name of rules differ between configures.
+type ReloadDiff struct {
+	Added,
+	Removed,
+	Changed []string
+}
+
+// Empty check if nothing changed among rules.
+func (d *ReloadDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+func (d *ReloadDiff) String() string {
+	return fmt.Sprintf("added: %v, removed: %v, changed: %v", d.Added, d.Removed, d.Changed)
+}
+
+// ruleHashes return hash of rules by name, rules with the same name hashed
+// together.
+func ruleHashes(ac *AggregatorConfigure) map[string]uint64 {
+	res := map[string]uint64{}
+	for _, ar := range ac.AggregateRules {
+		res[ar.Name] = HashCombine(res[ar.Name], ar.hash)
+	}
+	return res
+}
+
+func diffRules(from, to *AggregatorConfigure) *ReloadDiff {
+	var (
+		diff   = &ReloadDiff{}
+		hashes = ruleHashes(from)
+	)
+
+	for name, h := range ruleHashes(to) {
+		old, ok := hashes[name]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, name)
+		case old != h:
+			diff.Changed = append(diff.Changed, name)
+		}
+		delete(hashes, name)
+	}
+
+	for name := range hashes {
+		diff.Removed = append(diff.Removed, name)
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
+
+// Reload switch to cfg. Calculators of rules unchanged are kept, rules
+// removed or changed are flushed and their points returned, and rules added
+// start fresh. On error the current configure kept.
+func (a *Aggregator) Reload(cfg *AggregatorConfigure) (*ReloadDiff, []*PointsData, error) {
+	if err := cfg.Setup(); err != nil {
+		return nil, nil, err
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	diff := diffRules(a.cfg, cfg)
+	a.cfg = cfg
+
+	rules := map[string]bool{}
+	for _, name := range append(diff.Removed, diff.Changed...) {
+		rules[name] = true
+	}
+
+	if len(rules) == 0 {
+		return diff, nil, nil
+	}
+
+	return diff, WindowsToData(a.cache.flushRules(rules)), nil
+}
+
+// flushRules remove and return calculators of rules within the cache,
+// hopping/sliding windows of them emitted till the last pane.
+func (c *Cache) flushRules(rules map[string]bool) []*Window {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var res []*Window
+
+	take := func(ws *Windows) {
+		if ws == nil {
+			return
+		}
+
+		ws.lock.Lock()
+		defer ws.lock.Unlock()
+
+		for _, w := range ws.WS {
+			var x *Window
+
+			w.lock.Lock()
+			for h, calc := range w.cache {
+				if !rules[calc.Base().rule] {
+					continue
+				}
+
+				if x == nil {
+					x = windowPool.Get().(*Window)
+					x.Reset()
+					x.Token = w.Token
+					res = append(res, x)
+				}
+
+				x.cache[h] = calc
+				delete(w.cache, h)
+			}
+			w.lock.Unlock()
+		}
+	}
+
+	for _, ws := range c.WindowsBuckets {
+		take(ws)
+	}
+
+	for k, et := range c.eventTimes {
+		if rules[k.rule] {
+			for _, ws := range et.buckets {
+				res = append(res, ws.Close()...)
+			}
+			delete(c.eventTimes, k)
+		}
+	}
+
+	for _, ws := range c.corrections {
+		take(ws)
+	}
+
+	res = c.addPanes(res)
+	return append(res, c.emitPanes(func(_ paneKey, ps *paneSeries) (int64, bool) {
+		return math.MaxInt64, rules[ps.rule]
+	})...)
+}
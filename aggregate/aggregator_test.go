@@ -0,0 +1,117 @@
+package aggregate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func reloadTestRule(name, field string, method AlgoMethod) *AggregateRule {
+	return &AggregateRule{
+		Name:       name,
+		Selector:   &RuleSelector{Category: point.Metric.String(), MetricName: []string{field}},
+		Groupby:    []string{"host"},
+		Algorithms: map[string]*AggregationAlgoConfig{field: {Method: string(method), AddTags: map[string]string{"rule": name}}},
+	}
+}
+
+func reloadTestPoints(now time.Time) []*point.Point {
+	return []*point.Point{
+		point.NewPoint("request",
+			point.NewKVs(map[string]any{"a": 1.0, "b": 2.0, "c": 3.0, "d": 4.0, "e": 5.0}).AddTag("host", "web-1"),
+			point.WithTime(now)),
+	}
+}
+
+// reloadTestOutput return output points of pds by rule tag.
+func reloadTestOutput(pds []*PointsData) map[string][]*point.Point {
+	res := map[string][]*point.Point{}
+	for _, pd := range pds {
+		for _, pt := range pd.PTS {
+			res[pt.GetTag("rule")] = append(res[pt.GetTag("rule")], pt)
+		}
+	}
+	return res
+}
+
+func TestAggregatorReload(t *testing.T) {
+	now := time.Now()
+
+	hopping := reloadTestRule("e", "e", SUM)
+	hopping.Window = &WindowOptions{Type: WindowHopping, Length: int64(2 * time.Hour), Step: int64(time.Hour)}
+
+	a, err := NewAggregator(&AggregatorConfigure{
+		DefaultWindow: time.Hour,
+		AggregateRules: []*AggregateRule{
+			reloadTestRule("a", "a", SUM),
+			reloadTestRule("b", "b", SUM),
+			reloadTestRule("c", "c", SUM),
+			hopping,
+		},
+	}, time.Hour)
+	require.NoError(t, err)
+
+	n, _ := a.Aggregate("token-a", point.Metric.String(), reloadTestPoints(now))
+	assert.Equal(t, 4, n)
+
+	// invalid configure rejected and the current one kept
+	cfg := a.Configure()
+	_, _, err = a.Reload(&AggregatorConfigure{AggregateRules: []*AggregateRule{{Name: "bad"}}})
+	assert.Error(t, err)
+	assert.Equal(t, cfg, a.Configure())
+
+	diff, flushed, err := a.Reload(&AggregatorConfigure{
+		DefaultWindow: time.Hour,
+		AggregateRules: []*AggregateRule{
+			reloadTestRule("a", "a", SUM),
+			reloadTestRule("b", "b", MAX),
+			reloadTestRule("d", "d", SUM),
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &ReloadDiff{Added: []string{"d"}, Removed: []string{"c", "e"}, Changed: []string{"b"}}, diff)
+	assert.Equal(t, "added: [d], removed: [c e], changed: [b]", diff.String())
+
+	out := reloadTestOutput(flushed)
+	assert.Len(t, out, 3)
+	assert.Nil(t, out["a"], "unchanged rule not flushed")
+	require.Len(t, out["b"], 1)
+	assert.Equal(t, 2.0, out["b"][0].Get("b"))
+	require.Len(t, out["c"], 1)
+	assert.Equal(t, 3.0, out["c"][0].Get("c"))
+	require.Len(t, out["e"], 2, "all hopping windows covering the pane emitted")
+	assert.NotEmpty(t, out["e"][0].GetTag(WindowEndTag))
+
+	n, _ = a.Aggregate("token-a", point.Metric.String(), reloadTestPoints(now))
+	assert.Equal(t, 3, n)
+
+	var windows []*Window
+	for _, ws := range a.Cache().WindowsBuckets {
+		windows = append(windows, ws.Close()...)
+	}
+
+	out = reloadTestOutput(WindowsToData(windows))
+	assert.Len(t, out, 3)
+	require.Len(t, out["a"], 1)
+	assert.Equal(t, 2.0, out["a"][0].Get("a"), "calculators of unchanged rule kept")
+	assert.Equal(t, int64(2), out["a"][0].Get("a_count"))
+	assert.Equal(t, 2.0, out["b"][0].Get("b"), "changed rule start fresh")
+	assert.Equal(t, 4.0, out["d"][0].Get("d"))
+
+	diff, flushed, err = a.Reload(a.Configure())
+	require.NoError(t, err)
+	assert.True(t, diff.Empty())
+	assert.Empty(t, flushed)
+}
+
+func TestDiffRulesDefaultWindow(t *testing.T) {
+	from := &AggregatorConfigure{DefaultWindow: time.Minute, AggregateRules: []*AggregateRule{reloadTestRule("a", "a", SUM)}}
+	to := &AggregatorConfigure{DefaultWindow: time.Hour, AggregateRules: []*AggregateRule{reloadTestRule("a", "a", SUM)}}
+	require.NoError(t, from.Setup())
+	require.NoError(t, to.Setup())
+
+	assert.Equal(t, []string{"a"}, diffRules(from, to).Changed)
+}
@@ -17,7 +17,7 @@ var _ Calculator = &algoAvg{}
 
 func (c *algoAvg) Add(x any) {
 	if inst, ok := x.(*algoAvg); ok {
-		c.count++
+		c.count += inst.count
 		c.delta += inst.delta
 
 		if inst.maxTime > c.maxTime {
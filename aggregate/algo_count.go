@@ -16,7 +16,7 @@ var _ Calculator = &algoCount{}
 
 func (c *algoCount) Add(x any) {
 	if inst, ok := x.(*algoCount); ok {
-		c.count++
+		c.count += inst.count
 		if inst.maxTime > c.maxTime {
 			c.maxTime = inst.maxTime
 		}
@@ -0,0 +1,503 @@
+package aggregate
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/GuanceCloud/cliutils"
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/cespare/xxhash/v2"
+)
+
+const (
+	expoMaxScale          = 20
+	expoMinScale          = -10
+	expoDefaultMaxBuckets = 160
+)
+
+// Field suffixes of exponential histogram, the field <key><suffix> typed
+// with the EXPONENTIAL_HISTOGRAM_* MetricType.
+const (
+	expoSuffixCount           = "_count"
+	expoSuffixSum             = "_sum"
+	expoSuffixAvg             = "_avg"
+	expoSuffixMin             = "_min"
+	expoSuffixMax             = "_max"
+	expoSuffixScale           = "_scale"
+	expoSuffixZeroCount       = "_zero_count"
+	expoSuffixPosOffset       = "_pos_offset"
+	expoSuffixPosBucketCounts = "_pos_bucket_counts"
+	expoSuffixNegOffset       = "_neg_offset"
+	expoSuffixNegBucketCounts = "_neg_bucket_counts"
+	expoSuffixStartTime       = "_start_time"
+)
+
+var expoHistogramSuffixes = []string{
+	expoSuffixCount,
+	expoSuffixSum,
+	expoSuffixAvg,
+	expoSuffixMin,
+	expoSuffixMax,
+	expoSuffixScale,
+	expoSuffixZeroCount,
+	expoSuffixPosOffset,
+	expoSuffixPosBucketCounts,
+	expoSuffixNegOffset,
+	expoSuffixNegBucketCounts,
+	expoSuffixStartTime,
+}
+
+// expoBuckets is the continuous buckets start from index offset.
+type expoBuckets struct {
+	offset int32
+	counts []uint64
+}
+
+func (b *expoBuckets) empty() bool {
+	return len(b.counts) == 0
+}
+
+func (b *expoBuckets) high() int32 {
+	return b.offset + int32(len(b.counts)) - 1
+}
+
+// incr add n to bucket index, the buckets grown as needed.
+func (b *expoBuckets) incr(index int32, n uint64) {
+	switch {
+	case b.empty():
+		b.offset = index
+		b.counts = []uint64{n}
+		return
+
+	case index < b.offset:
+		counts := make([]uint64, b.high()-index+1)
+		copy(counts[b.offset-index:], b.counts)
+		b.offset, b.counts = index, counts
+
+	case index > b.high():
+		b.counts = append(b.counts, make([]uint64, index-b.high())...)
+	}
+
+	b.counts[index-b.offset] += n
+}
+
+// downscale merge every 2^change buckets into one.
+func (b *expoBuckets) downscale(change int32) {
+	if change <= 0 || b.empty() {
+		return
+	}
+
+	offset := b.offset >> change
+	counts := make([]uint64, b.high()>>change-offset+1)
+	for i, n := range b.counts {
+		counts[(b.offset+int32(i))>>change-offset] += n
+	}
+
+	b.offset, b.counts = offset, counts
+}
+
+// trimmed return the buckets without zero buckets at both end.
+func (b *expoBuckets) trimmed() (int32, []uint64) {
+	start, end := 0, len(b.counts)
+	for start < end && b.counts[start] == 0 {
+		start++
+	}
+
+	for end > start && b.counts[end-1] == 0 {
+		end--
+	}
+
+	return b.offset + int32(start), b.counts[start:end]
+}
+
+// expoIndex return the bucket index of v(v > 0) at scale. Bucket index i
+// is (base^i, base^(i+1)], where base = 2^(2^-scale).
+func expoIndex(v float64, scale int32) int32 {
+	frac, exp := math.Frexp(v) // v = frac * 2^exp, frac in [0.5, 1)
+
+	if frac == 0.5 { // v is power of 2, upper bound of the bucket
+		if scale > 0 {
+			return int32(exp-1)<<scale - 1
+		}
+		return int32(exp-2) >> -scale
+	}
+
+	if scale <= 0 {
+		return int32(exp-1) >> -scale
+	}
+
+	// v within (2^(exp-1), 2^exp), clamp the float error on the bounds.
+	idx := int32(math.Ceil(math.Ldexp(math.Log2(v), int(scale)))) - 1
+	if low := int32(exp-1) << scale; idx < low {
+		idx = low
+	}
+	if high := int32(exp)<<scale - 1; idx > high {
+		idx = high
+	}
+
+	return idx
+}
+
+// algoExpoHistogram is the base-2 exponential histogram, the scale lowered
+// automatically to keep positive(and negative) buckets within maxBuckets.
+type algoExpoHistogram struct {
+	MetricBase
+
+	maxScale     int32
+	maxBuckets   int
+	recordMinMax bool
+
+	scale     int32
+	count     uint64
+	zeroCount uint64
+	sum,
+	min,
+	max float64
+	pos,
+	neg expoBuckets
+
+	startTime,
+	maxTime int64
+}
+
+// type assertions.
+var _ Calculator = &algoExpoHistogram{}
+
+func newAlgoExpoHistogram(mb MetricBase, ts int64, opts *ExpoHistogramOptions) *algoExpoHistogram {
+	c := &algoExpoHistogram{
+		MetricBase:   mb,
+		maxScale:     expoMaxScale,
+		maxBuckets:   expoDefaultMaxBuckets,
+		recordMinMax: true,
+	}
+
+	if opts != nil {
+		if opts.HasMaxScale || opts.MaxScale != 0 {
+			c.maxScale = min(max(opts.MaxScale, expoMinScale), expoMaxScale)
+		}
+
+		if opts.MaxBuckets > 0 {
+			c.maxBuckets = max(int(opts.MaxBuckets), 2)
+		}
+
+		c.recordMinMax = !opts.DisableMinMax
+	}
+
+	c.Reset()
+	c.startTime, c.maxTime = ts, ts
+	return c
+}
+
+// newExpoHistogram create a histogram without bucket limit, used to hold
+// the histogram within a point before merged.
+func newExpoHistogram(scale int32) *algoExpoHistogram {
+	return &algoExpoHistogram{
+		scale: scale,
+		min:   math.Inf(1),
+		max:   math.Inf(-1),
+	}
+}
+
+func (c *algoExpoHistogram) downscale(change int32) {
+	if change <= 0 {
+		return
+	}
+
+	c.pos.downscale(change)
+	c.neg.downscale(change)
+	c.scale -= change
+}
+
+// changeFor return the scale change to keep index within maxBuckets of b.
+func (c *algoExpoHistogram) changeFor(b *expoBuckets, index int32) int32 {
+	if b.empty() || c.maxBuckets <= 0 {
+		return 0
+	}
+
+	low, high := min(b.offset, index), max(b.high(), index)
+
+	var change int32
+	for int64(high)-int64(low) >= int64(c.maxBuckets) && c.scale-change > expoMinScale {
+		low >>= 1
+		high >>= 1
+		change++
+	}
+
+	return change
+}
+
+// insert add n to bucket index(at scale, not less than c.scale) of b.
+func (c *algoExpoHistogram) insert(b *expoBuckets, index, scale int32, n uint64) {
+	index >>= scale - c.scale
+
+	if change := c.changeFor(b, index); change > 0 {
+		c.downscale(change)
+		index >>= change
+	}
+
+	b.incr(index, n)
+}
+
+func (c *algoExpoHistogram) addValue(v float64) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return
+	}
+
+	c.count++
+	c.sum += v
+	c.min = math.Min(c.min, v)
+	c.max = math.Max(c.max, v)
+
+	switch {
+	case v > 0:
+		c.insert(&c.pos, expoIndex(v, c.scale), c.scale, 1)
+	case v < 0:
+		c.insert(&c.neg, expoIndex(-v, c.scale), c.scale, 1)
+	default:
+		c.zeroCount++
+	}
+}
+
+func (c *algoExpoHistogram) merge(o *algoExpoHistogram) {
+	if o.count == 0 {
+		return
+	}
+
+	c.count += o.count
+	c.zeroCount += o.zeroCount
+	c.sum += o.sum
+	c.min = math.Min(c.min, o.min)
+	c.max = math.Max(c.max, o.max)
+
+	if o.startTime > 0 && (c.startTime == 0 || o.startTime < c.startTime) {
+		c.startTime = o.startTime
+	}
+
+	if o.maxTime > c.maxTime {
+		c.maxTime = o.maxTime
+	}
+
+	c.downscale(c.scale - o.scale)
+
+	for i, n := range o.pos.counts {
+		if n > 0 {
+			c.insert(&c.pos, o.pos.offset+int32(i), o.scale, n)
+		}
+	}
+
+	for i, n := range o.neg.counts {
+		if n > 0 {
+			c.insert(&c.neg, o.neg.offset+int32(i), o.scale, n)
+		}
+	}
+}
+
+func (c *algoExpoHistogram) Add(x any) {
+	if inst, ok := x.(*algoExpoHistogram); ok {
+		c.merge(inst)
+	}
+}
+
+func (c *algoExpoHistogram) Aggr() ([]*point.Point, error) {
+	var kvs point.KVs
+
+	kvs = kvs.Add(c.key+expoSuffixCount, int64(c.count), point.WithKVType(point.EXPONENTIAL_HISTOGRAM_COUNT)).
+		Add(c.key+expoSuffixSum, c.sum, point.WithKVType(point.EXPONENTIAL_HISTOGRAM_SUM)).
+		Add(c.key+expoSuffixScale, int64(c.scale), point.WithKVType(point.EXPONENTIAL_HISTOGRAM_SCALE)).
+		Add(c.key+expoSuffixZeroCount, int64(c.zeroCount), point.WithKVType(point.EXPONENTIAL_HISTOGRAM_ZERO_COUNT))
+
+	if c.count > 0 {
+		kvs = kvs.Add(c.key+expoSuffixAvg, c.sum/float64(c.count), point.WithKVType(point.EXPONENTIAL_HISTOGRAM_AVG))
+
+		if c.recordMinMax && !math.IsInf(c.min, 0) && !math.IsInf(c.max, 0) {
+			kvs = kvs.Add(c.key+expoSuffixMin, c.min, point.WithKVType(point.EXPONENTIAL_HISTOGRAM_MIN)).
+				Add(c.key+expoSuffixMax, c.max, point.WithKVType(point.EXPONENTIAL_HISTOGRAM_MAX))
+		}
+	}
+
+	if offset, counts := c.pos.trimmed(); len(counts) > 0 {
+		kvs = kvs.Add(c.key+expoSuffixPosOffset, int64(offset), point.WithKVType(point.EXPONENTIAL_HISTOGRAM_POS_OFFSET)).
+			Add(c.key+expoSuffixPosBucketCounts, counts, point.WithKVType(point.EXPONENTIAL_HISTOGRAM_POS_BUCKET_COUNTS))
+	}
+
+	if offset, counts := c.neg.trimmed(); len(counts) > 0 {
+		kvs = kvs.Add(c.key+expoSuffixNegOffset, int64(offset), point.WithKVType(point.EXPONENTIAL_HISTOGRAM_NEG_OFFSET)).
+			Add(c.key+expoSuffixNegBucketCounts, counts, point.WithKVType(point.EXPONENTIAL_HISTOGRAM_NEG_BUCKET_COUNTS))
+	}
+
+	if c.startTime > 0 {
+		kvs = kvs.Add(c.key+expoSuffixStartTime, c.startTime, point.WithKVType(point.EXPONENTIAL_HISTOGRAM_START_TIME))
+	}
+
+	for _, kv := range c.aggrTags {
+		// NOTE: if same-name tag key exist, apply the last one.
+		kvs = kvs.SetTag(kv[0], kv[1])
+	}
+
+	return []*point.Point{
+		point.NewPoint(c.name, kvs, point.WithTimestamp(c.maxTime)),
+	}, nil
+}
+
+func (c *algoExpoHistogram) Reset() {
+	c.scale = c.maxScale
+	c.count = 0
+	c.zeroCount = 0
+	c.sum = 0
+	c.min = math.Inf(1)
+	c.max = math.Inf(-1)
+	c.pos = expoBuckets{}
+	c.neg = expoBuckets{}
+	c.startTime = 0
+	c.maxTime = 0
+}
+
+func (c *algoExpoHistogram) doHash(h1 uint64) {
+	h := HashCombine(h1, xxhash.Sum64([]byte("expo_histogram")))
+	h = HashCombine(h, xxhash.Sum64(cliutils.ToUnsafeBytes(c.key)))
+	c.MetricBase.hash = HashCombine(h, xxhash.Sum64(cliutils.ToUnsafeBytes(c.name)))
+}
+
+func (c *algoExpoHistogram) Base() *MetricBase {
+	return &c.MetricBase
+}
+
+// expoHistogramBases return names of exponential histograms within pt, the
+// histogram of name x is fields x_scale, x_zero_count, x_pos_bucket_counts...
+func expoHistogramBases(pt *point.Point) (bases []string) {
+	for _, kv := range pt.KVs() {
+		if kv.IsTag || !strings.HasSuffix(kv.Key, expoSuffixScale) {
+			continue
+		}
+
+		base := strings.TrimSuffix(kv.Key, expoSuffixScale)
+		if base != "" && pt.Get(base+expoSuffixZeroCount) != nil {
+			bases = append(bases, base)
+		}
+	}
+
+	sort.Strings(bases)
+	return bases
+}
+
+// isExpoHistogramPoint check if exponential histogram of base within pt.
+func isExpoHistogramPoint(pt *point.Point, base string) bool {
+	return pt.Get(base+expoSuffixScale) != nil && pt.Get(base+expoSuffixZeroCount) != nil
+}
+
+// expoHistogramBaseOf return the histogram name if key is field of exponential histogram within pt.
+func expoHistogramBaseOf(pt *point.Point, key string) string {
+	for _, suffix := range expoHistogramSuffixes {
+		if base := strings.TrimSuffix(key, suffix); base != key && base != "" && isExpoHistogramPoint(pt, base) {
+			return base
+		}
+	}
+
+	return ""
+}
+
+// expoHistogramFromPoint parse exponential histogram of base within pt.
+func expoHistogramFromPoint(pt *point.Point, base string) (*algoExpoHistogram, bool) {
+	scale, ok := expoInt(pt.Get(base + expoSuffixScale))
+	if !ok || scale < expoMinScale || scale > expoMaxScale {
+		return nil, false
+	}
+
+	h := newExpoHistogram(int32(scale))
+
+	if x, ok := expoInt(pt.Get(base + expoSuffixZeroCount)); ok && x > 0 {
+		h.zeroCount = uint64(x)
+	}
+
+	for _, b := range []struct {
+		buckets        *expoBuckets
+		offset, counts string
+	}{
+		{&h.pos, base + expoSuffixPosOffset, base + expoSuffixPosBucketCounts},
+		{&h.neg, base + expoSuffixNegOffset, base + expoSuffixNegBucketCounts},
+	} {
+		offset, _ := expoInt(pt.Get(b.offset))
+		if offset < math.MinInt32 || offset > math.MaxInt32 {
+			return nil, false
+		}
+
+		b.buckets.offset = int32(offset)
+		b.buckets.counts = expoCounts(pt.Get(b.counts))
+	}
+
+	if x, ok := expoInt(pt.Get(base + expoSuffixCount)); ok && x > 0 {
+		h.count = uint64(x)
+	} else {
+		h.count = h.zeroCount
+		for _, b := range []*expoBuckets{&h.pos, &h.neg} {
+			for _, n := range b.counts {
+				h.count += n
+			}
+		}
+	}
+
+	h.sum, _ = expoFloat(pt.Get(base + expoSuffixSum))
+
+	if x, ok := expoFloat(pt.Get(base + expoSuffixMin)); ok {
+		h.min = x
+	}
+
+	if x, ok := expoFloat(pt.Get(base + expoSuffixMax)); ok {
+		h.max = x
+	}
+
+	h.maxTime = pt.Time().UnixNano()
+	h.startTime = h.maxTime
+	if x, ok := expoInt(pt.Get(base + expoSuffixStartTime)); ok && x > 0 {
+		h.startTime = x
+	}
+
+	return h, true
+}
+
+func expoInt(v any) (int64, bool) {
+	switch x := v.(type) {
+	case int64:
+		return x, true
+	case uint64:
+		if x > math.MaxInt64 {
+			return math.MaxInt64, true
+		}
+		return int64(x), true
+	case float64:
+		return int64(x), true
+	default:
+		return 0, false
+	}
+}
+
+func expoFloat(v any) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case int64:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	default:
+		return 0, false
+	}
+}
+
+func expoCounts(v any) []uint64 {
+	switch x := v.(type) {
+	case []uint64:
+		return append([]uint64(nil), x...)
+	case []int64:
+		res := make([]uint64, len(x))
+		for i, n := range x {
+			if n > 0 {
+				res[i] = uint64(n)
+			}
+		}
+		return res
+	default:
+		return nil
+	}
+}
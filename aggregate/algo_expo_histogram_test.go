@@ -0,0 +1,311 @@
+package aggregate
+
+import (
+	"math"
+	T "testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expoTotal(c *algoExpoHistogram) (n uint64) {
+	n = c.zeroCount
+	for _, b := range []*expoBuckets{&c.pos, &c.neg} {
+		for _, x := range b.counts {
+			n += x
+		}
+	}
+	return n
+}
+
+func TestExpoIndex(t *T.T) {
+	cases := []struct {
+		v     float64
+		scale int32
+		index int32
+	}{
+		// scale 0: bucket i is (2^i, 2^(i+1)]
+		{1, 0, -1},
+		{1.5, 0, 0},
+		{2, 0, 0},
+		{3, 0, 1},
+		{4, 0, 1},
+		{5, 0, 2},
+		{0.25, 0, -3},
+
+		// scale 1: base sqrt(2)
+		{2, 1, 1},
+		{3, 1, 3},
+		{4, 1, 3},
+
+		// scale -1: base 4
+		{2, -1, 0},
+		{4, -1, 0},
+		{5, -1, 1},
+		{16, -1, 1},
+		{17, -1, 2},
+
+		// scale 3
+		{1, 3, -1},
+		{1.1, 3, 1},
+		{1024, 3, 79},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.index, expoIndex(tc.v, tc.scale), "%v@%d", tc.v, tc.scale)
+	}
+
+	// v within the bucket bounds
+	for _, scale := range []int32{-2, 0, 5, 20} {
+		for _, v := range []float64{0.001, 0.3, 1.7, 42, 1e6, 3.3e12} {
+			idx := expoIndex(v, scale)
+			low := math.Exp2(math.Ldexp(float64(idx), -int(scale)))
+			high := math.Exp2(math.Ldexp(float64(idx+1), -int(scale)))
+			assert.True(t, v > low*(1-1e-9) && v <= high*(1+1e-9), "%v@%d: index %d (%v, %v]", v, scale, idx, low, high)
+		}
+	}
+}
+
+func TestAlgoExpoHistogram(t *T.T) {
+	mb := MetricBase{
+		key:      "latency",
+		name:     "request",
+		aggrTags: [][2]string{{"service", "checkout"}},
+	}
+
+	t.Run("raw-values", func(t *T.T) {
+		c := newAlgoExpoHistogram(mb, 100, nil)
+		for _, v := range []float64{1, 2, 3, 0, -2, math.NaN(), math.Inf(1)} {
+			c.addValue(v)
+		}
+
+		assert.Equal(t, uint64(5), c.count)
+		assert.Equal(t, uint64(1), c.zeroCount)
+		assert.Equal(t, 4.0, c.sum)
+		assert.Equal(t, -2.0, c.min)
+		assert.Equal(t, 3.0, c.max)
+		assert.Equal(t, int32(6), c.scale) // 1 ~ 3 within 160 buckets
+		assert.Equal(t, uint64(5), expoTotal(c))
+		assert.Equal(t, []uint64{1}, c.neg.counts)
+	})
+
+	t.Run("auto-downscale", func(t *T.T) {
+		c := newAlgoExpoHistogram(mb, 100, &ExpoHistogramOptions{MaxBuckets: 4})
+		for i := 0; i < 64; i++ {
+			c.addValue(math.Pow(2, float64(i)/4))
+		}
+
+		assert.Less(t, c.scale, int32(expoMaxScale))
+		assert.LessOrEqual(t, len(c.pos.counts), 4)
+		assert.Equal(t, uint64(64), expoTotal(c))
+
+		// 2^0 ~ 2^15.75 within 4 buckets: scale -3 with base 256
+		assert.Equal(t, int32(-3), c.scale)
+	})
+
+	t.Run("max-scale", func(t *T.T) {
+		c := newAlgoExpoHistogram(mb, 100, &ExpoHistogramOptions{MaxScale: 2})
+		c.addValue(3)
+		assert.Equal(t, int32(2), c.scale)
+		assert.Equal(t, expoIndex(3, 2), c.pos.offset)
+		assert.True(t, c.recordMinMax)
+	})
+
+	t.Run("max-scale-zero", func(t *T.T) {
+		c := newAlgoExpoHistogram(mb, 100, &ExpoHistogramOptions{HasMaxScale: true})
+		c.addValue(3)
+		assert.Equal(t, int32(0), c.scale)
+		assert.Equal(t, expoIndex(3, 0), c.pos.offset)
+
+		c = newAlgoExpoHistogram(mb, 100, &ExpoHistogramOptions{MaxBuckets: 8})
+		assert.Equal(t, int32(expoMaxScale), c.maxScale)
+		assert.True(t, c.recordMinMax)
+	})
+
+	t.Run("merge-same-as-add", func(t *T.T) {
+		values := []float64{0.5, 1, 7, 13, 100, 1000, 12345, -3, -70, 0}
+		opts := &ExpoHistogramOptions{MaxBuckets: 8}
+
+		all := newAlgoExpoHistogram(mb, 100, opts)
+		for _, v := range values {
+			all.addValue(v)
+		}
+
+		merged := newAlgoExpoHistogram(mb, 100, opts)
+		merged.addValue(values[0])
+		for i, v := range values[1:] {
+			c := newAlgoExpoHistogram(mb, int64(101+i), opts)
+			c.addValue(v)
+			merged.Add(c)
+		}
+
+		assert.Equal(t, all.scale, merged.scale)
+		assert.Equal(t, all.count, merged.count)
+		assert.Equal(t, all.zeroCount, merged.zeroCount)
+		assert.Equal(t, all.sum, merged.sum)
+
+		po1, pc1 := all.pos.trimmed()
+		po2, pc2 := merged.pos.trimmed()
+		assert.Equal(t, po1, po2)
+		assert.Equal(t, pc1, pc2)
+
+		no1, nc1 := all.neg.trimmed()
+		no2, nc2 := merged.neg.trimmed()
+		assert.Equal(t, no1, no2)
+		assert.Equal(t, nc1, nc2)
+
+		assert.Equal(t, int64(100), merged.startTime)
+		assert.Equal(t, int64(109), merged.maxTime)
+	})
+
+	t.Run("aggr-and-reset", func(t *T.T) {
+		c := newAlgoExpoHistogram(mb, time.Unix(1710000000, 0).UnixNano(), nil)
+		for _, v := range []float64{1, 2, 4, -1} {
+			c.addValue(v)
+		}
+
+		pts, err := c.Aggr()
+		require.NoError(t, err)
+		require.Len(t, pts, 1)
+
+		pt := pts[0]
+		assert.Equal(t, "request", pt.Name())
+		assert.Equal(t, "checkout", pt.GetTag("service"))
+
+		for k, v := range map[string]any{
+			"latency_count":      int64(4),
+			"latency_sum":        6.0,
+			"latency_avg":        1.5,
+			"latency_min":        -1.0,
+			"latency_max":        4.0,
+			"latency_scale":      int64(6), // 1 ~ 4 within 160 buckets
+			"latency_zero_count": int64(0),
+			"latency_neg_offset": int64(-1),
+		} {
+			assert.Equal(t, v, pt.Get(k), k)
+		}
+
+		kv := pt.KVs().Get("latency_pos_bucket_counts")
+		require.NotNil(t, kv)
+		assert.Equal(t, point.EXPONENTIAL_HISTOGRAM_POS_BUCKET_COUNTS, kv.Type)
+
+		counts, ok := kv.Raw().([]uint64)
+		require.True(t, ok, "got %T", kv.Raw())
+		assert.Len(t, counts, 2<<6+1) // buckets from 1 to 4
+		assert.Equal(t, uint64(1), counts[0])
+		assert.Equal(t, uint64(1), counts[1<<6])
+		assert.Equal(t, uint64(1), counts[len(counts)-1])
+
+		assert.Equal(t, point.EXPONENTIAL_HISTOGRAM_SCALE, pt.KVs().Get("latency_scale").Type)
+		assert.NotEmpty(t, c.ToString())
+
+		c.Reset()
+		assert.Zero(t, c.count)
+		assert.True(t, c.pos.empty())
+		assert.Equal(t, int32(expoMaxScale), c.scale)
+	})
+
+	t.Run("no-min-max", func(t *T.T) {
+		c := newAlgoExpoHistogram(mb, 100, &ExpoHistogramOptions{MaxScale: 4, DisableMinMax: true})
+		c.addValue(3)
+
+		pts, err := c.Aggr()
+		require.NoError(t, err)
+		assert.Nil(t, pts[0].Get("latency_min"))
+		assert.Nil(t, pts[0].Get("latency_max"))
+	})
+}
+
+func TestExpoHistogramAggregate(t *T.T) {
+	now := time.Unix(1710000000, 0)
+
+	cfg := &AggregatorConfigure{
+		DefaultWindow: time.Second * 10,
+		AggregateRules: []*AggregateRule{
+			{
+				Name:    "expo",
+				Groupby: []string{"service"},
+				Selector: &RuleSelector{
+					Category:   point.Metric.String(),
+					MetricName: []string{"latency"},
+				},
+				Algorithms: map[string]*AggregationAlgoConfig{
+					"latency": {
+						Method:   string(EXPO_HISTOGRAM),
+						ExpoOpts: &ExpoHistogramOptions{MaxScale: 3, MaxBuckets: 16},
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, cfg.Setup())
+
+	// histogram from upstream, with higher scale
+	upstream := newAlgoExpoHistogram(MetricBase{key: "latency", name: "request"}, now.UnixNano(), &ExpoHistogramOptions{MaxScale: 6})
+	for _, v := range []float64{1.5, 3, 9, 0} {
+		upstream.addValue(v)
+	}
+	upPts, err := upstream.Aggr()
+	require.NoError(t, err)
+
+	hpt := upPts[0]
+	hpt.SetTag("service", "checkout")
+	hpt.Add("other", 1.0)
+
+	var pts []*point.Point
+	pts = append(pts, hpt)
+	for _, v := range []float64{2, 5} {
+		pts = append(pts, point.NewPoint("request",
+			point.NewTags(map[string]string{"service": "checkout"}).Add("latency", v),
+			point.WithTime(now)))
+	}
+
+	// histogram fields selected within single point
+	selected := cfg.SelectPoints(pts)
+	require.Len(t, selected, 1)
+	require.Len(t, selected[0], 3)
+	assert.NotNil(t, selected[0][0].Get("latency_pos_bucket_counts"))
+	assert.Nil(t, selected[0][0].Get("other"))
+
+	var (
+		batches = cfg.AggregateRules[0].GroupbyBatch(cfg, selected[0])
+		res     *algoExpoHistogram
+	)
+
+	for _, b := range batches {
+		for _, calc := range newCalculators(b) {
+			c, ok := calc.(*algoExpoHistogram)
+			require.True(t, ok)
+			if res == nil {
+				res = c
+			} else {
+				require.Equal(t, res.hash, c.hash)
+				res.Add(c)
+			}
+		}
+	}
+
+	require.NotNil(t, res)
+	assert.Equal(t, uint64(6), res.count)
+	assert.Equal(t, uint64(1), res.zeroCount)
+	assert.Equal(t, 20.5, res.sum)
+	assert.Equal(t, 0.0, res.min)
+	assert.Equal(t, 9.0, res.max)
+	assert.Equal(t, uint64(6), expoTotal(res))
+
+	// same as raw values added
+	want := newAlgoExpoHistogram(MetricBase{}, 0, &ExpoHistogramOptions{MaxScale: 3, MaxBuckets: 16})
+	for _, v := range []float64{1.5, 3, 9, 0, 2, 5} {
+		want.addValue(v)
+	}
+
+	assert.Equal(t, int32(2), want.scale) // 1.5 ~ 9 not within 16 buckets on scale 3
+	assert.Equal(t, want.scale, res.scale)
+
+	wo, wc := want.pos.trimmed()
+	ro, rc := res.pos.trimmed()
+	assert.Equal(t, wo, ro)
+	assert.Equal(t, wc, rc)
+}
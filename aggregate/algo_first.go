@@ -13,13 +13,17 @@ type algoCountFirst struct {
 	first     float64
 	firstTime int64
 	count     int64
+
+	// raw is the non-numeric(string/bool/bytes) value, nil on numeric value.
+	raw any
 }
 
 func (a *algoCountFirst) Add(x any) {
 	if inst, ok := x.(*algoCountFirst); ok {
-		a.count++
+		a.count += inst.count
 		if a.firstTime == 0 || inst.firstTime < a.firstTime {
 			a.first = inst.first
+			a.raw = inst.raw
 			a.firstTime = inst.firstTime
 		}
 	}
@@ -28,8 +32,13 @@ func (a *algoCountFirst) Add(x any) {
 func (a *algoCountFirst) Aggr() ([]*point.Point, error) {
 	var kvs point.KVs
 
-	kvs = kvs.Add(a.key, a.first).
-		Add(a.key+"_count", a.count)
+	if a.raw != nil {
+		kvs = kvs.Add(a.key, a.raw)
+	} else {
+		kvs = kvs.Add(a.key, a.first)
+	}
+
+	kvs = kvs.Add(a.key+"_count", a.count)
 
 	for _, kv := range a.aggrTags {
 		kvs = kvs.SetTag(kv[0], kv[1])
@@ -42,6 +51,7 @@ func (a *algoCountFirst) Aggr() ([]*point.Point, error) {
 
 func (a *algoCountFirst) Reset() {
 	a.first = 0
+	a.raw = nil
 	a.firstTime = 0
 	a.count = 0
 }
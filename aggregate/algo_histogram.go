@@ -21,13 +21,13 @@ var _ Calculator = &algoHistogram{}
 
 func (c *algoHistogram) Add(x any) {
 	if inst, ok := x.(*algoHistogram); ok {
-		c.count++
+		c.count += inst.count
 
-		if inst.pt != nil {
-			kvs := point.KVs(inst.pt.Fields)
-			if le := kvs.GetTag("le"); le != "" {
-				c.leBucket[le] += inst.val
-			}
+		if c.leBucket == nil {
+			c.leBucket = map[string]float64{}
+		}
+		for le, f := range inst.leBucket {
+			c.leBucket[le] += f
 		}
 		if inst.maxTime > c.maxTime {
 			c.maxTime = inst.maxTime
@@ -13,13 +13,17 @@ type algoCountLast struct {
 	last     float64
 	lastTime int64
 	count    int64
+
+	// raw is the non-numeric(string/bool/bytes) value, nil on numeric value.
+	raw any
 }
 
 func (a *algoCountLast) Add(x any) {
 	if inst, ok := x.(*algoCountLast); ok {
-		a.count++
+		a.count += inst.count
 		if inst.lastTime > a.lastTime {
 			a.last = inst.last
+			a.raw = inst.raw
 			a.lastTime = inst.lastTime
 		}
 	}
@@ -28,8 +32,13 @@ func (a *algoCountLast) Add(x any) {
 func (a *algoCountLast) Aggr() ([]*point.Point, error) {
 	var kvs point.KVs
 
-	kvs = kvs.Add(a.key, a.last).
-		Add(a.key+"_count", a.count)
+	if a.raw != nil {
+		kvs = kvs.Add(a.key, a.raw)
+	} else {
+		kvs = kvs.Add(a.key, a.last)
+	}
+
+	kvs = kvs.Add(a.key+"_count", a.count)
 
 	for _, kv := range a.aggrTags {
 		kvs = kvs.SetTag(kv[0], kv[1])
@@ -42,6 +51,7 @@ func (a *algoCountLast) Aggr() ([]*point.Point, error) {
 
 func (a *algoCountLast) Reset() {
 	a.last = 0
+	a.raw = nil
 	a.lastTime = 0
 	a.count = 0
 }
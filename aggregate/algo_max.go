@@ -21,7 +21,7 @@ func (c *algoMax) Add(x any) {
 		if c.maxTime < inst.maxTime {
 			c.maxTime = inst.maxTime
 		}
-		c.count++
+		c.count += inst.count
 	}
 }
 
@@ -19,6 +19,12 @@ const (
 	COUNT_DISTINCT     AlgoMethod = "count_distinct"
 	LAST               AlgoMethod = "last"
 	FIRST              AlgoMethod = "first"
+	MODE               AlgoMethod = "mode"
+	DISTINCT_VALUES    AlgoMethod = "distinct_values"
+	RATE               AlgoMethod = "rate"
+	INCREASE           AlgoMethod = "increase"
+	IRATE              AlgoMethod = "irate"
+	TOPK               AlgoMethod = "topk"
 )
 
 func (m AlgoMethod) String() string {
@@ -53,6 +59,18 @@ func NormalizeAlgoMethod(raw string) AlgoMethod {
 		return LAST
 	case "first":
 		return FIRST
+	case "mode":
+		return MODE
+	case "distinct_values":
+		return DISTINCT_VALUES
+	case "rate":
+		return RATE
+	case "increase":
+		return INCREASE
+	case "irate":
+		return IRATE
+	case "topk":
+		return TOPK
 	default:
 		return AlgoMethod(strings.ToLower(strings.TrimSpace(raw)))
 	}
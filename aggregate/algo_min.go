@@ -17,7 +17,7 @@ var _ Calculator = &algoMin{}
 
 func (a *algoMin) Add(x any) {
 	if inst, ok := x.(*algoMin); ok {
-		a.count++
+		a.count += inst.count
 		if inst.min < a.min {
 			a.min = inst.min
 		}
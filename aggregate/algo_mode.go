@@ -0,0 +1,270 @@
+package aggregate
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/GuanceCloud/cliutils"
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/cespare/xxhash/v2"
+)
+
+const (
+	// topValuesCapacity is max counters kept for mode/distinct_values, values
+	// beyond it are tracked approximately(Space-Saving).
+	topValuesCapacity = 128
+
+	// distinctValuesDefaultK is default max values output by distinct_values.
+	distinctValuesDefaultK = 10
+)
+
+type topValue struct {
+	val   any
+	count int64
+}
+
+// topValues count frequency of values within bounded memory: when all
+// counters used, the least frequent one replaced by the new value, which
+// inherits its count(Space-Saving algorithm). Counts are over-estimated
+// only for values that entered after an eviction.
+type topValues struct {
+	counters map[uint64]*topValue
+	total    int64
+}
+
+func (tv *topValues) add(val any, n int64) {
+	if tv.counters == nil {
+		tv.counters = make(map[uint64]*topValue)
+	}
+
+	tv.total += n
+	h := hashDistinctValue(val)
+
+	if c, ok := tv.counters[h]; ok {
+		c.count += n
+		return
+	}
+
+	if len(tv.counters) < topValuesCapacity {
+		tv.counters[h] = &topValue{val: val, count: n}
+		return
+	}
+
+	var (
+		minHash uint64
+		minVal  *topValue
+	)
+
+	for k, c := range tv.counters {
+		if minVal == nil || c.count < minVal.count {
+			minHash, minVal = k, c
+		}
+	}
+
+	delete(tv.counters, minHash)
+	tv.counters[h] = &topValue{val: val, count: minVal.count + n}
+}
+
+func (tv *topValues) merge(other *topValues) {
+	for _, c := range other.sorted() {
+		tv.add(c.val, c.count)
+	}
+}
+
+// sorted return counters by count desc, values with same count sorted by
+// their string form for stable output.
+func (tv *topValues) sorted() []*topValue {
+	res := make([]*topValue, 0, len(tv.counters))
+	for _, c := range tv.counters {
+		res = append(res, c)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].count != res[j].count {
+			return res[i].count > res[j].count
+		}
+		return fmt.Sprint(res[i].val) < fmt.Sprint(res[j].val)
+	})
+
+	return res
+}
+
+func (tv *topValues) reset() {
+	tv.counters = nil
+	tv.total = 0
+}
+
+// isTopValueType check if the value can be counted by mode/distinct_values.
+func isTopValueType(val any) bool {
+	switch val.(type) {
+	case float64, int64, uint64, string, []byte, bool:
+		return true
+	default:
+		return false
+	}
+}
+
+type algoMode struct {
+	MetricBase
+	maxTime int64
+	values  topValues
+}
+
+var _ Calculator = &algoMode{}
+
+func newAlgoMode(mb MetricBase, maxTime int64, val any) *algoMode {
+	calc := &algoMode{
+		MetricBase: mb,
+		maxTime:    maxTime,
+	}
+	calc.values.add(val, 1)
+	return calc
+}
+
+func (c *algoMode) Add(x any) {
+	if inst, ok := x.(*algoMode); ok {
+		c.values.merge(&inst.values)
+		if inst.maxTime > c.maxTime {
+			c.maxTime = inst.maxTime
+		}
+	}
+}
+
+func (c *algoMode) Aggr() ([]*point.Point, error) {
+	arr := c.values.sorted()
+	if len(arr) == 0 {
+		return nil, nil
+	}
+
+	var kvs point.KVs
+
+	kvs = kvs.Add(c.key, arr[0].val).
+		Add(c.key+"_frequency", arr[0].count).
+		Add(c.key+"_count", c.values.total)
+
+	for _, kv := range c.aggrTags {
+		kvs = kvs.SetTag(kv[0], kv[1])
+	}
+
+	return []*point.Point{
+		point.NewPoint(c.name, kvs, point.WithTimestamp(c.maxTime)),
+	}, nil
+}
+
+func (c *algoMode) Reset() {
+	c.maxTime = 0
+	c.values.reset()
+}
+
+func (c *algoMode) Base() *MetricBase {
+	return &c.MetricBase
+}
+
+func (c *algoMode) doHash(h1 uint64) {
+	h := HashCombine(h1, xxhash.Sum64([]byte("mode")))
+	h = HashCombine(h, xxhash.Sum64(cliutils.ToUnsafeBytes(c.key)))
+	c.MetricBase.hash = HashCombine(h, xxhash.Sum64(cliutils.ToUnsafeBytes(c.name)))
+}
+
+type algoDistinctValues struct {
+	MetricBase
+	maxTime int64
+	k       int
+	values  topValues
+}
+
+var _ Calculator = &algoDistinctValues{}
+
+// distinctValuesK return max values output by distinct_values.
+func distinctValuesK(opts *DistinctValuesOptions) int {
+	if k := int(opts.GetK()); k > 0 {
+		return k
+	}
+	return distinctValuesDefaultK
+}
+
+func newAlgoDistinctValues(mb MetricBase, maxTime int64, val any, opts *DistinctValuesOptions) *algoDistinctValues {
+	calc := &algoDistinctValues{
+		MetricBase: mb,
+		maxTime:    maxTime,
+		k:          distinctValuesK(opts),
+	}
+	calc.values.add(val, 1)
+	return calc
+}
+
+func (c *algoDistinctValues) Add(x any) {
+	if inst, ok := x.(*algoDistinctValues); ok {
+		c.values.merge(&inst.values)
+		if inst.maxTime > c.maxTime {
+			c.maxTime = inst.maxTime
+		}
+	}
+}
+
+func (c *algoDistinctValues) Aggr() ([]*point.Point, error) {
+	arr := c.values.sorted()
+	if len(arr) == 0 {
+		return nil, nil
+	}
+
+	if len(arr) > c.k {
+		arr = arr[:c.k]
+	}
+
+	var (
+		vals  = make([]any, 0, len(arr))
+		freqs = make([]int64, 0, len(arr))
+	)
+
+	for _, x := range arr {
+		vals = append(vals, x.val)
+		freqs = append(freqs, x.count)
+	}
+
+	values, err := point.NewAnyArray(vals...)
+	if err != nil { // mixed typed values, convert to strings
+		strs := make([]string, 0, len(vals))
+		for _, v := range vals {
+			strs = append(strs, fieldToString(v))
+		}
+
+		if values, err = point.NewStringArray(strs...); err != nil {
+			return nil, err
+		}
+	}
+
+	frequencies, err := point.NewIntArray(freqs...)
+	if err != nil {
+		return nil, err
+	}
+
+	var kvs point.KVs
+
+	kvs = kvs.Add(c.key, values).
+		Add(c.key+"_frequencies", frequencies).
+		Add(c.key+"_count", c.values.total)
+
+	for _, kv := range c.aggrTags {
+		kvs = kvs.SetTag(kv[0], kv[1])
+	}
+
+	return []*point.Point{
+		point.NewPoint(c.name, kvs, point.WithTimestamp(c.maxTime)),
+	}, nil
+}
+
+func (c *algoDistinctValues) Reset() {
+	c.maxTime = 0
+	c.values.reset()
+}
+
+func (c *algoDistinctValues) Base() *MetricBase {
+	return &c.MetricBase
+}
+
+func (c *algoDistinctValues) doHash(h1 uint64) {
+	h := HashCombine(h1, xxhash.Sum64([]byte("distinct_values")))
+	h = HashCombine(h, xxhash.Sum64(cliutils.ToUnsafeBytes(c.key)))
+	c.MetricBase.hash = HashCombine(h, xxhash.Sum64(cliutils.ToUnsafeBytes(c.name)))
+}
@@ -0,0 +1,243 @@
+package aggregate
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopValues(t *testing.T) {
+	t.Run("exact", func(t *testing.T) {
+		var tv topValues
+		for _, v := range []any{"a", "b", "a", int64(1), "a", int64(1)} {
+			tv.add(v, 1)
+		}
+
+		arr := tv.sorted()
+		require.Len(t, arr, 3)
+		assert.Equal(t, "a", arr[0].val)
+		assert.Equal(t, int64(3), arr[0].count)
+		assert.Equal(t, int64(1), arr[1].val)
+		assert.Equal(t, int64(6), tv.total)
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		var tv topValues
+		for i := 0; i < 10*topValuesCapacity; i++ {
+			tv.add("hot", 1)
+			tv.add(fmt.Sprintf("cold-%d", i), 1)
+		}
+
+		assert.Len(t, tv.counters, topValuesCapacity)
+		assert.Equal(t, int64(20*topValuesCapacity), tv.total)
+
+		arr := tv.sorted()
+		assert.Equal(t, "hot", arr[0].val)
+		assert.Equal(t, int64(10*topValuesCapacity), arr[0].count)
+
+		sum := int64(0)
+		for _, x := range arr {
+			sum += x.count
+		}
+		assert.Equal(t, tv.total, sum)
+	})
+}
+
+func TestAlgoMode(t *testing.T) {
+	mb := MetricBase{
+		key:      "error_code",
+		name:     "request",
+		aggrTags: [][2]string{{"service", "checkout"}},
+	}
+
+	calc := newAlgoMode(mb, 1, "E100")
+	for i, v := range []any{"E200", "E100", "E300", "E200", "E100"} {
+		calc.Add(newAlgoMode(mb, int64(i+2), v))
+	}
+
+	pts, err := calc.Aggr()
+	require.NoError(t, err)
+	require.Len(t, pts, 1)
+
+	assert.Equal(t, "E100", pts[0].Get("error_code"))
+	assert.Equal(t, int64(3), pts[0].Get("error_code_frequency"))
+	assert.Equal(t, int64(6), pts[0].Get("error_code_count"))
+	assert.Equal(t, "checkout", pts[0].GetTag("service"))
+	assert.Equal(t, int64(6), pts[0].Time().UnixNano())
+	assert.Contains(t, calc.ToString(), "E100:3")
+
+	calc.Reset()
+	pts, err = calc.Aggr()
+	require.NoError(t, err)
+	assert.Empty(t, pts)
+
+	// bool kept as bool
+	calc = newAlgoMode(mb, 1, true)
+	calc.Add(newAlgoMode(mb, 1, false))
+	calc.Add(newAlgoMode(mb, 1, true))
+	pts, err = calc.Aggr()
+	require.NoError(t, err)
+	assert.Equal(t, true, pts[0].Get("error_code"))
+}
+
+func TestAlgoDistinctValues(t *testing.T) {
+	mb := MetricBase{key: "status", name: "request"}
+
+	calc := newAlgoDistinctValues(mb, 1, "ok", nil)
+	for _, v := range []any{"ok", "error", "ok", "timeout", "error", "ok"} {
+		calc.Add(newAlgoDistinctValues(mb, 1, v, nil))
+	}
+
+	pts, err := calc.Aggr()
+	require.NoError(t, err)
+	require.Len(t, pts, 1)
+
+	values := pts[0].KVs().Get("status")
+	require.NotNil(t, values)
+	assert.Equal(t, []string{"ok", "error", "timeout"}, values.Raw())
+
+	freqs := pts[0].KVs().Get("status_frequencies")
+	require.NotNil(t, freqs)
+	assert.Equal(t, []int64{4, 2, 1}, freqs.Raw())
+	assert.Equal(t, int64(7), pts[0].Get("status_count"))
+	assert.NotEmpty(t, calc.ToString())
+
+	t.Run("top-k", func(t *testing.T) {
+		calc := newAlgoDistinctValues(mb, 1, int64(0), nil)
+		for i := 1; i < 3*distinctValuesDefaultK; i++ {
+			for j := 0; j <= i; j++ {
+				calc.Add(newAlgoDistinctValues(mb, 1, int64(i), nil))
+			}
+		}
+
+		pts, err := calc.Aggr()
+		require.NoError(t, err)
+
+		values, ok := pts[0].KVs().Get("status").Raw().([]int64)
+		require.True(t, ok)
+		require.Len(t, values, distinctValuesDefaultK)
+		assert.Equal(t, int64(3*distinctValuesDefaultK-1), values[0])
+	})
+
+	t.Run("k-option", func(t *testing.T) {
+		opts := &DistinctValuesOptions{K: 3}
+		calc := newAlgoDistinctValues(mb, 1, int64(0), opts)
+		for i := 1; i < 10; i++ {
+			for j := 0; j <= i; j++ {
+				calc.Add(newAlgoDistinctValues(mb, 1, int64(i), opts))
+			}
+		}
+
+		pts, err := calc.Aggr()
+		require.NoError(t, err)
+
+		assert.Equal(t, []int64{9, 8, 7}, pts[0].KVs().Get("status").Raw())
+		assert.Equal(t, []int64{10, 9, 8}, pts[0].KVs().Get("status_frequencies").Raw())
+	})
+
+	t.Run("mixed-types", func(t *testing.T) {
+		calc := newAlgoDistinctValues(mb, 1, "ok", nil)
+		calc.Add(newAlgoDistinctValues(mb, 1, int64(200), nil))
+
+		pts, err := calc.Aggr()
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"ok", "200"}, pts[0].KVs().Get("status").Raw())
+	})
+}
+
+func TestNewCalculatorsNonNumeric(t *testing.T) {
+	now := time.Unix(1710000000, 0)
+
+	var pts []*point.PBPoint
+	for i, kvs := range []map[string]any{
+		{"status": "ok", "error_code": "E1", "retry": false},
+		{"status": "error", "error_code": "E2", "retry": true},
+		{"status": "error", "error_code": "E2", "retry": true},
+	} {
+		pt := point.NewPoint("request", point.NewKVs(kvs), point.WithTime(now.Add(time.Duration(i)*time.Second)))
+		pts = append(pts, pt.PBPoint())
+	}
+
+	batch := &AggregationBatch{
+		RoutingKey: 1,
+		Points:     &point.PBPoints{Arr: pts},
+		AggregationOpts: map[string]*AggregationAlgo{
+			"last_status":  {Method: string(LAST), SourceField: "status", Window: 10},
+			"first_status": {Method: string(FIRST), SourceField: "status", Window: 10},
+			"common_code":  {Method: string(MODE), SourceField: "error_code", Window: 10},
+			"codes":        {Method: string(DISTINCT_VALUES), SourceField: "error_code", Window: 10},
+			"last_retry":   {Method: string(LAST), SourceField: "retry", Window: 10},
+			"sum_status":   {Method: string(SUM), SourceField: "status", Window: 10},
+		},
+	}
+
+	calcs := newCalculators(batch)
+	require.Len(t, calcs, 15) // sum on string ignored
+
+	merged := map[uint64]Calculator{}
+	for _, c := range calcs {
+		if x, ok := merged[c.Base().hash]; ok {
+			x.Add(c)
+		} else {
+			merged[c.Base().hash] = c
+		}
+	}
+	require.Len(t, merged, 5)
+
+	res := map[string]any{}
+	for _, c := range merged {
+		pts, err := c.Aggr()
+		require.NoError(t, err)
+		require.Len(t, pts, 1)
+		res[c.Base().key] = pts[0].Get(c.Base().key)
+	}
+
+	assert.Equal(t, "error", res["last_status"])
+	assert.Equal(t, "ok", res["first_status"])
+	assert.Equal(t, "E2", res["common_code"])
+	assert.Equal(t, true, res["last_retry"])
+	assert.NotNil(t, res["codes"])
+}
+
+func TestDistinctValuesConfig(t *testing.T) {
+	for _, opts := range []*DistinctValuesOptions{
+		{K: -1},
+		{K: topValuesCapacity + 1},
+	} {
+		assert.Error(t, validateAggregationAlgo("codes", &AggregationAlgo{
+			Method:  string(DISTINCT_VALUES),
+			Options: &AggregationAlgo_DistinctValuesOpts{DistinctValuesOpts: opts},
+		}))
+	}
+
+	assert.NoError(t, validateAggregationAlgo("codes", &AggregationAlgo{Method: string(DISTINCT_VALUES)}))
+
+	var cfg AggregatorConfigure
+	require.NoError(t, cfg.UnmarshalTOML(map[string]any{
+		"aggregate_rules": []any{
+			map[string]any{
+				"name": "rule",
+				"algorithms": map[string]any{
+					"codes": map[string]any{"method": "distinct_values", "distinct_values_opts": map[string]any{"k": 3}},
+				},
+			},
+		},
+	}))
+	assert.Equal(t, &AggregationAlgo_DistinctValuesOpts{DistinctValuesOpts: &DistinctValuesOptions{K: 3}},
+		cfg.AggregateRules[0].Algorithms["codes"].ToAggregationAlgo().Options)
+
+	t.Run("snapshot", func(t *testing.T) {
+		calc := newAlgoDistinctValues(MetricBase{key: "codes", name: "request"}, 1, "E1", &DistinctValuesOptions{K: 3})
+
+		s, err := snapshotCalculator(calc)
+		require.NoError(t, err)
+
+		restored, err := restoreCalculator(s)
+		require.NoError(t, err)
+		assert.Equal(t, 3, restored.(*algoDistinctValues).k)
+	})
+}
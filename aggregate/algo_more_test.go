@@ -30,8 +30,8 @@ func TestNumericCalculators(t *testing.T) {
 			name: "sum",
 			calc: &algoSum{MetricBase: base, delta: 1, maxTime: now.UnixNano(), count: 1},
 			inputs: []any{
-				&algoSum{delta: 2, maxTime: now.Add(time.Second).UnixNano()},
-				&algoSum{delta: 3, maxTime: now.Add(2 * time.Second).UnixNano()},
+				&algoSum{delta: 2, maxTime: now.Add(time.Second).UnixNano(), count: 1},
+				&algoSum{delta: 3, maxTime: now.Add(2 * time.Second).UnixNano(), count: 1},
 			},
 			wantField: "latency",
 			wantFloat: 6,
@@ -46,8 +46,8 @@ func TestNumericCalculators(t *testing.T) {
 			name: "avg",
 			calc: &algoAvg{MetricBase: base, delta: 2, maxTime: now.UnixNano(), count: 1},
 			inputs: []any{
-				&algoAvg{delta: 4, maxTime: now.Add(time.Second).UnixNano()},
-				&algoAvg{delta: 6, maxTime: now.Add(2 * time.Second).UnixNano()},
+				&algoAvg{delta: 4, maxTime: now.Add(time.Second).UnixNano(), count: 1},
+				&algoAvg{delta: 6, maxTime: now.Add(2 * time.Second).UnixNano(), count: 1},
 			},
 			wantField: "latency",
 			wantFloat: 4,
@@ -62,8 +62,8 @@ func TestNumericCalculators(t *testing.T) {
 			name: "count",
 			calc: &algoCount{MetricBase: base, maxTime: now.UnixNano(), count: 1},
 			inputs: []any{
-				&algoCount{maxTime: now.Add(time.Second).UnixNano()},
-				&algoCount{maxTime: now.Add(2 * time.Second).UnixNano()},
+				&algoCount{maxTime: now.Add(time.Second).UnixNano(), count: 1},
+				&algoCount{maxTime: now.Add(2 * time.Second).UnixNano(), count: 1},
 			},
 			wantField: "latency",
 			wantFloat: 0,
@@ -78,8 +78,8 @@ func TestNumericCalculators(t *testing.T) {
 			name: "min",
 			calc: &algoMin{MetricBase: base, min: 5, maxTime: now.UnixNano(), count: 1},
 			inputs: []any{
-				&algoMin{min: 3, maxTime: now.Add(time.Second).UnixNano()},
-				&algoMin{min: 7, maxTime: now.Add(2 * time.Second).UnixNano()},
+				&algoMin{min: 3, maxTime: now.Add(time.Second).UnixNano(), count: 1},
+				&algoMin{min: 7, maxTime: now.Add(2 * time.Second).UnixNano(), count: 1},
 			},
 			wantField: "latency",
 			wantFloat: 3,
@@ -94,8 +94,8 @@ func TestNumericCalculators(t *testing.T) {
 			name: "max",
 			calc: &algoMax{MetricBase: base, max: 5, maxTime: now.UnixNano(), count: 1},
 			inputs: []any{
-				&algoMax{max: 9, maxTime: now.Add(time.Second).UnixNano()},
-				&algoMax{max: 7, maxTime: now.Add(2 * time.Second).UnixNano()},
+				&algoMax{max: 9, maxTime: now.Add(time.Second).UnixNano(), count: 1},
+				&algoMax{max: 7, maxTime: now.Add(2 * time.Second).UnixNano(), count: 1},
 			},
 			wantField: "latency",
 			wantFloat: 9,
@@ -153,15 +153,19 @@ func TestHistogramAndStdevCalculators(t *testing.T) {
 		MetricBase: MetricBase{
 			pt: point.NewPoint("request", point.KVs{}.Add("duration", 2.0).AddTag("le", "10"), point.DefaultMetricOptions()...).PBPoint(),
 		},
-		val:     2,
-		maxTime: now.UnixNano(),
+		val:      2,
+		leBucket: map[string]float64{"10": 2},
+		count:    1,
+		maxTime:  now.UnixNano(),
 	})
 	hist.Add(&algoHistogram{
 		MetricBase: MetricBase{
 			pt: point.NewPoint("request", point.KVs{}.Add("duration", 3.0).AddTag("le", "10"), point.DefaultMetricOptions()...).PBPoint(),
 		},
-		val:     3,
-		maxTime: now.Add(time.Second).UnixNano(),
+		val:      3,
+		leBucket: map[string]float64{"10": 3},
+		count:    1,
+		maxTime:  now.Add(time.Second).UnixNano(),
 	})
 	hist.Add("ignored")
 	hist.doHash(1)
@@ -241,7 +245,7 @@ func TestNewCalculatorsCoversSupportedMethods(t *testing.T) {
 	}
 
 	calcs := newCalculators(batch)
-	require.Len(t, calcs, 10)
+	require.Len(t, calcs, 11)
 	seen := map[string]bool{}
 	for _, calc := range calcs {
 		seen[calc.Base().key] = true
@@ -253,21 +257,20 @@ func TestNewCalculatorsCoversSupportedMethods(t *testing.T) {
 	}
 	assert.True(t, seen["sum_latency"])
 	assert.True(t, seen["distinct_user"])
+	assert.True(t, seen["expo_latency"])
 }
 
 func TestQuantileResetAndBounds(t *testing.T) {
-	q := &algoQuantiles{
-		MetricBase: MetricBase{key: "latency", name: "request"},
-		all:        []float64{10, 20, 30},
-		count:      3,
-		quantiles:  []float64{0, 1},
+	q := newAlgoQuantiles(MetricBase{key: "latency", name: "request"}, 0, &QuantileOptions{Percentiles: []float64{0, 1}})
+	for _, v := range []float64{10, 20, 30} {
+		q.addValue(v)
 	}
 	assert.Equal(t, 0.0, (&algoQuantiles{}).GetPercentile(50))
 	assert.Equal(t, 10.0, q.GetPercentile(0))
 	assert.Equal(t, 30.0, q.GetPercentile(100))
 	q.Reset()
-	assert.Empty(t, q.all)
-	assert.Zero(t, q.count)
+	assert.True(t, q.sketch.pos.empty())
+	assert.Zero(t, q.count())
 }
 
 func TestAlgoMethodString(t *testing.T) {
@@ -2,32 +2,29 @@ package aggregate
 
 import (
 	"fmt"
-	"sort"
+	"strings"
 
 	"github.com/GuanceCloud/cliutils"
 	"github.com/GuanceCloud/cliutils/point"
 	"github.com/cespare/xxhash/v2"
 )
 
-const quantileSampleLimit = 8192
+// quantileSketchSuffix is the field suffix of serialized sketch, the point
+// with field x_sketch can be merged by quantiles on x.
+const quantileSketchSuffix = "_sketch"
 
 type algoQuantiles struct {
 	maxTime int64
 	MetricBase
-	all       []float64
-	count     int64
-	quantiles []float64
+	sketch     *ddSketch
+	quantiles  []float64
+	emitSketch bool
 }
 
 func (a *algoQuantiles) Add(x any) {
 	if inst, ok := x.(*algoQuantiles); ok {
-		before := a.count
-		for _, v := range inst.all {
-			a.addValue(v)
-		}
-		if missing := inst.count - (a.count - before); missing > 0 {
-			a.count += missing
-		}
+		a.ensureSketch()
+		a.sketch.merge(inst.sketch)
 
 		if inst.maxTime > a.maxTime {
 			a.maxTime = inst.maxTime
@@ -35,68 +32,67 @@ func (a *algoQuantiles) Add(x any) {
 	}
 }
 
-func newAlgoQuantiles(mb MetricBase, maxTime int64, value float64) *algoQuantiles {
+func newAlgoQuantiles(mb MetricBase, maxTime int64, opts *QuantileOptions) *algoQuantiles {
 	calc := &algoQuantiles{
 		MetricBase: mb,
 		maxTime:    maxTime,
 	}
-	calc.addValue(value)
+
+	if opts != nil {
+		calc.quantiles = opts.Percentiles
+		calc.emitSketch = opts.EmitSketch
+		calc.sketch = newDDSketch(opts.RelativeAccuracy, int(opts.MaxBins))
+	} else {
+		calc.sketch = newDDSketch(0, 0)
+	}
+
 	return calc
 }
 
-func (a *algoQuantiles) addValue(v float64) {
-	a.count++
-	if len(a.all) < quantileSampleLimit {
-		a.all = append(a.all, v)
-		return
+func (a *algoQuantiles) ensureSketch() {
+	if a.sketch == nil {
+		a.sketch = newDDSketch(0, 0)
 	}
+}
 
-	j := int(HashCombine(Seed1, uint64(a.count)) % uint64(a.count))
-	if j < quantileSampleLimit {
-		a.all[j] = v
-	}
+func (a *algoQuantiles) addValue(v float64) {
+	a.ensureSketch()
+	a.sketch.add(v)
 }
 
-// GetPercentile 是一个通用方法，用于获取第 p 个百分位数 (0-100).
-func (a *algoQuantiles) GetPercentile(p float64) float64 {
-	if n := len(a.all); n == 0 {
+func (a *algoQuantiles) count() int64 {
+	if a.sketch == nil {
 		return 0
 	}
-
-	sort.Float64s(a.all)
-	return percentileFromSorted(a.all, p)
+	return int64(a.sketch.count)
 }
 
-func percentileFromSorted(sorted []float64, p float64) float64 {
-	n := len(sorted)
-	if n == 0 {
+// GetPercentile 是一个通用方法，用于获取第 p 个百分位数 (0-100).
+func (a *algoQuantiles) GetPercentile(p float64) float64 {
+	if a.sketch == nil {
 		return 0
 	}
 
-	// 2. 将百分比转换为 0-1 之间的比例
-	fraction := p / 100.0
-
-	// 3. 计算索引位置
-	pos := fraction * float64(n-1)
-	index := int(pos)
-	rest := pos - float64(index)
-
-	// 4. 边界处理与线性插值水电费
-	if index >= n-1 {
-		return sorted[n-1]
-	}
-	return sorted[index] + rest*(sorted[index+1]-sorted[index])
+	return a.sketch.quantile(p / 100.0)
 }
 
 func (a *algoQuantiles) Aggr() ([]*point.Point, error) {
 	var kvs point.KVs
 
-	kvs = kvs.Add(a.key+"_count", a.count)
-	sort.Float64s(a.all)
+	kvs = kvs.Add(a.key+"_count", a.count())
 	for _, quantile := range a.quantiles {
 		key := fmt.Sprintf("%s_P%.0f", a.key, quantile*100) // %.0f: float to int.
-		kvs = kvs.Add(key, percentileFromSorted(a.all, quantile*100))
+		kvs = kvs.Add(key, a.GetPercentile(quantile*100))
+	}
+
+	if a.emitSketch && a.sketch != nil {
+		raw, err := a.sketch.toProto().Marshal()
+		if err != nil {
+			return nil, err
+		}
+		kvs = kvs.Add(a.key+quantileSketchSuffix, raw)
 	}
+
 	for _, kv := range a.aggrTags {
 		// NOTE: if same-name tag key exist, apply the last one.
 		kvs = kvs.SetTag(kv[0], kv[1])
@@ -109,8 +105,9 @@ func (a *algoQuantiles) Aggr() ([]*point.Point, error) {
 
 func (a *algoQuantiles) Reset() {
 	a.maxTime = 0
-	a.all = nil
-	a.count = 0
+	if a.sketch != nil {
+		a.sketch.reset()
+	}
 }
 
 func (a *algoQuantiles) Base() *MetricBase {
@@ -125,8 +122,31 @@ func (a *algoQuantiles) doHash(h1 uint64) {
 	a.MetricBase.hash = HashCombine(h, xxhash.Sum64(cliutils.ToUnsafeBytes(a.name)))
 }
 
-func (a *algoQuantiles) addOpts(opts *AggregationAlgo_QuantileOpts) {
-	if opts.QuantileOpts != nil && opts.QuantileOpts.Percentiles != nil {
-		a.quantiles = opts.QuantileOpts.Percentiles
+// quantileSketchBaseOf return x if key is the sketch field x_sketch within pt.
+func quantileSketchBaseOf(pt *point.Point, key string) string {
+	base := strings.TrimSuffix(key, quantileSketchSuffix)
+	if base == key || base == "" {
+		return ""
+	}
+
+	if _, ok := pt.Get(key).([]byte); !ok {
+		return ""
 	}
+
+	return base
+}
+
+// quantileSketchFromPoint parse the sketch of base within pt.
+func quantileSketchFromPoint(pt *point.Point, base string, maxBins int) (*ddSketch, bool) {
+	raw, ok := pt.Get(base + quantileSketchSuffix).([]byte)
+	if !ok {
+		return nil, false
+	}
+
+	var pb QuantileSketch
+	if err := pb.Unmarshal(raw); err != nil || pb.RelativeAccuracy <= 0 || pb.RelativeAccuracy >= 1 {
+		return nil, false
+	}
+
+	return ddSketchFromProto(&pb, maxBins), true
 }
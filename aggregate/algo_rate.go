@@ -0,0 +1,319 @@
+package aggregate
+
+import (
+	"sort"
+	"time"
+
+	"github.com/GuanceCloud/cliutils"
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/cespare/xxhash/v2"
+)
+
+// Counter-aware methods rate/increase/irate on cumulative counters. Points
+// of different series(by all tags of the point) may fall into the same
+// calculator(by group_by), so samples are tracked per series, results of each
+// series are summed on output. Like Prometheus:
+//
+//   - increase: last - first of the series within window, counter resets(value
+//     drops) are compensated, and extrapolated to window boundaries.
+//   - rate: increase per second on the window.
+//   - irate: per second increase of the last 2 samples of the series.
+//
+// Series with less than 2 samples within window got no result.
+//
+// Only the first, the last and the one before last samples are kept for each
+// series, with count of samples and reset compensation accumulated between
+// them, so memory is bounded whatever the sample rate. Samples out of order
+// are exact if they fall after the one before last(or before the first),
+// samples further behind are only counted. Series merged from calculators
+// of disjoint time ranges(such as panes) are exact.
+
+var _ Calculator = &algoRate{}
+
+type counterSample struct {
+	time  int64 // unix nanoseconds
+	value float64
+}
+
+// counterReset return compensation of counter reset between adjacent samples a and b.
+func counterReset(a, b counterSample) float64 {
+	if b.value < a.value {
+		return a.value
+	}
+	return 0
+}
+
+// counterSeries is the compacted samples of a series within window.
+type counterSeries struct {
+	// prev is the sample before last, all the same if n is 1.
+	first, prev, last counterSample
+
+	// n is count of samples.
+	n int64
+
+	// resets is the compensation of counter resets between first and last.
+	resets float64
+}
+
+func (cs *counterSeries) add(s counterSample) {
+	switch {
+	case cs.n == 0:
+		cs.first, cs.prev, cs.last, cs.n = s, s, s, 1
+		return
+
+	case s.time > cs.last.time:
+		cs.resets += counterReset(cs.last, s)
+		cs.prev, cs.last = cs.last, s
+
+	case s.time == cs.last.time: // duplicated sample
+		if cs.n == 1 {
+			cs.first, cs.prev, cs.last = s, s, s
+		} else {
+			cs.resets += counterReset(cs.prev, s) - counterReset(cs.prev, cs.last)
+			cs.last = s
+		}
+		return
+
+	case s.time > cs.prev.time: // between prev and last
+		cs.resets += counterReset(cs.prev, s) + counterReset(s, cs.last) - counterReset(cs.prev, cs.last)
+		cs.prev = s
+
+	case s.time < cs.first.time:
+		cs.resets += counterReset(s, cs.first)
+		if cs.n == 1 {
+			cs.prev = s
+		}
+		cs.first = s
+
+	case s.time == cs.first.time: // duplicated sample
+		if cs.first == cs.prev {
+			cs.resets += counterReset(s, cs.last) - counterReset(cs.prev, cs.last)
+			cs.prev = s
+		}
+		cs.first = s
+		return
+
+	default: // between first and prev, neighbors unknown
+	}
+
+	cs.n++
+}
+
+func (cs *counterSeries) merge(x *counterSeries) {
+	switch {
+	case x.n == 0:
+	case cs.n == 0:
+		*cs = *x
+	case x.n == 1:
+		cs.add(x.last)
+	case cs.n == 1:
+		s := cs.last
+		*cs = *x
+		cs.add(s)
+
+	case x.first.time > cs.last.time: // x after cs
+		cs.resets += counterReset(cs.last, x.first) + x.resets
+		cs.prev, cs.last = x.prev, x.last
+		cs.n += x.n
+
+	case x.last.time < cs.first.time: // x before cs
+		cs.resets += counterReset(x.last, cs.first) + x.resets
+		cs.first = x.first
+		cs.n += x.n
+
+	default: // interleaved, only samples kept by x merged
+		n := cs.n + x.n
+		cs.add(x.first)
+		cs.add(x.prev)
+		cs.add(x.last)
+		cs.n = n
+	}
+}
+
+type algoRate struct {
+	MetricBase
+	method AlgoMethod
+
+	// series hash -> compacted samples
+	series map[uint64]*counterSeries
+
+	maxTime, count int64
+}
+
+func newAlgoRate(mb MetricBase, method AlgoMethod, series uint64, t int64, v float64) *algoRate {
+	cs := &counterSeries{}
+	cs.add(counterSample{time: t, value: v})
+
+	return &algoRate{
+		MetricBase: mb,
+		method:     method,
+		series:     map[uint64]*counterSeries{series: cs},
+		maxTime:    t,
+		count:      1,
+	}
+}
+
+// seriesHashField is the field carried on points forked for rate/increase/
+// irate, holding seriesHash of the source point. Forked points keep only tags
+// of group_by, so counters of different series within the group are told
+// apart by it.
+const seriesHashField = "aggr_series_hash"
+
+// seriesHash identify the series of pt by all its tags.
+func seriesHash(pt *point.Point) uint64 {
+	tags := pt.Tags()
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Key < tags[j].Key })
+
+	h := xxhash.Sum64(cliutils.ToUnsafeBytes(pt.Name()))
+	for _, kv := range tags {
+		h = HashCombine(h, xxhash.Sum64(cliutils.ToUnsafeBytes(kv.Key)))
+		h = HashCombine(h, xxhash.Sum64(cliutils.ToUnsafeBytes(kv.GetS())))
+	}
+	return h
+}
+
+// seriesOf return the series hash carried on forked point pt, or hash of its
+// own tags if not carried.
+func seriesOf(pt *point.Point) uint64 {
+	if h, ok := pt.Get(seriesHashField).(uint64); ok {
+		return h
+	}
+	return seriesHash(pt)
+}
+
+func (c *algoRate) mergeSeries(h uint64, x *counterSeries) {
+	if cs, ok := c.series[h]; ok {
+		cs.merge(x)
+	} else {
+		cp := *x
+		c.series[h] = &cp
+	}
+}
+
+func (c *algoRate) Add(x any) {
+	if inst, ok := x.(*algoRate); ok {
+		if c.series == nil {
+			c.series = map[uint64]*counterSeries{}
+		}
+
+		for h, cs := range inst.series {
+			c.mergeSeries(h, cs)
+		}
+
+		c.count += inst.count
+		if inst.maxTime > c.maxTime {
+			c.maxTime = inst.maxTime
+		}
+	}
+}
+
+// increase return increase within samples compensated with counter resets.
+func (cs *counterSeries) increase() float64 {
+	return cs.last.value - cs.first.value + cs.resets
+}
+
+// extrapolatedIncrease extrapolate increase of samples to window (start, end],
+// in unix nanoseconds.
+func (cs *counterSeries) extrapolatedIncrease(start, end int64) float64 {
+	var (
+		first, last     = cs.first, cs.last
+		res             = cs.increase()
+		sampledInterval = float64(last.time - first.time)
+		avgInterval     = sampledInterval / float64(cs.n-1)
+		threshold       = avgInterval * 1.1
+		toStart         = float64(first.time - start)
+		toEnd           = float64(end - last.time)
+	)
+
+	if toStart < 0 {
+		toStart = 0
+	}
+	if toEnd < 0 {
+		toEnd = 0
+	}
+
+	if toStart >= threshold {
+		toStart = avgInterval / 2
+	}
+
+	// counter should not go below zero
+	if res > 0 && first.value >= 0 {
+		if toZero := sampledInterval * (first.value / res); toZero < toStart {
+			toStart = toZero
+		}
+	}
+
+	if toEnd >= threshold {
+		toEnd = avgInterval / 2
+	}
+
+	return res * (sampledInterval + toStart + toEnd) / sampledInterval
+}
+
+func (cs *counterSeries) irate() float64 {
+	prev, last := cs.prev, cs.last
+
+	delta := last.value - prev.value
+	if delta < 0 { // counter reset
+		delta = last.value
+	}
+
+	return delta / time.Duration(last.time-prev.time).Seconds()
+}
+
+func (c *algoRate) Aggr() ([]*point.Point, error) {
+	var (
+		end    = c.nextWallTime * int64(time.Second)
+		start  = end - c.window
+		total  float64
+		series int
+	)
+
+	for _, cs := range c.series {
+		if cs.n < 2 {
+			continue
+		}
+
+		series++
+		switch c.method {
+		case IRATE:
+			total += cs.irate()
+		case RATE:
+			total += cs.extrapolatedIncrease(start, end) / time.Duration(c.window).Seconds()
+		default:
+			total += cs.extrapolatedIncrease(start, end)
+		}
+	}
+
+	if series == 0 {
+		return nil, nil
+	}
+
+	var kvs point.KVs
+	kvs = kvs.Add(c.key, total).
+		Add(c.key+"_count", c.count)
+
+	for _, kv := range c.aggrTags {
+		kvs = kvs.SetTag(kv[0], kv[1])
+	}
+
+	return []*point.Point{
+		point.NewPoint(c.name, kvs, point.WithTimestamp(c.maxTime)),
+	}, nil
+}
+
+func (c *algoRate) Reset() {
+	c.series = map[uint64]*counterSeries{}
+	c.maxTime = 0
+	c.count = 0
+}
+
+func (c *algoRate) Base() *MetricBase {
+	return &c.MetricBase
+}
+
+func (c *algoRate) doHash(h1 uint64) {
+	h := HashCombine(h1, xxhash.Sum64([]byte(c.method)))
+	h = HashCombine(h, xxhash.Sum64(cliutils.ToUnsafeBytes(c.key)))
+	c.MetricBase.hash = HashCombine(h, xxhash.Sum64(cliutils.ToUnsafeBytes(c.name)))
+}
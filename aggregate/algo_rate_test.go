@@ -0,0 +1,282 @@
+package aggregate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rateTestCalcs(t *testing.T) map[AlgoMethod]Calculator {
+	t.Helper()
+
+	t0 := time.Unix(1699999980, 0) // aligned to minute
+	samples := []struct {
+		host string
+		sec  int
+		v    float64
+	}{
+		{"a", 50, 15}, // out of order
+		{"a", 5, 10},
+		{"a", 20, 20},
+		{"a", 35, 5}, // counter reset
+		{"b", 10, 100},
+		{"b", 40, 130},
+		{"c", 30, 1}, // single sample, no result
+	}
+
+	var pts []*point.PBPoint
+	for _, s := range samples {
+		pts = append(pts, point.NewPoint("http",
+			point.KVs{}.Add("requests", s.v).AddTag("host", s.host).AddTag("service", "checkout"),
+			point.WithTime(t0.Add(time.Duration(s.sec)*time.Second))).PBPoint())
+	}
+
+	batch := &AggregationBatch{
+		RoutingKey: 1,
+		Points:     &point.PBPoints{Arr: pts},
+		AggregationOpts: map[string]*AggregationAlgo{
+			"rate":     {Method: string(RATE), SourceField: "requests", Window: int64(time.Minute)},
+			"increase": {Method: string(INCREASE), SourceField: "requests", Window: int64(time.Minute)},
+			"irate":    {Method: string(IRATE), SourceField: "requests", Window: int64(time.Minute)},
+		},
+	}
+
+	res := map[AlgoMethod]Calculator{}
+	for _, calc := range newCalculators(batch) {
+		c := calc.(*algoRate)
+		assert.Equal(t, t0.Add(time.Minute).Unix(), c.nextWallTime)
+
+		if x, ok := res[c.method]; ok {
+			x.Add(c)
+		} else {
+			c.build()
+			res[c.method] = c
+		}
+	}
+
+	require.Len(t, res, 3)
+	return res
+}
+
+func TestAlgoRate(t *testing.T) {
+	// host a: increase 5+20(reset) extrapolated from 45s to 60s
+	// host b: increase 30 extrapolated from 30s to 60s
+	expect := map[AlgoMethod]float64{
+		INCREASE: 25*60.0/45 + 60,
+		RATE:     (25*60.0/45 + 60) / 60,
+		IRATE:    10.0/15 + 30.0/30,
+	}
+
+	for method, calc := range rateTestCalcs(t) {
+		pts, err := calc.Aggr()
+		require.NoError(t, err)
+		require.Len(t, pts, 1)
+
+		v, ok := pts[0].GetF(string(method))
+		require.True(t, ok, "%s", method)
+		assert.InDelta(t, expect[method], v, 1e-9, "%s", method)
+		assert.Equal(t, int64(7), pts[0].Get(string(method)+"_count"))
+		assert.Equal(t, "checkout", pts[0].GetTag("service"))
+	}
+}
+
+func TestAlgoRatePickPoints(t *testing.T) {
+	cfg := &AggregatorConfigure{
+		DefaultWindow: time.Minute,
+		AggregateRules: []*AggregateRule{
+			{
+				Name:     "requests",
+				Groupby:  []string{"service"},
+				Selector: &RuleSelector{Category: point.Metric.String(), MetricName: []string{"requests"}},
+				Algorithms: map[string]*AggregationAlgoConfig{
+					"increase": {Method: string(INCREASE), SourceField: "requests"},
+					"requests": {Method: string(SUM)},
+				},
+			},
+		},
+	}
+	require.NoError(t, cfg.Setup())
+
+	// counters of host a and b grouped into the same service
+	t0 := time.Unix(1699999980, 0)
+	var pts []*point.Point
+	for _, s := range []struct {
+		host string
+		sec  int
+		v    float64
+	}{{"a", 10, 1000}, {"b", 10, 5}, {"a", 50, 1010}, {"b", 50, 15}} {
+		pts = append(pts, point.NewPoint("http",
+			point.KVs{}.Add("requests", s.v).AddTag("host", s.host).AddTag("service", "checkout"),
+			point.WithTime(t0.Add(time.Duration(s.sec)*time.Second))))
+	}
+
+	calcs := map[string]Calculator{} // key -> calculator
+	for _, bs := range cfg.PickPoints(point.Metric.String(), pts) {
+		for _, b := range bs.Batchs {
+			for _, calc := range newCalculators(b) {
+				if x, ok := calcs[calc.Base().key]; ok {
+					x.Add(calc)
+				} else {
+					calc.Base().build()
+					calcs[calc.Base().key] = calc
+				}
+			}
+		}
+	}
+	require.Len(t, calcs, 2)
+
+	// each counter increased 10 within 40s, extrapolated to 60s
+	pts, err := calcs["increase"].Aggr()
+	require.NoError(t, err)
+	require.Len(t, pts, 1)
+	assert.InDelta(t, 2*10*60.0/40, pts[0].Get("increase"), 1e-9)
+	assert.Empty(t, pts[0].GetTag("host"))
+
+	// series hash carried only on points of the rate source field, not
+	// aggregated as field
+	pts, err = calcs["requests"].Aggr()
+	require.NoError(t, err)
+	require.Len(t, pts, 1)
+	assert.Equal(t, 2030.0, pts[0].Get("requests"))
+	assert.Nil(t, pts[0].Get(seriesHashField))
+}
+
+func TestAlgoRateExtrapolation(t *testing.T) {
+	end := time.Unix(1699999980, 0).Add(time.Minute)
+	at := func(sec int) int64 { return end.Add(-time.Minute + time.Duration(sec)*time.Second).UnixNano() }
+
+	// gap to window start larger than 1.1 avg interval: extrapolated by half interval
+	cs := newCounterSeries(counterSample{at(40), 10}, counterSample{at(50), 20})
+	assert.InDelta(t, 10*(10+5+10)/10.0, cs.extrapolatedIncrease(end.Add(-time.Minute).UnixNano(), end.UnixNano()), 1e-9)
+
+	// counter not extrapolated below zero
+	cs = newCounterSeries(counterSample{at(15), 1}, counterSample{at(25), 11})
+	assert.InDelta(t, 10*(10+1+5)/10.0, cs.extrapolatedIncrease(end.Add(-time.Minute).UnixNano(), end.UnixNano()), 1e-9)
+
+	// irate on counter reset
+	assert.InDelta(t, 0.5, newCounterSeries(counterSample{at(10), 100}, counterSample{at(20), 5}).irate(), 1e-9)
+}
+
+func newCounterSeries(samples ...counterSample) *counterSeries {
+	cs := &counterSeries{}
+	for _, s := range samples {
+		cs.add(s)
+	}
+	return cs
+}
+
+func TestCounterSeries(t *testing.T) {
+	sec := func(x int64) int64 { return x * int64(time.Second) }
+
+	// counter reset at 30s and 50s
+	var samples []counterSample
+	for i, v := range []float64{1, 5, 2, 8, 3, 9} {
+		samples = append(samples, counterSample{sec(int64(i) * 10), v})
+	}
+	want := newCounterSeries(samples...)
+	assert.Equal(t, int64(6), want.n)
+	assert.Equal(t, 9-1+5+8.0, want.increase())
+	assert.Equal(t, samples[4], want.prev)
+
+	t.Run("merge-disjoint", func(t *testing.T) {
+		for i := 1; i < len(samples); i++ {
+			a, b := newCounterSeries(samples[:i]...), newCounterSeries(samples[i:]...)
+
+			b.merge(a) // merge former into later
+			assert.Equal(t, want, b, "split at %d", i)
+
+			a, b = newCounterSeries(samples[:i]...), newCounterSeries(samples[i:]...)
+			a.merge(b)
+			assert.Equal(t, want, a, "split at %d", i)
+		}
+	})
+
+	t.Run("out-of-order", func(t *testing.T) {
+		// before first, or after the one before last: exact
+		cs := newCounterSeries(samples[1], samples[2], samples[3], samples[5], samples[0], samples[4])
+		assert.Equal(t, want, cs)
+
+		// far behind only counted
+		cs = newCounterSeries(samples[0], samples[2], samples[3], samples[4], samples[5], samples[1])
+		assert.Equal(t, want.n, cs.n)
+		assert.Equal(t, want.first, cs.first)
+		assert.Equal(t, want.last, cs.last)
+	})
+
+	t.Run("duplicated", func(t *testing.T) {
+		cs := newCounterSeries(samples...)
+		cs.add(counterSample{sec(50), 10})
+		assert.Equal(t, int64(6), cs.n)
+		assert.Equal(t, 10-1+5+8.0, cs.increase())
+
+		cs.add(counterSample{0, 0})
+		assert.Equal(t, int64(6), cs.n)
+		assert.Equal(t, 10-0+5+8.0, cs.increase())
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		mb := MetricBase{key: "requests", name: "http", window: int64(time.Hour), nextWallTime: 3600}
+		calc := newAlgoRate(mb, INCREASE, 1, 0, 0)
+		for i := int64(1); i < 3600; i++ {
+			calc.Add(newAlgoRate(mb, INCREASE, 1, sec(i), float64(i)))
+		}
+
+		require.Len(t, calc.series, 1)
+		assert.Equal(t, int64(3600), calc.series[1].n)
+		assert.Equal(t, 3599.0, calc.series[1].increase())
+	})
+}
+
+func TestAlgoRateEmpty(t *testing.T) {
+	mb := MetricBase{key: "requests", name: "http", window: int64(time.Minute), nextWallTime: 1700000040}
+	calc := newAlgoRate(mb, RATE, 1, 1700000000*int64(time.Second), 1)
+	calc.Add(newAlgoRate(mb, RATE, 2, 1700000010*int64(time.Second), 2))
+
+	pts, err := calc.Aggr()
+	require.NoError(t, err)
+	assert.Empty(t, pts, "series with single sample got no result")
+}
+
+func TestAlgoRateSnapshot(t *testing.T) {
+	for method, calc := range rateTestCalcs(t) {
+		m, kvs, err := calculatorState(calc)
+		require.NoError(t, err)
+		assert.Equal(t, method, m)
+
+		restored, err := calculatorFromState(m, *calc.Base(), kvs)
+		require.NoError(t, err)
+
+		want, err := calc.Aggr()
+		require.NoError(t, err)
+		got, err := restored.Aggr()
+		require.NoError(t, err)
+		assert.Equal(t, want[0].LineProto(), got[0].LineProto())
+	}
+}
+
+func TestAlgoRateRestoreAllSamples(t *testing.T) {
+	// snapshot with all samples of each series
+	kvs := point.KVs{}.
+		Add("count", int64(3)).
+		Add("max_time", int64(30)).
+		Add("series", point.MustNewUintArray(uint64(1), 1, 1)).
+		Add("times", point.MustNewIntArray(int64(10), 20, 30)).
+		Add("values", point.MustNewFloatArray(5.0, 1, 3))
+
+	calc, err := calculatorFromState(INCREASE, MetricBase{key: "requests", name: "http"}, kvs)
+	require.NoError(t, err)
+
+	cs := calc.(*algoRate).series[1]
+	assert.Equal(t, int64(3), cs.n)
+	assert.Equal(t, 3-5+5.0, cs.increase())
+	assert.Equal(t, counterSample{20, 1}, cs.prev)
+}
+
+func TestRateConfig(t *testing.T) {
+	for _, m := range []string{"rate", "INCREASE", " irate "} {
+		assert.NoError(t, validateAggregationAlgo("requests", &AggregationAlgo{Method: m}))
+	}
+}
@@ -66,6 +66,15 @@ func formatHistogramBuckets(leBucket map[string]float64) string {
 	return "{" + strings.Join(parts, ", ") + "}"
 }
 
+func formatExpoBuckets(b *expoBuckets) string {
+	parts := make([]string, 0, len(b.counts))
+	for _, n := range b.counts {
+		parts = append(parts, fmt.Sprintf("%d", n))
+	}
+
+	return fmt.Sprintf("%d:[%s]", b.offset, strings.Join(parts, ", "))
+}
+
 func formatDistinctValues(values map[uint64]struct{}) string {
 	if len(values) == 0 {
 		return "[]"
@@ -142,16 +151,30 @@ func (c *algoHistogram) ToString() string {
 
 func (a *algoQuantiles) ToString() string {
 	return fmt.Sprintf(
-		"algoQuantiles{count=%d sample_count=%d max_time=%d quantiles=%s all=%s %s}",
-		a.count,
-		len(a.all),
+		"algoQuantiles{count=%d max_time=%d quantiles=%s emit_sketch=%t sketch=%s %s}",
+		a.count(),
 		a.maxTime,
 		formatFloat64Slice(a.quantiles),
-		formatFloat64Slice(a.all),
+		a.emitSketch,
+		formatDDSketch(a.sketch),
 		formatMetricBaseForCalc(&a.MetricBase),
 	)
 }
 
+func (c *algoExpoHistogram) ToString() string {
+	return fmt.Sprintf(
+		"algoExpoHistogram{count=%d sum=%g scale=%d zero_count=%d pos=%s neg=%s max_time=%d %s}",
+		c.count,
+		c.sum,
+		c.scale,
+		c.zeroCount,
+		formatExpoBuckets(&c.pos),
+		formatExpoBuckets(&c.neg),
+		c.maxTime,
+		formatMetricBaseForCalc(&c.MetricBase),
+	)
+}
+
 func (c *algoStdev) ToString() string {
 	return fmt.Sprintf(
 		"algoStdev{count=%d mean=%g m2=%g max_time=%d %s}",
@@ -163,6 +186,43 @@ func (c *algoStdev) ToString() string {
 	)
 }
 
+func (c *algoTopK) ToString() string {
+	items := make([]string, 0, c.k)
+	for i, x := range c.sketch.sorted() {
+		if i >= c.k {
+			break
+		}
+		items = append(items, fmt.Sprintf("%s:%g±%g", x.item, x.weight, x.err))
+	}
+
+	return fmt.Sprintf(
+		"algoTopK{k=%d capacity=%d total=%g max_time=%d top=[%s] %s}",
+		c.k,
+		c.sketch.capacity,
+		c.sketch.total,
+		c.maxTime,
+		strings.Join(items, ", "),
+		formatMetricBaseForCalc(&c.MetricBase),
+	)
+}
+
+func (c *algoRate) ToString() string {
+	var samples int64
+	for _, cs := range c.series {
+		samples += cs.n
+	}
+
+	return fmt.Sprintf(
+		"algoRate{method=%s series=%d samples=%d count=%d max_time=%d %s}",
+		c.method,
+		len(c.series),
+		samples,
+		c.count,
+		c.maxTime,
+		formatMetricBaseForCalc(&c.MetricBase),
+	)
+}
+
 func (c *algoCountDistinct) ToString() string {
 	return fmt.Sprintf(
 		"algoCountDistinct{count=%d sketch=%t max_time=%d distinct_values=%s %s}",
@@ -176,8 +236,9 @@ func (c *algoCountDistinct) ToString() string {
 
 func (a *algoCountFirst) ToString() string {
 	return fmt.Sprintf(
-		"algoCountFirst{first=%g first_time=%d count=%d %s}",
+		"algoCountFirst{first=%g raw=%v first_time=%d count=%d %s}",
 		a.first,
+		a.raw,
 		a.firstTime,
 		a.count,
 		formatMetricBaseForCalc(&a.MetricBase),
@@ -186,10 +247,55 @@ func (a *algoCountFirst) ToString() string {
 
 func (a *algoCountLast) ToString() string {
 	return fmt.Sprintf(
-		"algoCountLast{last=%g last_time=%d count=%d %s}",
+		"algoCountLast{last=%g raw=%v last_time=%d count=%d %s}",
 		a.last,
+		a.raw,
 		a.lastTime,
 		a.count,
 		formatMetricBaseForCalc(&a.MetricBase),
 	)
 }
+
+func formatTopValues(tv *topValues) string {
+	arr := tv.sorted()
+	if len(arr) == 0 {
+		return "[]"
+	}
+
+	items := make([]string, 0, len(arr))
+	for _, x := range arr {
+		items = append(items, fmt.Sprintf("%v:%d", x.val, x.count))
+	}
+
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+func (c *algoMode) ToString() string {
+	return fmt.Sprintf(
+		"algoMode{total=%d max_time=%d values=%s %s}",
+		c.values.total,
+		c.maxTime,
+		formatTopValues(&c.values),
+		formatMetricBaseForCalc(&c.MetricBase),
+	)
+}
+
+func (c *algoDistinctValues) ToString() string {
+	return fmt.Sprintf(
+		"algoDistinctValues{total=%d max_time=%d k=%d values=%s %s}",
+		c.values.total,
+		c.maxTime,
+		c.k,
+		formatTopValues(&c.values),
+		formatMetricBaseForCalc(&c.MetricBase),
+	)
+}
+
+func formatDDSketch(s *ddSketch) string {
+	if s == nil {
+		return "<nil>"
+	}
+
+	return fmt.Sprintf("{alpha=%g max_bins=%d zero_count=%d pos_bins=%d neg_bins=%d sum=%g min=%g max=%g}",
+		s.alpha, s.maxBins, s.zeroCount, len(s.pos.counts), len(s.neg.counts), s.sum, s.min, s.max)
+}
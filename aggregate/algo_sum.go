@@ -17,7 +17,7 @@ var _ Calculator = &algoSum{}
 
 func (c *algoSum) Add(x any) {
 	if inst, ok := x.(*algoSum); ok {
-		c.count++
+		c.count += inst.count
 		c.delta += inst.delta
 
 		if inst.maxTime > c.maxTime {
@@ -0,0 +1,330 @@
+package aggregate
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/GuanceCloud/cliutils"
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/cespare/xxhash/v2"
+)
+
+const (
+	// topkSketchSuffix is the field suffix of serialized topk sketch, the point
+	// with field x_topk can be merged by topk on x.
+	topkSketchSuffix = "_topk"
+
+	topkDefaultK           = 10
+	topkMinCapacity        = 100
+	topkCapacityMultiplier = 10
+
+	// fields and tag of topk output.
+	topkWeightSuffix = "_weight"
+	topkErrorSuffix  = "_error"
+	topkRankSuffix   = "_rank"
+)
+
+type topkCounter struct {
+	weight,
+	err float64
+}
+
+// topkSketch is a weighted Space-Saving sketch: when all counters used, the
+// lightest counter replaced by the new item, which inherits its weight as
+// error. So weight of each item over-estimated by at most err, and any item
+// heavier than total/capacity is kept.
+type topkSketch struct {
+	capacity int
+	total    float64
+	counters map[string]*topkCounter
+}
+
+func newTopKSketch(capacity int) *topkSketch {
+	return &topkSketch{capacity: capacity, counters: map[string]*topkCounter{}}
+}
+
+func topkCapacity(opts *TopKOptions) (k, capacity int) {
+	k, capacity = int(opts.GetK()), int(opts.GetCapacity())
+	if k <= 0 {
+		k = topkDefaultK
+	}
+
+	if capacity <= 0 {
+		capacity = k * topkCapacityMultiplier
+		if capacity < topkMinCapacity {
+			capacity = topkMinCapacity
+		}
+	}
+
+	return k, capacity
+}
+
+// add item with weight w, err is the weight over-estimated already(from
+// another sketch).
+func (s *topkSketch) add(item string, w, err float64) {
+	s.total += w
+
+	if c, ok := s.counters[item]; ok {
+		c.weight += w
+		c.err += err
+		return
+	}
+
+	if len(s.counters) < s.capacity {
+		s.counters[item] = &topkCounter{weight: w, err: err}
+		return
+	}
+
+	var (
+		minItem string
+		minC    *topkCounter
+	)
+
+	for k, c := range s.counters {
+		if minC == nil || c.weight < minC.weight || (c.weight == minC.weight && k < minItem) {
+			minItem, minC = k, c
+		}
+	}
+
+	delete(s.counters, minItem)
+	s.counters[item] = &topkCounter{weight: minC.weight + w, err: minC.weight + err}
+}
+
+// merge other into s as mergeable Space-Saving: an item missing from a full
+// sketch may have been evicted with weight up to its lightest counter, so the
+// lightest weight of that sketch added to both weight and error of the item.
+// The heaviest counters within capacity kept.
+func (s *topkSketch) merge(other *topkSketch) {
+	if other == nil {
+		return
+	}
+
+	var (
+		sMin     = s.minWeight()
+		otherMin = other.minWeight()
+		merged   = make(map[string]*topkCounter, len(s.counters)+len(other.counters))
+	)
+
+	for k, c := range s.counters {
+		x := &topkCounter{weight: c.weight + otherMin, err: c.err + otherMin}
+		if o, ok := other.counters[k]; ok {
+			x.weight, x.err = c.weight+o.weight, c.err+o.err
+		}
+		merged[k] = x
+	}
+
+	for k, o := range other.counters {
+		if _, ok := merged[k]; !ok {
+			merged[k] = &topkCounter{weight: o.weight + sMin, err: o.err + sMin}
+		}
+	}
+
+	s.total += other.total
+	s.counters = merged
+
+	if len(s.counters) > s.capacity {
+		for _, x := range s.sorted()[s.capacity:] {
+			delete(s.counters, x.item)
+		}
+	}
+}
+
+// minWeight return the lightest weight if all counters used, else 0: items
+// not counted are never evicted.
+func (s *topkSketch) minWeight() float64 {
+	if len(s.counters) < s.capacity {
+		return 0
+	}
+
+	var res float64
+	first := true
+	for _, c := range s.counters {
+		if first || c.weight < res {
+			res, first = c.weight, false
+		}
+	}
+	return res
+}
+
+type topkItem struct {
+	item string
+	topkCounter
+}
+
+// sorted return items by weight desc.
+func (s *topkSketch) sorted() []*topkItem {
+	res := make([]*topkItem, 0, len(s.counters))
+	for k, c := range s.counters {
+		res = append(res, &topkItem{item: k, topkCounter: *c})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].weight != res[j].weight {
+			return res[i].weight > res[j].weight
+		}
+		return res[i].item < res[j].item
+	})
+
+	return res
+}
+
+func (s *topkSketch) toProto() *TopKSketch {
+	pb := &TopKSketch{Capacity: int32(s.capacity), Total: s.total}
+	for _, x := range s.sorted() {
+		pb.Counters = append(pb.Counters, &TopKCounter{Item: x.item, Weight: x.weight, Error: x.err})
+	}
+	return pb
+}
+
+// topkSketchFromProto rebuild sketch from pb, counters beyond capacity evicted.
+// Capacity not positive(such as a corrupted pb) taken as topkMinCapacity.
+func topkSketchFromProto(pb *TopKSketch, capacity int) *topkSketch {
+	if capacity <= 0 {
+		capacity = topkMinCapacity
+	}
+
+	s := newTopKSketch(capacity)
+	for _, c := range pb.Counters {
+		s.add(c.Item, c.Weight, c.Error)
+	}
+	s.total = pb.Total
+	return s
+}
+
+// topkSketchBaseOf return x if key is the sketch field x_topk within pt.
+func topkSketchBaseOf(pt *point.Point, key string) string {
+	base := strings.TrimSuffix(key, topkSketchSuffix)
+	if base == key || base == "" {
+		return ""
+	}
+
+	if _, ok := pt.Get(key).([]byte); !ok {
+		return ""
+	}
+
+	return base
+}
+
+// topkSketchFromPoint parse the sketch of base within pt.
+func topkSketchFromPoint(pt *point.Point, base string, capacity int) (*topkSketch, bool) {
+	raw, ok := pt.Get(base + topkSketchSuffix).([]byte)
+	if !ok {
+		return nil, false
+	}
+
+	var pb TopKSketch
+	if err := pb.Unmarshal(raw); err != nil {
+		return nil, false
+	}
+
+	return topkSketchFromProto(&pb, capacity), true
+}
+
+// topkWeight get weight of the item on pt, false if the weight field not
+// found or not numeric.
+func topkWeight(pt *point.Point, opts *TopKOptions) (float64, bool) {
+	field := opts.GetWeightField()
+	if field == "" {
+		return 1, true
+	}
+
+	switch x := pt.Get(field).(type) {
+	case float64:
+		return x, true
+	case int64:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	default:
+		return 0, false
+	}
+}
+
+type algoTopK struct {
+	MetricBase
+	maxTime    int64
+	k          int
+	emitSketch bool
+	sketch     *topkSketch
+}
+
+var _ Calculator = &algoTopK{}
+
+func newAlgoTopK(mb MetricBase, maxTime int64, opts *TopKOptions) *algoTopK {
+	k, capacity := topkCapacity(opts)
+
+	return &algoTopK{
+		MetricBase: mb,
+		maxTime:    maxTime,
+		k:          k,
+		emitSketch: opts.GetEmitSketch(),
+		sketch:     newTopKSketch(capacity),
+	}
+}
+
+func (c *algoTopK) Add(x any) {
+	if inst, ok := x.(*algoTopK); ok {
+		c.sketch.merge(inst.sketch)
+		if inst.maxTime > c.maxTime {
+			c.maxTime = inst.maxTime
+		}
+	}
+}
+
+// Aggr output a point for each of the top k items, the item set as tag <key>,
+// with fields <key>_weight, <key>_error and <key>_rank(from 1).
+func (c *algoTopK) Aggr() ([]*point.Point, error) {
+	var res []*point.Point
+
+	arr := c.sketch.sorted()
+	if len(arr) > c.k {
+		arr = arr[:c.k]
+	}
+
+	for i, x := range arr {
+		var kvs point.KVs
+
+		kvs = kvs.Add(c.key+topkWeightSuffix, x.weight).
+			Add(c.key+topkErrorSuffix, x.err).
+			Add(c.key+topkRankSuffix, int64(i+1))
+
+		for _, kv := range c.aggrTags {
+			kvs = kvs.SetTag(kv[0], kv[1])
+		}
+		kvs = kvs.SetTag(c.key, x.item)
+
+		res = append(res, point.NewPoint(c.name, kvs, point.WithTimestamp(c.maxTime)))
+	}
+
+	if c.emitSketch && len(c.sketch.counters) > 0 {
+		raw, err := c.sketch.toProto().Marshal()
+		if err != nil {
+			return nil, err
+		}
+
+		var kvs point.KVs
+		kvs = kvs.Add(c.key+topkSketchSuffix, raw)
+		for _, kv := range c.aggrTags {
+			kvs = kvs.SetTag(kv[0], kv[1])
+		}
+
+		res = append(res, point.NewPoint(c.name, kvs, point.WithTimestamp(c.maxTime)))
+	}
+
+	return res, nil
+}
+
+func (c *algoTopK) Reset() {
+	c.maxTime = 0
+	c.sketch = newTopKSketch(c.sketch.capacity)
+}
+
+func (c *algoTopK) Base() *MetricBase {
+	return &c.MetricBase
+}
+
+func (c *algoTopK) doHash(h1 uint64) {
+	h := HashCombine(h1, xxhash.Sum64([]byte("topk")))
+	h = HashCombine(h, xxhash.Sum64(cliutils.ToUnsafeBytes(c.key)))
+	c.MetricBase.hash = HashCombine(h, xxhash.Sum64(cliutils.ToUnsafeBytes(c.name)))
+}
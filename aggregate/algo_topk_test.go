@@ -0,0 +1,317 @@
+package aggregate
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopKSketch(t *testing.T) {
+	t.Run("exact", func(t *testing.T) {
+		s := newTopKSketch(10)
+		for _, x := range []string{"a", "b", "a", "c", "a", "b"} {
+			s.add(x, 1, 0)
+		}
+
+		arr := s.sorted()
+		require.Len(t, arr, 3)
+		assert.Equal(t, "a", arr[0].item)
+		assert.Equal(t, 3.0, arr[0].weight)
+		assert.Zero(t, arr[0].err)
+		assert.Equal(t, "b", arr[1].item)
+		assert.Equal(t, 6.0, s.total)
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		s := newTopKSketch(20)
+		for i := 0; i < 1000; i++ {
+			s.add("hot", 1, 0)
+			s.add(fmt.Sprintf("cold-%d", i), 1, 0)
+		}
+
+		assert.Len(t, s.counters, 20)
+		assert.Equal(t, 2000.0, s.total)
+
+		arr := s.sorted()
+		assert.Equal(t, "hot", arr[0].item)
+		assert.GreaterOrEqual(t, arr[0].weight, 1000.0)
+		assert.LessOrEqual(t, arr[0].weight-arr[0].err, 1000.0)
+
+		for _, x := range arr[1:] { // error covers the over-estimated weight
+			assert.LessOrEqual(t, x.weight-x.err, 1.0)
+		}
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		s1, s2 := newTopKSketch(10), newTopKSketch(10)
+		s1.add("a", 5, 0)
+		s1.add("b", 1, 0)
+		s2.add("b", 7, 0)
+
+		s1.merge(topkSketchFromProto(s2.toProto(), 10))
+		arr := s1.sorted()
+		require.Len(t, arr, 2)
+		assert.Equal(t, "b", arr[0].item)
+		assert.Equal(t, 8.0, arr[0].weight)
+		assert.Equal(t, 13.0, s1.total)
+	})
+
+	t.Run("merge-full", func(t *testing.T) {
+		// both full: a missing from s2 may be evicted there with weight up
+		// to 2, so does c missing from s1 with weight up to 3
+		s1, s2 := newTopKSketch(2), newTopKSketch(2)
+		s1.add("a", 10, 0)
+		s1.add("b", 3, 0)
+		s2.add("b", 4, 0)
+		s2.add("c", 2, 0)
+
+		s1.merge(s2)
+		assert.Equal(t, 19.0, s1.total)
+
+		arr := s1.sorted()
+		require.Len(t, arr, 2)
+		assert.Equal(t, &topkItem{item: "a", topkCounter: topkCounter{weight: 12, err: 2}}, arr[0])
+		assert.Equal(t, &topkItem{item: "b", topkCounter: topkCounter{weight: 7}}, arr[1])
+
+		// receiver not full: no error added to items of s2
+		s1, s2 = newTopKSketch(10), newTopKSketch(2)
+		s1.add("a", 10, 0)
+		s2.add("b", 4, 0)
+		s2.add("c", 2, 0)
+
+		s1.merge(s2)
+		arr = s1.sorted()
+		require.Len(t, arr, 3)
+		assert.Equal(t, &topkItem{item: "a", topkCounter: topkCounter{weight: 12, err: 2}}, arr[0])
+		assert.Equal(t, &topkItem{item: "b", topkCounter: topkCounter{weight: 4}}, arr[1])
+		assert.Equal(t, &topkItem{item: "c", topkCounter: topkCounter{weight: 2}}, arr[2])
+	})
+
+	t.Run("from-proto-invalid-capacity", func(t *testing.T) {
+		pb := &TopKSketch{Total: 3, Counters: []*TopKCounter{{Item: "a", Weight: 2}, {Item: "b", Weight: 1}}}
+
+		s := topkSketchFromProto(pb, 0)
+		assert.Equal(t, topkMinCapacity, s.capacity)
+		assert.Len(t, s.sorted(), 2)
+	})
+}
+
+func topkTestRule(opts *TopKOptions, metric string) *AggregatorConfigure {
+	return &AggregatorConfigure{
+		DefaultWindow: time.Minute,
+		AggregateRules: []*AggregateRule{
+			{
+				Name:       "top-urls",
+				Selector:   &RuleSelector{Category: point.Logging.String(), MetricName: []string{metric}},
+				Groupby:    []string{"host"},
+				Algorithms: map[string]*AggregationAlgoConfig{"url": {Method: string(TOPK), TopKOpts: opts}},
+			},
+		},
+	}
+}
+
+// topkAggr run pts through rule of cfg and return the aggregated points.
+func topkAggr(t *testing.T, cfg *AggregatorConfigure, pts []*point.Point) []*point.Point {
+	t.Helper()
+
+	ar := cfg.AggregateRules[0]
+	calcs := map[uint64]Calculator{}
+	for _, b := range ar.GroupbyBatch(cfg, ar.SelectPoints(pts)) {
+		for _, calc := range newCalculators(b) {
+			if x, ok := calcs[calc.Base().hash]; ok {
+				x.Add(calc)
+			} else {
+				calc.Base().build()
+				calcs[calc.Base().hash] = calc
+			}
+		}
+	}
+
+	require.Len(t, calcs, 1)
+
+	var res []*point.Point
+	for _, calc := range calcs {
+		pts, err := calc.Aggr()
+		require.NoError(t, err)
+		res = append(res, pts...)
+	}
+	return res
+}
+
+func topkTestPoints(now time.Time, reqs map[string][]int64) (pts []*point.Point) {
+	for url, arr := range reqs {
+		for _, bytes := range arr {
+			pts = append(pts, point.NewPoint("nginx",
+				point.NewKVs(map[string]any{"url": url, "bytes": bytes, "status": 200}).AddTag("host", "web-1"),
+				point.WithTime(now)))
+		}
+	}
+	return pts
+}
+
+func TestAlgoTopK(t *testing.T) {
+	now := time.Now()
+	reqs := map[string][]int64{
+		"/a": {100, 100, 100},
+		"/b": {1000},
+		"/c": {10, 10},
+	}
+
+	t.Run("by-count", func(t *testing.T) {
+		cfg := topkTestRule(&TopKOptions{K: 2}, "url")
+		require.NoError(t, cfg.Setup())
+
+		pts := topkAggr(t, cfg, topkTestPoints(now, reqs))
+		require.Len(t, pts, 2)
+
+		assert.Equal(t, "/a", pts[0].GetTag("url"))
+		assert.Equal(t, 3.0, pts[0].Get("url_weight"))
+		assert.Equal(t, 0.0, pts[0].Get("url_error"))
+		assert.Equal(t, int64(1), pts[0].Get("url_rank"))
+		assert.Equal(t, "web-1", pts[0].GetTag("host"))
+
+		assert.Equal(t, "/c", pts[1].GetTag("url"))
+		assert.Equal(t, int64(2), pts[1].Get("url_rank"))
+	})
+
+	t.Run("by-weight", func(t *testing.T) {
+		cfg := topkTestRule(&TopKOptions{K: 2, WeightField: "bytes"}, "url")
+		require.NoError(t, cfg.Setup())
+
+		pts := topkAggr(t, cfg, topkTestPoints(now, reqs))
+		require.Len(t, pts, 2)
+
+		assert.Equal(t, "/b", pts[0].GetTag("url"))
+		assert.Equal(t, 1000.0, pts[0].Get("url_weight"))
+		assert.Equal(t, "/a", pts[1].GetTag("url"))
+		assert.Equal(t, 300.0, pts[1].Get("url_weight"))
+	})
+
+	t.Run("with-sum-on-weight", func(t *testing.T) {
+		cfg := topkTestRule(&TopKOptions{K: 2, WeightField: "bytes"}, "url")
+		ar := cfg.AggregateRules[0]
+		ar.Selector.MetricName = []string{"url", "bytes"}
+		ar.Algorithms["bytes"] = &AggregationAlgoConfig{Method: string(SUM)}
+		require.NoError(t, cfg.Setup())
+
+		calcs := map[uint64]Calculator{}
+		for _, b := range ar.GroupbyBatch(cfg, ar.SelectPoints(topkTestPoints(now, reqs))) {
+			for _, calc := range newCalculators(b) {
+				if x, ok := calcs[calc.Base().hash]; ok {
+					x.Add(calc)
+				} else {
+					calc.Base().build()
+					calcs[calc.Base().hash] = calc
+				}
+			}
+		}
+		require.Len(t, calcs, 2)
+
+		var sums, tops []*point.Point
+		for _, calc := range calcs {
+			pts, err := calc.Aggr()
+			require.NoError(t, err)
+			if _, ok := calc.(*algoTopK); ok {
+				tops = append(tops, pts...)
+			} else {
+				sums = append(sums, pts...)
+			}
+		}
+
+		// bytes summed once
+		require.Len(t, sums, 1)
+		assert.Equal(t, 1320.0, sums[0].Get("bytes"))
+		assert.Equal(t, int64(6), sums[0].Get("bytes_count"))
+
+		require.Len(t, tops, 2)
+		assert.Equal(t, "/b", tops[0].GetTag("url"))
+		assert.Equal(t, 1000.0, tops[0].Get("url_weight"))
+	})
+
+	t.Run("merge-across-agents", func(t *testing.T) {
+		agent := topkTestRule(&TopKOptions{K: 1, EmitSketch: true}, "url")
+		require.NoError(t, agent.Setup())
+
+		var partial []*point.Point
+		for _, r := range []map[string][]int64{
+			{"/a": {1, 1}, "/b": {1}},
+			{"/b": {1, 1}, "/c": {1}},
+		} {
+			pts := topkAggr(t, agent, topkTestPoints(now, r))
+			require.Len(t, pts, 2) // top 1 and the sketch
+
+			sketch := pts[1]
+			assert.Empty(t, sketch.GetTag("url"))
+			partial = append(partial, sketch)
+		}
+
+		center := topkTestRule(&TopKOptions{K: 3}, "url")
+		require.NoError(t, center.Setup())
+
+		pts := topkAggr(t, center, partial)
+		require.Len(t, pts, 3)
+		for i, x := range []struct {
+			url    string
+			weight float64
+		}{{"/b", 3}, {"/a", 2}, {"/c", 1}} {
+			assert.Equal(t, x.url, pts[i].GetTag("url"))
+			assert.Equal(t, x.weight, pts[i].Get("url_weight"))
+		}
+	})
+}
+
+func TestAlgoTopKSnapshot(t *testing.T) {
+	mb := MetricBase{key: "url", name: "nginx"}
+	calc := newAlgoTopK(mb, 1, &TopKOptions{K: 2, EmitSketch: true})
+	for _, x := range []string{"/a", "/b", "/a"} {
+		calc.sketch.add(x, 1, 0)
+	}
+
+	m, kvs, err := calculatorState(calc)
+	require.NoError(t, err)
+	assert.Equal(t, TOPK, m)
+
+	restored, err := calculatorFromState(m, mb, kvs)
+	require.NoError(t, err)
+
+	want, err := calc.Aggr()
+	require.NoError(t, err)
+	got, err := restored.Aggr()
+	require.NoError(t, err)
+
+	require.Len(t, got, len(want))
+	for i := range want {
+		assert.Equal(t, want[i].LineProto(), got[i].LineProto())
+	}
+}
+
+func TestTopKConfig(t *testing.T) {
+	for _, opts := range []*TopKOptions{
+		{K: -1},
+		{K: 20, Capacity: 10},
+	} {
+		assert.Error(t, validateAggregationAlgo("url", &AggregationAlgo{
+			Method:  string(TOPK),
+			Options: &AggregationAlgo_TopkOpts{TopkOpts: opts},
+		}))
+	}
+
+	assert.NoError(t, validateAggregationAlgo("url", &AggregationAlgo{Method: "topk"}))
+
+	var cfg AggregatorConfigure
+	require.NoError(t, cfg.UnmarshalTOML(map[string]any{
+		"aggregate_rules": []any{
+			map[string]any{
+				"name": "rule",
+				"algorithms": map[string]any{
+					"url": map[string]any{"method": "topk", "topk_opts": map[string]any{"k": 20, "weight_field": "bytes"}},
+				},
+			},
+		},
+	}))
+	assert.Equal(t, &TopKOptions{K: 20, WeightField: "bytes"}, cfg.AggregateRules[0].Algorithms["url"].TopKOpts)
+}
@@ -51,7 +51,10 @@ func TestAggregatorConfigBusinessBranches(t *testing.T) {
 		{name: "nil-algo", algorithms: map[string]*AggregationAlgoConfig{"x": nil}},
 		{name: "missing-method", algorithms: map[string]*AggregationAlgoConfig{"x": {}}},
 		{name: "unknown-method", algorithms: map[string]*AggregationAlgoConfig{"x": {Method: "unknown"}}},
-		{name: "expo", algorithms: map[string]*AggregationAlgoConfig{"x": {Method: string(EXPO_HISTOGRAM)}}},
+		{name: "expo-max-scale", algorithms: map[string]*AggregationAlgoConfig{"x": {Method: string(EXPO_HISTOGRAM), ExpoOpts: &ExpoHistogramOptions{MaxScale: 21}}}},
+		{name: "expo-max-buckets", algorithms: map[string]*AggregationAlgoConfig{"x": {Method: string(EXPO_HISTOGRAM), ExpoOpts: &ExpoHistogramOptions{MaxBuckets: 1}}}},
+		{name: "quantile-accuracy", algorithms: map[string]*AggregationAlgoConfig{"x": {Method: string(QUANTILES), QuantileOpts: &QuantileOptions{Percentiles: []float64{0.5}, RelativeAccuracy: 1}}}},
+		{name: "quantile-max-bins", algorithms: map[string]*AggregationAlgoConfig{"x": {Method: string(QUANTILES), QuantileOpts: &QuantileOptions{Percentiles: []float64{0.5}, MaxBins: 1}}}},
 		{name: "quantile-missing", algorithms: map[string]*AggregationAlgoConfig{"x": {Method: string(QUANTILES)}}},
 		{name: "quantile-out-of-range", algorithms: map[string]*AggregationAlgoConfig{"x": {Method: string(QUANTILES), QuantileOpts: &QuantileOptions{Percentiles: []float64{1.1}}}}},
 	}
@@ -155,21 +158,21 @@ func TestPickPointsAndSelectorBranches(t *testing.T) {
 		AddTag("host", "node-1"),
 		point.DefaultMetricOptions()...,
 	)
-	forked := selector.selectKVS(false, pt)
+	forked := selector.selectKVS(false, pt, nil)
 	require.Len(t, forked, 3)
 	singleSelector := &RuleSelector{Category: point.Metric.String(), MetricName: []string{"latency"}}
 	require.NoError(t, singleSelector.Setup())
 	singlePoint := point.NewPoint("request", point.KVs{}.Add("latency", 1.0), point.DefaultMetricOptions()...)
-	require.Len(t, singleSelector.selectKVS(true, singlePoint), 1)
+	require.Len(t, singleSelector.selectKVS(true, singlePoint, nil), 1)
 	assert.Nil(t, singlePoint.Get("latency"))
-	assert.Empty(t, selector.selectKVS(false, point.NewPoint("request", point.KVs{}.Add("missing", 1), point.DefaultMetricOptions()...)))
+	assert.Empty(t, selector.selectKVS(false, point.NewPoint("request", point.KVs{}.Add("missing", 1), point.DefaultMetricOptions()...), nil))
 	tagSelector := &RuleSelector{Category: point.Metric.String(), MetricName: []string{"host"}}
 	require.NoError(t, tagSelector.Setup())
-	assert.Empty(t, tagSelector.selectKVS(false, point.NewPoint("request", point.KVs{}.AddTag("host", "node-1"), point.DefaultMetricOptions()...)))
+	assert.Empty(t, tagSelector.selectKVS(false, point.NewPoint("request", point.KVs{}.AddTag("host", "node-1"), point.DefaultMetricOptions()...), nil))
 
 	unsignedSelector := &RuleSelector{Category: point.Metric.String(), MetricName: []string{"u", "d"}}
 	require.NoError(t, unsignedSelector.Setup())
-	assert.NotEmpty(t, unsignedSelector.selectKVS(false, point.NewPoint("request", point.KVs{}.Add("u", uint64(1)).Add("d", []byte("x")), point.WithPrecheck(false))))
+	assert.NotEmpty(t, unsignedSelector.selectKVS(false, point.NewPoint("request", point.KVs{}.Add("u", uint64(1)).Add("d", []byte("x")), point.WithPrecheck(false)), nil))
 }
 
 func TestBatchRequestBranches(t *testing.T) {
@@ -212,7 +215,7 @@ func TestSmallBusinessHelpersAndStrings(t *testing.T) {
 		&algoMax{MetricBase: base, max: 1, count: 1, maxTime: 1},
 		&algoMin{MetricBase: base, min: 1, count: 1, maxTime: 1},
 		&algoHistogram{MetricBase: base, count: 1, val: 1, maxTime: 1, leBucket: map[string]float64{"1": 1}},
-		&algoQuantiles{MetricBase: base, count: 1, maxTime: 1, quantiles: []float64{0.5}, all: []float64{1}},
+		newAlgoQuantiles(base, 1, &QuantileOptions{Percentiles: []float64{0.5}}),
 		newAlgoStdev(base, 1, 1),
 		newAlgoCountDistinct(base, 1, "alice"),
 		&algoCountFirst{MetricBase: base, first: 1, firstTime: 1, count: 1},
@@ -235,7 +238,9 @@ func TestSmallBusinessHelpersAndStrings(t *testing.T) {
 	for _, method := range []string{"", "method_unspecified", " SUM ", "avg", "count", "min", "max", "histogram", "merge_histogram", "expo_histogram", "stdev", "quantiles", "count_distinct", "last", "first", "Custom"} {
 		_ = NormalizeAlgoMethod(method).String()
 	}
-	q := &algoQuantiles{MetricBase: MetricBase{key: "latency", name: "request", aggrTags: [][2]string{{"service", "checkout"}}}, all: []float64{1, 2}, count: 2, quantiles: []float64{0.5}}
+	q := newAlgoQuantiles(MetricBase{key: "latency", name: "request", aggrTags: [][2]string{{"service", "checkout"}}}, 0, &QuantileOptions{Percentiles: []float64{0.5}})
+	q.addValue(1)
+	q.addValue(2)
 	qpts, err := q.Aggr()
 	require.NoError(t, err)
 	assert.Equal(t, "checkout", qpts[0].GetTag("service"))
@@ -0,0 +1,772 @@
+package aggregate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+)
+
+// Snapshot write all calculators cached in c to w, the snapshot can be
+// restored by RestoreCache after restart.
+func (c *Cache) Snapshot(w io.Writer) error {
+	cs := &CacheSnapshot{Expired: int64(c.Expired)}
+
+	// NOTE: hold the cache lock during snapshot, so windows being flushed by
+	// GetExpWidows are either all within the snapshot or none of them.
+	c.lock.Lock()
+	for exp, ws := range c.WindowsBuckets {
+		arr, err := ws.snapshot(exp)
+		if err != nil {
+			c.lock.Unlock()
+			return err
+		}
+		cs.Calculators = append(cs.Calculators, arr...)
+	}
+
+	for k, et := range c.eventTimes {
+		cs.EventTimes = append(cs.EventTimes, &EventTimeSnapshot{
+			Token:   k.token,
+			Rule:    k.rule,
+			MaxTime: et.maxTime,
+			Options: et.opts,
+			Late:    et.late,
+			Closed:  et.closed,
+		})
+
+		for end, ws := range et.buckets {
+			arr, err := ws.snapshot(end)
+			if err != nil {
+				c.lock.Unlock()
+				return err
+			}
+
+			for _, s := range arr {
+				s.EventTime = true
+			}
+			cs.Calculators = append(cs.Calculators, arr...)
+		}
+	}
+
+	for end, ws := range c.corrections {
+		arr, err := ws.snapshot(end)
+		if err != nil {
+			c.lock.Unlock()
+			return err
+		}
+
+		for _, s := range arr {
+			s.Correction = true
+		}
+		cs.Calculators = append(cs.Calculators, arr...)
+	}
+
+	for token, pbs := range c.sideOutput {
+		cs.SideOutput = append(cs.SideOutput, &SideOutputSnapshot{Token: token, Points: pbs})
+	}
+
+	for k, ps := range c.panes {
+		for end, calc := range ps.panes {
+			s, err := snapshotCalculator(calc)
+			if err != nil {
+				c.lock.Unlock()
+				return err
+			}
+
+			s.Token = k.token
+			s.Expire = end
+			s.Pane = true
+			s.LastEmit = ps.lastEmit
+			cs.Calculators = append(cs.Calculators, s)
+		}
+	}
+	c.lock.Unlock()
+
+	raw, err := cs.Marshal()
+	if err != nil {
+		return fmt.Errorf("marshal cache snapshot: %w", err)
+	}
+
+	_, err = w.Write(raw)
+	return err
+}
+
+// RestoreCache rebuild the cache from snapshot within r. Windows already
+// expired during the downtime(or closed by watermark on event-time windows)
+// are not put back to cache, their aggregated points returned to be sent
+// immediately. Pending corrections and side output of late points are put
+// back to cache.
+func RestoreCache(r io.Reader) (*Cache, []*PointsData, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read cache snapshot: %w", err)
+	}
+
+	var cs CacheSnapshot
+	if err := cs.Unmarshal(raw); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal cache snapshot: %w", err)
+	}
+
+	var (
+		c       = NewCache(time.Duration(cs.Expired))
+		expired = NewCache(time.Duration(cs.Expired))
+		now     = time.Now().Unix()
+	)
+
+	for _, s := range cs.EventTimes {
+		et := c.eventTimeWindows(s.Token, s.Rule)
+		et.opts = s.Options
+		et.maxTime = s.MaxTime
+		et.late = s.Late
+		et.closed = s.Closed
+		et.lastUpdate = now
+	}
+
+	for _, s := range cs.Calculators {
+		calc, err := restoreCalculator(s)
+		if err != nil {
+			l.Warnf("restore calculator %q on token %q: %s, ignored", s.GetKey(), s.Token, err)
+			continue
+		}
+
+		if s.Pane {
+			calc.Base().build()
+			c.addPane(s.Token, calc)
+			c.panes[paneKey{token: s.Token, hash: s.Hash}].lastEmit = s.LastEmit
+			continue
+		}
+
+		if s.Correction {
+			c.addCorrection(s.Token, calc)
+			continue
+		}
+
+		if s.EventTime {
+			et := c.eventTimeWindows(s.Token, s.Rule)
+			if s.Expire > et.watermark() {
+				et.addCal(s.Token, calc)
+			} else {
+				expired.getAndSetBucket(s.Expire, s.Token, calc)
+			}
+			continue
+		}
+
+		if s.Expire <= now {
+			expired.getAndSetBucket(s.Expire, s.Token, calc)
+		} else {
+			c.getAndSetBucket(s.Expire, s.Token, calc)
+		}
+	}
+
+	for _, s := range cs.SideOutput {
+		c.sideOutput[s.Token] = append(c.sideOutput[s.Token], s.Points...)
+	}
+
+	var windows []*Window
+	for _, ws := range expired.WindowsBuckets {
+		windows = append(windows, ws.Close()...)
+	}
+
+	windows = c.addPanes(windows)
+	windows = append(windows, c.getSlideWindows(now)...)
+
+	return c, WindowsToData(windows), nil
+}
+
+// Checkpoint write snapshot of c to file path. The snapshot written to a
+// temporary file first and then renamed, so path always holds a complete
+// snapshot.
+func (c *Cache) Checkpoint(path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	tmp := f.Name()
+	if err := c.Snapshot(f); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	return nil
+}
+
+// RestoreCacheFile restore cache from checkpoint file path, see RestoreCache.
+func RestoreCacheFile(path string) (*Cache, []*PointsData, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close() //nolint:errcheck
+
+	return RestoreCache(f)
+}
+
+// RunCheckpoint checkpoint c to file path every interval until ctx done, and
+// a final checkpoint made on exit.
+func (c *Cache) RunCheckpoint(ctx context.Context, path string, interval time.Duration) {
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := c.Checkpoint(path); err != nil {
+				l.Warnf("checkpoint cache to %q: %s", path, err)
+			}
+			return
+
+		case <-tick.C:
+			if err := c.Checkpoint(path); err != nil {
+				l.Warnf("checkpoint cache to %q: %s", path, err)
+			}
+		}
+	}
+}
+
+func (ws *Windows) snapshot(exp int64) ([]*CalculatorSnapshot, error) {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	if ws.closed {
+		return nil, nil
+	}
+
+	var res []*CalculatorSnapshot
+	for _, w := range ws.WS {
+		arr, err := w.snapshot(exp)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, arr...)
+	}
+
+	return res, nil
+}
+
+func (w *Window) snapshot(exp int64) ([]*CalculatorSnapshot, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	res := make([]*CalculatorSnapshot, 0, len(w.cache))
+	for _, calc := range w.cache {
+		s, err := snapshotCalculator(calc)
+		if err != nil {
+			return nil, err
+		}
+
+		s.Token = w.Token
+		s.Expire = exp
+		res = append(res, s)
+	}
+
+	return res, nil
+}
+
+func snapshotCalculator(calc Calculator) (*CalculatorSnapshot, error) {
+	method, kvs, err := calculatorState(calc)
+	if err != nil {
+		return nil, err
+	}
+
+	mb := calc.Base()
+
+	// NOTE: keep tags in order, same-name tags applied by order on Aggr().
+	fields := make([]*point.Field, 0, len(mb.aggrTags)+len(kvs))
+	for _, kv := range mb.aggrTags {
+		fields = append(fields, point.NewKV(kv[0], kv[1], point.WithKVTagSet(true)))
+	}
+	fields = append(fields, kvs...)
+
+	return &CalculatorSnapshot{
+		Method:       string(method),
+		Key:          mb.key,
+		Hash:         mb.hash,
+		Window:       mb.window,
+		NextWallTime: mb.nextWallTime,
+		State:        &point.PBPoint{Name: mb.name, Fields: fields},
+		Rule:         mb.rule,
+		WindowOpts:   mb.slide,
+		Group:        mb.group,
+		Expressions:  mb.exprs,
+	}, nil
+}
+
+func restoreCalculator(s *CalculatorSnapshot) (Calculator, error) {
+	if s.State == nil {
+		return nil, fmt.Errorf("missing state")
+	}
+
+	mb := MetricBase{
+		// tags within state point rebuilt into aggrTags when cached.
+		pt:           s.State,
+		key:          s.Key,
+		name:         s.State.Name,
+		hash:         s.Hash,
+		window:       s.Window,
+		nextWallTime: s.NextWallTime,
+		rule:         s.Rule,
+		slide:        s.WindowOpts,
+		group:        s.Group,
+		exprs:        s.Expressions,
+	}
+
+	var state point.KVs
+	for _, kv := range s.State.Fields {
+		if !kv.IsTag {
+			state = append(state, kv)
+		}
+	}
+
+	return calculatorFromState(NormalizeAlgoMethod(s.Method), mb, state)
+}
+
+// cloneCalculator deep copy calc through its snapshot.
+func cloneCalculator(calc Calculator) (Calculator, error) {
+	s, err := snapshotCalculator(calc)
+	if err != nil {
+		return nil, err
+	}
+
+	x, err := restoreCalculator(s)
+	if err != nil {
+		return nil, err
+	}
+
+	x.Base().build()
+	return x, nil
+}
+
+// calculatorState return the method and state of calc as fields.
+func calculatorState(calc Calculator) (AlgoMethod, point.KVs, error) {
+	var kvs point.KVs
+
+	switch c := calc.(type) {
+	case *algoSum:
+		return SUM, kvs.Add("delta", c.delta).Add("count", c.count).Add("max_time", c.maxTime), nil
+
+	case *algoAvg:
+		return AVG, kvs.Add("delta", c.delta).Add("count", c.count).Add("max_time", c.maxTime), nil
+
+	case *algoCount:
+		return COUNT, kvs.Add("count", c.count).Add("max_time", c.maxTime), nil
+
+	case *algoMin:
+		return MIN, kvs.Add("min", c.min).Add("count", c.count).Add("max_time", c.maxTime), nil
+
+	case *algoMax:
+		return MAX, kvs.Add("max", c.max).Add("count", c.count).Add("max_time", c.maxTime), nil
+
+	case *algoStdev:
+		return STDEV, kvs.Add("count", c.count).
+			Add("mean", c.mean).
+			Add("m2", c.m2).
+			Add("max_time", c.maxTime), nil
+
+	case *algoHistogram:
+		les := make([]string, 0, len(c.leBucket))
+		vals := make([]float64, 0, len(c.leBucket))
+		for le, v := range c.leBucket {
+			les = append(les, le)
+			vals = append(vals, v)
+		}
+
+		kvs = kvs.Add("count", c.count).Add("val", c.val).Add("max_time", c.maxTime)
+		if len(les) > 0 {
+			kvs = kvs.Add("le", point.MustNewStringArray(les...)).
+				Add("le_values", point.MustNewFloatArray(vals...))
+		}
+		return HISTOGRAM, kvs, nil
+
+	case *algoCountDistinct:
+		kvs = kvs.Add("max_time", c.maxTime)
+		if c.sketch != nil {
+			return COUNT_DISTINCT, kvs.Add("sketch", point.MustNewUintArray(c.sketch...)), nil
+		}
+
+		hashes := make([]uint64, 0, len(c.distinctValues))
+		for h := range c.distinctValues {
+			hashes = append(hashes, h)
+		}
+		if len(hashes) > 0 {
+			kvs = kvs.Add("hashes", point.MustNewUintArray(hashes...))
+		}
+		return COUNT_DISTINCT, kvs, nil
+
+	case *algoCountFirst:
+		kvs = kvs.Add("first", c.first).Add("first_time", c.firstTime).Add("count", c.count)
+		if c.raw != nil {
+			kvs = kvs.Add("raw", c.raw)
+		}
+		return FIRST, kvs, nil
+
+	case *algoCountLast:
+		kvs = kvs.Add("last", c.last).Add("last_time", c.lastTime).Add("count", c.count)
+		if c.raw != nil {
+			kvs = kvs.Add("raw", c.raw)
+		}
+		return LAST, kvs, nil
+
+	case *algoMode:
+		return MODE, topValuesState(kvs.Add("max_time", c.maxTime), &c.values), nil
+
+	case *algoDistinctValues:
+		return DISTINCT_VALUES, topValuesState(kvs.Add("max_time", c.maxTime).Add("k", int64(c.k)), &c.values), nil
+
+	case *algoQuantiles:
+		c.ensureSketch()
+		raw, err := c.sketch.toProto().Marshal()
+		if err != nil {
+			return "", nil, err
+		}
+
+		kvs = kvs.Add("max_time", c.maxTime).
+			Add("emit_sketch", c.emitSketch).
+			Add("max_bins", int64(c.sketch.maxBins)).
+			Add("sketch", raw)
+		if len(c.quantiles) > 0 {
+			kvs = kvs.Add("quantiles", point.MustNewFloatArray(c.quantiles...))
+		}
+		return QUANTILES, kvs, nil
+
+	case *algoExpoHistogram:
+		kvs = kvs.Add("max_scale", int64(c.maxScale)).
+			Add("max_buckets", int64(c.maxBuckets)).
+			Add("record_min_max", c.recordMinMax).
+			Add("scale", int64(c.scale)).
+			Add("count", c.count).
+			Add("zero_count", c.zeroCount).
+			Add("sum", c.sum).
+			Add("min", c.min).
+			Add("max", c.max).
+			Add("start_time", c.startTime).
+			Add("max_time", c.maxTime)
+
+		if !c.pos.empty() {
+			kvs = kvs.Add("pos_offset", int64(c.pos.offset)).Add("pos_counts", point.MustNewUintArray(c.pos.counts...))
+		}
+		if !c.neg.empty() {
+			kvs = kvs.Add("neg_offset", int64(c.neg.offset)).Add("neg_counts", point.MustNewUintArray(c.neg.counts...))
+		}
+		return EXPO_HISTOGRAM, kvs, nil
+
+	case *algoTopK:
+		raw, err := c.sketch.toProto().Marshal()
+		if err != nil {
+			return "", nil, err
+		}
+
+		return TOPK, kvs.Add("max_time", c.maxTime).
+			Add("k", int64(c.k)).
+			Add("emit_sketch", c.emitSketch).
+			Add("sketch", raw), nil
+
+	case *algoRate:
+		var (
+			series  []uint64
+			samples []int64
+			resets  []float64
+			times   []int64   // first, prev and last of each series
+			values  []float64 // first, prev and last of each series
+		)
+
+		for h, cs := range c.series {
+			series = append(series, h)
+			samples = append(samples, cs.n)
+			resets = append(resets, cs.resets)
+			for _, s := range []counterSample{cs.first, cs.prev, cs.last} {
+				times = append(times, s.time)
+				values = append(values, s.value)
+			}
+		}
+
+		kvs = kvs.Add("count", c.count).Add("max_time", c.maxTime)
+		if len(series) > 0 {
+			kvs = kvs.Add("series", point.MustNewUintArray(series...)).
+				Add("samples", point.MustNewIntArray(samples...)).
+				Add("resets", point.MustNewFloatArray(resets...)).
+				Add("times", point.MustNewIntArray(times...)).
+				Add("values", point.MustNewFloatArray(values...))
+		}
+		return c.method, kvs, nil
+
+	default:
+		return "", nil, fmt.Errorf("snapshot not supported on calculator %T", calc)
+	}
+}
+
+// calculatorFromState is the reverse of calculatorState.
+func calculatorFromState(method AlgoMethod, mb MetricBase, kvs point.KVs) (Calculator, error) {
+	var (
+		getI = func(k string) int64 { return kvs.Get(k).GetI() }
+		getU = func(k string) uint64 { return kvs.Get(k).GetU() }
+		getF = func(k string) float64 { return kvs.Get(k).GetF() }
+	)
+
+	switch method {
+	case SUM:
+		return &algoSum{MetricBase: mb, delta: getF("delta"), count: getI("count"), maxTime: getI("max_time")}, nil
+
+	case AVG:
+		return &algoAvg{MetricBase: mb, delta: getF("delta"), count: getI("count"), maxTime: getI("max_time")}, nil
+
+	case COUNT:
+		return &algoCount{MetricBase: mb, count: getI("count"), maxTime: getI("max_time")}, nil
+
+	case MIN:
+		return &algoMin{MetricBase: mb, min: getF("min"), count: getI("count"), maxTime: getI("max_time")}, nil
+
+	case MAX:
+		return &algoMax{MetricBase: mb, max: getF("max"), count: getI("count"), maxTime: getI("max_time")}, nil
+
+	case STDEV:
+		return &algoStdev{
+			MetricBase: mb,
+			count:      getI("count"),
+			mean:       getF("mean"),
+			m2:         getF("m2"),
+			maxTime:    getI("max_time"),
+		}, nil
+
+	case HISTOGRAM:
+		c := &algoHistogram{
+			MetricBase: mb,
+			count:      getI("count"),
+			val:        getF("val"),
+			maxTime:    getI("max_time"),
+			leBucket:   map[string]float64{},
+		}
+
+		les, vals := stateSlice[string](kvs, "le"), stateSlice[float64](kvs, "le_values")
+		if len(les) != len(vals) {
+			return nil, fmt.Errorf("histogram got %d le but %d values", len(les), len(vals))
+		}
+		for i, le := range les {
+			c.leBucket[le] = vals[i]
+		}
+		return c, nil
+
+	case COUNT_DISTINCT:
+		c := &algoCountDistinct{
+			MetricBase:     mb,
+			maxTime:        getI("max_time"),
+			distinctValues: map[uint64]struct{}{},
+		}
+
+		if sketch := stateSlice[uint64](kvs, "sketch"); sketch != nil {
+			if len(sketch) != countDistinctSketchBits/64 {
+				return nil, fmt.Errorf("count_distinct sketch got %d words", len(sketch))
+			}
+			c.sketch, c.distinctValues = sketch, nil
+		} else {
+			for _, h := range stateSlice[uint64](kvs, "hashes") {
+				c.distinctValues[h] = struct{}{}
+			}
+		}
+		return c, nil
+
+	case FIRST:
+		c := &algoCountFirst{MetricBase: mb, first: getF("first"), firstTime: getI("first_time"), count: getI("count")}
+		if kv := kvs.Get("raw"); kv != nil {
+			c.raw = kv.Raw()
+		}
+		return c, nil
+
+	case LAST:
+		c := &algoCountLast{MetricBase: mb, last: getF("last"), lastTime: getI("last_time"), count: getI("count")}
+		if kv := kvs.Get("raw"); kv != nil {
+			c.raw = kv.Raw()
+		}
+		return c, nil
+
+	case MODE:
+		c := &algoMode{MetricBase: mb, maxTime: getI("max_time")}
+		if err := restoreTopValues(kvs, &c.values); err != nil {
+			return nil, err
+		}
+		return c, nil
+
+	case DISTINCT_VALUES:
+		c := &algoDistinctValues{MetricBase: mb, maxTime: getI("max_time"), k: int(getI("k"))}
+		if c.k <= 0 { // snapshot without k
+			c.k = distinctValuesDefaultK
+		}
+		if err := restoreTopValues(kvs, &c.values); err != nil {
+			return nil, err
+		}
+		return c, nil
+
+	case QUANTILES:
+		var pb QuantileSketch
+		if err := pb.Unmarshal(kvs.Get("sketch").GetD()); err != nil {
+			return nil, fmt.Errorf("quantile sketch: %w", err)
+		}
+
+		return &algoQuantiles{
+			MetricBase: mb,
+			maxTime:    getI("max_time"),
+			quantiles:  stateSlice[float64](kvs, "quantiles"),
+			emitSketch: kvs.Get("emit_sketch").GetB(),
+			sketch:     ddSketchFromProto(&pb, int(getI("max_bins"))),
+		}, nil
+
+	case EXPO_HISTOGRAM:
+		c := &algoExpoHistogram{
+			MetricBase:   mb,
+			maxScale:     int32(getI("max_scale")),
+			maxBuckets:   int(getI("max_buckets")),
+			recordMinMax: kvs.Get("record_min_max").GetB(),
+			scale:        int32(getI("scale")),
+			count:        getU("count"),
+			zeroCount:    getU("zero_count"),
+			sum:          getF("sum"),
+			min:          getF("min"),
+			max:          getF("max"),
+			startTime:    getI("start_time"),
+			maxTime:      getI("max_time"),
+		}
+
+		if counts := stateSlice[uint64](kvs, "pos_counts"); len(counts) > 0 {
+			c.pos = expoBuckets{offset: int32(getI("pos_offset")), counts: counts}
+		}
+		if counts := stateSlice[uint64](kvs, "neg_counts"); len(counts) > 0 {
+			c.neg = expoBuckets{offset: int32(getI("neg_offset")), counts: counts}
+		}
+		return c, nil
+
+	case TOPK:
+		var pb TopKSketch
+		if err := pb.Unmarshal(kvs.Get("sketch").GetD()); err != nil {
+			return nil, fmt.Errorf("topk sketch: %w", err)
+		}
+
+		return &algoTopK{
+			MetricBase: mb,
+			maxTime:    getI("max_time"),
+			k:          int(getI("k")),
+			emitSketch: kvs.Get("emit_sketch").GetB(),
+			sketch:     topkSketchFromProto(&pb, int(pb.Capacity)),
+		}, nil
+
+	case RATE, INCREASE, IRATE:
+		c := &algoRate{
+			MetricBase: mb,
+			method:     method,
+			series:     map[uint64]*counterSeries{},
+			count:      getI("count"),
+			maxTime:    getI("max_time"),
+		}
+
+		series, times, values := stateSlice[uint64](kvs, "series"), stateSlice[int64](kvs, "times"), stateSlice[float64](kvs, "values")
+
+		if kvs.Get("samples") == nil { // snapshot with all samples of each series
+			if len(series) != len(times) || len(series) != len(values) {
+				return nil, fmt.Errorf("%s got %d series but %d times and %d values", method, len(series), len(times), len(values))
+			}
+			for i, h := range series {
+				c.mergeSeries(h, &counterSeries{
+					first: counterSample{time: times[i], value: values[i]},
+					prev:  counterSample{time: times[i], value: values[i]},
+					last:  counterSample{time: times[i], value: values[i]},
+					n:     1,
+				})
+			}
+			return c, nil
+		}
+
+		samples, resets := stateSlice[int64](kvs, "samples"), stateSlice[float64](kvs, "resets")
+		if len(series) != len(samples) || len(series) != len(resets) ||
+			3*len(series) != len(times) || 3*len(series) != len(values) {
+			return nil, fmt.Errorf("%s got %d series but %d samples, %d resets, %d times and %d values",
+				method, len(series), len(samples), len(resets), len(times), len(values))
+		}
+
+		for i, h := range series {
+			c.series[h] = &counterSeries{
+				first:  counterSample{time: times[3*i], value: values[3*i]},
+				prev:   counterSample{time: times[3*i+1], value: values[3*i+1]},
+				last:   counterSample{time: times[3*i+2], value: values[3*i+2]},
+				n:      samples[i],
+				resets: resets[i],
+			}
+		}
+		return c, nil
+
+	default:
+		return nil, fmt.Errorf("snapshot not supported on method %q", method)
+	}
+}
+
+// topValuesState add counters of tv as fields value_<i> and counts.
+func topValuesState(kvs point.KVs, tv *topValues) point.KVs {
+	arr := tv.sorted()
+
+	counts := make([]int64, 0, len(arr))
+	for i, x := range arr {
+		kvs = kvs.Add(fmt.Sprintf("value_%d", i), x.val)
+		counts = append(counts, x.count)
+	}
+
+	kvs = kvs.Add("total", tv.total)
+	if len(counts) > 0 {
+		kvs = kvs.Add("counts", point.MustNewIntArray(counts...))
+	}
+
+	return kvs
+}
+
+func restoreTopValues(kvs point.KVs, tv *topValues) error {
+	counts := stateSlice[int64](kvs, "counts")
+	tv.counters = make(map[uint64]*topValue, len(counts))
+
+	for i, n := range counts {
+		kv := kvs.Get(fmt.Sprintf("value_%d", i))
+		if kv == nil {
+			return fmt.Errorf("missing value_%d", i)
+		}
+
+		val := kv.Raw()
+		tv.counters[hashDistinctValue(val)] = &topValue{val: val, count: n}
+	}
+
+	tv.total = kvs.Get("total").GetI()
+	return nil
+}
+
+// stateSlice get array field k within kvs as []T.
+func stateSlice[T any](kvs point.KVs, k string) []T {
+	kv := kvs.Get(k)
+	if kv == nil {
+		return nil
+	}
+
+	switch x := kv.Raw().(type) {
+	case []T:
+		return x
+	case []any: // mixed array enabled
+		res := make([]T, 0, len(x))
+		for _, v := range x {
+			if t, ok := v.(T); ok {
+				res = append(res, t)
+			}
+		}
+		return res
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,200 @@
+package aggregate
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func snapshotTestBatch(now time.Time) *AggregationBatch {
+	var pts []*point.PBPoint
+	for i, kvs := range []map[string]any{
+		{"latency": 10.0, "status": "ok"},
+		{"latency": 30.0, "status": "error"},
+		{"latency": 20.0, "status": "ok"},
+	} {
+		pt := point.NewPoint("request",
+			point.NewKVs(kvs).AddTag("host", "node-1").AddTag("le", "100"),
+			point.WithTime(now.Add(time.Duration(i)*time.Second)))
+		pts = append(pts, pt.PBPoint())
+	}
+
+	window := int64(time.Hour)
+	algo := func(method AlgoMethod, src string) *AggregationAlgo {
+		return &AggregationAlgo{
+			Method:      string(method),
+			SourceField: src,
+			Window:      window,
+			AddTags:     map[string]string{"rule": "r1"},
+		}
+	}
+
+	quantiles := algo(QUANTILES, "latency")
+	quantiles.Options = &AggregationAlgo_QuantileOpts{QuantileOpts: &QuantileOptions{Percentiles: []float64{0.5, 0.9}}}
+
+	return &AggregationBatch{
+		RoutingKey: 1,
+		Points:     &point.PBPoints{Arr: pts},
+		AggregationOpts: map[string]*AggregationAlgo{
+			"sum":       algo(SUM, "latency"),
+			"avg":       algo(AVG, "latency"),
+			"count":     algo(COUNT, "latency"),
+			"min":       algo(MIN, "latency"),
+			"max":       algo(MAX, "latency"),
+			"stdev":     algo(STDEV, "latency"),
+			"hist":      algo(HISTOGRAM, "latency"),
+			"distinct":  algo(COUNT_DISTINCT, "status"),
+			"first":     algo(FIRST, "status"),
+			"last":      algo(LAST, "latency"),
+			"mode":      algo(MODE, "status"),
+			"values":    algo(DISTINCT_VALUES, "status"),
+			"quantiles": quantiles,
+			"expo":      algo(EXPO_HISTOGRAM, "latency"),
+		},
+	}
+}
+
+func cacheLineProtos(t *testing.T, c *Cache) []string {
+	t.Helper()
+
+	var windows []*Window
+	for _, ws := range c.WindowsBuckets {
+		windows = append(windows, ws.Close()...)
+	}
+
+	return pointsDataLineProtos(WindowsToData(windows))
+}
+
+func pointsDataLineProtos(pds []*PointsData) []string {
+	var res []string
+	for _, pd := range pds {
+		for _, pt := range pd.PTS {
+			res = append(res, pd.Token+" "+pt.LineProto())
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+func TestCacheSnapshotRestore(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	newCache := func() *Cache {
+		c := NewCache(time.Second)
+		n, expN := c.AddBatch("token-a", snapshotTestBatch(now))
+		require.Equal(t, 42, n)
+		require.Zero(t, expN)
+		return c
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, newCache().Snapshot(&buf))
+
+	restored, flushed, err := RestoreCache(&buf)
+	require.NoError(t, err)
+	assert.Empty(t, flushed)
+	assert.Equal(t, time.Second, restored.Expired)
+
+	// more data after restart merged into restored calculators
+	n, _ := restored.AddBatch("token-a", snapshotTestBatch(now))
+	require.Equal(t, 42, n)
+
+	want := newCache()
+	want.AddBatch("token-a", snapshotTestBatch(now))
+
+	got := cacheLineProtos(t, restored)
+	require.NotEmpty(t, got)
+	assert.Equal(t, cacheLineProtos(t, want), got)
+}
+
+func TestRestoreCacheFlushExpired(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+
+	c := NewCache(time.Second)
+	c.AddBatch("token-a", snapshotTestBatch(now))
+
+	var buf bytes.Buffer
+	require.NoError(t, c.Snapshot(&buf))
+
+	var cs CacheSnapshot
+	require.NoError(t, cs.Unmarshal(buf.Bytes()))
+	for _, s := range cs.Calculators {
+		s.Expire = now.Add(-time.Minute).Unix() // expired during restart
+	}
+
+	raw, err := cs.Marshal()
+	require.NoError(t, err)
+
+	restored, flushed, err := RestoreCache(bytes.NewReader(raw))
+	require.NoError(t, err)
+	assert.Empty(t, restored.WindowsBuckets)
+
+	require.Len(t, flushed, 1)
+	assert.Equal(t, "token-a", flushed[0].Token)
+	assert.Equal(t, cacheLineProtos(t, c), pointsDataLineProtos(flushed))
+
+	for _, pt := range flushed[0].PTS {
+		assert.Equal(t, "node-1", pt.GetTag("host"))
+		assert.Equal(t, "r1", pt.GetTag("rule"))
+	}
+}
+
+func TestRestoreCacheInvalid(t *testing.T) {
+	_, _, err := RestoreCache(bytes.NewReader([]byte("not a snapshot")))
+	require.Error(t, err)
+
+	cs := &CacheSnapshot{
+		Expired: int64(time.Second),
+		Calculators: []*CalculatorSnapshot{
+			{Token: "token-a", Expire: time.Now().Add(time.Hour).Unix(), Method: "unknown", State: &point.PBPoint{Name: "request"}},
+			{Token: "token-a", Expire: time.Now().Add(time.Hour).Unix(), Method: string(SUM)},
+		},
+	}
+
+	raw, err := cs.Marshal()
+	require.NoError(t, err)
+
+	c, flushed, err := RestoreCache(bytes.NewReader(raw))
+	require.NoError(t, err)
+	assert.Empty(t, flushed)
+	assert.Empty(t, c.WindowsBuckets)
+}
+
+func TestCacheCheckpoint(t *testing.T) {
+	var (
+		now  = time.Now().Truncate(time.Second)
+		path = filepath.Join(t.TempDir(), "aggr.ckpt")
+		c    = NewCache(time.Second)
+	)
+
+	_, _, err := RestoreCacheFile(path)
+	require.Error(t, err)
+
+	c.AddBatch("token-a", snapshotTestBatch(now))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.RunCheckpoint(ctx, path, time.Hour)
+	}()
+
+	cancel() // final checkpoint on exit
+	<-done
+
+	restored, flushed, err := RestoreCacheFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, flushed)
+	assert.Equal(t, cacheLineProtos(t, c), cacheLineProtos(t, restored))
+
+	matches, err := filepath.Glob(path + ".*.tmp")
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
@@ -5,7 +5,9 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/GuanceCloud/cliutils"
 	"github.com/GuanceCloud/cliutils/point"
+	"github.com/cespare/xxhash/v2"
 )
 
 /*
@@ -148,6 +150,15 @@ func newCalculators(batch *AggregationBatch) (res []Calculator) {
 	var ptwrap *point.Point
 	// now    = time.Now()
 
+	// fields carried by points of other fields(such as the weight field of
+	// topk) only aggregated on points selected by themselves.
+	carried := map[string]bool{}
+	for _, arr := range carryFields(batch.AggregationOpts) {
+		for _, f := range arr {
+			carried[f] = true
+		}
+	}
+
 	for key, algo := range batch.AggregationOpts {
 		if algo == nil {
 			continue
@@ -165,27 +176,39 @@ func newCalculators(batch *AggregationBatch) (res []Calculator) {
 			}
 		}
 
+		method := NormalizeAlgoMethod(algo.Method)
+		ruleHash := xxhash.Sum64(cliutils.ToUnsafeBytes(batch.Rule))
+
 		for _, pt := range batch.Points.Arr {
 			var (
 				keyName string
 				val     any
 				ptwrap  = point.WrapPB(ptwrap, pt)
+				srcKey  = key
 			)
 
 			if algo.SourceField != "" {
-				if val = ptwrap.Get(algo.SourceField); val == nil {
-					continue
-				}
+				srcKey = algo.SourceField
+			}
 
-				keyName = key
-			} else {
-				if val = ptwrap.Get(key); val == nil {
+			if carried[srcKey] && selectedField(ptwrap) != srcKey {
+				continue
+			}
+
+			if val = ptwrap.Get(srcKey); val == nil {
+				// exponential histogram point got no field srcKey, but srcKey_scale, srcKey_pos_bucket_counts...
+				// and quantile sketch point got srcKey_sketch.
+				switch {
+				case method == EXPO_HISTOGRAM && isExpoHistogramPoint(ptwrap, srcKey):
+				case method == QUANTILES && quantileSketchBaseOf(ptwrap, srcKey+quantileSketchSuffix) != "":
+				case method == TOPK && topkSketchBaseOf(ptwrap, srcKey+topkSketchSuffix) != "":
+				default:
 					continue
 				}
-
-				keyName = key
 			}
 
+			keyName = key
+
 			if keyName == "" {
 				continue
 			}
@@ -199,19 +222,34 @@ func newCalculators(batch *AggregationBatch) (res []Calculator) {
 				// XXX: what if the point reach too late?
 				nextWallTime: AlignNextWallTime(ptwrap.Time(), time.Duration(algo.Window)),
 				window:       algo.Window,
+				rule:         batch.Rule,
+			}
+			if batch.Window.overlapping() {
+				mb.slide = batch.Window
 			}
-			method := NormalizeAlgoMethod(algo.Method)
-			f64, ok := val.(float64)
-			if !ok {
+			if len(batch.Expressions) > 0 {
+				mb.group = HashCombine(ruleHash, seriesHash(ptwrap))
+				mb.exprs = batch.Expressions
+			}
+			if x, ok := val.([]byte); ok { // do not refer to the point's buffer
+				val = append([]byte(nil), x...)
+			}
+
+			f64, isNumeric := val.(float64)
+			if !isNumeric {
 				if i64, ok := val.(int64); !ok {
 					if method == COUNT_DISTINCT || method == COUNT {
 						// 这两种类型可以不转换成 float64
+					} else if (method == MODE || method == DISTINCT_VALUES || method == FIRST || method == LAST || method == TOPK) && isTopValueType(val) {
+						// non-numeric values kept as is
+					} else if (method == EXPO_HISTOGRAM || method == QUANTILES || method == TOPK) && val == nil {
+						// exponential histogram, quantile or topk sketch point
 					} else {
 						l.Warnf("key %s non-numeric type(%s) for method %s, ignored", keyName, reflect.TypeOf(val), method)
 						continue
 					}
 				} else {
-					f64 = float64(i64)
+					f64, isNumeric = float64(i64), true
 				}
 			}
 			// we get the kv for current algorithm.
@@ -282,13 +320,19 @@ func newCalculators(batch *AggregationBatch) (res []Calculator) {
 				res = append(res, calc)
 
 			case QUANTILES:
-				calc := newAlgoQuantiles(mb, ptwrap.Time().UnixNano(), f64)
-				if algo.Options != nil {
-					switch opt := algo.Options.(type) {
-					case *AggregationAlgo_QuantileOpts:
-						calc.addOpts(opt)
-					default: //nolint
-					}
+				var opts *QuantileOptions
+				if opt, ok := algo.Options.(*AggregationAlgo_QuantileOpts); ok {
+					opts = opt.QuantileOpts
+				}
+
+				calc := newAlgoQuantiles(mb, ptwrap.Time().UnixNano(), opts)
+				if val != nil {
+					calc.addValue(f64)
+				} else if s, ok := quantileSketchFromPoint(ptwrap, srcKey, calc.sketch.maxBins); ok {
+					calc.sketch.merge(s)
+				} else {
+					l.Warnf("key %s invalid quantile sketch, ignored", keyName)
+					continue
 				}
 
 				calc.doHash(batch.RoutingKey)
@@ -311,6 +355,9 @@ func newCalculators(batch *AggregationBatch) (res []Calculator) {
 					lastTime:   ptwrap.Time().UnixNano(),
 					count:      1,
 				}
+				if !isNumeric {
+					calc.raw = val
+				}
 				calc.doHash(batch.RoutingKey)
 				res = append(res, calc)
 
@@ -321,11 +368,76 @@ func newCalculators(batch *AggregationBatch) (res []Calculator) {
 					firstTime:  ptwrap.Time().UnixNano(),
 					count:      1,
 				}
+				if !isNumeric {
+					calc.raw = val
+				}
+				calc.doHash(batch.RoutingKey)
+				res = append(res, calc)
+
+			case MODE:
+				calc := newAlgoMode(mb, ptwrap.Time().UnixNano(), val)
+				calc.doHash(batch.RoutingKey)
+				res = append(res, calc)
+
+			case DISTINCT_VALUES:
+				var opts *DistinctValuesOptions
+				if opt, ok := algo.Options.(*AggregationAlgo_DistinctValuesOpts); ok {
+					opts = opt.DistinctValuesOpts
+				}
+
+				calc := newAlgoDistinctValues(mb, ptwrap.Time().UnixNano(), val, opts)
+				calc.doHash(batch.RoutingKey)
+				res = append(res, calc)
+
+			case TOPK:
+				var opts *TopKOptions
+				if opt, ok := algo.Options.(*AggregationAlgo_TopkOpts); ok {
+					opts = opt.TopkOpts
+				}
+
+				calc := newAlgoTopK(mb, ptwrap.Time().UnixNano(), opts)
+				if val != nil {
+					w, ok := topkWeight(ptwrap, opts)
+					if !ok {
+						l.Warnf("key %s missing numeric weight field %q, ignored", keyName, opts.GetWeightField())
+						continue
+					}
+					calc.sketch.add(fieldToString(val), w, 0)
+				} else if s, ok := topkSketchFromPoint(ptwrap, srcKey, calc.sketch.capacity); ok {
+					calc.sketch.merge(s)
+				} else {
+					l.Warnf("key %s invalid topk sketch, ignored", keyName)
+					continue
+				}
+
+				calc.doHash(batch.RoutingKey)
+				res = append(res, calc)
+
+			case RATE, INCREASE, IRATE:
+				calc := newAlgoRate(mb, method, seriesOf(ptwrap), ptwrap.Time().UnixNano(), f64)
+				calc.doHash(batch.RoutingKey)
+				res = append(res, calc)
+
+			case EXPO_HISTOGRAM:
+				var opts *ExpoHistogramOptions
+				if opt, ok := algo.Options.(*AggregationAlgo_ExpoOpts); ok {
+					opts = opt.ExpoOpts
+				}
+
+				calc := newAlgoExpoHistogram(mb, ptwrap.Time().UnixNano(), opts)
+				if val != nil {
+					calc.addValue(f64)
+				} else if h, ok := expoHistogramFromPoint(ptwrap, srcKey); ok {
+					calc.merge(h)
+				} else {
+					l.Warnf("key %s invalid exponential histogram, ignored", keyName)
+					continue
+				}
+
 				calc.doHash(batch.RoutingKey)
 				res = append(res, calc)
 
 			case METHOD_UNSPECIFIED:
-			case EXPO_HISTOGRAM: // TODO
 			default: // pass
 			}
 		}
@@ -21,27 +21,23 @@ func Test_alignNextWallTime(t *T.T) {
 }
 
 func TestQuantile(t *T.T) {
-	q := &algoQuantiles{
-		MetricBase: MetricBase{
-			key:  "latency",
-			name: "tail_sampling",
-		},
-		count:     2,
-		all:       []float64{10, 30},
-		quantiles: []float64{0.5, 0.95},
-		maxTime:   time.Unix(1700000002, 0).UnixNano(),
+	mb := MetricBase{
+		key:  "latency",
+		name: "tail_sampling",
 	}
 
-	q.Add(&algoQuantiles{
-		count:   2,
-		all:     []float64{20, 40},
-		maxTime: time.Unix(1700000003, 0).UnixNano(),
-	})
+	q := newAlgoQuantiles(mb, time.Unix(1700000002, 0).UnixNano(), &QuantileOptions{Percentiles: []float64{0.5, 0.95}})
+	q.addValue(10)
+	q.addValue(30)
+
+	other := newAlgoQuantiles(mb, time.Unix(1700000003, 0).UnixNano(), nil)
+	other.addValue(20)
+	other.addValue(40)
+	q.Add(other)
 
-	assert.Equal(t, int64(4), q.count)
-	assert.Equal(t, []float64{10, 30, 20, 40}, q.all)
-	assert.Equal(t, 25.0, q.GetPercentile(50))
-	assert.Equal(t, 38.5, q.GetPercentile(95))
+	assert.Equal(t, int64(4), q.count())
+	assert.InEpsilon(t, 20.0, q.GetPercentile(50), quantileDefaultRelativeAccuracy)
+	assert.InEpsilon(t, 30.0, q.GetPercentile(95), quantileDefaultRelativeAccuracy)
 
 	pts, err := q.Aggr()
 	require.NoError(t, err)
@@ -49,11 +45,12 @@ func TestQuantile(t *T.T) {
 
 	p50, ok := pts[0].GetF("latency_P50")
 	require.True(t, ok)
-	assert.Equal(t, 25.0, p50)
+	assert.InEpsilon(t, 20.0, p50, quantileDefaultRelativeAccuracy)
 
 	p95, ok := pts[0].GetF("latency_P95")
 	require.True(t, ok)
-	assert.Equal(t, 38.5, p95)
+	assert.InEpsilon(t, 30.0, p95, quantileDefaultRelativeAccuracy)
+	assert.Nil(t, pts[0].Get("latency_sketch"))
 
 	count := pts[0].Get("latency_count")
 	require.NotNil(t, count)
@@ -89,8 +86,8 @@ func TestNewCalculatorsQuantiles(t *T.T) {
 
 	q, ok := calcs[0].(*algoQuantiles)
 	require.True(t, ok)
-	assert.Equal(t, int64(1), q.count)
-	assert.Equal(t, []float64{42}, q.all)
+	assert.Equal(t, int64(1), q.count())
+	assert.Equal(t, 42.0, q.sketch.min)
 	assert.Equal(t, []float64{0.5, 0.9}, q.quantiles)
 }
 
@@ -0,0 +1,253 @@
+package aggregate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/GuanceCloud/cliutils"
+	"github.com/cespare/xxhash/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// Merge service is the receiving side of distributed aggregation: agents
+// POST AggregationBatch(see batchRequest) to any node of the service, the
+// batch routed to its owner node by consistent hashing of RoutingKey, so
+// calculators of the same instance from all agents merged within the same
+// node's cache.
+
+const (
+	// GuanceToken is the header of the token(workspace) of the batch.
+	GuanceToken = "Guance-Token"
+
+	// GuanceForwarded is the header set on batch forwarded among nodes, the
+	// receiver should not forward it again. The value is the forwarding peer,
+	// only accepted from peers on the ring for routing keys owned by the
+	// receiver.
+	GuanceForwarded = "Guance-Forwarded"
+
+	// response headers of calculators added and expired.
+	mergeHeaderAdded   = "Guance-Aggr-Added"
+	mergeHeaderExpired = "Guance-Aggr-Expired"
+
+	mergeDefaultReplicas = 128
+
+	// mergeDefaultMaxBody is default max size of the request body.
+	mergeDefaultMaxBody = 32 << 20
+)
+
+// hashRing is the consistent hashing ring of nodes.
+type hashRing struct {
+	hashes []uint64
+	nodes  map[uint64]string
+}
+
+func newHashRing(nodes []string, replicas int) *hashRing {
+	r := &hashRing{nodes: map[uint64]string{}}
+
+	for _, node := range nodes {
+		for i := 0; i < replicas; i++ {
+			h := xxhash.Sum64(cliutils.ToUnsafeBytes(node + "#" + strconv.Itoa(i)))
+			if _, ok := r.nodes[h]; ok { // hash conflict, keep the first
+				continue
+			}
+
+			r.nodes[h] = node
+			r.hashes = append(r.hashes, h)
+		}
+	}
+
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+	return r
+}
+
+// get return the node owns key, the first node clockwise on the ring.
+func (r *hashRing) get(key uint64) string {
+	if len(r.hashes) == 0 {
+		return ""
+	}
+
+	// routing key hashed again, or keys close to each other go to the same node
+	h := HashCombine(Seed2, key)
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if i == len(r.hashes) {
+		i = 0
+	}
+
+	return r.nodes[r.hashes[i]]
+}
+
+// MergeService merge batches of the instances owned by self among peers.
+type MergeService struct {
+	self  string
+	peers map[string]bool
+	ring  *hashRing
+
+	cache *Cache
+	cli   *http.Client
+
+	replicas int
+	maxBody  int64
+}
+
+type MergeServiceOption func(*MergeService)
+
+// WithMergeHTTPClient set the client forwarding batches to peers.
+func WithMergeHTTPClient(cli *http.Client) MergeServiceOption {
+	return func(s *MergeService) {
+		s.cli = cli
+	}
+}
+
+// WithMergeReplicas set virtual nodes of each peer on the hash ring.
+func WithMergeReplicas(n int) MergeServiceOption {
+	return func(s *MergeService) {
+		if n > 0 {
+			s.replicas = n
+		}
+	}
+}
+
+// WithMergeMaxBodySize set max size of the request body, larger requests
+// rejected with 413.
+func WithMergeMaxBodySize(n int64) MergeServiceOption {
+	return func(s *MergeService) {
+		if n > 0 {
+			s.maxBody = n
+		}
+	}
+}
+
+// NewMergeService create merge service on node self, peers are URLs of
+// Handler() on all nodes(self included), and exp is the Expired of the cache.
+func NewMergeService(self string, peers []string, exp time.Duration, opts ...MergeServiceOption) *MergeService {
+	s := &MergeService{
+		self:     self,
+		cache:    NewCache(exp),
+		peers:    make(map[string]bool, len(peers)),
+		cli:      &http.Client{Timeout: 30 * time.Second},
+		replicas: mergeDefaultReplicas,
+		maxBody:  mergeDefaultMaxBody,
+	}
+
+	for _, peer := range peers {
+		s.peers[peer] = true
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(s)
+		}
+	}
+
+	s.ring = newHashRing(peers, s.replicas)
+	return s
+}
+
+// Cache return the cache merging batches owned by self.
+func (s *MergeService) Cache() *Cache {
+	return s.cache
+}
+
+// Owner return the peer owns the routing key.
+func (s *MergeService) Owner(routingKey uint64) string {
+	return s.ring.get(routingKey)
+}
+
+// AddBatch add the batch into local cache if owned by self, or forward it to
+// the owner, n and expN are calculators added and expired.
+func (s *MergeService) AddBatch(token string, batch *AggregationBatch) (n, expN int, err error) {
+	owner := s.Owner(batch.RoutingKey)
+	if owner == "" || owner == s.self {
+		n, expN = s.cache.AddBatch(token, batch)
+		return n, expN, nil
+	}
+
+	return s.forward(owner, token, batch)
+}
+
+func (s *MergeService) forward(owner, token string, batch *AggregationBatch) (n, expN int, err error) {
+	req, err := batchRequest(batch, owner)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	req.Header.Set(GuanceToken, token)
+	req.Header.Set(GuanceForwarded, s.self)
+
+	resp, err := s.cli.Do(req)
+	if err != nil {
+		return 0, 0, fmt.Errorf("forward batch to %s: %w", owner, err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, 0, fmt.Errorf("forward batch to %s: %s: %s", owner, resp.Status, body)
+	}
+
+	n, _ = strconv.Atoi(resp.Header.Get(mergeHeaderAdded))
+	expN, _ = strconv.Atoi(resp.Header.Get(mergeHeaderExpired))
+	return n, expN, nil
+}
+
+// Handler accept the protobuf AggregationBatch posted by agents or peers.
+func (s *MergeService) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, s.maxBody))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.String(http.StatusRequestEntityTooLarge, "body exceeds %d bytes", tooLarge.Limit)
+				return
+			}
+
+			c.String(http.StatusBadRequest, "read body: %s", err)
+			return
+		}
+
+		var batch AggregationBatch
+		if err := batch.Unmarshal(body); err != nil {
+			c.String(http.StatusBadRequest, "invalid batch: %s", err)
+			return
+		}
+
+		var (
+			token = c.GetHeader(GuanceToken)
+			n     int
+			expN  int
+		)
+
+		if peer := c.GetHeader(GuanceForwarded); peer != "" { // never forward again
+			if !s.peers[peer] || peer == s.self {
+				c.String(http.StatusForbidden, "forwarded from unknown peer %q", peer)
+				return
+			}
+
+			// rings of peers differ(such as during a rolling update),
+			// forwarding again may loop among peers.
+			if owner := s.Owner(batch.RoutingKey); owner != "" && owner != s.self {
+				c.String(http.StatusMisdirectedRequest, "routing key %d owned by %s", batch.RoutingKey, owner)
+				return
+			}
+
+			n, expN = s.cache.AddBatch(token, &batch)
+		} else if n, expN, err = s.AddBatch(token, &batch); err != nil {
+			c.String(http.StatusBadGateway, "%s", err)
+			return
+		}
+
+		c.Header(mergeHeaderAdded, strconv.Itoa(n))
+		c.Header(mergeHeaderExpired, strconv.Itoa(expN))
+		c.Status(http.StatusOK)
+	}
+}
+
+// Flush return final points of windows expired on self.
+func (s *MergeService) Flush() []*PointsData {
+	return WindowsToData(s.cache.GetExpWidows())
+}
@@ -0,0 +1,222 @@
+package aggregate
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashRing(t *testing.T) {
+	nodes := []string{"node-a", "node-b", "node-c"}
+	r := newHashRing(nodes, mergeDefaultReplicas)
+
+	assert.Empty(t, newHashRing(nil, mergeDefaultReplicas).get(1))
+
+	owners := map[uint64]string{}
+	dist := map[string]int{}
+	for k := uint64(0); k < 3000; k++ {
+		owner := r.get(k)
+		owners[k] = owner
+		dist[owner]++
+	}
+
+	for _, n := range nodes {
+		assert.Greater(t, dist[n], 500, "keys should spread among nodes: %v", dist)
+	}
+
+	// only keys of removed node moved
+	r2 := newHashRing(nodes[:2], mergeDefaultReplicas)
+	for k, owner := range owners {
+		if owner != "node-c" {
+			assert.Equal(t, owner, r2.get(k))
+		}
+	}
+}
+
+// mergeTestNodes start n merge nodes on loopback.
+func mergeTestNodes(t *testing.T, n int) []*MergeService {
+	t.Helper()
+
+	gin.SetMode(gin.ReleaseMode)
+
+	var (
+		engines []*gin.Engine
+		urls    []string
+	)
+
+	for i := 0; i < n; i++ {
+		r := gin.New()
+		ts := httptest.NewServer(r)
+		t.Cleanup(ts.Close)
+
+		engines = append(engines, r)
+		urls = append(urls, ts.URL+"/v1/aggregate")
+	}
+
+	var res []*MergeService
+	for i, r := range engines {
+		s := NewMergeService(urls[i], urls, time.Hour)
+		r.POST("/v1/aggregate", s.Handler())
+		res = append(res, s)
+	}
+
+	return res
+}
+
+func mergeTestBatches(t *testing.T, now time.Time, agent int) []*AggregationBatch {
+	t.Helper()
+
+	cfg := &AggregatorConfigure{
+		DefaultWindow: time.Hour,
+		AggregateRules: []*AggregateRule{
+			{
+				Name:       "bytes",
+				Selector:   &RuleSelector{Category: point.Metric.String(), MetricName: []string{"bytes"}},
+				Groupby:    []string{"host"},
+				Algorithms: map[string]*AggregationAlgoConfig{"bytes": {Method: string(SUM)}},
+			},
+		},
+	}
+	require.NoError(t, cfg.Setup())
+
+	var pts []*point.Point
+	for i := 0; i < 20; i++ {
+		pts = append(pts, point.NewPoint("net",
+			point.NewKVs(map[string]any{"bytes": float64(agent + 1)}).AddTag("host", fmt.Sprintf("host-%02d", i)),
+			point.WithTime(now)))
+	}
+
+	var res []*AggregationBatch
+	for _, bs := range cfg.PickPoints(point.Metric.String(), pts) {
+		res = append(res, bs.Batchs...)
+	}
+	return res
+}
+
+func TestMergeService(t *testing.T) {
+	var (
+		now   = time.Now()
+		nodes = mergeTestNodes(t, 3)
+		cli   = &http.Client{}
+	)
+
+	// 3 agents post to different nodes
+	for agent := 0; agent < 3; agent++ {
+		for _, b := range mergeTestBatches(t, now, agent) {
+			req, err := batchRequest(b, nodes[agent].self)
+			require.NoError(t, err)
+			req.Header.Set(GuanceToken, "token-a")
+
+			resp, err := cli.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close() //nolint:errcheck
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, "1", resp.Header.Get(mergeHeaderAdded))
+		}
+	}
+
+	var all []string
+	for _, node := range nodes {
+		lines := cacheLineProtos(t, node.Cache())
+		assert.NotEmpty(t, lines, "instances should spread among nodes")
+		all = append(all, lines...)
+	}
+
+	// each instance merged on a single node, from all agents
+	require.Len(t, all, 20)
+	for _, line := range all {
+		assert.True(t, strings.HasPrefix(line, "token-a net,host=host-"), line)
+		assert.Contains(t, line, "bytes=6,bytes_count=3i", line)
+	}
+}
+
+func TestMergeServiceForwardError(t *testing.T) {
+	self := "http://127.0.0.1:1/self"
+	s := NewMergeService(self, []string{self, "http://127.0.0.1:1/down"}, time.Hour,
+		WithMergeHTTPClient(&http.Client{Timeout: time.Second}), WithMergeReplicas(16))
+
+	var forwarded int
+	for _, b := range mergeTestBatches(t, time.Now(), 0) {
+		if s.Owner(b.RoutingKey) == self {
+			n, _, err := s.AddBatch("token-a", b)
+			require.NoError(t, err)
+			assert.Equal(t, 1, n)
+			continue
+		}
+
+		forwarded++
+		_, _, err := s.AddBatch("token-a", b)
+		assert.Error(t, err)
+	}
+
+	assert.NotZero(t, forwarded)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/self", strings.NewReader("not a batch"))
+	s.Handler()(c)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestMergeServiceHandlerReject(t *testing.T) {
+	var (
+		self = "http://127.0.0.1:1/self"
+		peer = "http://127.0.0.1:1/peer"
+		s    = NewMergeService(self, []string{self, peer}, time.Hour, WithMergeReplicas(16))
+
+		owned, notOwned *AggregationBatch
+	)
+
+	for _, b := range mergeTestBatches(t, time.Now(), 0) {
+		if s.Owner(b.RoutingKey) == self {
+			owned = b
+		} else {
+			notOwned = b
+		}
+	}
+	require.NotNil(t, owned)
+	require.NotNil(t, notOwned)
+
+	handle := func(s *MergeService, b *AggregationBatch, forwarded string) int {
+		req, err := batchRequest(b, self)
+		require.NoError(t, err)
+		req.Header.Set(GuanceToken, "token-a")
+		if forwarded != "" {
+			req.Header.Set(GuanceForwarded, forwarded)
+		}
+
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = req
+		s.Handler()(c)
+		return rec.Code
+	}
+
+	t.Run("body-too-large", func(t *testing.T) {
+		s := NewMergeService(self, []string{self}, time.Hour, WithMergeMaxBodySize(16))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, handle(s, owned, ""))
+		assert.Empty(t, cacheLineProtos(t, s.Cache()))
+	})
+
+	t.Run("forwarded-from-unknown-peer", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, handle(s, notOwned, "http://127.0.0.1:1/unknown"))
+		assert.Equal(t, http.StatusForbidden, handle(s, owned, self))
+	})
+
+	t.Run("forwarded-not-owned", func(t *testing.T) {
+		assert.Equal(t, http.StatusMisdirectedRequest, handle(s, notOwned, peer))
+	})
+
+	t.Run("forwarded", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, handle(s, owned, peer))
+		assert.Len(t, cacheLineProtos(t, s.Cache()), 1)
+	})
+}
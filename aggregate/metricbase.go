@@ -20,6 +20,16 @@ type MetricBase struct {
 	window,
 	nextWallTime int64
 	heapIdx int
+
+	// rule of the calculator, and the window spec if it's a pane of
+	// hopping/sliding window.
+	rule  string
+	slide *WindowOptions
+
+	// group of the calculator and expressions evaluated on outputs of the
+	// group, see aggr_expression.go.
+	group uint64
+	exprs []*Expression
 }
 
 // build used to delay build the tags.
@@ -2,6 +2,7 @@ package aggregate
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/GuanceCloud/cliutils"
 	"github.com/GuanceCloud/cliutils/point"
@@ -104,13 +105,51 @@ func hash(pt *point.Point, sortedTagKeys []string) uint64 {
 		}
 		// NOTE: only get the first non-tag filed for hash, we should
 		// make sure there only one field on each aggregate point.
-		h = HashCombine(h, xxhash.Sum64(cliutils.ToUnsafeBytes(kv.Key)))
+		// Exponential histogram x got fields x_xxx, all hashed as x, so
+		// does the quantile sketch x_sketch and topk sketch x_topk.
+		h = HashCombine(h, xxhash.Sum64(cliutils.ToUnsafeBytes(fieldBaseOf(pt, kv))))
 		break
 	}
 
 	return h
 }
 
+// selectedField return base name of the selected field of pt forked by selector.
+func selectedField(pt *point.Point) string {
+	for _, kv := range pt.KVs() {
+		if !kv.IsTag {
+			return fieldBaseOf(pt, kv)
+		}
+	}
+	return ""
+}
+
+// fieldBaseOf return x if kv is field x_xxx of exponential histogram, quantile sketch
+// x_sketch or topk sketch x_topk within pt, else the key of kv. Suffix and type of kv
+// checked first, so plain fields never lookup pt.
+func fieldBaseOf(pt *point.Point, kv *point.Field) string {
+	key := kv.Key
+
+	if _, ok := kv.Val.(*point.Field_D); ok {
+		for _, suffix := range []string{quantileSketchSuffix, topkSketchSuffix} {
+			if base := strings.TrimSuffix(key, suffix); base != key && base != "" {
+				return base
+			}
+		}
+	}
+
+	for _, suffix := range expoHistogramSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			if base := expoHistogramBaseOf(pt, key); base != "" {
+				return base
+			}
+			break
+		}
+	}
+
+	return key
+}
+
 func pickHash(pt *point.Point, sortedTagKeys []string) uint64 {
 	h := Seed1
 
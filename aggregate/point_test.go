@@ -0,0 +1,62 @@
+package aggregate
+
+import (
+	"testing"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHash(t *testing.T) {
+	tagKeys := []string{"host"}
+	newPt := func(kvs point.KVs) *point.Point {
+		return point.NewPoint("m", kvs.AddTag("host", "h1"))
+	}
+
+	t.Run("plain", func(t *testing.T) {
+		assert.Equal(t,
+			hash(newPt(point.KVs{}.Add("latency", 1.0)), tagKeys),
+			hash(newPt(point.KVs{}.Add("latency", 2.0)), tagKeys))
+
+		assert.NotEqual(t,
+			hash(newPt(point.KVs{}.Add("latency", 1.0)), tagKeys),
+			hash(newPt(point.KVs{}.Add("latency_count", 1.0)), tagKeys))
+	})
+
+	t.Run("sketch", func(t *testing.T) {
+		base := hash(newPt(point.KVs{}.Add("latency", 1.0)), tagKeys)
+
+		assert.Equal(t, base, hash(newPt(point.KVs{}.Add("latency"+quantileSketchSuffix, []byte("x"))), tagKeys))
+		assert.Equal(t, base, hash(newPt(point.KVs{}.Add("latency"+topkSketchSuffix, []byte("x"))), tagKeys))
+
+		// not bytes, not a sketch
+		assert.NotEqual(t, base, hash(newPt(point.KVs{}.Add("latency"+topkSketchSuffix, 1.0)), tagKeys))
+	})
+
+	t.Run("expo-histogram", func(t *testing.T) {
+		base := hash(newPt(point.KVs{}.Add("latency", 1.0)), tagKeys)
+
+		pt := newPt(point.KVs{}.
+			Add("latency"+expoSuffixCount, int64(1)).
+			Add("latency"+expoSuffixScale, int64(2)).
+			Add("latency"+expoSuffixZeroCount, int64(0)))
+		assert.Equal(t, base, hash(pt, tagKeys))
+
+		// _count without the histogram fields is a plain field
+		assert.NotEqual(t, base, hash(newPt(point.KVs{}.Add("latency"+expoSuffixCount, int64(1))), tagKeys))
+	})
+}
+
+func BenchmarkHash(b *testing.B) {
+	tagKeys := []string{"host", "service"}
+	pt := point.NewPoint("m", point.KVs{}.
+		AddTag("host", "h1").
+		AddTag("service", "s1").
+		Add("latency", 1.0))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hash(pt, tagKeys)
+	}
+}
@@ -0,0 +1,299 @@
+package aggregate
+
+import (
+	"math"
+)
+
+const (
+	quantileDefaultRelativeAccuracy = 0.01
+	quantileDefaultMaxBins          = 2048
+
+	// values less than it(in absolute) counted as zero.
+	quantileMinIndexableValue = 0x1p-1022
+)
+
+// ddBins are dense bins of DDSketch, counts[i] is the count of bin offset+i.
+type ddBins struct {
+	offset int32
+	counts []uint64
+}
+
+func (b *ddBins) empty() bool {
+	return len(b.counts) == 0
+}
+
+func (b *ddBins) high() int32 {
+	return b.offset + int32(len(b.counts)) - 1
+}
+
+// add n to bin idx, keeps at most maxBins bins by collapsing lowest ones.
+func (b *ddBins) add(idx int32, n uint64, maxBins int) {
+	if n == 0 {
+		return
+	}
+
+	if b.empty() {
+		b.offset = idx
+		b.counts = []uint64{n}
+		return
+	}
+
+	lo, hi := b.offset, b.high()
+	if idx < lo {
+		lo = idx
+	}
+	if idx > hi {
+		hi = idx
+	}
+
+	if int64(hi)-int64(lo)+1 > int64(maxBins) {
+		lo = hi - int32(maxBins) + 1
+	}
+
+	if idx < lo {
+		idx = lo
+	}
+
+	b.resize(lo, hi)
+	b.counts[idx-b.offset] += n
+}
+
+// resize bins to [lo, hi], counts of bins below lo folded into lo.
+func (b *ddBins) resize(lo, hi int32) {
+	if lo == b.offset && hi == b.high() {
+		return
+	}
+
+	counts := make([]uint64, int(hi-lo)+1)
+	for i, c := range b.counts {
+		j := b.offset + int32(i)
+		if j < lo {
+			j = lo
+		}
+		counts[j-lo] += c
+	}
+
+	b.offset = lo
+	b.counts = counts
+}
+
+func (b *ddBins) total() (n uint64) {
+	for _, c := range b.counts {
+		n += c
+	}
+	return n
+}
+
+// trimmed return the bins without leading and trailing zero counts.
+func (b *ddBins) trimmed() (int32, []uint64) {
+	start, end := 0, len(b.counts)
+	for start < end && b.counts[start] == 0 {
+		start++
+	}
+	for end > start && b.counts[end-1] == 0 {
+		end--
+	}
+
+	if start == end {
+		return 0, nil
+	}
+
+	return b.offset + int32(start), b.counts[start:end]
+}
+
+// ddSketch is a mergeable quantile sketch(DDSketch): value v counted in bin
+// ceil(log_gamma(|v|)), the value of the bin estimated within the relative
+// accuracy alpha, gamma = (1+alpha)/(1-alpha).
+type ddSketch struct {
+	alpha    float64
+	gamma    float64
+	logGamma float64
+	maxBins  int
+
+	pos, neg  ddBins
+	zeroCount uint64
+
+	count    uint64
+	sum      float64
+	min, max float64
+}
+
+func newDDSketch(alpha float64, maxBins int) *ddSketch {
+	if alpha <= 0 || alpha >= 1 {
+		alpha = quantileDefaultRelativeAccuracy
+	}
+
+	if maxBins <= 0 {
+		maxBins = quantileDefaultMaxBins
+	}
+
+	gamma := (1 + alpha) / (1 - alpha)
+
+	return &ddSketch{
+		alpha:    alpha,
+		gamma:    gamma,
+		logGamma: math.Log(gamma),
+		maxBins:  maxBins,
+		min:      math.Inf(1),
+		max:      math.Inf(-1),
+	}
+}
+
+func (s *ddSketch) index(v float64) int32 {
+	return int32(math.Ceil(math.Log(v) / s.logGamma))
+}
+
+// value of bin idx, the relative error to any value within the bin is
+// bounded by alpha.
+func (s *ddSketch) value(idx int32) float64 {
+	return 2 * math.Pow(s.gamma, float64(idx)) / (1 + s.gamma)
+}
+
+func (s *ddSketch) addN(v float64, n uint64) {
+	if n == 0 || math.IsNaN(v) || math.IsInf(v, 0) {
+		return
+	}
+
+	switch {
+	case v >= quantileMinIndexableValue:
+		s.pos.add(s.index(v), n, s.maxBins)
+	case v <= -quantileMinIndexableValue:
+		s.neg.add(s.index(-v), n, s.maxBins)
+	default:
+		s.zeroCount += n
+	}
+
+	s.count += n
+	s.sum += v * float64(n)
+	s.min = math.Min(s.min, v)
+	s.max = math.Max(s.max, v)
+}
+
+func (s *ddSketch) add(v float64) {
+	s.addN(v, 1)
+}
+
+func (s *ddSketch) merge(other *ddSketch) {
+	if other == nil || other.count == 0 {
+		return
+	}
+
+	if other.gamma == s.gamma {
+		for i, c := range other.pos.counts {
+			s.pos.add(other.pos.offset+int32(i), c, s.maxBins)
+		}
+		for i, c := range other.neg.counts {
+			s.neg.add(other.neg.offset+int32(i), c, s.maxBins)
+		}
+	} else { // different accuracy: re-add values of bins
+		for i, c := range other.pos.counts {
+			if c > 0 {
+				s.pos.add(s.index(other.value(other.pos.offset+int32(i))), c, s.maxBins)
+			}
+		}
+		for i, c := range other.neg.counts {
+			if c > 0 {
+				s.neg.add(s.index(other.value(other.neg.offset+int32(i))), c, s.maxBins)
+			}
+		}
+	}
+
+	s.zeroCount += other.zeroCount
+	s.count += other.count
+	s.sum += other.sum
+	s.min = math.Min(s.min, other.min)
+	s.max = math.Max(s.max, other.max)
+}
+
+// quantile return value at q(within [0,1]) of all values added.
+func (s *ddSketch) quantile(q float64) float64 {
+	if s.count == 0 {
+		return 0
+	}
+
+	if q <= 0 {
+		return s.min
+	}
+	if q >= 1 {
+		return s.max
+	}
+
+	rank := uint64(q * float64(s.count-1))
+
+	var (
+		v     float64
+		n     uint64
+		found bool
+	)
+
+	// negative values from the most negative one(highest bin)
+	for i := len(s.neg.counts) - 1; i >= 0 && !found; i-- {
+		if n += s.neg.counts[i]; n > rank {
+			v, found = -s.value(s.neg.offset+int32(i)), true
+		}
+	}
+
+	if !found {
+		if n += s.zeroCount; n > rank {
+			v, found = 0, true
+		}
+	}
+
+	for i := 0; i < len(s.pos.counts) && !found; i++ {
+		if n += s.pos.counts[i]; n > rank {
+			v, found = s.value(s.pos.offset+int32(i)), true
+		}
+	}
+
+	if !found {
+		return s.max
+	}
+
+	return math.Max(s.min, math.Min(s.max, v))
+}
+
+func (s *ddSketch) reset() {
+	s.pos = ddBins{}
+	s.neg = ddBins{}
+	s.zeroCount = 0
+	s.count = 0
+	s.sum = 0
+	s.min = math.Inf(1)
+	s.max = math.Inf(-1)
+}
+
+func (s *ddSketch) toProto() *QuantileSketch {
+	pb := &QuantileSketch{
+		RelativeAccuracy: s.alpha,
+		Count:            s.count,
+		Sum:              s.sum,
+		Min:              s.min,
+		Max:              s.max,
+		ZeroCount:        s.zeroCount,
+	}
+
+	pb.PosOffset, pb.PosCounts = s.pos.trimmed()
+	pb.NegOffset, pb.NegCounts = s.neg.trimmed()
+
+	return pb
+}
+
+// ddSketchFromProto rebuild the sketch, bins beyond maxBins collapsed.
+func ddSketchFromProto(pb *QuantileSketch, maxBins int) *ddSketch {
+	s := newDDSketch(pb.RelativeAccuracy, maxBins)
+
+	for i, c := range pb.PosCounts {
+		s.pos.add(pb.PosOffset+int32(i), c, s.maxBins)
+	}
+	for i, c := range pb.NegCounts {
+		s.neg.add(pb.NegOffset+int32(i), c, s.maxBins)
+	}
+
+	s.zeroCount = pb.ZeroCount
+	s.count = pb.Count
+	s.sum = pb.Sum
+	s.min = pb.Min
+	s.max = pb.Max
+
+	return s
+}
@@ -0,0 +1,190 @@
+package aggregate
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	T "testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDDSketchAccuracy(t *T.T) {
+	r := rand.New(rand.NewSource(1)) //nolint:gosec
+
+	var (
+		s    = newDDSketch(0.01, 0)
+		vals []float64
+	)
+
+	for i := 0; i < 10000; i++ {
+		v := math.Exp(r.NormFloat64() * 3)
+		if i%10 == 0 {
+			v = -v
+		}
+		vals = append(vals, v)
+		s.add(v)
+	}
+	s.add(0)
+	vals = append(vals, 0)
+
+	sort.Float64s(vals)
+
+	for _, q := range []float64{0.01, 0.05, 0.25, 0.5, 0.75, 0.9, 0.99, 0.999} {
+		want := vals[int(q*float64(len(vals)-1))]
+		got := s.quantile(q)
+		assert.LessOrEqual(t, math.Abs(got-want), 0.01*math.Abs(want)+1e-9, "q=%v want=%v got=%v", q, want, got)
+	}
+
+	assert.Equal(t, vals[0], s.quantile(0))
+	assert.Equal(t, vals[len(vals)-1], s.quantile(1))
+	assert.Equal(t, uint64(len(vals)), s.count)
+}
+
+func TestDDSketchMerge(t *T.T) {
+	var (
+		all   = newDDSketch(0.02, 0)
+		left  = newDDSketch(0.02, 0)
+		right = newDDSketch(0.02, 0)
+	)
+
+	for i := 1; i <= 1000; i++ {
+		v := float64(i) * 0.37
+		all.add(v)
+		if i%3 == 0 {
+			left.add(v)
+		} else {
+			right.add(v)
+		}
+	}
+
+	left.merge(right)
+	assert.Equal(t, all.pos, left.pos)
+	assert.Equal(t, all.count, left.count)
+	assert.InDelta(t, all.sum, left.sum, 1e-6)
+	assert.Equal(t, all.quantile(0.5), left.quantile(0.5))
+
+	// merge sketch with different accuracy
+	coarse := newDDSketch(0.05, 0)
+	for i := 1; i <= 1000; i++ {
+		coarse.add(float64(i) * 0.37)
+	}
+
+	fine := newDDSketch(0.01, 0)
+	fine.merge(coarse)
+	assert.Equal(t, coarse.count, fine.count)
+	assert.InEpsilon(t, all.quantile(0.5), fine.quantile(0.5), 0.07)
+}
+
+func TestDDSketchMaxBins(t *T.T) {
+	s := newDDSketch(0.01, 64)
+	for i := 0; i < 1000; i++ {
+		s.add(math.Pow(1.1, float64(i%200)))
+	}
+
+	assert.LessOrEqual(t, len(s.pos.counts), 64)
+	assert.Equal(t, uint64(1000), s.pos.total())
+
+	// higher quantiles keep accuracy, lower ones collapsed
+	assert.InEpsilon(t, math.Pow(1.1, 197), s.quantile(0.99), 0.01)
+}
+
+func TestDDSketchProto(t *T.T) {
+	s := newDDSketch(0.01, 0)
+	for _, v := range []float64{-3, 0, 0.5, 1, 100, 1e6} {
+		s.add(v)
+	}
+
+	raw, err := s.toProto().Marshal()
+	require.NoError(t, err)
+
+	var pb QuantileSketch
+	require.NoError(t, pb.Unmarshal(raw))
+
+	x := ddSketchFromProto(&pb, 0)
+	assert.Equal(t, s.toProto(), x.toProto())
+	assert.Equal(t, s.quantile(0.5), x.quantile(0.5))
+
+	// collapsed on restore with less bins
+	x = ddSketchFromProto(&pb, 2)
+	assert.LessOrEqual(t, len(x.pos.counts), 2)
+	assert.Equal(t, s.count, x.count)
+}
+
+func TestQuantileSketchAggregate(t *T.T) {
+	now := time.Unix(1710000000, 0)
+
+	cfg := &AggregatorConfigure{
+		DefaultWindow: time.Second * 10,
+		AggregateRules: []*AggregateRule{
+			{
+				Name:    "quantiles",
+				Groupby: []string{"service"},
+				Selector: &RuleSelector{
+					Category:   point.Metric.String(),
+					MetricName: []string{"latency"},
+				},
+				Algorithms: map[string]*AggregationAlgoConfig{
+					"latency": {
+						Method:       string(QUANTILES),
+						QuantileOpts: &QuantileOptions{Percentiles: []float64{0.5, 0.99}, EmitSketch: true},
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, cfg.Setup())
+
+	// sketch from upstream aggregator
+	upstream := newAlgoQuantiles(MetricBase{key: "latency", name: "request"}, now.UnixNano(),
+		&QuantileOptions{Percentiles: []float64{0.5}, EmitSketch: true})
+	for i := 1; i <= 50; i++ {
+		upstream.addValue(float64(i))
+	}
+
+	upPts, err := upstream.Aggr()
+	require.NoError(t, err)
+	require.NotNil(t, upPts[0].Get("latency_sketch"))
+
+	spt := upPts[0]
+	spt.SetTag("service", "checkout")
+
+	pts := []*point.Point{spt}
+	for i := 51; i <= 100; i++ {
+		pts = append(pts, point.NewPoint("request",
+			point.NewTags(map[string]string{"service": "checkout"}).Add("latency", float64(i)),
+			point.WithTime(now)))
+	}
+
+	selected := cfg.SelectPoints(pts)
+	require.Len(t, selected, 1)
+	require.Len(t, selected[0], 51)
+
+	var res *algoQuantiles
+	for _, b := range cfg.AggregateRules[0].GroupbyBatch(cfg, selected[0]) {
+		for _, calc := range newCalculators(b) {
+			c, ok := calc.(*algoQuantiles)
+			require.True(t, ok)
+			if res == nil {
+				res = c
+			} else {
+				require.Equal(t, res.hash, c.hash)
+				res.Add(c)
+			}
+		}
+	}
+
+	require.NotNil(t, res)
+	assert.Equal(t, int64(100), res.count())
+	assert.InEpsilon(t, 50.0, res.GetPercentile(50), quantileDefaultRelativeAccuracy)
+	assert.InEpsilon(t, 99.0, res.GetPercentile(99), quantileDefaultRelativeAccuracy)
+
+	out, err := res.Aggr()
+	require.NoError(t, err)
+	require.Len(t, out, 1)
+	assert.Equal(t, int64(100), out[0].Get("latency_count"))
+	assert.NotNil(t, out[0].Get("latency_sketch"))
+}
@@ -21,6 +21,7 @@ type mockSpiller struct {
 	getCalls    int
 	deleteCalls int
 	failGet     bool
+	onGet       func(key string)
 }
 
 func newMockSpiller() *mockSpiller {
@@ -40,6 +41,9 @@ func (m *mockSpiller) Get(key string) ([]byte, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.getCalls++
+	if m.onGet != nil {
+		m.onGet(key)
+	}
 	if m.failGet {
 		return nil, fmt.Errorf("key %s read failed", key)
 	}
@@ -27,11 +27,24 @@ type (
 	PipelineAction string
 )
 
+// DerivedMetric is a user-defined metric evaluated on each point(span or log)
+// of the grouped data, see tail_sampling_derived_metrics.go.
 type DerivedMetric struct {
 	Name      string     `toml:"name" json:"name"`
-	Type      AlgoMethod `toml:"type" json:"type"`
+	Type      AlgoMethod `toml:"type" json:"type"` // count/sum/histogram
 	Condition string     `toml:"condition" json:"condition"`
 	Groupby   []string   `toml:"group_by" json:"group_by"`
+
+	// Field is the point field to sum or observe, not required for count.
+	Field string `toml:"field,omitempty" json:"field,omitempty"`
+
+	// Stage is one of ingest(default), pre_decision or decision.
+	Stage DerivedMetricStage `toml:"stage,omitempty" json:"stage,omitempty"`
+
+	// Buckets are upper bounds of histogram buckets.
+	Buckets []float64 `toml:"buckets,omitempty" json:"buckets,omitempty"`
+
+	conds fp.WhereConditions
 }
 
 type SamplingPipeline struct {
@@ -380,8 +393,8 @@ func (t *TailSamplingConfigs) Init() error {
 				errs = append(errs, fmt.Sprintf("trace pipeline: %s", err))
 			}
 		}
-		if len(t.Tracing.DerivedMetrics) > 0 {
-			errs = append(errs, "trace derived_metrics is not supported yet")
+		for _, err := range validateDerivedMetrics(t.Tracing.DerivedMetrics, traceBuiltinMetricNames()) {
+			errs = append(errs, fmt.Sprintf("trace derived_metrics: %s", err))
 		}
 		t.Tracing.BuiltinMetrics = initBuiltinMetricCfgs(t.Tracing.BuiltinMetrics, traceBuiltinMetricNames())
 	}
@@ -404,8 +417,8 @@ func (t *TailSamplingConfigs) Init() error {
 					errs = append(errs, fmt.Sprintf("logging group %q pipeline: %s", group.GroupKey, err))
 				}
 			}
-			if len(group.DerivedMetrics) > 0 {
-				errs = append(errs, fmt.Sprintf("logging group %q derived_metrics is not supported yet", group.GroupKey))
+			for _, err := range validateDerivedMetrics(group.DerivedMetrics, loggingBuiltinMetricNames()) {
+				errs = append(errs, fmt.Sprintf("logging group %q derived_metrics: %s", group.GroupKey, err))
 			}
 		}
 		t.Logging.BuiltinMetrics = initBuiltinMetricCfgs(t.Logging.BuiltinMetrics, loggingBuiltinMetricNames())
@@ -429,8 +442,8 @@ func (t *TailSamplingConfigs) Init() error {
 					errs = append(errs, fmt.Sprintf("rum group %q pipeline: %s", group.GroupKey, err))
 				}
 			}
-			if len(group.DerivedMetrics) > 0 {
-				errs = append(errs, fmt.Sprintf("rum group %q derived_metrics is not supported yet", group.GroupKey))
+			for _, err := range validateDerivedMetrics(group.DerivedMetrics, rumBuiltinMetricNames()) {
+				errs = append(errs, fmt.Sprintf("rum group %q derived_metrics: %s", group.GroupKey, err))
 			}
 		}
 		t.RUM.BuiltinMetrics = initBuiltinMetricCfgs(t.RUM.BuiltinMetrics, rumBuiltinMetricNames())
@@ -823,8 +836,8 @@ func derivedMetricNames(metrics []*DerivedMetric) string {
 			items = append(items, "<nil>")
 			continue
 		}
-		items = append(items, fmt.Sprintf("{name=%q,type=%q,condition=%q,group_by=%v}",
-			metric.Name, metric.Type.String(), metric.Condition, metric.Groupby))
+		items = append(items, fmt.Sprintf("{name=%q,type=%q,condition=%q,group_by=%v,field=%q,stage=%q}",
+			metric.Name, metric.Type.String(), metric.Condition, metric.Groupby, metric.Field, metric.Stage))
 	}
 
 	return "[" + strings.Join(items, ", ") + "]"
@@ -528,7 +528,7 @@ func TestTailSamplingConfigs_Init(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "config with unsupported derived metrics",
+			name: "config with invalid derived metrics",
 			config: &TailSamplingConfigs{
 				Version: 1,
 				Tracing: &TraceTailSampling{
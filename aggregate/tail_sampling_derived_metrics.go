@@ -0,0 +1,218 @@
+package aggregate
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	fp "github.com/GuanceCloud/cliutils/filter"
+)
+
+// Apply parse the condition of the derived metric.
+func (m *DerivedMetric) Apply() error {
+	if m == nil {
+		return nil
+	}
+	if m.Condition == "" {
+		m.conds = nil
+		return nil
+	}
+
+	if ast, err := fp.GetConds(m.Condition); err != nil {
+		return err
+	} else {
+		m.conds = ast
+		return nil
+	}
+}
+
+func (m *DerivedMetric) stage() DerivedMetricStage {
+	if m.Stage == "" {
+		return DerivedMetricStageIngest
+	}
+	return m.Stage
+}
+
+func validateDerivedMetric(m *DerivedMetric) error {
+	if m == nil {
+		return fmt.Errorf("derived metric is nil")
+	}
+
+	if m.Name == "" {
+		return fmt.Errorf("derived metric missing name")
+	}
+
+	switch m.Type {
+	case COUNT:
+	case SUM:
+		if m.Field == "" {
+			return fmt.Errorf("derived metric %q of type %q missing field", m.Name, m.Type)
+		}
+	case HISTOGRAM:
+		if m.Field == "" {
+			return fmt.Errorf("derived metric %q of type %q missing field", m.Name, m.Type)
+		}
+		if len(m.Buckets) == 0 {
+			return fmt.Errorf("derived metric %q missing buckets", m.Name)
+		}
+		if !sort.Float64sAreSorted(m.Buckets) {
+			return fmt.Errorf("derived metric %q buckets not sorted", m.Name)
+		}
+	default:
+		return fmt.Errorf("derived metric %q has invalid type %q", m.Name, m.Type)
+	}
+
+	switch m.Stage {
+	case "", DerivedMetricStageIngest, DerivedMetricStagePreDecision, DerivedMetricStageDecision:
+	default:
+		return fmt.Errorf("derived metric %q has invalid stage %q", m.Name, m.Stage)
+	}
+
+	if err := m.Apply(); err != nil {
+		return fmt.Errorf("derived metric %q invalid condition: %w", m.Name, err)
+	}
+
+	return nil
+}
+
+// validateDerivedMetrics check derived metrics of a data type(or a group
+// dimension), their names should be unique and not conflict with builtin ones.
+func validateDerivedMetrics(metrics []*DerivedMetric, builtinNames []string) []error {
+	var (
+		errs  []error
+		names = map[string]bool{}
+	)
+
+	for _, name := range builtinNames {
+		names[name] = true
+	}
+
+	for _, m := range metrics {
+		if err := validateDerivedMetric(m); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if names[m.Name] {
+			errs = append(errs, fmt.Errorf("derived metric %q duplicated", m.Name))
+			continue
+		}
+		names[m.Name] = true
+	}
+
+	return errs
+}
+
+// customDerivedMetricRecords evaluate derived metrics of the stage on each
+// point of the packet.
+func customDerivedMetricRecords(
+	packet *DataPacket,
+	metrics []*DerivedMetric,
+	stage DerivedMetricStage,
+	decision DerivedMetricDecision,
+) []DerivedMetricRecord {
+	if packet == nil || len(packet.PointsPayload) == 0 {
+		return nil
+	}
+
+	var staged []*DerivedMetric
+	for _, m := range metrics {
+		if m != nil && m.stage() == stage {
+			staged = append(staged, m)
+		}
+	}
+
+	if len(staged) == 0 {
+		return nil
+	}
+
+	var (
+		records []DerivedMetricRecord
+		ptw     = &ptWrap{}
+	)
+
+	walkErr := packet.WalkRawPBPoints(func(raw []byte) bool {
+		if err := ptw.Reset(raw); err != nil {
+			l.Errorf("decode datapacket point failed: %v", err)
+			return false
+		}
+
+		for _, m := range staged {
+			if record, ok := m.record(ptw, packet, stage, decision); ok {
+				records = append(records, record)
+			}
+		}
+
+		return true
+	})
+	if walkErr != nil {
+		l.Errorf("walk datapacket payload failed: %v", walkErr)
+	}
+
+	return records
+}
+
+func (m *DerivedMetric) record(
+	ptw *ptWrap,
+	packet *DataPacket,
+	stage DerivedMetricStage,
+	decision DerivedMetricDecision,
+) (DerivedMetricRecord, bool) {
+	if m.conds != nil && m.conds.Eval(ptw) < 0 {
+		return DerivedMetricRecord{}, false
+	}
+
+	value := 1.0
+	if m.Type != COUNT {
+		v, ok := ptw.Get(m.Field)
+		if !ok {
+			return DerivedMetricRecord{}, false
+		}
+
+		if value, ok = derivedFieldValue(v); !ok {
+			return DerivedMetricRecord{}, false
+		}
+	}
+
+	tags := builtinRecordTags(packet)
+	for _, k := range m.Groupby {
+		if v, ok := ptw.Get(k); ok {
+			if s := fieldToString(v); s != "" {
+				tags[k] = s
+			}
+		}
+	}
+
+	if m.Type == HISTOGRAM {
+		return newHistogramDerivedMetricRecord(packet, m.Name, stage, decision, value, tags, m.Buckets), true
+	}
+
+	record := newDerivedMetricRecord(packet, m.Name, stage, decision, value)
+	record.Tags = tags
+	return record, true
+}
+
+func derivedFieldValue(v any) (float64, bool) {
+	var f float64
+
+	switch x := v.(type) {
+	case float64:
+		f = x
+	case int64:
+		f = float64(x)
+	case uint64:
+		f = float64(x)
+	case bool:
+		if x {
+			f = 1
+		}
+	default: // string/bytes are not numeric
+		return 0, false
+	}
+
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, false
+	}
+
+	return f, true
+}
@@ -0,0 +1,289 @@
+package aggregate
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateDerivedMetrics(t *testing.T) {
+	cases := []struct {
+		name    string
+		metric  *DerivedMetric
+		wantErr string
+	}{
+		{name: "nil", wantErr: "derived metric is nil"},
+		{name: "no-name", metric: &DerivedMetric{Type: COUNT}, wantErr: "missing name"},
+		{name: "bad-type", metric: &DerivedMetric{Name: "x", Type: AVG}, wantErr: "invalid type"},
+		{name: "sum-no-field", metric: &DerivedMetric{Name: "x", Type: SUM}, wantErr: "missing field"},
+		{name: "hist-no-field", metric: &DerivedMetric{Name: "x", Type: HISTOGRAM, Buckets: []float64{1}}, wantErr: "missing field"},
+		{name: "hist-no-buckets", metric: &DerivedMetric{Name: "x", Type: HISTOGRAM, Field: "duration"}, wantErr: "missing buckets"},
+		{name: "hist-unsorted", metric: &DerivedMetric{Name: "x", Type: HISTOGRAM, Field: "duration", Buckets: []float64{10, 1}}, wantErr: "not sorted"},
+		{name: "bad-stage", metric: &DerivedMetric{Name: "x", Type: COUNT, Stage: "bad"}, wantErr: "invalid stage"},
+		{name: "bad-condition", metric: &DerivedMetric{Name: "x", Type: COUNT, Condition: "{ bad"}, wantErr: "invalid condition"},
+		{name: "builtin-name", metric: &DerivedMetric{Name: "trace_total_count", Type: COUNT}, wantErr: "duplicated"},
+		{name: "ok", metric: &DerivedMetric{Name: "x", Type: COUNT, Condition: `{ status = "error" }`}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validateDerivedMetrics([]*DerivedMetric{tc.metric}, traceBuiltinMetricNames())
+			if tc.wantErr == "" {
+				assert.Empty(t, errs)
+				assert.NotNil(t, tc.metric.conds)
+				return
+			}
+
+			require.Len(t, errs, 1)
+			assert.Contains(t, errs[0].Error(), tc.wantErr)
+		})
+	}
+
+	errs := validateDerivedMetrics([]*DerivedMetric{
+		{Name: "x", Type: COUNT},
+		{Name: "x", Type: COUNT},
+	}, nil)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), `"x" duplicated`)
+
+	cfg := &TailSamplingConfigs{
+		Logging: &LoggingTailSampling{GroupDimensions: []*LoggingGroupDimension{
+			{GroupKey: "user", DerivedMetrics: []*DerivedMetric{{Name: "logging_total_count", Type: COUNT}}},
+		}},
+	}
+	err := cfg.Init()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `logging group "user" derived_metrics`)
+}
+
+func TestTailSamplingProcessorRecordsCustomDerivedMetrics(t *testing.T) {
+	now := time.Unix(1710000000, 0)
+
+	span := func(id, service, status string, duration int64) *point.Point {
+		return point.NewPoint("span", point.KVs{}.
+			Add("trace_id", "trace-1").
+			Add("span_id", id).
+			AddTag("service", service).
+			AddTag("status", status).
+			Add("duration", duration),
+			point.WithTime(now))
+	}
+
+	processor := NewDefaultTailSamplingProcessor(2, time.Second)
+	require.NoError(t, processor.UpdateConfig("token-a", &TailSamplingConfigs{
+		Version: 1,
+		Tracing: &TraceTailSampling{
+			DataTTL: time.Second,
+			DerivedMetrics: []*DerivedMetric{
+				{
+					Name:      "error_span_count",
+					Type:      COUNT,
+					Condition: `{ status = "error" }`,
+					Groupby:   []string{"service"},
+				},
+				{
+					Name:    "span_duration",
+					Type:    HISTOGRAM,
+					Field:   "duration",
+					Stage:   DerivedMetricStagePreDecision,
+					Buckets: []float64{10, 100},
+				},
+				{
+					Name:    "decided_span_duration",
+					Type:    SUM,
+					Field:   "duration",
+					Groupby: []string{"service"},
+					Stage:   DerivedMetricStageDecision,
+				},
+			},
+			Pipelines: []*SamplingPipeline{
+				{Name: "keep_all", Type: PipelineTypeSampling, Rate: 1},
+			},
+		},
+	}))
+
+	grouped := PickTrace("ddtrace", []*point.Point{
+		span("1", "checkout", "error", 5),
+		span("2", "checkout", "error", 50),
+		span("3", "cart", "ok", 500),
+	}, 1)
+	require.Len(t, grouped, 1)
+
+	for _, packet := range grouped {
+		packet.Token = "token-a"
+		processor.IngestPacket(packet)
+	}
+
+	expired := processor.AdvanceTime()
+	require.Len(t, expired, 1)
+	require.Len(t, processor.TailSamplingData(expired), 1)
+
+	pointsByToken := processor.FlushDerivedMetrics(now.Add(time.Minute))
+	require.Len(t, pointsByToken, 1)
+
+	var (
+		errorCount = map[string]any{}
+		decided    = map[string]any{}
+		buckets    = map[string]any{}
+	)
+
+	for _, pt := range pointsByToken[0].PTS {
+		if v := pt.Get("error_span_count"); v != nil {
+			assert.Equal(t, string(DerivedMetricStageIngest), pt.GetTag("stage"))
+			errorCount[pt.GetTag("service")] = v
+		}
+
+		if v := pt.Get("decided_span_duration"); v != nil {
+			assert.Equal(t, string(DerivedMetricDecisionKept), pt.GetTag("decision"))
+			decided[pt.GetTag("service")] = v
+		}
+
+		if v := pt.Get("span_duration_bucket"); v != nil {
+			assert.Equal(t, string(DerivedMetricStagePreDecision), pt.GetTag("stage"))
+			buckets[pt.GetTag("le")] = v
+		}
+
+		if v := pt.Get("span_duration_sum"); v != nil {
+			assert.Equal(t, 555.0, v)
+		}
+	}
+
+	assert.Equal(t, map[string]any{"checkout": 2.0}, errorCount)
+	assert.Equal(t, map[string]any{"checkout": 55.0, "cart": 500.0}, decided)
+	assert.Len(t, buckets, 3)
+	assert.Equal(t, 1.0, buckets["10"])
+	assert.Equal(t, 2.0, buckets["100"])
+	assert.Equal(t, 3.0, buckets["+Inf"])
+}
+
+func TestCustomDerivedMetricsOnSpilledPacket(t *testing.T) {
+	const token = "tkn_spill_derived"
+
+	spiller := newMockSpiller()
+	processor := NewDefaultTailSamplingProcessor(1, time.Second)
+	processor.Sampler().SetPayloadSpiller(spiller, 16)
+
+	require.NoError(t, processor.UpdateConfig(token, &TailSamplingConfigs{
+		Version: 1,
+		Tracing: &TraceTailSampling{
+			DataTTL: time.Second,
+			DerivedMetrics: []*DerivedMetric{
+				{Name: "dropped_span_count", Type: COUNT, Stage: DerivedMetricStageDecision},
+			},
+			Pipelines: []*SamplingPipeline{
+				{Name: "drop-all", Type: PipelineTypeSampling, Rate: 0.000001},
+			},
+		},
+	}))
+
+	now := time.Now()
+	grouped := PickTrace("ddtrace", []*point.Point{
+		point.NewPoint("span", point.KVs{}.Add("trace_id", "trace-spill").Add("span_id", "1"), point.WithTime(now)),
+		point.NewPoint("span", point.KVs{}.Add("trace_id", "trace-spill").Add("span_id", "2"), point.WithTime(now)),
+	}, 1)
+	for _, packet := range grouped {
+		packet.Token = token
+		processor.IngestPacket(packet)
+	}
+
+	expired := processor.AdvanceTime()
+	require.Len(t, expired, 1)
+	for _, dg := range expired {
+		require.NotEmpty(t, dg.spillKey)
+	}
+
+	outcomes := processor.TailSamplingOutcomes(expired)
+	require.Len(t, outcomes, 1)
+	assert.Equal(t, 1, spiller.getCount())
+	assert.Equal(t, 1, spiller.deleteCount())
+
+	var dropped any
+	for _, res := range processor.FlushDerivedMetrics(now.Add(time.Minute)) {
+		for _, pt := range res.PTS {
+			if v := pt.Get("dropped_span_count"); v != nil {
+				dropped = v
+				assert.Equal(t, string(DerivedMetricDecisionDropped), pt.GetTag("decision"))
+			}
+		}
+	}
+	assert.Equal(t, 2.0, dropped)
+}
+
+func TestCustomDerivedMetricsHydrateSpillOneByOne(t *testing.T) {
+	const token = "tkn_spill_one_by_one"
+
+	spiller := newMockSpiller()
+	processor := NewDefaultTailSamplingProcessor(1, time.Second)
+	processor.Sampler().SetPayloadSpiller(spiller, 16)
+
+	require.NoError(t, processor.UpdateConfig(token, &TailSamplingConfigs{
+		Version: 1,
+		Tracing: &TraceTailSampling{
+			DataTTL: time.Second,
+			DerivedMetrics: []*DerivedMetric{
+				{Name: "pre_span_count", Type: COUNT, Stage: DerivedMetricStagePreDecision},
+				{Name: "dropped_span_count", Type: COUNT, Stage: DerivedMetricStageDecision},
+			},
+			Pipelines: []*SamplingPipeline{
+				{Name: "drop-all", Type: PipelineTypeSampling, Rate: 0.000001},
+			},
+		},
+	}))
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		traceID := fmt.Sprintf("trace-%d", i)
+		grouped := PickTrace("ddtrace", []*point.Point{
+			point.NewPoint("span", point.KVs{}.Add("trace_id", traceID).Add("span_id", "1"), point.WithTime(now)),
+			point.NewPoint("span", point.KVs{}.Add("trace_id", traceID).Add("span_id", "2"), point.WithTime(now)),
+		}, 1)
+		for _, packet := range grouped {
+			packet.Token = token
+			processor.IngestPacket(packet)
+		}
+	}
+
+	expired := processor.AdvanceTime()
+	require.Len(t, expired, 5)
+
+	var packets []*DataPacket
+	for _, dg := range expired {
+		require.NotEmpty(t, dg.spillKey)
+		packets = append(packets, dg.packet)
+	}
+
+	// Before reading back a spilled payload, payloads of other groups should
+	// have been released.
+	maxResident := 0
+	spiller.onGet = func(string) {
+		resident := 0
+		for _, packet := range packets {
+			if len(packet.PointsPayload) > 0 {
+				resident++
+			}
+		}
+		maxResident = max(maxResident, resident)
+	}
+
+	outcomes := processor.TailSamplingOutcomes(expired)
+	require.Len(t, outcomes, 5)
+	assert.Equal(t, 5, spiller.getCount())
+	assert.Equal(t, 5, spiller.deleteCount())
+	assert.Zero(t, maxResident)
+
+	got := map[string]any{}
+	for _, res := range processor.FlushDerivedMetrics(now.Add(time.Minute)) {
+		for _, pt := range res.PTS {
+			for _, name := range []string{"pre_span_count", "dropped_span_count"} {
+				if v := pt.Get(name); v != nil {
+					got[name] = v
+				}
+			}
+		}
+	}
+	assert.Equal(t, map[string]any{"pre_span_count": 10.0, "dropped_span_count": 10.0}, got)
+}
@@ -99,8 +99,8 @@ func (r *TailSamplingProcessor) IngestPacket(packet *DataPacket) {
 		return
 	}
 
-	if r.collector != nil && len(r.metrics) > 0 {
-		r.collector.Add(r.filterBuiltinRecords(packet, r.metrics.OnIngest(packet)))
+	if r.collector != nil {
+		r.collector.Add(r.derivedRecords(packet, DerivedMetricStageIngest, DerivedMetricDecisionUnknown))
 	}
 
 	if r.sampler != nil {
@@ -139,41 +139,27 @@ func (r *TailSamplingProcessor) TailSamplingOutcomes(dataGroups map[uint64]*Data
 		return nil
 	}
 
-	if r.collector != nil && len(r.metrics) > 0 {
-		for _, dg := range dataGroups {
-			if dg == nil || dg.packet == nil {
-				continue
-			}
-			r.collector.Add(r.filterBuiltinRecords(dg.packet, r.metrics.OnPreDecision(dg.packet)))
-		}
-	}
-
-	outcomes := r.sampler.TailSamplingOutcomes(dataGroups)
-
-	if r.collector == nil || len(r.metrics) == 0 {
-		return outcomes
-	}
-
-	for _, outcome := range outcomes {
-		if outcome == nil {
-			continue
-		}
-
-		packetForMetrics := outcome.SourcePacket
-		if packetForMetrics != nil {
-			r.collector.Add(r.filterBuiltinRecords(packetForMetrics, r.metrics.OnDecision(packetForMetrics, outcome.Decision)))
-		}
+	if r.collector == nil {
+		return r.sampler.TailSamplingOutcomes(dataGroups)
 	}
 
-	return outcomes
+	return r.sampler.tailSamplingOutcomes(dataGroups,
+		func(packet *DataPacket) {
+			r.collector.Add(r.derivedRecords(packet, DerivedMetricStagePreDecision, DerivedMetricDecisionUnknown))
+		},
+		func(outcome *TailSamplingOutcome) {
+			if outcome.SourcePacket != nil {
+				r.collector.Add(r.derivedRecords(outcome.SourcePacket, DerivedMetricStageDecision, outcome.Decision))
+			}
+		})
 }
 
 func (r *TailSamplingProcessor) RecordDecision(packet *DataPacket, decision DerivedMetricDecision) {
-	if r == nil || packet == nil || r.collector == nil || len(r.metrics) == 0 {
+	if r == nil || packet == nil || r.collector == nil {
 		return
 	}
 
-	r.collector.Add(r.filterBuiltinRecords(packet, r.metrics.OnDecision(packet, decision)))
+	r.collector.Add(r.derivedRecords(packet, DerivedMetricStageDecision, decision))
 }
 
 func (r *TailSamplingProcessor) FlushDerivedMetrics(now time.Time) []*DerivedMetricPoints {
@@ -184,6 +170,34 @@ func (r *TailSamplingProcessor) FlushDerivedMetrics(now time.Time) []*DerivedMet
 	return r.collector.Flush(now)
 }
 
+// derivedRecords collects records of both builtin and custom derived metrics
+// on the stage.
+func (r *TailSamplingProcessor) derivedRecords(
+	packet *DataPacket,
+	stage DerivedMetricStage,
+	decision DerivedMetricDecision,
+) []DerivedMetricRecord {
+	var records []DerivedMetricRecord
+
+	switch stage {
+	case DerivedMetricStageIngest:
+		records = r.metrics.OnIngest(packet)
+	case DerivedMetricStagePreDecision:
+		records = r.metrics.OnPreDecision(packet)
+	case DerivedMetricStageDecision:
+		records = r.metrics.OnDecision(packet, decision)
+	}
+
+	records = r.filterBuiltinRecords(packet, records)
+
+	if r.sampler != nil {
+		records = append(records, customDerivedMetricRecords(packet,
+			r.sampler.derivedMetricsFor(packet.DataType, packet.Token, packet.GroupKey), stage, decision)...)
+	}
+
+	return records
+}
+
 func (r *TailSamplingProcessor) filterBuiltinRecords(packet *DataPacket, records []DerivedMetricRecord) []DerivedMetricRecord {
 	if len(records) == 0 || packet == nil {
 		return records
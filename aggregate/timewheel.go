@@ -436,27 +436,48 @@ func (s *GlobalSampler) AdvanceTime() map[uint64]*DataGroup {
 }
 
 func (s *GlobalSampler) TailSamplingOutcomes(dataGroups map[uint64]*DataGroup) map[uint64]*TailSamplingOutcome {
+	return s.tailSamplingOutcomes(dataGroups, nil, nil)
+}
+
+// tailSamplingOutcomes decides the data groups one by one. preDecision is
+// called on each source packet before its decision and decided on each
+// outcome, both within the loop, so that a spilled payload read back for
+// derived metrics is resident only while its own group is decided.
+func (s *GlobalSampler) tailSamplingOutcomes(dataGroups map[uint64]*DataGroup,
+	preDecision func(*DataPacket),
+	decided func(*TailSamplingOutcome),
+) map[uint64]*TailSamplingOutcome {
 	outcomes := make(map[uint64]*TailSamplingOutcome, len(dataGroups))
 	for key, dg := range dataGroups {
 		if dg == nil || dg.packet == nil {
 			outcomes[key] = &TailSamplingOutcome{Decision: DerivedMetricDecisionDropped}
+			if decided != nil {
+				decided(outcomes[key])
+			}
 			continue
 		}
 
 		sourcePacket := dg.packet
 
 		// Spilled packets are read back only when the decision needs payload
-		// content (pipelines not coverable by predicates) or when the predicate
-		// summary is untrusted (all-zero: legacy data / hand-crafted). Disk data
-		// is released uniformly after the decision.
+		// content (pipelines not coverable by predicates), when the predicate
+		// summary is untrusted (all-zero: legacy data / hand-crafted), or when
+		// derived metrics are evaluated on the payload. Disk data is released
+		// uniformly after the decision.
 		spillKey := dg.spillKey
 		if spillKey != "" && (s.needsPayloadForDecision(dg.dataType, sourcePacket.Token, sourcePacket.GroupKey) ||
-			!packetHasSpanPredicates(sourcePacket)) {
+			!packetHasSpanPredicates(sourcePacket) ||
+			(preDecision != nil || decided != nil) &&
+				s.needsPayloadForDerivedMetrics(dg.dataType, sourcePacket.Token, sourcePacket.GroupKey)) {
 			if err := s.hydrateDataGroup(dg); err != nil {
 				l.Errorf("hydrate spill payload for decision failed: %v", err)
 			}
 		}
 
+		if preDecision != nil {
+			preDecision(sourcePacket)
+		}
+
 		decision := DerivedMetricDecisionDropped
 		var keptPacket *DataPacket
 
@@ -501,6 +522,16 @@ func (s *GlobalSampler) TailSamplingOutcomes(dataGroups map[uint64]*DataGroup) m
 			Decision:     decision,
 		}
 
+		if decided != nil {
+			decided(outcomes[key])
+		}
+
+		// Dropped payload read back from disk is no longer needed, do not
+		// keep it resident until all groups are decided.
+		if spillKey != "" && keptPacket == nil {
+			sourcePacket.PointsPayload = nil
+		}
+
 		dg.Reset()
 		dataGroupPool.Put(dg)
 	}
@@ -534,6 +565,45 @@ func (s *GlobalSampler) pipelinesFor(dataType, token, groupKey string) []*Sampli
 	return nil
 }
 
+// derivedMetricsFor returns the custom derived metrics by data type / group dimension.
+func (s *GlobalSampler) derivedMetricsFor(dataType, token, groupKey string) []*DerivedMetric {
+	switch dataType {
+	case point.STracing:
+		if cfg := s.GetTraceConfig(token); cfg != nil {
+			return cfg.DerivedMetrics
+		}
+	case point.SLogging:
+		if cfg := s.GetLoggingConfig(token); cfg != nil {
+			for _, group := range cfg.GroupDimensions {
+				if group != nil && group.GroupKey == groupKey {
+					return group.DerivedMetrics
+				}
+			}
+		}
+	case point.SRUM:
+		if cfg := s.GetRUMConfig(token); cfg != nil {
+			for _, group := range cfg.GroupDimensions {
+				if group != nil && group.GroupKey == groupKey {
+					return group.DerivedMetrics
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// needsPayloadForDerivedMetrics reports whether derived metrics of
+// pre-decision or decision stage are configured, these metrics are evaluated
+// on the payload content.
+func (s *GlobalSampler) needsPayloadForDerivedMetrics(dataType, token, groupKey string) bool {
+	for _, m := range s.derivedMetricsFor(dataType, token, groupKey) {
+		if m != nil && m.stage() != DerivedMetricStageIngest {
+			return true
+		}
+	}
+	return false
+}
+
 // decideWithPredicates decides pipelines via span predicates without reading
 // the payload. All pipelines must be compilable; otherwise no match is returned.
 func decideWithPredicates(sourcePacket *DataPacket, pipelines []*SamplingPipeline) (bool, *DataPacket) {
@@ -55,6 +55,10 @@ type Windows struct {
 	WS []*Window
 }
 
+func newWindows() *Windows {
+	return &Windows{IDs: make(map[string]int), WS: make([]*Window, 0)}
+}
+
 func (ws *Windows) AddCal(token string, cal Calculator) {
 	_ = ws.addCal(token, cal)
 }
@@ -100,12 +104,24 @@ type Cache struct {
 	WindowsBuckets map[int64]*Windows
 
 	Expired time.Duration
+
+	// event-time windows of rules, see windows_event_time.go.
+	eventTimes  map[eventTimeKey]*eventTimeWindows
+	corrections map[int64]*Windows // keyed by end of the closed window
+	sideOutput  map[string][]*point.PBPoint
+
+	// panes of hopping/sliding windows, see windows_sliding.go.
+	panes map[paneKey]*paneSeries
 }
 
 func NewCache(exp time.Duration) *Cache {
 	return &Cache{
 		WindowsBuckets: make(map[int64]*Windows),
 		Expired:        exp,
+		eventTimes:     make(map[eventTimeKey]*eventTimeWindows),
+		corrections:    make(map[int64]*Windows),
+		sideOutput:     make(map[string][]*point.PBPoint),
+		panes:          make(map[paneKey]*paneSeries),
 	}
 }
 
@@ -118,7 +134,7 @@ func (c *Cache) getAndSetBucket(exp int64, token string, cal Calculator) bool {
 		c.lock.Lock()
 		ws, ok := c.WindowsBuckets[exp]
 		if !ok {
-			ws = &Windows{IDs: make(map[string]int), WS: make([]*Window, 0)}
+			ws = newWindows()
 			c.WindowsBuckets[exp] = ws
 		}
 		c.lock.Unlock()
@@ -133,6 +149,10 @@ func (c *Cache) getAndSetBucket(exp int64, token string, cal Calculator) bool {
 }
 
 func (c *Cache) AddBatch(token string, batch *AggregationBatch) (n, expN int) {
+	if batch.GetEventTime() != nil {
+		return c.addEventTimeBatch(token, batch)
+	}
+
 	nowTime := time.Now().Unix()
 	for _, cal := range newCalculators(batch) {
 		exp := cal.Base().nextWallTime + int64(c.Expired/time.Second)
@@ -170,7 +190,8 @@ func (c *Cache) GetExpWidows() []*Window {
 		}
 	}
 
-	return wss
+	wss = c.addPanes(append(wss, c.getEventTimeWindows(now)...))
+	return append(wss, c.getSlideWindows(now)...)
 }
 
 type PointsData struct {
@@ -181,7 +202,11 @@ type PointsData struct {
 func WindowsToData(ws []*Window) []*PointsData {
 	pds := make([]*PointsData, 0)
 	for _, window := range ws {
-		var pts []*point.Point
+		var (
+			pts    []*point.Point
+			groups = exprGroups{}
+		)
+
 		for _, cal := range window.cache {
 			pbs, err := cal.Aggr()
 			if err != nil {
@@ -189,7 +214,9 @@ func WindowsToData(ws []*Window) []*PointsData {
 				continue
 			}
 			pts = append(pts, pbs...)
+			groups.add(cal, pbs)
 		}
+		pts = append(pts, groups.points()...)
 		if len(pts) == 0 {
 			window.Reset()
 			windowPool.Put(window)
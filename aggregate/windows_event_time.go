@@ -0,0 +1,291 @@
+package aggregate
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+)
+
+// Event-time windowing: windows of a rule(on each token) are closed by the
+// watermark, which is the max point time observed minus allowed lateness,
+// instead of wall clock. So backfilled and delayed data aggregated into
+// windows by their own time. Points of windows already closed are late data,
+// handled by the rule's late policy.
+
+const (
+	// late policies.
+	LatePolicyDrop       = "drop"
+	LatePolicyCorrection = "correction"
+	LatePolicySideOutput = "side_output"
+
+	// CorrectionTag is the tag added to correction points(aggregated from
+	// late points) under late policy correction.
+	CorrectionTag = "aggr_correction"
+)
+
+func validateEventTime(opts *EventTimeOptions) error {
+	if opts == nil {
+		return nil
+	}
+
+	if opts.AllowedLateness < 0 {
+		return fmt.Errorf("event_time: allowed_lateness %d should not be negative", opts.AllowedLateness)
+	}
+
+	switch opts.LatePolicy {
+	case "", LatePolicyDrop, LatePolicyCorrection, LatePolicySideOutput:
+	default:
+		return fmt.Errorf("event_time: invalid late_policy %q", opts.LatePolicy)
+	}
+
+	return nil
+}
+
+type eventTimeKey struct {
+	token, rule string
+}
+
+// eventTimeWindows are the event-time windows of a rule on token.
+type eventTimeWindows struct {
+	opts *EventTimeOptions
+
+	// max point time(unix nanoseconds) observed.
+	maxTime int64
+
+	// wall time(unix seconds) of last batch added, windows of rule idle
+	// for long flushed even watermark not passed.
+	lastUpdate int64
+
+	// closed is the max window end flushed on idle, windows ended before it
+	// treated as closed.
+	closed int64
+
+	// window end -> windows
+	buckets map[int64]*Windows
+
+	late int64
+}
+
+// watermark in unix seconds, windows ended not after it are closed.
+func (et *eventTimeWindows) watermark() int64 {
+	if et.maxTime == 0 {
+		return et.closed
+	}
+
+	wm := time.Unix(0, et.maxTime).Add(-time.Duration(et.opts.GetAllowedLateness())).Unix()
+	if wm < et.closed {
+		return et.closed
+	}
+	return wm
+}
+
+func (et *eventTimeWindows) idleTimeout(exp time.Duration) int64 {
+	return int64((exp + time.Duration(et.opts.GetAllowedLateness())) / time.Second)
+}
+
+func (et *eventTimeWindows) policy() string {
+	if p := et.opts.GetLatePolicy(); p != "" {
+		return p
+	}
+	return LatePolicyDrop
+}
+
+func (et *eventTimeWindows) addCal(token string, cal Calculator) {
+	end := cal.Base().nextWallTime
+
+	ws, ok := et.buckets[end]
+	if !ok {
+		ws = newWindows()
+		et.buckets[end] = ws
+	}
+	ws.addCal(token, cal)
+}
+
+// eventTimeWindows get or create event-time windows of rule on token, c.lock
+// should be held.
+func (c *Cache) eventTimeWindows(token, rule string) *eventTimeWindows {
+	if c.eventTimes == nil {
+		c.eventTimes = make(map[eventTimeKey]*eventTimeWindows)
+	}
+
+	key := eventTimeKey{token: token, rule: rule}
+	et, ok := c.eventTimes[key]
+	if !ok {
+		et = &eventTimeWindows{buckets: map[int64]*Windows{}}
+		c.eventTimes[key] = et
+	}
+
+	return et
+}
+
+// addEventTimeBatch add calculators of batch into event-time windows, the
+// batch must got EventTime and Rule set.
+func (c *Cache) addEventTimeBatch(token string, batch *AggregationBatch) (n, expN int) {
+	calcs := newCalculators(batch)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	et := c.eventTimeWindows(token, batch.Rule)
+	et.opts = batch.EventTime
+	et.lastUpdate = time.Now().Unix()
+
+	// NOTE: watermark advanced by the whole batch before windowing, so points
+	// within the batch are late if they are far behind others.
+	for _, pt := range batch.GetPoints().GetArr() {
+		if pt.Time > et.maxTime {
+			et.maxTime = pt.Time
+		}
+	}
+
+	var (
+		wm   = et.watermark()
+		late = map[*point.PBPoint]struct{}{}
+	)
+
+	for _, cal := range calcs {
+		mb := cal.Base()
+		if mb.nextWallTime > wm {
+			et.addCal(token, cal)
+			n++
+			continue
+		}
+
+		if _, ok := late[mb.pt]; !ok {
+			late[mb.pt] = struct{}{}
+			et.late++
+
+			if et.policy() == LatePolicySideOutput {
+				if c.sideOutput == nil {
+					c.sideOutput = make(map[string][]*point.PBPoint)
+				}
+				c.sideOutput[token] = append(c.sideOutput[token], mb.pt)
+			}
+		}
+
+		switch et.policy() {
+		case LatePolicyCorrection:
+			mb.aggrTags = append(mb.aggrTags, [2]string{CorrectionTag, "true"})
+			c.addCorrection(token, cal)
+			n++
+		default:
+			expN++
+		}
+	}
+
+	return n, expN
+}
+
+// getEventTimeWindows remove and return windows closed by watermark or idle
+// for long, c.lock should be held. Windows of rule idle for another idle
+// timeout after all flushed are removed, so are the closed window end and
+// late count kept to reject late points.
+func (c *Cache) getEventTimeWindows(now int64) (wss []*Window) {
+	for k, et := range c.eventTimes {
+		var (
+			idle    = now - et.lastUpdate
+			timeout = et.idleTimeout(c.Expired)
+		)
+
+		if len(et.buckets) == 0 && idle > 2*timeout {
+			delete(c.eventTimes, k)
+			continue
+		}
+
+		wm := et.watermark()
+		if idle >= timeout {
+			wm = now // flush all
+		}
+
+		for end, ws := range et.buckets {
+			if end <= wm {
+				wss = append(wss, ws.Close()...)
+				delete(et.buckets, end)
+
+				if end > et.closed {
+					et.closed = end
+				}
+			}
+		}
+	}
+
+	for end, ws := range c.corrections {
+		wss = append(wss, ws.Close()...)
+		delete(c.corrections, end)
+	}
+
+	return wss
+}
+
+// addCorrection add late calculator into corrections of the window it
+// belongs to, so late points of different closed windows are not merged
+// into one correction, c.lock should be held.
+func (c *Cache) addCorrection(token string, cal Calculator) {
+	if c.corrections == nil {
+		c.corrections = make(map[int64]*Windows)
+	}
+
+	end := cal.Base().nextWallTime
+
+	ws, ok := c.corrections[end]
+	if !ok {
+		ws = newWindows()
+		c.corrections[end] = ws
+	}
+	ws.addCal(token, cal)
+}
+
+// GetSideOutput remove and return late points kept under late policy
+// side_output.
+func (c *Cache) GetSideOutput() []*PointsData {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var res []*PointsData
+	for token, pbs := range c.sideOutput {
+		pd := &PointsData{Token: token}
+		for _, pb := range pbs {
+			pd.PTS = append(pd.PTS, point.FromPB(pb))
+		}
+
+		res = append(res, pd)
+		delete(c.sideOutput, token)
+	}
+
+	return res
+}
+
+// LateStat is the count of late points on rule of token.
+type LateStat struct {
+	Token,
+	Rule,
+	Policy string
+	Late int64
+}
+
+// LateStats return count of late points on all event-time rules.
+func (c *Cache) LateStats() []*LateStat {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	res := make([]*LateStat, 0, len(c.eventTimes))
+	for k, et := range c.eventTimes {
+		res = append(res, &LateStat{
+			Token:  k.token,
+			Rule:   k.rule,
+			Policy: et.policy(),
+			Late:   et.late,
+		})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Token != res[j].Token {
+			return res[i].Token < res[j].Token
+		}
+		return res[i].Rule < res[j].Rule
+	})
+
+	return res
+}
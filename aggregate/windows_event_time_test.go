@@ -0,0 +1,316 @@
+package aggregate
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func eventTimeBatch(opts *EventTimeOptions, ts ...time.Time) *AggregationBatch {
+	var pts []*point.PBPoint
+	for i, t := range ts {
+		pt := point.NewPoint("request",
+			point.KVs{}.Add("latency", float64(i+1)).AddTag("host", "node-1"),
+			point.WithTime(t))
+		pts = append(pts, pt.PBPoint())
+	}
+
+	return &AggregationBatch{
+		RoutingKey: 1,
+		Rule:       "latency-rule",
+		EventTime:  opts,
+		Points:     &point.PBPoints{Arr: pts},
+		AggregationOpts: map[string]*AggregationAlgo{
+			"latency": {Method: string(SUM), Window: int64(10 * time.Second)},
+		},
+	}
+}
+
+func sumOfWindows(t *testing.T, ws []*Window) (res []float64) {
+	t.Helper()
+
+	for _, pd := range WindowsToData(ws) {
+		for _, pt := range pd.PTS {
+			v, ok := pt.GetF("latency")
+			require.True(t, ok)
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func TestEventTimeConfig(t *testing.T) {
+	setup := func(opts *EventTimeOptions) error {
+		return (&AggregatorConfigure{
+			DefaultWindow: time.Second * 10,
+			AggregateRules: []*AggregateRule{
+				{
+					Name:       "rule",
+					Selector:   &RuleSelector{Category: point.Metric.String(), MetricName: []string{"latency"}},
+					Algorithms: map[string]*AggregationAlgoConfig{"latency": {Method: string(SUM)}},
+					EventTime:  opts,
+				},
+			},
+		}).Setup()
+	}
+
+	assert.NoError(t, setup(nil))
+	assert.NoError(t, setup(&EventTimeOptions{AllowedLateness: int64(time.Second), LatePolicy: LatePolicyCorrection}))
+	assert.ErrorContains(t, setup(&EventTimeOptions{AllowedLateness: -1}), "allowed_lateness")
+	assert.ErrorContains(t, setup(&EventTimeOptions{LatePolicy: "bad"}), "late_policy")
+
+	var cfg AggregatorConfigure
+	require.NoError(t, cfg.UnmarshalTOML(map[string]any{
+		"aggregate_rules": []any{
+			map[string]any{
+				"name":       "rule",
+				"event_time": map[string]any{"allowed_lateness": int64(time.Second), "late_policy": "side_output"},
+			},
+		},
+	}))
+	require.Len(t, cfg.AggregateRules, 1)
+	assert.Equal(t, &EventTimeOptions{AllowedLateness: int64(time.Second), LatePolicy: LatePolicySideOutput}, cfg.AggregateRules[0].EventTime)
+
+	cfg = AggregatorConfigure{
+		DefaultWindow: time.Second * 10,
+		AggregateRules: []*AggregateRule{
+			{
+				Name:       "rule",
+				Selector:   &RuleSelector{Category: point.Metric.String(), MetricName: []string{"latency"}},
+				Algorithms: map[string]*AggregationAlgoConfig{"latency": {Method: string(SUM)}},
+				EventTime:  &EventTimeOptions{LatePolicy: LatePolicyDrop},
+			},
+		},
+	}
+	require.NoError(t, cfg.Setup())
+
+	batches := cfg.AggregateRules[0].GroupbyBatch(&cfg, []*point.Point{
+		point.NewPoint("request", point.KVs{}.Add("latency", 1.0)),
+	})
+	require.Len(t, batches, 1)
+	assert.Equal(t, "rule", batches[0].Rule)
+	assert.Equal(t, LatePolicyDrop, batches[0].EventTime.LatePolicy)
+}
+
+func TestEventTimeWatermark(t *testing.T) {
+	var (
+		t0   = time.Unix(1700000000, 0) // backfilled data, far behind wall clock
+		opts = &EventTimeOptions{AllowedLateness: int64(5 * time.Second)}
+		c    = NewCache(time.Hour)
+	)
+
+	n, expN := c.AddBatch("token-a", eventTimeBatch(opts, t0.Add(time.Second), t0.Add(3*time.Second)))
+	assert.Equal(t, 2, n)
+	assert.Zero(t, expN)
+	assert.Empty(t, c.GetExpWidows(), "watermark not passed window end")
+
+	// watermark advanced to t0+11s, window [t0, t0+10s] closed
+	n, _ = c.AddBatch("token-a", eventTimeBatch(opts, t0.Add(16*time.Second)))
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []float64{3}, sumOfWindows(t, c.GetExpWidows()))
+
+	// late point dropped
+	n, expN = c.AddBatch("token-a", eventTimeBatch(opts, t0.Add(2*time.Second)))
+	assert.Zero(t, n)
+	assert.Equal(t, 1, expN)
+	assert.Equal(t, []*LateStat{{Token: "token-a", Rule: "latency-rule", Policy: LatePolicyDrop, Late: 1}}, c.LateStats())
+
+	// within allowed lateness of window [t0+10s, t0+20s]
+	n, _ = c.AddBatch("token-a", eventTimeBatch(opts, t0.Add(12*time.Second)))
+	assert.Equal(t, 1, n)
+	assert.Empty(t, c.GetExpWidows())
+}
+
+func TestEventTimeLatePolicy(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+
+	t.Run("correction", func(t *testing.T) {
+		c := NewCache(time.Hour)
+		opts := &EventTimeOptions{LatePolicy: LatePolicyCorrection}
+
+		c.AddBatch("token-a", eventTimeBatch(opts, t0.Add(time.Second)))
+		c.AddBatch("token-a", eventTimeBatch(opts, t0.Add(25*time.Second)))
+		require.Len(t, c.GetExpWidows(), 1)
+
+		n, expN := c.AddBatch("token-a", eventTimeBatch(opts, t0.Add(2*time.Second), t0.Add(3*time.Second)))
+		assert.Equal(t, 2, n)
+		assert.Zero(t, expN)
+
+		pds := WindowsToData(c.GetExpWidows())
+		require.Len(t, pds, 1)
+		require.Len(t, pds[0].PTS, 1)
+
+		pt := pds[0].PTS[0]
+		assert.Equal(t, "true", pt.GetTag(CorrectionTag))
+		assert.Equal(t, "node-1", pt.GetTag("host"))
+		assert.Equal(t, 3.0, pt.Get("latency"))
+		assert.Equal(t, int64(2), c.LateStats()[0].Late)
+	})
+
+	t.Run("correction-per-window", func(t *testing.T) {
+		c := NewCache(time.Hour)
+		opts := &EventTimeOptions{LatePolicy: LatePolicyCorrection}
+
+		c.AddBatch("token-a", eventTimeBatch(opts, t0.Add(time.Second), t0.Add(12*time.Second)))
+		c.AddBatch("token-a", eventTimeBatch(opts, t0.Add(35*time.Second)))
+		require.Len(t, c.GetExpWidows(), 2)
+
+		// late points of windows [t0, t0+10s] and [t0+10s, t0+20s] in one batch
+		n, _ := c.AddBatch("token-a", eventTimeBatch(opts, t0.Add(2*time.Second), t0.Add(13*time.Second), t0.Add(3*time.Second)))
+		assert.Equal(t, 3, n)
+
+		pds := WindowsToData(c.GetExpWidows())
+
+		var sums []float64
+		for _, pd := range pds {
+			for _, pt := range pd.PTS {
+				assert.Equal(t, "true", pt.GetTag(CorrectionTag))
+				sums = append(sums, pt.Get("latency").(float64))
+			}
+		}
+		assert.ElementsMatch(t, []float64{4, 2}, sums)
+	})
+
+	t.Run("side-output", func(t *testing.T) {
+		c := NewCache(time.Hour)
+		opts := &EventTimeOptions{LatePolicy: LatePolicySideOutput}
+
+		c.AddBatch("token-a", eventTimeBatch(opts, t0.Add(25*time.Second)))
+		n, expN := c.AddBatch("token-a", eventTimeBatch(opts, t0.Add(2*time.Second)))
+		assert.Zero(t, n)
+		assert.Equal(t, 1, expN)
+
+		side := c.GetSideOutput()
+		require.Len(t, side, 1)
+		assert.Equal(t, "token-a", side[0].Token)
+		require.Len(t, side[0].PTS, 1)
+		assert.Equal(t, t0.Add(2*time.Second).UnixNano(), side[0].PTS[0].Time().UnixNano())
+		assert.Empty(t, c.GetSideOutput())
+	})
+}
+
+func TestEventTimeIdleFlush(t *testing.T) {
+	var (
+		t0 = time.Unix(1700000000, 0)
+		c  = NewCache(0) // idle immediately
+	)
+
+	c.AddBatch("token-a", eventTimeBatch(&EventTimeOptions{}, t0.Add(time.Second)))
+	assert.Equal(t, []float64{1}, sumOfWindows(t, c.GetExpWidows()))
+
+	// window flushed on idle is closed
+	_, expN := c.AddBatch("token-a", eventTimeBatch(&EventTimeOptions{}, t0.Add(2*time.Second)))
+	assert.Equal(t, 1, expN)
+}
+
+func TestEventTimeSnapshot(t *testing.T) {
+	var (
+		t0   = time.Unix(1700000000, 0)
+		opts = &EventTimeOptions{AllowedLateness: int64(5 * time.Second)}
+		c    = NewCache(time.Hour)
+	)
+
+	c.AddBatch("token-a", eventTimeBatch(opts, t0.Add(time.Second), t0.Add(12*time.Second)))
+	c.AddBatch("token-a", eventTimeBatch(opts, t0.Add(16*time.Second))) // window 1 closed
+	c.AddBatch("token-a", eventTimeBatch(opts, t0.Add(2*time.Second)))  // late
+
+	var buf bytes.Buffer
+	require.NoError(t, c.Snapshot(&buf))
+
+	restored, flushed, err := RestoreCache(&buf)
+	require.NoError(t, err)
+
+	// window [t0, t0+10s] closed by watermark before snapshot
+	require.Len(t, flushed, 1)
+	require.Len(t, flushed[0].PTS, 1)
+	assert.Equal(t, 1.0, flushed[0].PTS[0].Get("latency"))
+
+	assert.Equal(t, c.LateStats(), restored.LateStats())
+
+	// watermark restored, window [t0+10s, t0+20s] continues
+	restored.AddBatch("token-a", eventTimeBatch(opts, t0.Add(26*time.Second)))
+	assert.Equal(t, []float64{3}, sumOfWindows(t, restored.GetExpWidows()))
+}
+
+func TestEventTimeSnapshotLatePolicy(t *testing.T) {
+	t0 := time.Unix(1700000000, 0)
+
+	snapshot := func(t *testing.T, c *Cache) *Cache {
+		t.Helper()
+
+		var buf bytes.Buffer
+		require.NoError(t, c.Snapshot(&buf))
+
+		restored, flushed, err := RestoreCache(&buf)
+		require.NoError(t, err)
+		assert.Empty(t, flushed)
+		return restored
+	}
+
+	t.Run("correction", func(t *testing.T) {
+		c := NewCache(time.Hour)
+		opts := &EventTimeOptions{LatePolicy: LatePolicyCorrection}
+
+		c.AddBatch("token-a", eventTimeBatch(opts, t0.Add(25*time.Second)))
+		c.AddBatch("token-a", eventTimeBatch(opts, t0.Add(2*time.Second), t0.Add(3*time.Second)))
+
+		// correction not flushed yet
+		restored := snapshot(t, c)
+
+		pds := WindowsToData(restored.GetExpWidows())
+		require.Len(t, pds, 1)
+		require.Len(t, pds[0].PTS, 1)
+
+		pt := pds[0].PTS[0]
+		assert.Equal(t, "token-a", pds[0].Token)
+		assert.Equal(t, "true", pt.GetTag(CorrectionTag))
+		assert.Equal(t, "node-1", pt.GetTag("host"))
+		assert.Equal(t, 3.0, pt.Get("latency"))
+	})
+
+	t.Run("side-output", func(t *testing.T) {
+		c := NewCache(time.Hour)
+		opts := &EventTimeOptions{LatePolicy: LatePolicySideOutput}
+
+		c.AddBatch("token-a", eventTimeBatch(opts, t0.Add(25*time.Second)))
+		c.AddBatch("token-a", eventTimeBatch(opts, t0.Add(2*time.Second)))
+
+		restored := snapshot(t, c)
+
+		side := restored.GetSideOutput()
+		require.Len(t, side, 1)
+		assert.Equal(t, "token-a", side[0].Token)
+		require.Len(t, side[0].PTS, 1)
+		assert.Equal(t, t0.Add(2*time.Second).UnixNano(), side[0].PTS[0].Time().UnixNano())
+	})
+}
+
+func TestEventTimeIdleEvict(t *testing.T) {
+	var (
+		t0   = time.Unix(1700000000, 0)
+		opts = &EventTimeOptions{AllowedLateness: int64(time.Second)}
+		c    = NewCache(time.Second) // idle timeout 2s
+		key  = eventTimeKey{token: "token-a", rule: "latency-rule"}
+		now  = time.Now().Unix()
+	)
+
+	c.AddBatch("token-a", eventTimeBatch(opts, t0.Add(time.Second)))
+
+	// idle, windows flushed but closed window end kept
+	c.eventTimes[key].lastUpdate = now - 3
+	assert.Equal(t, []float64{1}, sumOfWindows(t, c.GetExpWidows()))
+	require.Contains(t, c.eventTimes, key)
+
+	_, expN := c.AddBatch("token-a", eventTimeBatch(opts, t0.Add(2*time.Second)))
+	assert.Equal(t, 1, expN)
+
+	// idle for another idle timeout after flushed, removed
+	c.eventTimes[key].lastUpdate = now - 5
+	assert.Empty(t, c.GetExpWidows())
+	assert.NotContains(t, c.eventTimes, key)
+	assert.Empty(t, c.LateStats())
+}
@@ -0,0 +1,288 @@
+package aggregate
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Hopping and sliding windows: calculators of the rule aggregated within
+// panes of window step(tumbling windows of the step), flushed panes are kept
+// and merged into each window covering them, so overlapping windows share
+// the panes instead of aggregating the same point multiple times.
+//
+// Hopping window emitted every step, sliding window emitted on each step only
+// if its content changed(a pane with data entered or left the window).
+// Outputs of both got tags window_start and window_end(unix seconds).
+
+const (
+	// window types.
+	WindowTumbling = "tumbling"
+	WindowHopping  = "hopping"
+	WindowSliding  = "sliding"
+
+	WindowStartTag = "window_start"
+	WindowEndTag   = "window_end"
+
+	// slidingDefaultPanes is panes of sliding window if step not set.
+	slidingDefaultPanes = 60
+)
+
+// setup validate the window spec and fill default step.
+func (wo *WindowOptions) setup() error {
+	if wo == nil {
+		return nil
+	}
+
+	if wo.Length < 0 || wo.Length%int64(time.Second) != 0 {
+		return fmt.Errorf("window: length %s should be multiple of 1s", time.Duration(wo.Length))
+	}
+
+	if wo.Step < 0 || wo.Step%int64(time.Second) != 0 {
+		return fmt.Errorf("window: step %s should be multiple of 1s", time.Duration(wo.Step))
+	}
+
+	switch wo.Type {
+	case "", WindowTumbling:
+		wo.Step = wo.Length
+		return nil
+
+	case WindowHopping:
+		if wo.Length == 0 || wo.Step == 0 {
+			return fmt.Errorf("window: hopping window requires length and step")
+		}
+
+	case WindowSliding:
+		if wo.Length == 0 {
+			return fmt.Errorf("window: sliding window requires length")
+		}
+
+		if wo.Step == 0 {
+			wo.Step = wo.Length / slidingDefaultPanes
+			wo.Step -= wo.Step % int64(time.Second)
+			if wo.Step < int64(time.Second) {
+				wo.Step = int64(time.Second)
+			}
+		}
+
+	default:
+		return fmt.Errorf("window: invalid type %q", wo.Type)
+	}
+
+	if wo.Step > wo.Length || wo.Length%wo.Step != 0 {
+		return fmt.Errorf("window: length %s should be multiple of step %s",
+			time.Duration(wo.Length), time.Duration(wo.Step))
+	}
+
+	return nil
+}
+
+// paneSize return the window of calculators under the spec, 0 if not set.
+func (wo *WindowOptions) paneSize() int64 {
+	if wo == nil {
+		return 0
+	}
+
+	if wo.overlapping() {
+		return wo.Step
+	}
+	return wo.Length
+}
+
+func (wo *WindowOptions) overlapping() bool {
+	return wo != nil && wo.Step > 0 && (wo.Type == WindowHopping || wo.Type == WindowSliding)
+}
+
+type paneKey struct {
+	token string
+	hash  uint64
+}
+
+// paneSeries are flushed panes of the same calculator.
+type paneSeries struct {
+	opts  *WindowOptions
+	rule  string
+	panes map[int64]Calculator // pane end -> calculator
+
+	// end of the last window emitted.
+	lastEmit int64
+}
+
+func (ps *paneSeries) span() (first, last int64) {
+	for end := range ps.panes {
+		if first == 0 || end < first {
+			first = end
+		}
+		if end > last {
+			last = end
+		}
+	}
+	return first, last
+}
+
+// addPanes move panes within flushed windows into pane series, windows left
+// empty after that are dropped, c.lock should be held.
+func (c *Cache) addPanes(wss []*Window) []*Window {
+	res := wss[:0]
+
+	for _, w := range wss {
+		moved := false
+		for h, calc := range w.cache {
+			if calc.Base().slide != nil {
+				delete(w.cache, h)
+				c.addPane(w.Token, calc)
+				moved = true
+			}
+		}
+
+		if moved && len(w.cache) == 0 {
+			w.Reset()
+			windowPool.Put(w)
+			continue
+		}
+
+		res = append(res, w)
+	}
+
+	return res
+}
+
+func (c *Cache) addPane(token string, calc Calculator) {
+	if c.panes == nil {
+		c.panes = map[paneKey]*paneSeries{}
+	}
+
+	mb := calc.Base()
+	key := paneKey{token: token, hash: mb.hash}
+	ps, ok := c.panes[key]
+	if !ok {
+		ps = &paneSeries{opts: mb.slide, rule: mb.rule, panes: map[int64]Calculator{}}
+		c.panes[key] = ps
+	}
+
+	if x, ok := ps.panes[mb.nextWallTime]; ok { // pane flushed more than once
+		x.Add(calc)
+	} else {
+		ps.panes[mb.nextWallTime] = calc
+	}
+}
+
+// paneFrontier return the max pane end flushed on rule of token.
+func (c *Cache) paneFrontier(token, rule string, now int64) int64 {
+	if et, ok := c.eventTimes[eventTimeKey{token: token, rule: rule}]; ok {
+		if now-et.lastUpdate >= et.idleTimeout(c.Expired) {
+			return now
+		}
+		return et.watermark()
+	}
+
+	return now - int64(c.Expired/time.Second)
+}
+
+// getSlideWindows emit hopping/sliding windows ended before frontier of
+// their rule, c.lock should be held.
+func (c *Cache) getSlideWindows(now int64) []*Window {
+	return c.emitPanes(func(key paneKey, ps *paneSeries) (int64, bool) {
+		return c.paneFrontier(key.token, ps.rule, now), true
+	})
+}
+
+// emitPanes emit windows of pane series ended before the frontier, series
+// not ok skipped, c.lock should be held.
+func (c *Cache) emitPanes(frontierOf func(paneKey, *paneSeries) (int64, bool)) []*Window {
+	var (
+		windows = map[string]*Window{}
+		res     []*Window
+	)
+
+	for key, ps := range c.panes {
+		frontier, ok := frontierOf(key, ps)
+		if !ok {
+			continue
+		}
+
+		var (
+			size        = ps.opts.Length / int64(time.Second)
+			step        = ps.opts.Step / int64(time.Second)
+			first, last = ps.span()
+			from        = ps.lastEmit + step
+		)
+
+		if ps.lastEmit == 0 || from < first {
+			from = first
+		}
+
+		// windows after last+size got no pane
+		for end := from; end <= frontier && end-size < last; end += step {
+			ps.lastEmit = end
+
+			if ps.opts.Type == WindowSliding && ps.panes[end] == nil && ps.panes[end-size] == nil {
+				continue // not changed
+			}
+
+			calc := ps.window(end-size, end, step)
+			if calc == nil {
+				continue
+			}
+
+			w, ok := windows[key.token]
+			if !ok {
+				w = windowPool.Get().(*Window)
+				w.Reset()
+				w.Token = key.token
+				windows[key.token] = w
+				res = append(res, w)
+			}
+
+			w.cache[calc.Base().hash^uint64(end)] = calc
+		}
+
+		// drop panes not covered by further windows
+		for end := range ps.panes {
+			if end <= ps.lastEmit+step-size {
+				delete(ps.panes, end)
+			}
+		}
+
+		if len(ps.panes) == 0 {
+			delete(c.panes, key)
+		}
+	}
+
+	return res
+}
+
+// window merge panes within (start, end] into a new calculator.
+func (ps *paneSeries) window(start, end, step int64) Calculator {
+	var res Calculator
+
+	for pe := end; pe > start; pe -= step {
+		calc, ok := ps.panes[pe]
+		if !ok {
+			continue
+		}
+
+		if res == nil {
+			x, err := cloneCalculator(calc)
+			if err != nil {
+				l.Warnf("clone calculator %q: %s, window ignored", calc.Base().key, err)
+				return nil
+			}
+			res = x
+		} else {
+			res.Add(calc)
+		}
+	}
+
+	if res != nil {
+		mb := res.Base()
+		mb.nextWallTime = end
+		mb.window = (end - start) * int64(time.Second)
+		mb.slide = nil
+		mb.aggrTags = append(mb.aggrTags,
+			[2]string{WindowStartTag, strconv.FormatInt(start, 10)},
+			[2]string{WindowEndTag, strconv.FormatInt(end, 10)})
+	}
+
+	return res
+}
@@ -0,0 +1,223 @@
+package aggregate
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/point"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var slideT0 = time.Unix(1700000000, 0)
+
+// slideBatch create batch with point of value v at slideT0+sec.
+func slideBatch(wo *WindowOptions, sec int, v float64) *AggregationBatch {
+	pt := point.NewPoint("request",
+		point.KVs{}.Add("latency", v).AddTag("host", "node-1"),
+		point.WithTime(slideT0.Add(time.Duration(sec)*time.Second)))
+
+	return &AggregationBatch{
+		RoutingKey: 1,
+		Rule:       "slo",
+		EventTime:  &EventTimeOptions{},
+		Window:     wo,
+		Points:     &point.PBPoints{Arr: []*point.PBPoint{pt.PBPoint()}},
+		AggregationOpts: map[string]*AggregationAlgo{
+			"latency": {Method: string(SUM), Window: wo.paneSize()},
+		},
+	}
+}
+
+type slideResult struct {
+	start, end int64 // seconds since slideT0
+	sum        float64
+}
+
+func slideResults(t *testing.T, ws []*Window) (res []slideResult) {
+	t.Helper()
+
+	for _, pd := range WindowsToData(ws) {
+		for _, pt := range pd.PTS {
+			start, err := strconv.ParseInt(pt.GetTag(WindowStartTag), 10, 64)
+			require.NoError(t, err)
+			end, err := strconv.ParseInt(pt.GetTag(WindowEndTag), 10, 64)
+			require.NoError(t, err)
+			assert.Equal(t, "node-1", pt.GetTag("host"))
+
+			res = append(res, slideResult{
+				start: start - slideT0.Unix(),
+				end:   end - slideT0.Unix(),
+				sum:   pt.Get("latency").(float64),
+			})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].end < res[j].end })
+	return res
+}
+
+func TestWindowOptionsSetup(t *testing.T) {
+	setup := func(wo *WindowOptions) (*AggregateRule, error) {
+		ar := &AggregateRule{
+			Name:       "rule",
+			Selector:   &RuleSelector{Category: point.Metric.String(), MetricName: []string{"latency"}},
+			Algorithms: map[string]*AggregationAlgoConfig{"latency": {Method: string(SUM)}},
+			Window:     wo,
+		}
+
+		return ar, (&AggregatorConfigure{
+			DefaultWindow:  time.Second * 10,
+			AggregateRules: []*AggregateRule{ar},
+		}).Setup()
+	}
+
+	for _, wo := range []*WindowOptions{
+		{Type: "bad", Length: int64(time.Minute)},
+		{Type: WindowHopping, Length: int64(time.Minute)},
+		{Type: WindowHopping, Length: int64(time.Minute), Step: int64(7 * time.Second)},
+		{Type: WindowHopping, Length: int64(time.Minute), Step: int64(2 * time.Minute)},
+		{Type: WindowHopping, Length: int64(time.Minute), Step: int64(1500 * time.Millisecond)},
+		{Type: WindowSliding},
+		{Length: -1},
+	} {
+		_, err := setup(wo)
+		assert.Error(t, err, "%+#v", wo)
+	}
+
+	ar, err := setup(&WindowOptions{Type: WindowHopping, Length: int64(5 * time.Minute), Step: int64(30 * time.Second)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(30*time.Second), ar.aggregationOpts["latency"].Window)
+
+	ar, err = setup(&WindowOptions{Type: WindowSliding, Length: int64(5 * time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5*time.Second), ar.Window.Step)
+	assert.Equal(t, int64(5*time.Second), ar.aggregationOpts["latency"].Window)
+
+	ar, err = setup(&WindowOptions{Type: WindowTumbling, Length: int64(time.Minute)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(time.Minute), ar.aggregationOpts["latency"].Window)
+	assert.False(t, ar.Window.overlapping())
+
+	var cfg AggregatorConfigure
+	require.NoError(t, cfg.UnmarshalTOML(map[string]any{
+		"aggregate_rules": []any{
+			map[string]any{
+				"name":   "rule",
+				"window": map[string]any{"type": "hopping", "length": int64(time.Minute), "step": int64(10 * time.Second)},
+			},
+		},
+	}))
+	assert.Equal(t, &WindowOptions{Type: WindowHopping, Length: int64(time.Minute), Step: int64(10 * time.Second)},
+		cfg.AggregateRules[0].Window)
+}
+
+func TestHoppingWindow(t *testing.T) {
+	var (
+		wo = &WindowOptions{Type: WindowHopping, Length: int64(30 * time.Second), Step: int64(10 * time.Second)}
+		c  = NewCache(time.Hour)
+	)
+
+	for _, x := range []struct {
+		sec int
+		v   float64
+	}{{1, 1}, {11, 2}, {21, 4}, {45, 8}} {
+		n, _ := c.AddBatch("token-a", slideBatch(wo, x.sec, x.v))
+		require.Equal(t, 1, n)
+	}
+
+	// panes ended at 10/20/30 closed by watermark 45
+	assert.Equal(t, []slideResult{
+		{-20, 10, 1},
+		{-10, 20, 3},
+		{0, 30, 7},
+		{10, 40, 6},
+	}, slideResults(t, c.GetExpWidows()))
+
+	var buf bytes.Buffer
+	require.NoError(t, c.Snapshot(&buf))
+
+	restored, flushed, err := RestoreCache(&buf)
+	require.NoError(t, err)
+	assert.Empty(t, flushed)
+
+	for _, cache := range []*Cache{c, restored} {
+		cache.AddBatch("token-a", slideBatch(wo, 75, 16))
+		assert.Equal(t, []slideResult{
+			{20, 50, 12},
+			{30, 60, 8},
+			{40, 70, 8},
+		}, slideResults(t, cache.GetExpWidows()))
+	}
+
+	// panes ended at 30/50 not covered by window ended at 80, pane 80 still
+	// open within event-time windows
+	assert.Empty(t, c.panes)
+
+	// window [0, 20] merged from panes ended at 10 and 20
+	merged := func(t *testing.T, method AlgoMethod) (res []*point.Point) {
+		t.Helper()
+
+		var (
+			wo = &WindowOptions{Type: WindowHopping, Length: int64(20 * time.Second), Step: int64(10 * time.Second)}
+			c  = NewCache(time.Hour)
+		)
+
+		for _, secs := range [][]int{{1, 2, 3}, {11, 12}, {45}} {
+			batch := slideBatch(wo, secs[0], float64(secs[0]))
+			batch.AggregationOpts["latency"].Method = string(method)
+			batch.Points.Arr = nil
+
+			for _, sec := range secs {
+				kvs := point.KVs{}.Add("latency", float64(sec)).AddTag("host", "node-1")
+				if method == HISTOGRAM {
+					kvs = kvs.AddTag("le", strconv.Itoa((sec/10+1)*10))
+				}
+
+				batch.Points.Arr = append(batch.Points.Arr,
+					point.NewPoint("request", kvs, point.WithTime(slideT0.Add(time.Duration(sec)*time.Second))).PBPoint())
+			}
+			c.AddBatch("token-a", batch)
+		}
+
+		for _, pd := range WindowsToData(c.GetExpWidows()) {
+			for _, pt := range pd.PTS {
+				if pt.GetTag(WindowEndTag) == strconv.FormatInt(slideT0.Unix()+20, 10) {
+					res = append(res, pt)
+				}
+			}
+		}
+		return res
+	}
+
+	t.Run("count", func(t *testing.T) {
+		pts := merged(t, COUNT)
+		require.Len(t, pts, 1)
+		assert.Equal(t, int64(5), pts[0].Get("latency"))
+		assert.Equal(t, int64(5), pts[0].Get("latency_count"))
+	})
+
+	t.Run("avg", func(t *testing.T) {
+		pts := merged(t, AVG)
+		require.Len(t, pts, 1)
+		assert.InDelta(t, 5.8, pts[0].Get("latency"), 1e-9)
+		assert.Equal(t, int64(5), pts[0].Get("latency_count"))
+	})
+
+	t.Run("histogram", func(t *testing.T) {
+		// NOTE: le of bucket output overwritten by le tag of the series, so
+		// check values of buckets only.
+		var buckets []float64
+		for _, pt := range merged(t, HISTOGRAM) {
+			if v := pt.Get("latency_count"); v != nil {
+				assert.Equal(t, int64(5), v)
+				continue
+			}
+			buckets = append(buckets, pt.Get("latency").(float64))
+		}
+		assert.ElementsMatch(t, []float64{6, 23}, buckets)
+	})
+}
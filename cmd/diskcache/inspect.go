@@ -0,0 +1,227 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	dc "github.com/GuanceCloud/cliutils/diskcache"
+	"github.com/GuanceCloud/cliutils/point"
+)
+
+// runCommand run inspection command on a offline cache.
+func runCommand(cmd string, args []string) error {
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+
+	var (
+		cachePath = fs.String("path", "./diskcache", "cache path")
+		all       = fs.Bool("all", false, "dump: include records that already consumed")
+		limit     = fs.Int("n", 0, "dump: max records to dump, 0 for all")
+		format    = fs.String("format", "hex", "dump: record format, hex/raw/point")
+		enc       = fs.String("enc", "protobuf", "dump: point encoding, protobuf/lineproto/json")
+		output    = fs.String("o", "", "export: output file")
+		dst       = fs.String("dst", "", "migrate: path of the new cache, default replace the cache in place")
+		batch     = fs.Int64("batch", 0, "migrate: batch size of the new cache, 0 for default")
+		maxData   = fs.Int("max-data", 0, "migrate: max data size of the new cache, 0 for no limit")
+		capacity  = fs.Int64("cap", 0, "migrate: capacity of the new cache, 0 for no limit")
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch cmd {
+	case "ls", "pos", "dump", "verify", "compact", "export":
+	case "migrate":
+		return migrate(*cachePath, *dst, *batch, *maxData, *capacity)
+	default:
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+
+	in, err := dc.OpenInspector(*cachePath)
+	if err != nil {
+		return err
+	}
+
+	defer in.Close() //nolint:errcheck
+
+	switch cmd {
+	case "ls":
+		return listFiles(in)
+
+	case "pos":
+		j, err := in.PosJSON()
+		if err != nil {
+			return err
+		}
+
+		name, seek, err := in.Pos()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("json: %s\nfile: %s\nseek: %d\n", string(j), name, seek)
+		return nil
+
+	case "dump":
+		return dump(in, !*all, *limit, *format, point.EncodingStr(*enc))
+
+	case "verify":
+		dfis, err := in.Verify()
+		for _, dfi := range dfis {
+			status := "ok"
+			if dfi.Err != nil {
+				status = dfi.Err.Error()
+			}
+			fmt.Printf("%s: %s\n", filepath.Base(dfi.Name), status)
+		}
+		return err
+
+	case "compact":
+		res, err := in.Compact()
+		if err != nil {
+			return err
+		}
+
+		if res.File == "" {
+			fmt.Println("nothing to compact")
+		} else {
+			fmt.Printf("compacted %s: %d records, %d -> %d bytes\n",
+				res.File, res.Records, res.SizeBefore, res.SizeAfter)
+		}
+		return nil
+
+	case "export":
+		if *output == "" {
+			return fmt.Errorf("-o required")
+		}
+
+		f, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+		if err != nil {
+			return err
+		}
+
+		n, err := in.Export(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("exported %d records to %s\n", n, *output)
+		return nil
+	}
+
+	return nil
+}
+
+func migrate(src, dst string, batch int64, maxData int, capacity int64) error {
+	if dst == "" {
+		dst = src
+	}
+
+	opts := []dc.CacheOption{dc.WithCapacity(capacity)}
+	if batch > 0 {
+		opts = append(opts, dc.WithBatchSize(batch))
+	}
+
+	if maxData > 0 {
+		opts = append(opts, dc.WithMaxDataSize(int32(maxData)))
+	}
+
+	rep, err := dc.Migrate(src, dst, opts...)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("migrated %d records(%d bytes) from %s(%d files) to %s(%d files), %d consumed records skipped, cost %s\n",
+		rep.Records, rep.Bytes, src, rep.SrcFiles, dst, rep.DstFiles, rep.Skipped, rep.Cost)
+
+	if src != dst {
+		fmt.Printf("%s left untouched, remove it if not used\n", src)
+	}
+
+	return nil
+}
+
+func listFiles(in *dc.Inspector) error {
+	dfis, err := in.DataFiles()
+	if err != nil {
+		return err
+	}
+
+	name, seek, err := in.Pos()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tSIZE\tRECORDS\tEOF\tNOTE")
+
+	var size int64
+	var records int
+	for _, dfi := range dfis {
+		note := ""
+		switch {
+		case dfi.Err != nil:
+			note = "broken: " + dfi.Err.Error()
+		case dfi.Writing:
+			note = "writing"
+		case dfi.Name == name:
+			note = fmt.Sprintf("reading at %d", seek)
+		}
+
+		size += dfi.Size
+		records += dfi.Records
+		fmt.Fprintf(w, "%s\t%d\t%d\t%v\t%s\n", filepath.Base(dfi.Name), dfi.Size, dfi.Records, dfi.HasEOF, note)
+	}
+
+	fmt.Fprintf(w, "total(%d files)\t%d\t%d\t\t\n", len(dfis), size, records)
+	return w.Flush()
+}
+
+func dump(in *dc.Inspector, unreadOnly bool, limit int, format string, enc point.Encoding) error {
+	switch format {
+	case "hex", "raw", "point":
+	default:
+		return fmt.Errorf("unknown dump format %q", format)
+	}
+
+	n := 0
+	return in.Records(unreadOnly, func(r *dc.Record) bool {
+		fmt.Printf("# %s@%d, %d bytes\n", filepath.Base(r.File), r.Offset, len(r.Data))
+
+		switch format {
+		case "raw":
+			fmt.Println(string(r.Data))
+		case "point":
+			dec := point.GetDecoder(point.WithDecEncoding(enc))
+			pts, err := dec.Decode(r.Data)
+			point.PutDecoder(dec)
+
+			if err != nil {
+				fmt.Printf("decode failed: %s\n", err.Error())
+				break
+			}
+
+			for _, pt := range pts {
+				fmt.Println(pt.LineProto())
+			}
+		default:
+			fmt.Print(hex.Dump(r.Data))
+		}
+
+		n++
+		return limit <= 0 || n < limit
+	})
+}
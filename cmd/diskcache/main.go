@@ -8,8 +8,11 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"math/rand"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -54,7 +57,40 @@ func init() {
 	flag.StringVar(&http, "http", "localhost:9090", "bind HTTP to serve /metrics")
 }
 
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), `Usage: %s [command] [flags]
+
+Commands:
+  bench    run Put/Get load on the cache(default command)
+  ls       list data files with sizes and record counts
+  pos      show .pos content
+  dump     dump records as hex, raw or decoded points
+  verify   scan all data files for broken headers
+  compact  rewrite the reading file with only unread records
+  export   export unread records to a file
+  migrate  rewrite unread records into a new cache with new options
+
+Run '%s <command> -h' for command flags.
+
+Flags of bench:
+`, os.Args[0], os.Args[0])
+	flag.PrintDefaults()
+}
+
 func main() {
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		cmd := os.Args[1]
+		if cmd != "bench" {
+			if err := runCommand(cmd, os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+
+		os.Args = append(os.Args[:1], os.Args[2:]...) // strip the bench command
+	}
+
+	flag.Usage = usage
 	flag.Parse()
 	var err error
 
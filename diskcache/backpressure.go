@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"sync"
+	"time"
+)
+
+// Backpressure is the pressure on the cache, producers can shed load on
+// high pressure before Put() failed with ErrCacheFull(or old data dropped).
+type Backpressure struct {
+	Size       int64         // current size of the cache
+	Capacity   int64         // current effective capacity, 0 for no limit
+	FillRatio  float64       // Size/Capacity, 0 if no capacity limit
+	GrowthRate float64       // bytes per second the size grows, negative if shrinking
+	TimeToFull time.Duration // estimated time to reach the capacity, -1 if not growing or no capacity limit
+}
+
+// growthTracker estimate growth rate of the cache size, the rate smoothed
+// by EWMA over samples at least interval apart.
+type growthTracker struct {
+	mu       sync.Mutex
+	interval time.Duration
+	alpha    float64
+
+	lastTime time.Time
+	lastSize int64
+	rate     float64
+}
+
+func newGrowthTracker() *growthTracker {
+	return &growthTracker{
+		interval: time.Second,
+		alpha:    0.5,
+	}
+}
+
+// sample add size at now and return current growth rate.
+func (g *growthTracker) sample(size int64, now time.Time) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.lastTime.IsZero() {
+		g.lastTime, g.lastSize = now, size
+		return 0
+	}
+
+	elapsed := now.Sub(g.lastTime)
+	if elapsed < g.interval {
+		return g.rate
+	}
+
+	x := float64(size-g.lastSize) / elapsed.Seconds()
+	g.rate = g.alpha*x + (1-g.alpha)*g.rate
+	g.lastTime, g.lastSize = now, size
+
+	return g.rate
+}
+
+// Backpressure return current pressure on the cache.
+//
+// The growth rate is estimated on each Backpressure() call(at most once per
+// second), so call it periodically to get a accurate growth rate.
+func (c *DiskCache) Backpressure() *Backpressure {
+	bp := &Backpressure{
+		Size:       c.size.Load(),
+		Capacity:   c.EffectiveCapacity(),
+		TimeToFull: -1,
+	}
+
+	if c.growth != nil {
+		bp.GrowthRate = c.growth.sample(bp.Size, time.Now())
+	}
+
+	if bp.Capacity <= 0 {
+		if c.capacity > 0 || c.fsw != nil { // no space left
+			bp.FillRatio = 1
+			bp.TimeToFull = 0
+		}
+		return bp
+	}
+
+	bp.FillRatio = float64(bp.Size) / float64(bp.Capacity)
+
+	switch {
+	case bp.Size >= bp.Capacity:
+		bp.TimeToFull = 0
+	case bp.GrowthRate > 0:
+		bp.TimeToFull = time.Duration(float64(bp.Capacity-bp.Size) / bp.GrowthRate * float64(time.Second))
+	}
+
+	return bp
+}
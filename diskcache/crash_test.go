@@ -0,0 +1,315 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+//go:build crashtest && !windows
+
+package diskcache
+
+// Crash-consistency tests: a child process run random Put/Get/Rotate on a
+// cache and SIGKILLed at random point, then the cache reopened and checked.
+//
+// Run with `make crash_test`, or
+//
+//	go test -tags crashtest -run TestCrashConsistency -v ./diskcache/
+//
+// Env DISKCACHE_CRASH_ROUNDS set how many times the child killed(default 50).
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	T "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	envCrashPath    = "DISKCACHE_CRASH_PATH"
+	envCrashLog     = "DISKCACHE_CRASH_LOG"
+	envCrashSeed    = "DISKCACHE_CRASH_SEED"
+	envCrashSeqBase = "DISKCACHE_CRASH_SEQ_BASE"
+	envCrashRounds  = "DISKCACHE_CRASH_ROUNDS"
+
+	// records duplicated on each crash: the record returned but the .pos
+	// not updated(.pos dumped on every Get() within the test).
+	crashDupWindow = 1
+)
+
+// crashPayload generate data of record seq, the data can be verified by seq.
+func crashPayload(seq int64) []byte {
+	r := rand.New(rand.NewSource(seq)) //nolint:gosec
+	hdr := fmt.Sprintf("seq:%d:", seq)
+
+	buf := make([]byte, len(hdr)+r.Intn(4096))
+	copy(buf, hdr)
+	for i := len(hdr); i < len(buf); i++ {
+		buf[i] = byte('a' + r.Intn(26))
+	}
+
+	return buf
+}
+
+// crashParse return seq of the record, error if the data broken.
+func crashParse(data []byte) (int64, error) {
+	arr := bytes.SplitN(data, []byte(":"), 3)
+	if len(arr) != 3 || string(arr[0]) != "seq" {
+		return -1, fmt.Errorf("bad record header: %q", data[:min(len(data), 32)])
+	}
+
+	seq, err := strconv.ParseInt(string(arr[1]), 10, 64)
+	if err != nil {
+		return -1, err
+	}
+
+	if !bytes.Equal(data, crashPayload(seq)) {
+		return seq, fmt.Errorf("record %d broken", seq)
+	}
+
+	return seq, nil
+}
+
+func crashOpen(path string) (*DiskCache, error) {
+	return Open(WithPath(path), WithBatchSize(32*1024), WithPosUpdate(0, 0), WithWakeup(100*time.Millisecond))
+}
+
+// TestCrashConsistencyChild is the child process of TestCrashConsistency,
+// it run until killed.
+//
+// The log records each operation:
+//
+//	A <seq>  Put() of seq attempted
+//	P <seq>  Put() of seq ok
+//	G <seq>  seq returned by Get()
+//	X <msg>  broken record returned by Get()
+func TestCrashConsistencyChild(t *T.T) {
+	path := os.Getenv(envCrashPath)
+	if path == "" {
+		t.Skip("not a crash child")
+	}
+
+	seed, _ := strconv.ParseInt(os.Getenv(envCrashSeed), 10, 64)
+	seq, _ := strconv.ParseInt(os.Getenv(envCrashSeqBase), 10, 64)
+	r := rand.New(rand.NewSource(seed)) //nolint:gosec
+
+	logf, err := os.OpenFile(os.Getenv(envCrashLog), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	require.NoError(t, err)
+
+	log := func(format string, args ...any) {
+		_, err := fmt.Fprintf(logf, format+"\n", args...)
+		require.NoError(t, err)
+	}
+
+	c, err := crashOpen(path)
+	require.NoError(t, err)
+
+	for {
+		switch x := r.Intn(100); {
+		case x < 55:
+			seq++
+			log("A %d", seq)
+			if err := c.Put(crashPayload(seq)); err == nil {
+				log("P %d", seq)
+			}
+
+		case x < 95:
+			_ = c.Get(func(data []byte) error {
+				n, err := crashParse(data)
+				if err != nil {
+					log("X %s", err.Error())
+				} else {
+					log("G %d", n)
+				}
+				return nil
+			})
+
+		default:
+			_ = c.Rotate()
+		}
+	}
+}
+
+// crashCheckPos check the .pos is within bound of the reading file.
+func crashCheckPos(t *T.T, path string) {
+	t.Helper()
+
+	fname := filepath.Join(path, ".pos")
+	if _, err := os.Stat(fname); err != nil {
+		return
+	}
+
+	p, err := posFromFile(OSStorage{}, fname)
+	require.NoError(t, err, "load .pos")
+
+	if p == nil || p.Name == nil {
+		return
+	}
+
+	fi, err := os.Stat(string(p.Name))
+	if err != nil { // the reading file removed, the .pos reset on Open()
+		return
+	}
+
+	require.LessOrEqual(t, p.Seek, fi.Size(), ".pos %s@%d out of file", p.Name, p.Seek)
+	require.NoError(t, checkRecordOffset(OSStorage{}, string(p.Name), p.Seek),
+		".pos %s@%d not on record boundary", p.Name, p.Seek)
+}
+
+func TestCrashConsistency(t *T.T) {
+	rounds := 50
+	if x, err := strconv.Atoi(os.Getenv(envCrashRounds)); err == nil && x > 0 {
+		rounds = x
+	}
+
+	var (
+		dir     = t.TempDir()
+		path    = filepath.Join(dir, "cache")
+		logFile = filepath.Join(dir, "ops.log")
+		seed    = time.Now().UnixNano()
+		r       = rand.New(rand.NewSource(seed)) //nolint:gosec
+	)
+
+	t.Logf("seed: %d, rounds: %d", seed, rounds)
+
+	for i := 0; i < rounds; i++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestCrashConsistencyChild$") //nolint:gosec
+		cmd.Env = append(os.Environ(),
+			envCrashPath+"="+path,
+			envCrashLog+"="+logFile,
+			envCrashSeed+"="+strconv.FormatInt(r.Int63(), 10),
+			envCrashSeqBase+"="+strconv.Itoa((i+1)*10_000_000),
+		)
+
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		require.NoError(t, cmd.Start())
+
+		time.Sleep(time.Duration(50+r.Intn(450)) * time.Millisecond)
+		require.NoError(t, cmd.Process.Signal(syscall.SIGKILL))
+
+		err := cmd.Wait()
+		var ee *exec.ExitError
+		if !errors.As(err, &ee) || ee.Sys().(syscall.WaitStatus).Signal() != syscall.SIGKILL {
+			t.Fatalf("round %d: child not killed: %v\nstderr:\n%s", i, err, stderr.String())
+		}
+
+		crashCheckPos(t, path)
+
+		// reopen ok after crash
+		c, err := crashOpen(path)
+		require.NoError(t, err, "round %d: reopen after crash", i)
+		require.NoError(t, c.Close())
+		ResetMetrics()
+	}
+
+	// drain all records left in the cache
+	c, err := crashOpen(path)
+	require.NoError(t, err)
+
+	var drained []int64
+	deadline := time.Now().Add(time.Minute)
+	for time.Now().Before(deadline) {
+		err := c.Get(func(data []byte) error {
+			seq, err := crashParse(data)
+			require.NoError(t, err, "drain")
+			drained = append(drained, seq)
+			return nil
+		})
+
+		if errors.Is(err, ErrNoData) {
+			if c.curBatchSize == 0 {
+				break
+			}
+			time.Sleep(100 * time.Millisecond) // wait the writing file wakeup
+			continue
+		}
+
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, c.Close())
+	ResetMetrics()
+
+	// check the log
+	f, err := os.Open(logFile)
+	require.NoError(t, err)
+	defer f.Close() //nolint:errcheck
+
+	var (
+		attempted = map[int64]bool{}
+		acked     = map[int64]bool{}
+		got       = map[int64]int{}
+		broken    []string
+	)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		arr := strings.SplitN(line, " ", 2)
+		if len(arr) != 2 { // partial line on kill
+			continue
+		}
+
+		if arr[0] == "X" {
+			broken = append(broken, arr[1])
+			continue
+		}
+
+		seq, err := strconv.ParseInt(arr[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		switch arr[0] {
+		case "A":
+			attempted[seq] = true
+		case "P":
+			acked[seq] = true
+		case "G":
+			got[seq]++
+		}
+	}
+	require.NoError(t, scanner.Err())
+
+	for _, seq := range drained {
+		got[seq]++
+	}
+
+	assert.Empty(t, broken, "broken records returned")
+
+	// no phantom records
+	for seq := range got {
+		assert.True(t, attempted[seq], "phantom record %d", seq)
+	}
+
+	// no acked record lost
+	lost := 0
+	for seq := range acked {
+		if got[seq] == 0 {
+			lost++
+			t.Logf("lost %d", seq)
+		}
+	}
+	assert.Zero(t, lost, "%d acked records lost", lost)
+
+	// duplicated records within the at-least-once window
+	dup := 0
+	for _, n := range got {
+		dup += n - 1
+	}
+	assert.LessOrEqual(t, dup, rounds*crashDupWindow, "too many duplicated records")
+
+	t.Logf("attempted %d, acked %d, returned %d(%d drained), duplicated %d",
+		len(attempted), len(acked), len(got), len(drained), dup)
+}
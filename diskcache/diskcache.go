@@ -14,6 +14,9 @@
 //  5. Auto-rotate on batch size.
 //  6. Drop in FIFO policy when max capacity reached.
 //  7. We can configure various specifics in environments without to modify options source code.
+//  8. Drop expired data files when max-age set.
+//  9. Pluggable storage backend(local file system, in-memory and fault-injecting).
+//  10. Multiple producers among processes on the same cache.
 package diskcache
 
 import (
@@ -84,8 +87,15 @@ type DiskCache struct {
 	curWriteFile,
 	curReadfile string
 
+	// storage that data files stored on, default on local file system
+	fs Storage
+
 	// current write/read fd
-	wfd, rfd *os.File
+	wfd, rfd File
+
+	// read-ahead buffer on rfd, nil if disabled
+	readAheadSize int
+	ra            *readAhead
 
 	// If current write file go nothing put for a
 	// long time(wakeup), we rotate it manually.
@@ -94,6 +104,31 @@ type DiskCache struct {
 	// how long to wakeup a sleeping write-file
 	wakeup time.Duration
 
+	// data older than maxAge are dropped on Get(), 0 means never expire
+	maxAge time.Duration
+
+	// keep free space on the filesystem, the capacity shrink on low free space
+	freeSpaceReserve int64
+	freeSpaceCB      FreeSpaceCallback
+	fsw              *freeSpaceWatcher
+
+	// limit bytes/records on Get()
+	drainBytesRate,
+	drainRecordsRate float64
+	drain *drainLimiter
+
+	// growth rate of the cache size, used by Backpressure()
+	growth *growthTracker
+
+	// accept data from producers in other processes
+	multiProducer    bool
+	segmentRecoverAt time.Time
+
+	// group commit: concurrent Put() queued and synced once for each group
+	groupCommitInterval time.Duration
+	groupCommitBytes    int
+	gc                  *groupCommitter
+
 	wlock  *InstrumentedMutex // write-lock: used to exclude concurrent Put to the header file.
 	rlock  *InstrumentedMutex // read-lock: used to exclude concurrent Get on the tail file.
 	rwlock *InstrumentedMutex // used to exclude switch/rotate/drop/Close on current disk cache instance.
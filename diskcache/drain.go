@@ -0,0 +1,126 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// drainLimiter limit the bytes and records that Get() from the cache.
+type drainLimiter struct {
+	bytes, records *rate.Limiter
+
+	// canceled on Close(), to wakeup the waiting Get()
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newDrainLimiter(bytesPerSec, recordsPerSec float64) *drainLimiter {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	d := &drainLimiter{
+		bytes:   rate.NewLimiter(rate.Inf, 1),
+		records: rate.NewLimiter(rate.Inf, 1),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	d.set(bytesPerSec, recordsPerSec)
+	return d
+}
+
+func setLimit(lim *rate.Limiter, perSec float64) {
+	if perSec <= 0 {
+		lim.SetLimit(rate.Inf)
+		return
+	}
+
+	// burst up to 1 second
+	burst := int(math.Min(math.Ceil(perSec), math.MaxInt32))
+	lim.SetBurst(burst)
+	lim.SetLimit(rate.Limit(perSec))
+}
+
+func limitOf(lim *rate.Limiter) float64 {
+	if x := lim.Limit(); x != rate.Inf {
+		return float64(x)
+	}
+	return 0
+}
+
+func (d *drainLimiter) set(bytesPerSec, recordsPerSec float64) {
+	setLimit(d.bytes, bytesPerSec)
+	setLimit(d.records, recordsPerSec)
+}
+
+func (d *drainLimiter) limited() bool {
+	return d.bytes.Limit() != rate.Inf || d.records.Limit() != rate.Inf
+}
+
+// wait until a record with n bytes allowed to drain.
+func (d *drainLimiter) wait(n int) error {
+	if err := d.records.Wait(d.ctx); err != nil {
+		return err
+	}
+
+	// record larger than burst are waited in pieces
+	for n > 0 {
+		x := n
+		if b := d.bytes.Burst(); d.bytes.Limit() != rate.Inf && x > b {
+			x = b
+		}
+
+		if err := d.bytes.WaitN(d.ctx, x); err != nil {
+			return err
+		}
+
+		n -= x
+	}
+
+	return nil
+}
+
+// drainWait wait for drain limit before Get() a record with n bytes.
+func (c *DiskCache) drainWait(n int) error {
+	if c.drain == nil || !c.drain.limited() {
+		return nil
+	}
+
+	start := time.Now()
+	defer func() {
+		drainWaitVec.WithLabelValues(c.path).Observe(time.Since(start).Seconds())
+	}()
+
+	if err := c.drain.wait(n); err != nil {
+		if c.drain.ctx.Err() != nil {
+			return ErrClosed
+		}
+		return err
+	}
+
+	return nil
+}
+
+// SetDrainRate set the max bytes and records per second that Get() from the
+// cache, 0 for no limit. The rate can be changed at any time.
+func (c *DiskCache) SetDrainRate(bytesPerSec, recordsPerSec float64) {
+	if c.drain != nil {
+		c.drain.set(bytesPerSec, recordsPerSec)
+	}
+}
+
+// DrainRate return current bytes and records per second limit on Get(), 0 for no limit.
+func (c *DiskCache) DrainRate() (bytesPerSec, recordsPerSec float64) {
+	if c.drain == nil {
+		return 0, 0
+	}
+
+	return limitOf(c.drain.bytes), limitOf(c.drain.records)
+}
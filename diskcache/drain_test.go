@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"errors"
+	"fmt"
+	T "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrainRate(t *T.T) {
+	t.Run(`records-per-sec`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithDrainRate(0, 20))
+		require.NoError(t, err)
+
+		for i := 0; i < 30; i++ {
+			require.NoError(t, c.Put([]byte(fmt.Sprintf("data-%d", i))))
+		}
+		require.NoError(t, c.Rotate())
+
+		// 20 records within the burst, the other 10 need 0.5s
+		start := time.Now()
+		for i := 0; i < 30; i++ {
+			require.NoError(t, c.Get(func(x []byte) error {
+				assert.Equal(t, fmt.Sprintf("data-%d", i), string(x))
+				return nil
+			}))
+		}
+		assert.Greater(t, time.Since(start), 400*time.Millisecond)
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`bytes-per-sec`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithDrainRate(1000, 0))
+		require.NoError(t, err)
+
+		// record larger than the burst
+		require.NoError(t, c.Put(make([]byte, 1500)))
+		require.NoError(t, c.Rotate())
+
+		start := time.Now()
+		require.NoError(t, c.Get(func(x []byte) error {
+			assert.Len(t, x, 1500)
+			return nil
+		}))
+		assert.Greater(t, time.Since(start), 400*time.Millisecond)
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`set-at-runtime`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		b, r := c.DrainRate()
+		assert.Zero(t, b)
+		assert.Zero(t, r)
+
+		c.SetDrainRate(1024, 10)
+		b, r = c.DrainRate()
+		assert.Equal(t, 1024.0, b)
+		assert.Equal(t, 10.0, r)
+
+		c.SetDrainRate(0, 0)
+		b, r = c.DrainRate()
+		assert.Zero(t, b)
+		assert.Zero(t, r)
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`close-during-wait`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithDrainRate(0, 0.1), WithPosUpdate(0, 0))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put([]byte("first")))
+		require.NoError(t, c.Put([]byte("second")))
+		require.NoError(t, c.Rotate())
+
+		require.NoError(t, c.Get(nil))
+
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			time.Sleep(50 * time.Millisecond)
+			assert.NoError(t, c.Close())
+		}()
+
+		start := time.Now()
+		err = c.Get(nil)
+		assert.True(t, errors.Is(err, ErrClosed), "got %v", err)
+		assert.Less(t, time.Since(start), 5*time.Second)
+
+		<-closed
+		ResetMetrics()
+
+		// the waiting record not lost
+		c, err = Open(WithPath(p))
+		require.NoError(t, err)
+		require.NoError(t, c.Get(func(x []byte) error {
+			assert.Equal(t, []byte("second"), x)
+			return nil
+		}))
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+}
+
+func TestBackpressure(t *T.T) {
+	t.Run(`growth-rate`, func(t *T.T) {
+		g := newGrowthTracker()
+		now := time.Now()
+
+		assert.Zero(t, g.sample(0, now))
+		assert.Equal(t, 500.0, g.sample(1000, now.Add(time.Second)))
+
+		// sample too close ignored
+		assert.Equal(t, 500.0, g.sample(5000, now.Add(time.Second+time.Millisecond)))
+
+		// shrinking
+		assert.Equal(t, -250.0, g.sample(0, now.Add(2*time.Second)))
+	})
+
+	t.Run(`fill-ratio-and-time-to-full`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithCapacity(1<<20))
+		require.NoError(t, err)
+
+		bp := c.Backpressure()
+		assert.Equal(t, int64(1<<20), bp.Capacity)
+		assert.Zero(t, bp.FillRatio)
+		assert.Equal(t, time.Duration(-1), bp.TimeToFull)
+
+		// size of the rotated file(EOF included) is 256KB
+		require.NoError(t, c.Put(make([]byte, 1<<18-2*dataHeaderLen)))
+		require.NoError(t, c.Rotate())
+
+		// grow 1KB per second
+		c.growth.rate = 1024
+		c.growth.lastTime = time.Now()
+
+		bp = c.Backpressure()
+		assert.Equal(t, int64(1<<18), bp.Size)
+		assert.Equal(t, 0.25, bp.FillRatio)
+		assert.Equal(t, 1024.0, bp.GrowthRate)
+		assert.Equal(t, 768*time.Second, bp.TimeToFull)
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`no-capacity`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put([]byte("hello")))
+
+		bp := c.Backpressure()
+		assert.Zero(t, bp.Capacity)
+		assert.Zero(t, bp.FillRatio)
+		assert.Equal(t, time.Duration(-1), bp.TimeToFull)
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+}
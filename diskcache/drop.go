@@ -5,14 +5,11 @@
 
 package diskcache
 
-import (
-	"os"
-)
-
 const (
 	reasonExceedCapacity     = "exceed-max-capacity"
 	reasonBadDataFile        = "bad-data-file"
 	reasonTooSmallReadBuffer = "too-small-read-buffer"
+	reasonExpired            = "expired"
 )
 
 func (c *DiskCache) dropBatch() error {
@@ -35,8 +32,8 @@ func (c *DiskCache) dropBatch() error {
 		c.rfd = nil
 	}
 
-	if fi, err := os.Stat(fname); err == nil {
-		if err := os.Remove(fname); err != nil {
+	if fi, err := c.fs.Stat(fname); err == nil {
+		if err := c.fs.Remove(fname); err != nil {
 			return WrapFileOperationError(OpRemove, err, c.path, fname).
 				WithDetails("failed_to_remove_file_during_drop")
 		}
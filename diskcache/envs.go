@@ -50,6 +50,18 @@ func (c *DiskCache) syncEnv() {
 		}
 	}
 
+	if v, ok := os.LookupEnv("ENV_DISKCACHE_MAX_AGE"); ok && v != "" {
+		if du, err := time.ParseDuration(v); err == nil && du > 0 {
+			c.maxAge = du
+		}
+	}
+
+	if v, ok := os.LookupEnv("ENV_DISKCACHE_FREE_SPACE_RESERVE"); ok && v != "" {
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil && i > 0 {
+			c.freeSpaceReserve = i
+		}
+	}
+
 	if v, ok := os.LookupEnv("ENV_DISKCACHE_NO_LOCK"); ok && v != "" {
 		c.noLock = true
 	}
@@ -34,6 +34,9 @@ const (
 	OpRemove    Operation = "Remove"
 	OpRename    Operation = "Rename"
 	OpStat      Operation = "Stat"
+	OpTruncate  Operation = "Truncate"
+	OpMkdir     Operation = "Mkdir"
+	OpList      Operation = "List"
 )
 
 // CacheError represents an enhanced error with operation context and details.
@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"time"
+)
+
+// expired test if all data within fname are older than max-age.
+//
+// The mtime of a data file is the time rotate() appended the EOF hint to
+// it, which is not before the last Put() on it, and the rename keep the
+// mtime. So if the mtime expired, all records within the file expired, and
+// records may live at most the rotate interval longer than max-age.
+func (c *DiskCache) expired(fname string) (bool, int64) {
+	if c.maxAge <= 0 || fname == "" {
+		return false, 0
+	}
+
+	fi, err := c.fs.Stat(fname)
+	if err != nil {
+		return false, 0
+	}
+
+	return time.Since(fi.ModTime()) > c.maxAge, fi.Size()
+}
+
+// dropExpiredFiles remove expired data files from the head of the reading list.
+// The caller should hold the rwlock.
+func (c *DiskCache) dropExpiredFiles() error {
+	for len(c.dataFiles) > 0 {
+		fname := c.dataFiles[0]
+
+		ok, size := c.expired(fname)
+		if !ok {
+			return nil
+		}
+
+		if c.rfd != nil && c.curReadfile == fname {
+			if err := c.rfd.Close(); err != nil {
+				return WrapFileOperationError(OpClose, err, c.path, fname).
+					WithDetails("failed_to_close_read_file_during_expire")
+			}
+
+			c.rfd = nil
+			c.curReadfile = ""
+		}
+
+		if err := c.fs.Remove(fname); err != nil {
+			return WrapFileOperationError(OpRemove, err, c.path, fname).
+				WithDetails("failed_to_remove_expired_file")
+		}
+
+		l.Infof("drop expired file %s with size %d bytes", fname, size)
+
+		// the same as rotate(), file with only the EOF hint not counted in size
+		if size > dataHeaderLen {
+			c.size.Add(-size)
+			sizeVec.WithLabelValues(c.path).Sub(float64(size))
+		}
+		c.dataFiles = c.dataFiles[1:]
+
+		droppedDataVec.WithLabelValues(c.path, reasonExpired).Observe(float64(size))
+		datafilesVec.WithLabelValues(c.path).Set(float64(len(c.dataFiles)))
+	}
+
+	return nil
+}
+
+// expireReadingFile drop current reading file if it expired.
+func (c *DiskCache) expireReadingFile() error {
+	if c.rfd == nil {
+		return nil
+	}
+
+	if ok, _ := c.expired(c.curReadfile); !ok {
+		return nil
+	}
+
+	c.rwlock.Lock()
+	err := c.dropExpiredFiles()
+	c.rwlock.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if c.rfd == nil { // current reading file dropped, switch to next one
+		return c.doSwitchNextFile()
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"errors"
+	"os"
+	T "testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxAge(t *T.T) {
+	t.Run(`drop-expired-files`, func(t *T.T) {
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(Metrics()...)
+
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithMaxAge(time.Hour))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put([]byte("old-data")))
+		require.NoError(t, c.Rotate())
+
+		require.NoError(t, c.Put([]byte("new-data")))
+		require.NoError(t, c.Rotate())
+
+		require.Len(t, c.dataFiles, 2)
+
+		// make the first file expired
+		old := time.Now().Add(-2 * time.Hour)
+		require.NoError(t, os.Chtimes(c.dataFiles[0], old, old))
+
+		require.NoError(t, c.Get(func(x []byte) error {
+			assert.Equal(t, []byte("new-data"), x)
+			return nil
+		}))
+
+		assert.True(t, errors.Is(c.Get(nil), ErrNoData))
+
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+
+		m := metrics.GetMetricOnLabels(mfs, "diskcache_dropped_data", c.path, reasonExpired)
+		require.NotNil(t, m, "got metrics\n%s", metrics.MetricFamily2Text(mfs))
+		assert.Equal(t, uint64(1), m.GetSummary().GetSampleCount())
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`expire-eof-only-file`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithMaxAge(time.Hour))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Rotate()) // nothing put, only the EOF hint
+		require.NoError(t, c.Put([]byte("data")))
+		require.NoError(t, c.Rotate())
+		require.Len(t, c.dataFiles, 2)
+
+		old := time.Now().Add(-2 * time.Hour)
+		for _, f := range c.dataFiles {
+			require.NoError(t, os.Chtimes(f, old, old))
+		}
+
+		assert.True(t, errors.Is(c.Get(nil), ErrNoData))
+		assert.Equal(t, int64(0), c.size.Load(), "Size() hide the negative size on no data file")
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`expire-reading-file`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithMaxAge(time.Hour))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put([]byte("data-1")))
+		require.NoError(t, c.Put([]byte("data-2")))
+		require.NoError(t, c.Rotate())
+
+		require.NoError(t, c.Get(func(x []byte) error {
+			assert.Equal(t, []byte("data-1"), x)
+			return nil
+		}))
+
+		// file expired during reading
+		old := time.Now().Add(-2 * time.Hour)
+		require.NoError(t, os.Chtimes(c.curReadfile, old, old))
+
+		assert.True(t, errors.Is(c.Get(nil), ErrNoData))
+		assert.Equal(t, int64(0), c.Size())
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`no-max-age`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put([]byte("data")))
+		require.NoError(t, c.Rotate())
+
+		old := time.Now().Add(-24 * 365 * time.Hour)
+		require.NoError(t, os.Chtimes(c.dataFiles[0], old, old))
+
+		require.NoError(t, c.Get(func(x []byte) error {
+			assert.Equal(t, []byte("data"), x)
+			return nil
+		}))
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+}
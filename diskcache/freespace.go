@@ -0,0 +1,143 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"sync"
+	"time"
+)
+
+// FreeSpaceCallback called when free space of the cache's filesystem
+// crossed the reserve watermark, low is true if free space below the reserve.
+//
+// The callback called within Put() after all locks of the cache released, so
+// it's ok to call into the cache, but it blocks the Put().
+type FreeSpaceCallback func(low bool, free int64)
+
+// freeSpaceEvent is a crossing of the reserve watermark not notified yet.
+type freeSpaceEvent struct {
+	low  bool
+	free int64
+}
+
+// statFreeSpace used to get free space, replaced within testing.
+var statFreeSpace = diskFreeSpace
+
+// freeSpaceWatcher watch free space of the filesystem that the cache located,
+// the free space cached for a while to avoid statfs on each Put().
+type freeSpaceWatcher struct {
+	path     string
+	reserve  int64
+	interval time.Duration
+	cb       FreeSpaceCallback
+
+	mu        sync.Mutex
+	lastCheck time.Time
+	free      int64
+	low       bool
+	pending   []freeSpaceEvent
+}
+
+func newFreeSpaceWatcher(path string, reserve int64, cb FreeSpaceCallback) *freeSpaceWatcher {
+	return &freeSpaceWatcher{
+		path:     path,
+		reserve:  reserve,
+		interval: time.Second,
+		cb:       cb,
+	}
+}
+
+// freeBytes return (cached) free bytes of the filesystem.
+func (w *freeSpaceWatcher) freeBytes() int64 {
+	w.mu.Lock()
+
+	if time.Since(w.lastCheck) < w.interval {
+		defer w.mu.Unlock()
+		return w.free
+	}
+
+	w.lastCheck = time.Now()
+
+	free, err := statFreeSpace(w.path)
+	if err != nil {
+		defer w.mu.Unlock()
+		l.Warnf("statfs on %s: %s, use last free space %d", w.path, err.Error(), w.free)
+		return w.free
+	}
+
+	w.free = free
+	freeSpaceVec.WithLabelValues(w.path).Set(float64(free))
+
+	low := free < w.reserve
+	crossed := low != w.low
+	w.low = low
+
+	// freeBytes called with locks of the cache held, the callback delayed
+	// to notify().
+	if crossed && w.cb != nil {
+		w.pending = append(w.pending, freeSpaceEvent{low: low, free: free})
+	}
+	w.mu.Unlock()
+
+	if crossed {
+		if low {
+			l.Warnf("free space %d below reserve %d on %s", free, w.reserve, w.path)
+			lowFreeSpaceVec.WithLabelValues(w.path).Inc()
+		} else {
+			l.Infof("free space %d back above reserve %d on %s", free, w.reserve, w.path)
+		}
+	}
+
+	return free
+}
+
+// notify call the callback on crossings since last notify, the caller
+// should not hold any lock of the cache.
+func (w *freeSpaceWatcher) notify() {
+	if w == nil || w.cb == nil {
+		return
+	}
+
+	w.mu.Lock()
+	events := w.pending
+	w.pending = nil
+	w.mu.Unlock()
+
+	for _, e := range events {
+		w.cb(e.low, e.free)
+	}
+}
+
+// EffectiveCapacity return current capacity of the cache.
+//
+// If free space reserve set, the capacity shrink to keep the reserved free
+// space on the filesystem, and never exceed the configured capacity.
+func (c *DiskCache) EffectiveCapacity() int64 {
+	if c.fsw == nil {
+		return c.capacity
+	}
+
+	// all space we can use: current used plus free space not reserved.
+	limit := c.size.Load() + c.fsw.freeBytes() - c.fsw.reserve
+	if limit < 0 {
+		limit = 0
+	}
+
+	if c.capacity > 0 && c.capacity < limit {
+		return c.capacity
+	}
+
+	return limit
+}
+
+// isFull test if reach capacity limit after n bytes put into cache.
+func (c *DiskCache) isFull(n int64) bool {
+	if c.fsw != nil {
+		return c.size.Load()+n > c.EffectiveCapacity()
+	}
+
+	return c.capacity > 0 && c.size.Load()+n > c.capacity
+}
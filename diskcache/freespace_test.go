@@ -0,0 +1,152 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"bytes"
+	"errors"
+	"sync/atomic"
+	T "testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreeSpaceReserve(t *T.T) {
+	t.Run(`real-statfs`, func(t *T.T) {
+		free, err := diskFreeSpace(t.TempDir())
+		require.NoError(t, err)
+		assert.Greater(t, free, int64(0))
+	})
+
+	t.Run(`shrink-capacity`, func(t *T.T) {
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(Metrics()...)
+
+		var free atomic.Int64
+		free.Store(1 << 20)
+
+		statFreeSpace = func(string) (int64, error) { return free.Load(), nil }
+		defer func() { statFreeSpace = diskFreeSpace }()
+
+		var events []bool
+		p := t.TempDir()
+		c, err := Open(WithPath(p),
+			WithCapacity(1<<30),
+			WithFreeSpaceReserve(512*1024),
+			WithFreeSpaceCallback(func(low bool, _ int64) {
+				events = append(events, low)
+			}),
+			WithNoDrop(true))
+		require.NoError(t, err)
+
+		c.fsw.interval = 0 // always statfs
+
+		// capacity limited by the free space
+		assert.Equal(t, int64(512*1024), c.EffectiveCapacity())
+
+		sample := bytes.Repeat([]byte("x"), 1024)
+		require.NoError(t, c.Put(sample))
+
+		// other processes used the disk
+		free.Store(256 * 1024)
+		assert.Equal(t, int64(0), c.EffectiveCapacity())
+		assert.True(t, errors.Is(c.Put(sample), ErrCacheFull))
+
+		// disk space released
+		free.Store(1 << 40)
+		assert.Equal(t, int64(1<<30), c.EffectiveCapacity())
+		assert.NoError(t, c.Put(sample))
+
+		assert.Equal(t, []bool{true, false}, events)
+
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+
+		m := metrics.GetMetricOnLabels(mfs, "diskcache_low_free_space_total", c.path)
+		require.NotNil(t, m, "got metrics\n%s", metrics.MetricFamily2Text(mfs))
+		assert.Equal(t, float64(1), m.GetCounter().GetValue())
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`drop-early`, func(t *T.T) {
+		statFreeSpace = func(string) (int64, error) { return 0, nil }
+		defer func() { statFreeSpace = diskFreeSpace }()
+
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithFreeSpaceReserve(1024))
+		require.NoError(t, err)
+
+		sample := bytes.Repeat([]byte("x"), 1024)
+		for i := 0; i < 3; i++ {
+			c.fsw.interval = 0
+			require.NoError(t, c.Put(sample))
+			require.NoError(t, c.Rotate())
+		}
+
+		// on each Put(), the old data dropped
+		assert.LessOrEqual(t, len(c.dataFiles), 1)
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+	t.Run(`callback-call-into-cache`, func(t *T.T) {
+		var free atomic.Int64
+		free.Store(1 << 20)
+
+		statFreeSpace = func(string) (int64, error) { return free.Load(), nil }
+		defer func() { statFreeSpace = diskFreeSpace }()
+
+		var (
+			c      *DiskCache
+			events []int64
+		)
+
+		p := t.TempDir()
+		c, err := Open(WithPath(p),
+			WithFreeSpaceReserve(512*1024),
+			WithFreeSpaceCallback(func(low bool, _ int64) {
+				events = append(events, c.Size())
+				if low {
+					assert.NoError(t, c.Put([]byte("from-callback")))
+				}
+			}))
+		require.NoError(t, err)
+
+		c.fsw.interval = 0
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			free.Store(256 * 1024)
+			assert.NoError(t, c.Put([]byte("x")))
+			free.Store(1 << 20)
+			assert.NoError(t, c.Put([]byte("x")))
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatal("Put() blocked by the free space callback")
+		}
+
+		assert.Len(t, events, 2)
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+}
@@ -110,6 +110,13 @@ func (c *DiskCache) doGet(buf []byte, fn Fn, bfn BufFunc) error {
 		}
 	}
 
+	if c.maxAge > 0 {
+		if err = c.expireReadingFile(); err != nil {
+			return WrapGetError(err, c.path, c.readFileName()).
+				WithDetails("failed_to_expire_reading_file")
+		}
+	}
+
 	if c.rfd == nil { // no file reading, reading on the first file
 		if err = c.switchNextFile(); err != nil {
 			return WrapGetError(err, c.path, "")
@@ -121,7 +128,7 @@ retry:
 		return ErrNoData
 	}
 
-	if n, err = c.rfd.Read(c.batchHeader); err != nil || n != dataHeaderLen {
+	if n, err = c.readData(c.batchHeader); err != nil || n != dataHeaderLen {
 		if err != nil && !errors.Is(err, io.EOF) {
 			l.Errorf("read %d bytes header error: %s", dataHeaderLen, err.Error())
 			err = WrapFileOperationError(OpRead, err, c.path, c.readFileName()).
@@ -153,6 +160,16 @@ retry:
 		goto retry // read next new file to save another Get() calling.
 	}
 
+	if werr := c.drainWait(nbytes); werr != nil {
+		// put back the header, the record read on next Get()
+		if _, serr := c.seekRead(-int64(dataHeaderLen)); serr != nil {
+			return WrapFileOperationError(OpSeek, serr, c.path, c.readFileName()).
+				WithDetails("failed_to_seek_back_on_drain_wait")
+		}
+
+		return WrapGetError(werr, c.path, c.readFileName()).WithDetails("drain_wait_failed")
+	}
+
 	var readbuf []byte
 
 	switch {
@@ -166,7 +183,7 @@ retry:
 
 	if len(readbuf) < nbytes {
 		// seek to next read position
-		if x, err := c.rfd.Seek(int64(nbytes), io.SeekCurrent); err != nil {
+		if x, err := c.seekRead(int64(nbytes)); err != nil {
 			return WrapFileOperationError(OpSeek, err, c.path, c.readFileName()).
 				WithDetails(fmt.Sprintf("failed_to_seek_past_data: data_size=%d", nbytes))
 		} else {
@@ -179,7 +196,7 @@ retry:
 		}
 	}
 
-	if n, err := c.rfd.Read(readbuf[:nbytes]); err != nil {
+	if n, err := c.readData(readbuf[:nbytes]); err != nil {
 		return WrapFileOperationError(OpRead, err, c.path, c.readFileName()).
 			WithDetails(fmt.Sprintf("data_read: expected=%d, actual=%d", nbytes, n))
 	} else if n != nbytes {
@@ -194,7 +211,7 @@ retry:
 	if err = fn(readbuf[:nbytes]); err != nil {
 		// seek back
 		if !c.noFallbackOnError {
-			if _, serr := c.rfd.Seek(-int64(dataHeaderLen+nbytes), io.SeekCurrent); serr != nil {
+			if _, serr := c.seekRead(-int64(dataHeaderLen + nbytes)); serr != nil {
 				return WrapFileOperationError(OpSeek, serr, c.path, c.readFileName()).
 					WithDetails(fmt.Sprintf("fallback_seek_failed: offset=%d", -int64(dataHeaderLen+nbytes)))
 			}
@@ -340,7 +340,7 @@ func TestPutGet(t *T.T) {
 		assert.NoError(t, c.Get(func(data []byte) error { return nil }))
 		assert.Error(t, c.Get(func(data []byte) error { return nil })) // EOF
 
-		pos, err := posFromFile(c.pos.fname)
+		pos, err := posFromFile(c.fs, c.pos.fname)
 		assert.NoError(t, err)
 
 		t.Logf("pos: %s", pos)
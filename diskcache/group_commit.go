@@ -0,0 +1,148 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type commitReq struct {
+	data  []byte
+	start time.Time
+	done  chan error
+}
+
+// groupCommitter queue concurrent Put() and write them together, the
+// writing file synced once for each group.
+type groupCommitter struct {
+	c *DiskCache
+
+	interval time.Duration
+	maxBytes int
+
+	reqs chan *commitReq
+	exit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newGroupCommitter(c *DiskCache) *groupCommitter {
+	gc := &groupCommitter{
+		c:        c,
+		interval: c.groupCommitInterval,
+		maxBytes: c.groupCommitBytes,
+		reqs:     make(chan *commitReq, 1024),
+		exit:     make(chan struct{}),
+	}
+
+	if gc.maxBytes <= 0 {
+		gc.maxBytes = math.MaxInt
+	}
+
+	gc.wg.Add(1)
+	go func() {
+		defer gc.wg.Done()
+		gc.run()
+	}()
+
+	return gc
+}
+
+// put queue the data and wait until the group it belongs to committed.
+func (gc *groupCommitter) put(data []byte) error {
+	req := &commitReq{
+		data:  data,
+		start: time.Now(),
+		done:  make(chan error, 1),
+	}
+
+	select {
+	case gc.reqs <- req:
+	case <-gc.exit:
+		return WrapPutError(ErrClosed, gc.c.path, len(data))
+	}
+
+	return <-req.done
+}
+
+func (gc *groupCommitter) run() {
+	var (
+		batch []*commitReq
+		bytes int
+	)
+
+	for {
+		select {
+		case <-gc.exit:
+			return
+
+		case req := <-gc.reqs:
+			batch = append(batch[:0], req)
+			bytes = len(req.data)
+
+			timer := time.NewTimer(gc.interval)
+
+		collect:
+			for bytes < gc.maxBytes {
+				select {
+				case req := <-gc.reqs:
+					batch = append(batch, req)
+					bytes += len(req.data)
+				case <-timer.C:
+					break collect
+				}
+			}
+
+			timer.Stop()
+			gc.commit(batch, bytes)
+		}
+	}
+}
+
+// commit write all data within batch, and sync once.
+func (gc *groupCommitter) commit(batch []*commitReq, bytes int) {
+	c := gc.c
+
+	c.wlock.Lock()
+
+	written := 0
+	errs := make([]error, len(batch))
+	for i, req := range batch {
+		if errs[i] = c.doPut(req.data, false); errs[i] == nil {
+			written++
+		}
+	}
+
+	if written > 0 && !c.noSync && c.wfd != nil {
+		if err := c.wfd.Sync(); err != nil {
+			serr := WrapFileOperationError(OpSync, err, c.path, c.writeFileName()).
+				WithDetails("failed_to_sync_group_commit")
+
+			for i := range errs {
+				if errs[i] == nil {
+					errs[i] = serr
+				}
+			}
+		}
+	}
+
+	c.wlock.Unlock()
+
+	groupCommitBatchVec.WithLabelValues(c.path).Observe(float64(len(batch)))
+	groupCommitBytesVec.WithLabelValues(c.path).Observe(float64(bytes))
+
+	for i, req := range batch {
+		groupCommitLatencyVec.WithLabelValues(c.path).Observe(time.Since(req.start).Seconds())
+		req.done <- errs[i]
+	}
+}
+
+// stop the committer, all queued Put() should be committed before stop.
+func (gc *groupCommitter) stop() {
+	close(gc.exit)
+	gc.wg.Wait()
+}
@@ -0,0 +1,133 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	T "testing"
+	"time"
+
+	"github.com/GuanceCloud/cliutils/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupCommit(t *T.T) {
+	t.Run(`concurrent-put`, func(t *T.T) {
+		reg := prometheus.NewRegistry()
+		reg.MustRegister(Metrics()...)
+
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithGroupCommit(10*time.Millisecond, 0), WithBatchSize(4*1024))
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					assert.NoError(t, c.Put([]byte(fmt.Sprintf("worker-%d-%d", i, j))))
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		require.NoError(t, c.Rotate())
+
+		n := 0
+		for {
+			if err := c.Get(func([]byte) error { return nil }); err != nil {
+				require.True(t, errors.Is(err, ErrNoData))
+				break
+			}
+			n++
+		}
+
+		assert.Equal(t, 8*50, n)
+
+		mfs, err := reg.Gather()
+		require.NoError(t, err)
+
+		m := metrics.GetMetricOnLabels(mfs, "diskcache_group_commit_batch_size", c.path)
+		require.NotNil(t, m, "got metrics\n%s", metrics.MetricFamily2Text(mfs))
+
+		// some Put() must be committed within the same group
+		assert.Less(t, m.GetSummary().GetSampleCount(), uint64(8*50))
+		assert.Equal(t, float64(8*50), m.GetSummary().GetSampleSum())
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`commit-on-bytes`, func(t *T.T) {
+		p := t.TempDir()
+
+		// the interval is long enough, commit triggered by bytes
+		c, err := Open(WithPath(p), WithGroupCommit(time.Hour, 1))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put([]byte("hello")))
+
+		require.NoError(t, c.Close())
+		assert.True(t, errors.Is(c.Put([]byte("hello")), ErrClosed))
+
+		ResetMetrics()
+	})
+
+	t.Run(`too-large-data`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithGroupCommit(time.Millisecond, 0), WithMaxDataSize(4))
+		require.NoError(t, err)
+
+		assert.True(t, errors.Is(c.Put([]byte("hello")), ErrTooLargeData))
+		assert.NoError(t, c.Put([]byte("hi")))
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+}
+
+func BenchmarkGroupCommit(b *T.B) {
+	data := make([]byte, 1024)
+
+	cases := []struct {
+		name string
+		opts []CacheOption
+	}{
+		{name: "sync", opts: nil},
+		{name: "no-sync", opts: []CacheOption{WithNoSync(true)}},
+		{name: "group-commit", opts: []CacheOption{WithGroupCommit(time.Millisecond, 1024*1024)}},
+	}
+
+	for _, bc := range cases {
+		b.Run(bc.name, func(b *T.B) {
+			c, err := Open(append(bc.opts, WithPath(b.TempDir()))...)
+			require.NoError(b, err)
+
+			b.SetParallelism(128) // many small writers
+			b.ResetTimer()
+			b.RunParallel(func(pb *T.PB) {
+				for pb.Next() {
+					if err := c.Put(data); err != nil {
+						b.Error(err)
+					}
+				}
+			})
+			b.StopTimer()
+
+			require.NoError(b, c.Close())
+			ResetMetrics()
+		})
+	}
+}
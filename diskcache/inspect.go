@@ -0,0 +1,448 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Inspector used to inspect and repair a cache offline.
+//
+// Inspector hold the .lock of the cache, so a cache that in use can't be
+// inspected, and the cache can't be opened during the inspection.
+type Inspector struct {
+	path  string
+	fs    Storage
+	flock *walLock
+}
+
+// DataFileInfo is the scan result of a data file.
+type DataFileInfo struct {
+	Name    string
+	Size    int64
+	Records int
+	Bytes   int64 // data bytes(header excluded)
+	HasEOF  bool  // EOF hint found at the end of file
+	Writing bool  // current writing file that not rotated
+	Err     error // error found during the scan
+}
+
+// OpenInspector open the cache under path for inspection.
+func OpenInspector(path string) (*Inspector, error) {
+	path = filepath.Clean(path)
+
+	if fi, err := os.Stat(path); err != nil {
+		return nil, WrapOpenError(err, path).WithDetails("cache_path_not_found")
+	} else if !fi.IsDir() {
+		return nil, WrapOpenError(os.ErrInvalid, path).WithDetails("cache_path_not_dir")
+	}
+
+	fl := newFlock(path)
+	if ok, err := fl.tryLock(); !ok {
+		if err == nil {
+			err = errors.New("locked")
+		}
+		return nil, WrapLockError(err, path, 0).WithDetails("cache_in_use")
+	}
+
+	if err := recoverCompact(OSStorage{}, path); err != nil {
+		_ = fl.unlock()
+		return nil, err
+	}
+
+	return &Inspector{path: path, fs: OSStorage{}, flock: fl}, nil
+}
+
+// Close release the .lock of the cache.
+func (in *Inspector) Close() error {
+	if in.flock != nil {
+		fl := in.flock
+		in.flock = nil
+		return fl.unlock()
+	}
+
+	return nil
+}
+
+// Path return dir of the cache.
+func (in *Inspector) Path() string {
+	return in.path
+}
+
+func (in *Inspector) writeFile() string {
+	return filepath.Join(in.path, "data")
+}
+
+func (in *Inspector) posFile() string {
+	return filepath.Join(in.path, ".pos")
+}
+
+// files return all data files in Get() order, current writing file appended at the end.
+func (in *Inspector) files() ([]string, error) {
+	files, err := listDataFiles(in.fs, in.path)
+	if err != nil {
+		return nil, NewCacheError(OpOpen, err, "failed_to_list_data_files").WithPath(in.path)
+	}
+
+	if _, err := in.fs.Stat(in.writeFile()); err == nil {
+		files = append(files, in.writeFile())
+	}
+
+	return files, nil
+}
+
+// Pos return the file and offset that the next Get() read from.
+// Empty name returned if there is no .pos. The file within .pos is
+// rebased onto Path(), the cache may be opened with a different path.
+func (in *Inspector) Pos() (string, int64, error) {
+	if _, err := in.fs.Stat(in.posFile()); err != nil {
+		return "", 0, nil
+	}
+
+	p, err := posFromFile(in.fs, in.posFile())
+	if err != nil {
+		return "", 0, err
+	}
+
+	if p == nil || p.Name == nil {
+		return "", 0, nil
+	}
+
+	return filepath.Join(in.path, filepath.Base(string(p.Name))), p.Seek, nil
+}
+
+// PosJSON return JSON content of the .pos.
+func (in *Inspector) PosJSON() ([]byte, error) {
+	if _, err := in.fs.Stat(in.posFile()); err != nil {
+		return nil, nil
+	}
+
+	p, err := posFromFile(in.fs, in.posFile())
+	if err != nil {
+		return nil, err
+	}
+
+	if p == nil {
+		p = &pos{Seek: -1}
+	}
+
+	return p.dumpJSON()
+}
+
+// DataFiles scan all data files and return their details.
+func (in *Inspector) DataFiles() ([]*DataFileInfo, error) {
+	files, err := in.files()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*DataFileInfo
+	for _, f := range files {
+		res = append(res, in.scanFile(f))
+	}
+
+	return res, nil
+}
+
+func (in *Inspector) scanFile(fname string) *DataFileInfo {
+	dfi := &DataFileInfo{
+		Name:    fname,
+		Writing: fname == in.writeFile(),
+	}
+
+	if fi, err := in.fs.Stat(fname); err != nil {
+		dfi.Err = err
+		return dfi
+	} else {
+		dfi.Size = fi.Size()
+	}
+
+	end, eof, err := scanDataFile(in.fs, fname, 0, func(_ int64, data []byte) error {
+		dfi.Records++
+		dfi.Bytes += int64(len(data))
+		return nil
+	})
+
+	dfi.HasEOF = eof
+	dfi.Err = err
+
+	if err == nil && eof && end != dfi.Size {
+		dfi.Err = NewCacheError(OpRead, ErrBadHeader,
+			fmt.Sprintf("extra_bytes_after_eof: eof_at=%d, size=%d", end, dfi.Size)).WithFile(fname)
+	}
+
+	return dfi
+}
+
+// Verify scan all data files, and return error on any bad data file.
+//
+// A data file is bad if any record header invalid(data size exceed the file),
+// or the file truncated, or a rotated file without EOF hint.
+func (in *Inspector) Verify() ([]*DataFileInfo, error) {
+	dfis, err := in.DataFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, dfi := range dfis {
+		switch {
+		case dfi.Err != nil:
+			errs = append(errs, dfi.Err)
+		case !dfi.Writing && !dfi.HasEOF:
+			dfi.Err = NewCacheError(OpRead, ErrBadHeader, "missing_eof_hint").WithFile(dfi.Name)
+			errs = append(errs, dfi.Err)
+		}
+	}
+
+	return dfis, errors.Join(errs...)
+}
+
+// Records iterate records within the cache. If unreadOnly set, records
+// that already Get() are skipped. The iteration stopped if fn return false.
+func (in *Inspector) Records(unreadOnly bool, fn func(*Record) bool) error {
+	files, err := in.files()
+	if err != nil {
+		return err
+	}
+
+	var (
+		posName string
+		posSeek int64
+	)
+
+	if unreadOnly {
+		if posName, posSeek, err = in.Pos(); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range files {
+		off := int64(0)
+		if f == posName && posSeek > 0 {
+			off = posSeek
+		}
+
+		stop := false
+		if _, _, err := scanDataFile(in.fs, f, off, func(off int64, data []byte) error {
+			if !fn(&Record{File: f, Offset: off, Data: data}) {
+				stop = true
+				return errStopScan
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		if stop {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// Export write unread records into w, each record encoded in the same format
+// within the data file(4 bytes little-endian data size + data).
+func (in *Inspector) Export(w io.Writer) (int, error) {
+	var (
+		n    int
+		werr error
+		hdr  = make([]byte, dataHeaderLen)
+	)
+
+	if err := in.Records(true, func(r *Record) bool {
+		binary.LittleEndian.PutUint32(hdr, uint32(len(r.Data)))
+		if _, werr = w.Write(hdr); werr != nil {
+			return false
+		}
+
+		if _, werr = w.Write(r.Data); werr != nil {
+			return false
+		}
+
+		n++
+		return true
+	}); err != nil {
+		return n, err
+	}
+
+	return n, werr
+}
+
+// CompactResult is the result of Compact().
+type CompactResult struct {
+	File       string
+	Records    int
+	SizeBefore int64
+	SizeAfter  int64
+}
+
+// compactedPrefix is the prefix of the file Compact() written, followed by
+// base name of the reading file, it's not a data file.
+const compactedPrefix = ".compacted."
+
+// afterCompactRename called after the compacted file renamed, replaced within
+// testing.
+var afterCompactRename = func() {}
+
+// recoverCompact finish Compact() crashed after .pos pointed to the compacted
+// file, and remove compacted files left by Compact() crashed before that.
+func recoverCompact(fs Storage, path string) error {
+	posFile := filepath.Join(path, ".pos")
+
+	if _, err := fs.Stat(posFile); err == nil {
+		p, err := posFromFile(fs, posFile)
+		if err != nil {
+			return err
+		}
+
+		if p != nil && strings.HasPrefix(filepath.Base(string(p.Name)), compactedPrefix) {
+			var (
+				compacted = filepath.Join(path, filepath.Base(string(p.Name)))
+				name      = filepath.Join(path, strings.TrimPrefix(filepath.Base(compacted), compactedPrefix))
+			)
+
+			// not exist if crashed after the rename
+			if _, err := fs.Stat(compacted); err == nil {
+				l.Warnf("recover compacted file %s", name)
+				if err := fs.Rename(compacted, name); err != nil {
+					return WrapFileOperationError(OpRename, err, path, compacted).
+						WithDetails("failed_to_recover_compacted_file")
+				}
+			}
+
+			np := &pos{fs: fs, fname: posFile, Name: []byte(name), Seek: 0}
+			if err := np.doDumpFile(); err != nil {
+				return err
+			}
+
+			if err := np.close(); err != nil {
+				return err
+			}
+		}
+	}
+
+	files, err := fs.List(path)
+	if err != nil {
+		return NewCacheError(OpList, err, "failed_to_list_directory").WithPath(path)
+	}
+
+	for _, f := range files {
+		if strings.HasPrefix(filepath.Base(f), compactedPrefix) {
+			l.Warnf("remove stale compacted file %s", f)
+			if err := fs.Remove(f); err != nil {
+				return WrapFileOperationError(OpRemove, err, path, f).
+					WithDetails("failed_to_remove_stale_compacted_file")
+			}
+		}
+	}
+
+	return nil
+}
+
+// Compact rewrite the reading file with only unread records, and reset .pos
+// to the start of the file. Records after a broken record are dropped.
+//
+// The compacted file written aside first, then .pos pointed to it, which is
+// the commit point: Compact() crashed before that leaves the cache as it
+// was, and crashed after that is finished on next Open()(or
+// OpenInspector()).
+func (in *Inspector) Compact() (*CompactResult, error) {
+	name, seek, err := in.Pos()
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" || seek <= 0 {
+		return &CompactResult{File: name}, nil // nothing to compact
+	}
+
+	fi, err := in.fs.Stat(name)
+	if err != nil {
+		return nil, WrapFileOperationError(OpStat, err, in.path, name)
+	}
+
+	res := &CompactResult{File: name, SizeBefore: fi.Size()}
+
+	tmp := filepath.Join(in.path, compactedPrefix+filepath.Base(name))
+	fd, err := in.fs.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fi.Mode().Perm())
+	if err != nil {
+		return nil, WrapFileOperationError(OpCreate, err, in.path, tmp)
+	}
+
+	hdr := make([]byte, dataHeaderLen)
+	write := func(b []byte) {
+		if err == nil {
+			_, err = fd.Write(b)
+		}
+	}
+
+	_, _, serr := scanDataFile(in.fs, name, seek, func(_ int64, data []byte) error {
+		binary.LittleEndian.PutUint32(hdr, uint32(len(data)))
+		write(hdr)
+		write(data)
+		res.Records++
+		return err
+	})
+
+	if serr != nil {
+		l.Warnf("drop broken records within %s: %s", name, serr.Error())
+	}
+
+	binary.LittleEndian.PutUint32(hdr, EOFHint)
+	write(hdr)
+
+	if err == nil {
+		err = fd.Sync()
+	}
+
+	if cerr := fd.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		_ = in.fs.Remove(tmp)
+		return nil, WrapFileOperationError(OpWrite, err, in.path, tmp).WithDetails("failed_to_write_compact_file")
+	}
+
+	// commit: point .pos to the compacted file
+	p := &pos{fs: in.fs, fname: in.posFile(), Name: []byte(tmp), Seek: 0}
+	if err = p.doDumpFile(); err == nil {
+		err = p.fd.Sync()
+	}
+	if err != nil {
+		_ = p.close()
+		_ = in.fs.Remove(tmp)
+		return nil, WrapPosError(err, in.path, 0).WithDetails("failed_to_commit_compacted_file")
+	}
+
+	// already committed, the rename retried on next Open()
+	if err := in.fs.Rename(tmp, name); err != nil {
+		_ = p.close()
+		return nil, WrapFileOperationError(OpRename, err, in.path, name).WithDetails("failed_to_replace_compacted_file")
+	}
+
+	afterCompactRename()
+
+	if fi, err := in.fs.Stat(name); err == nil {
+		res.SizeAfter = fi.Size()
+	}
+
+	p.Name = []byte(name)
+	if err := p.doDumpFile(); err != nil {
+		_ = p.close()
+		return nil, err
+	}
+
+	return res, p.close()
+}
@@ -0,0 +1,323 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	T "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspector(t *T.T) {
+	t.Run(`locked`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		_, err = OpenInspector(p)
+		assert.Error(t, err)
+
+		require.NoError(t, c.Close())
+
+		in, err := OpenInspector(p)
+		require.NoError(t, err)
+
+		// cache can't open during inspection
+		_, err = Open(WithPath(p))
+		assert.Error(t, err)
+
+		assert.NoError(t, in.Close())
+		ResetMetrics()
+	})
+
+	t.Run(`list-dump-export`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithPosUpdate(0, 0))
+		require.NoError(t, err)
+
+		for i := 0; i < 10; i++ {
+			require.NoError(t, c.Put([]byte(fmt.Sprintf("data-%d", i))))
+			if i%4 == 3 {
+				require.NoError(t, c.Rotate())
+			}
+		}
+
+		// consume 2 records
+		for i := 0; i < 2; i++ {
+			require.NoError(t, c.Get(nil))
+		}
+
+		require.NoError(t, c.Close())
+
+		in, err := OpenInspector(p)
+		require.NoError(t, err)
+		defer in.Close() //nolint:errcheck
+
+		dfis, err := in.DataFiles()
+		require.NoError(t, err)
+		require.Len(t, dfis, 3) // 2 rotated files and the writing file
+
+		assert.Equal(t, 4, dfis[0].Records)
+		assert.True(t, dfis[0].HasEOF)
+		assert.Equal(t, 2, dfis[2].Records)
+		assert.True(t, dfis[2].Writing)
+
+		name, seek, err := in.Pos()
+		require.NoError(t, err)
+		assert.Equal(t, dfis[0].Name, name)
+		assert.Equal(t, int64(2*(dataHeaderLen+len("data-0"))), seek)
+
+		j, err := in.PosJSON()
+		require.NoError(t, err)
+		assert.Contains(t, string(j), fmt.Sprintf(`"seek":%d`, seek))
+
+		var all, unread []string
+		require.NoError(t, in.Records(false, func(r *Record) bool {
+			all = append(all, string(r.Data))
+			return true
+		}))
+		require.NoError(t, in.Records(true, func(r *Record) bool {
+			unread = append(unread, string(r.Data))
+			return true
+		}))
+
+		assert.Len(t, all, 10)
+		assert.Len(t, unread, 8)
+		assert.Equal(t, "data-2", unread[0])
+
+		buf := &bytes.Buffer{}
+		n, err := in.Export(buf)
+		require.NoError(t, err)
+		assert.Equal(t, 8, n)
+		assert.Equal(t, uint32(len("data-2")), binary.LittleEndian.Uint32(buf.Bytes()))
+
+		_, err = in.Verify()
+		assert.NoError(t, err)
+
+		ResetMetrics()
+	})
+
+	t.Run(`compact`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithPosUpdate(0, 0))
+		require.NoError(t, err)
+
+		for i := 0; i < 4; i++ {
+			require.NoError(t, c.Put([]byte(fmt.Sprintf("data-%d", i))))
+		}
+		require.NoError(t, c.Rotate())
+		require.NoError(t, c.Get(nil))
+		require.NoError(t, c.Close())
+
+		in, err := OpenInspector(p)
+		require.NoError(t, err)
+
+		res, err := in.Compact()
+		require.NoError(t, err)
+		assert.Equal(t, 3, res.Records)
+		assert.Less(t, res.SizeAfter, res.SizeBefore)
+
+		_, seek, err := in.Pos()
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), seek)
+		require.NoError(t, in.Close())
+
+		c, err = Open(WithPath(p))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Get(func(x []byte) error {
+			assert.Equal(t, []byte("data-1"), x)
+			return nil
+		}))
+
+		require.NoError(t, c.Close())
+		ResetMetrics()
+	})
+
+	prepareCompact := func(t *T.T) string {
+		t.Helper()
+
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithPosUpdate(0, 0))
+		require.NoError(t, err)
+
+		for i := 0; i < 4; i++ {
+			require.NoError(t, c.Put([]byte(fmt.Sprintf("data-%d", i))))
+		}
+		require.NoError(t, c.Rotate())
+		require.NoError(t, c.Get(nil))
+		require.NoError(t, c.Close())
+		ResetMetrics()
+
+		return p
+	}
+
+	// records left after Compact() failed
+	assertUnread := func(t *T.T, p string) {
+		t.Helper()
+
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		for i := 1; i < 4; i++ {
+			require.NoError(t, c.Get(func(x []byte) error {
+				assert.Equal(t, []byte(fmt.Sprintf("data-%d", i)), x)
+				return nil
+			}))
+		}
+
+		files, err := os.ReadDir(p)
+		require.NoError(t, err)
+		for _, f := range files {
+			assert.False(t, strings.HasPrefix(f.Name(), compactedPrefix), f.Name())
+		}
+
+		require.NoError(t, c.Close())
+		ResetMetrics()
+	}
+
+	t.Run(`compact-fail-before-commit`, func(t *T.T) {
+		p := prepareCompact(t)
+
+		in, err := OpenInspector(p)
+		require.NoError(t, err)
+
+		fs := NewFaultStorage(in.fs)
+		fs.Inject(&Fault{Op: OpOpen, Match: ".pos", Err: syscall.EIO, Times: 1})
+		in.fs = fs
+
+		_, err = in.Compact()
+		assert.Error(t, err)
+		require.NoError(t, in.Close())
+
+		assertUnread(t, p)
+	})
+
+	t.Run(`compact-fail-after-rename`, func(t *T.T) {
+		p := prepareCompact(t)
+
+		in, err := OpenInspector(p)
+		require.NoError(t, err)
+
+		fs := NewFaultStorage(in.fs)
+		in.fs = fs
+
+		// .pos still point to the compacted file
+		afterCompactRename = func() {
+			fs.Inject(&Fault{Op: OpWrite, Match: ".pos", Err: syscall.EIO})
+		}
+		t.Cleanup(func() { afterCompactRename = func() {} })
+
+		_, err = in.Compact()
+		assert.Error(t, err)
+		require.NoError(t, in.Close())
+
+		assertUnread(t, p)
+	})
+
+	t.Run(`compact-fail-on-rename`, func(t *T.T) {
+		p := prepareCompact(t)
+
+		in, err := OpenInspector(p)
+		require.NoError(t, err)
+
+		fs := NewFaultStorage(in.fs)
+		fs.Inject(&Fault{Op: OpRename, Match: compactedPrefix, Err: syscall.EIO, Times: 1})
+		in.fs = fs
+
+		_, err = in.Compact()
+		assert.Error(t, err)
+		require.NoError(t, in.Close())
+
+		assertUnread(t, p)
+	})
+
+	t.Run(`unread-on-other-path`, func(t *T.T) {
+		p := prepareCompact(t)
+
+		// inspect via symlink, .pos got the path the cache opened with
+		link := filepath.Join(t.TempDir(), "link")
+		require.NoError(t, os.Symlink(p, link))
+
+		in, err := OpenInspector(link)
+		require.NoError(t, err)
+
+		var unread []string
+		require.NoError(t, in.Records(true, func(r *Record) bool {
+			unread = append(unread, string(r.Data))
+			return true
+		}))
+		assert.Equal(t, []string{"data-1", "data-2", "data-3"}, unread)
+
+		res, err := in.Compact()
+		require.NoError(t, err)
+		assert.Equal(t, 3, res.Records)
+		require.NoError(t, in.Close())
+
+		assertUnread(t, p)
+	})
+
+	t.Run(`stale-compact-file`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put([]byte("data-0")))
+		require.NoError(t, c.Rotate())
+		fname := c.dataFiles[0]
+		require.NoError(t, c.Close())
+
+		// Compact() crashed before .pos pointed to the compacted file
+		compacted := filepath.Join(p, compactedPrefix+filepath.Base(fname))
+		b, err := os.ReadFile(fname)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(compacted, b, 0o600))
+
+		c, err = Open(WithPath(p))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{fname}, c.dataFiles)
+		assert.NoFileExists(t, compacted)
+
+		require.NoError(t, c.Close())
+		ResetMetrics()
+	})
+
+	t.Run(`verify-bad-file`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithWakeup(time.Hour))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put([]byte("hello")))
+		require.NoError(t, c.Rotate())
+		fname := c.dataFiles[0]
+		require.NoError(t, c.Close())
+
+		// truncate the data
+		require.NoError(t, os.Truncate(fname, dataHeaderLen+2))
+
+		in, err := OpenInspector(p)
+		require.NoError(t, err)
+		defer in.Close() //nolint:errcheck
+
+		dfis, err := in.Verify()
+		assert.Error(t, err)
+		require.Len(t, dfis, 2)
+		assert.Error(t, dfis[0].Err)
+		assert.NoError(t, dfis[1].Err)
+
+		ResetMetrics()
+	})
+}
@@ -0,0 +1,135 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"errors"
+	"os"
+)
+
+// readPosition return unread data files and the offset within the first file.
+// The caller should hold the rlock.
+func (c *DiskCache) readPosition() ([]string, int64, error) {
+	c.rwlock.Lock()
+	defer c.rwlock.Unlock()
+
+	files := append([]string{}, c.dataFiles...)
+
+	if c.rfd == nil {
+		return files, 0, nil
+	}
+
+	off, err := c.seekRead(0)
+	if err != nil {
+		return nil, 0, WrapFileOperationError(OpSeek, err, c.path, c.readFileName()).
+			WithDetails("failed_to_get_read_position")
+	}
+
+	// skip files before current reading file
+	for i, f := range files {
+		if f == c.curReadfile {
+			return files[i:], off, nil
+		}
+	}
+
+	return files, 0, nil
+}
+
+// iterateFiles iterate records within files, start from offset off of the
+// first file. Files that removed during the iteration are skipped.
+func iterateFiles(fs Storage, files []string, off int64, fn func(*Record) bool) error {
+	for i, f := range files {
+		if i > 0 {
+			off = 0
+		}
+
+		stop := false
+		if _, _, err := scanDataFile(fs, f, off, func(off int64, data []byte) error {
+			if !fn(&Record{File: f, Offset: off, Data: data}) {
+				stop = true
+				return errStopScan
+			}
+			return nil
+		}); err != nil {
+			if errors.Is(err, os.ErrNotExist) { // file consumed or dropped
+				continue
+			}
+			return err
+		}
+
+		if stop {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// Peek return the next n records without consuming them, the read
+// position not changed.
+func (c *DiskCache) Peek(n int) ([][]byte, error) {
+	c.lifecycleMu.RLock()
+	defer c.lifecycleMu.RUnlock()
+
+	if c.closed {
+		return nil, WrapGetError(ErrClosed, c.path, "")
+	}
+
+	c.rlock.Lock()
+	defer c.rlock.Unlock()
+
+	files, off, err := c.readPosition()
+	if err != nil {
+		return nil, err
+	}
+
+	var res [][]byte
+	if n <= 0 {
+		return res, nil
+	}
+
+	if err := iterateFiles(c.fs, files, off, func(r *Record) bool {
+		res = append(res, r.Data)
+		return len(res) < n
+	}); err != nil {
+		return nil, WrapGetError(err, c.path, "").WithDetails("failed_to_peek")
+	}
+
+	if len(res) == 0 {
+		return nil, ErrNoData
+	}
+
+	return res, nil
+}
+
+// Iterate iterate all unread records within rotated data files, the
+// iteration stopped if fn return false. The read position not changed.
+//
+// Iterate is safe to call concurrently with Put() and Get(), data files
+// consumed or dropped during the iteration are skipped. Data within current
+// writing file(not rotated) are not iterated.
+func (c *DiskCache) Iterate(fn func(*Record) bool) error {
+	c.lifecycleMu.RLock()
+	defer c.lifecycleMu.RUnlock()
+
+	if c.closed {
+		return WrapGetError(ErrClosed, c.path, "")
+	}
+
+	c.rlock.Lock()
+	files, off, err := c.readPosition()
+	c.rlock.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if err := iterateFiles(c.fs, files, off, fn); err != nil {
+		return WrapGetError(err, c.path, "").WithDetails("failed_to_iterate")
+	}
+
+	return nil
+}
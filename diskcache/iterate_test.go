@@ -0,0 +1,128 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	T "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeek(t *T.T) {
+	p := t.TempDir()
+	c, err := Open(WithPath(p), WithReadAhead(64))
+	require.NoError(t, err)
+
+	_, err = c.Peek(1)
+	assert.True(t, errors.Is(err, ErrNoData))
+
+	for i := 0; i < 6; i++ {
+		require.NoError(t, c.Put([]byte(fmt.Sprintf("data-%d", i))))
+		if i%2 == 1 {
+			require.NoError(t, c.Rotate())
+		}
+	}
+
+	require.NoError(t, c.Get(nil))
+
+	// peek across files
+	res, err := c.Peek(3)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("data-1"), []byte("data-2"), []byte("data-3")}, res)
+
+	// peek do not move the read position
+	require.NoError(t, c.Get(func(x []byte) error {
+		assert.Equal(t, []byte("data-1"), x)
+		return nil
+	}))
+
+	res, err = c.Peek(100)
+	require.NoError(t, err)
+	assert.Len(t, res, 4)
+
+	t.Cleanup(func() {
+		assert.NoError(t, c.Close())
+		ResetMetrics()
+	})
+}
+
+func TestIterate(t *T.T) {
+	t.Run(`basic`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		for i := 0; i < 10; i++ {
+			require.NoError(t, c.Put([]byte(fmt.Sprintf("data-%d", i))))
+			if i%3 == 2 {
+				require.NoError(t, c.Rotate())
+			}
+		}
+
+		require.NoError(t, c.Get(nil))
+
+		var got []string
+		require.NoError(t, c.Iterate(func(r *Record) bool {
+			got = append(got, string(r.Data))
+			return true
+		}))
+
+		// data-9 still in writing file
+		assert.Len(t, got, 8)
+		assert.Equal(t, "data-1", got[0])
+
+		got = got[:0]
+		require.NoError(t, c.Iterate(func(r *Record) bool {
+			got = append(got, string(r.Data))
+			return len(got) < 2
+		}))
+		assert.Len(t, got, 2)
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`with-concurrent-put`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithBatchSize(1024))
+		require.NoError(t, err)
+
+		for i := 0; i < 100; i++ {
+			require.NoError(t, c.Put([]byte(fmt.Sprintf("data-%d", i))))
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				assert.NoError(t, c.Put([]byte(fmt.Sprintf("data-%d", i))))
+			}
+		}()
+
+		for i := 0; i < 10; i++ {
+			n := 0
+			require.NoError(t, c.Iterate(func(r *Record) bool {
+				n++
+				return true
+			}))
+			assert.Greater(t, n, 0)
+		}
+
+		wg.Wait()
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+}
@@ -23,18 +23,28 @@ var (
 	capVec,
 	maxDataVec,
 	batchSizeVec,
-	datafilesVec *prometheus.GaugeVec
+	datafilesVec,
+	freeSpaceVec *prometheus.GaugeVec
 
 	droppedDataVec,
 	putBytesVec,
 	getBytesVec,
 	getLatencyVec,
-	putLatencyVec *prometheus.SummaryVec
+	putLatencyVec,
+	groupCommitLatencyVec,
+	groupCommitBatchVec,
+	groupCommitBytesVec *prometheus.SummaryVec
 
 	// Lock contention metrics.
 	lockWaitTimeVec   *prometheus.HistogramVec
 	lockContentionVec *prometheus.CounterVec
 
+	lowFreeSpaceVec *prometheus.CounterVec
+
+	adoptedSegmentsVec *prometheus.CounterVec
+
+	drainWaitVec *prometheus.SummaryVec
+
 	ns = "diskcache"
 )
 
@@ -95,6 +105,48 @@ func setupMetrics() {
 		[]string{"path"},
 	)
 
+	groupCommitLatencyVec = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Namespace: ns,
+			Name:      "group_commit_latency",
+			Help:      "Put() cost seconds(queue time included) under group commit",
+			Objectives: map[float64]float64{
+				0.5:  0.05,
+				0.9:  0.01,
+				0.99: 0.001,
+			},
+		},
+		[]string{"path"},
+	)
+
+	groupCommitBatchVec = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Namespace: ns,
+			Name:      "group_commit_batch_size",
+			Help:      "Put() count within each group commit",
+			Objectives: map[float64]float64{
+				0.5:  0.05,
+				0.9:  0.01,
+				0.99: 0.001,
+			},
+		},
+		[]string{"path"},
+	)
+
+	groupCommitBytesVec = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Namespace: ns,
+			Name:      "group_commit_bytes",
+			Help:      "Put() bytes within each group commit",
+			Objectives: map[float64]float64{
+				0.5:  0.05,
+				0.9:  0.01,
+				0.99: 0.001,
+			},
+		},
+		[]string{"path"},
+	)
+
 	droppedDataVec = prometheus.NewSummaryVec(
 		prometheus.SummaryOpts{
 			Namespace: ns,
@@ -224,6 +276,47 @@ func setupMetrics() {
 		[]string{"path"},
 	)
 
+	freeSpaceVec = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: ns,
+			Name:      "free_space",
+			Help:      "Free space(in bytes) of the filesystem that cache located, only set if free space reserve set",
+		},
+		[]string{"path"},
+	)
+
+	lowFreeSpaceVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: ns,
+			Name:      "low_free_space_total",
+			Help:      "Count of free space dropped below the reserve",
+		},
+		[]string{"path"},
+	)
+
+	drainWaitVec = prometheus.NewSummaryVec(
+		prometheus.SummaryOpts{
+			Namespace: ns,
+			Name:      "drain_wait",
+			Help:      "Get() wait seconds on drain rate limit",
+			Objectives: map[float64]float64{
+				0.5:  0.05,
+				0.9:  0.01,
+				0.99: 0.001,
+			},
+		},
+		[]string{"path"},
+	)
+
+	adoptedSegmentsVec = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: ns,
+			Name:      "adopted_segments_total",
+			Help:      "Count of producer segments adopted as data files",
+		},
+		[]string{"path"},
+	)
+
 	// Lock contention metrics
 	lockWaitTimeVec = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
@@ -267,10 +360,17 @@ func ResetMetrics() {
 	maxDataVec.Reset()
 	sizeVec.Reset()
 	datafilesVec.Reset()
+	freeSpaceVec.Reset()
+	lowFreeSpaceVec.Reset()
+	adoptedSegmentsVec.Reset()
+	drainWaitVec.Reset()
 	getLatencyVec.Reset()
 	putLatencyVec.Reset()
 	putBytesVec.Reset()
 	getBytesVec.Reset()
+	groupCommitLatencyVec.Reset()
+	groupCommitBatchVec.Reset()
+	groupCommitBytesVec.Reset()
 
 	// Lock contention metrics
 	lockWaitTimeVec.Reset()
@@ -293,12 +393,20 @@ func Metrics() []prometheus.Collector {
 		maxDataVec,
 		batchSizeVec,
 		datafilesVec,
+		freeSpaceVec,
+		lowFreeSpaceVec,
+		adoptedSegmentsVec,
+		drainWaitVec,
 
 		getLatencyVec,
 		putLatencyVec,
 		getBytesVec,
 		putBytesVec,
 
+		groupCommitLatencyVec,
+		groupCommitBatchVec,
+		groupCommitBytesVec,
+
 		// Lock contention metrics
 		lockWaitTimeVec,
 		lockContentionVec,
@@ -0,0 +1,231 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// errSegmentsNotAdopted returned on migrating cache with segments of
+// multi-producer mode not adopted.
+var errSegmentsNotAdopted = errors.New("segments not adopted")
+
+// beforeMigrateSwap called before the migrated dir swapped, replaced within
+// testing.
+var beforeMigrateSwap = func(src, dst string) {}
+
+// MigrateReport is the result of Migrate().
+type MigrateReport struct {
+	Records  int   // records migrated
+	Bytes    int64 // data bytes migrated(header excluded)
+	Skipped  int   // records within src already consumed, not migrated
+	SrcFiles int   // data files within src
+	DstFiles int   // data files within dst after the migration
+	Cost     time.Duration
+}
+
+// Migrate rewrite unread records of cache src into cache dst, the dst
+// cache created with opts(WithPath() ignored).
+//
+// Records are written into a temporary dir first, and the dir renamed to
+// dst after all records written. If src and dst are the same path, the
+// src is replaced on success. Otherwise dst must not exist or be empty,
+// and src is left untouched, the caller should remove it after the
+// migration.
+//
+// Both src and dst must not in use during the migration, the .lock of
+// src held till the swap finished. The migration aborted without any change
+// on any bad record within src, if dst can't hold all records(dst never
+// drop data during the migration), or if src got segments of multi-producer
+// mode not adopted(open src in multi-producer mode to adopt them first).
+func Migrate(src, dst string, opts ...CacheOption) (*MigrateReport, error) {
+	setupLogger()
+
+	start := time.Now()
+	src, dst = filepath.Clean(src), filepath.Clean(dst)
+	inplace := src == dst
+
+	if !inplace {
+		if empty, err := isEmptyDir(dst); err != nil {
+			return nil, WrapOpenError(err, dst).WithDetails("failed_to_check_migrate_dst")
+		} else if !empty {
+			return nil, WrapOpenError(os.ErrExist, dst).WithDetails("migrate_dst_not_empty")
+		}
+	}
+
+	in, err := OpenInspector(src)
+	if err != nil {
+		return nil, err
+	}
+
+	defer in.Close() //nolint:errcheck
+
+	// segments are not data files of the inspector, they would be lost
+	if n, err := pendingSegments(src); err != nil {
+		return nil, WrapFileOperationError(OpList, err, src, segmentsDirName).
+			WithDetails("failed_to_list_migrate_src_segments")
+	} else if n > 0 {
+		return nil, NewCacheError(OpOpen, errSegmentsNotAdopted,
+			fmt.Sprintf("migrate_src_got_segments: segments=%d", n)).WithPath(src)
+	}
+
+	dfis, err := in.Verify()
+	if err != nil {
+		return nil, NewCacheError(OpRead, err, "migrate_src_broken").WithPath(src)
+	}
+
+	rep := &MigrateReport{SrcFiles: len(dfis)}
+	for _, dfi := range dfis {
+		rep.Skipped += dfi.Records
+	}
+
+	tmp := dst + ".migrating"
+	if err := os.RemoveAll(tmp); err != nil { // remove dirty migration
+		return nil, WrapFileOperationError(OpRemove, err, tmp, "").WithDetails("failed_to_remove_dirty_migration")
+	}
+
+	if err := migrateRecords(in, tmp, rep, opts); err != nil {
+		_ = os.RemoveAll(tmp)
+		return nil, err
+	}
+
+	rep.Skipped -= rep.Records
+
+	if files, err := listDataFiles(OSStorage{}, tmp); err == nil {
+		rep.DstFiles = len(files)
+	}
+
+	// lock the migrated dir too, so it can't be opened once renamed to dst
+	// before the swap finished.
+	tl := newFlock(tmp)
+	if ok, err := tl.tryLock(); !ok {
+		_ = os.RemoveAll(tmp)
+		return nil, WrapLockError(err, tmp, 0).WithDetails("failed_to_lock_migrated_dir")
+	}
+
+	beforeMigrateSwap(src, dst)
+
+	err = swapDir(tmp, dst)
+
+	if uerr := tl.unlock(); uerr != nil {
+		l.Warnf("unlock %s: %s", tl.file, uerr.Error())
+	}
+
+	if err != nil {
+		_ = os.RemoveAll(tmp)
+		return nil, err
+	}
+
+	if err := in.Close(); err != nil {
+		l.Warnf("unlock migrate src %s: %s", src, err.Error())
+	}
+
+	rep.Cost = time.Since(start)
+	return rep, nil
+}
+
+// pendingSegments return count of files(sealed, writing or their locks)
+// within segments dir of cache path.
+func pendingSegments(path string) (int, error) {
+	entries, err := os.ReadDir(segmentsDir(path))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return len(entries), nil
+}
+
+func migrateRecords(in *Inspector, tmp string, rep *MigrateReport, opts []CacheOption) error {
+	c, err := Open(append(opts,
+		WithPath(tmp),
+		WithStorage(OSStorage{}),
+		WithNoDrop(true),
+		WithMultiProducer(false))...)
+	if err != nil {
+		return err
+	}
+
+	var perr error
+	if err := in.Records(true, func(r *Record) bool {
+		if perr = c.Put(r.Data); perr != nil {
+			return false
+		}
+
+		rep.Records++
+		rep.Bytes += int64(len(r.Data))
+		return true
+	}); err != nil {
+		perr = errors.Join(perr, err)
+	}
+
+	// make the last writing file readable
+	if perr == nil && c.curBatchSize > 0 {
+		perr = c.Rotate()
+	}
+
+	if err := c.Close(); err != nil {
+		perr = errors.Join(perr, err)
+	}
+
+	if perr != nil {
+		return NewCacheError(OpPut, perr, fmt.Sprintf("failed_to_migrate: migrated=%d", rep.Records)).WithPath(tmp)
+	}
+
+	return nil
+}
+
+// swapDir replace dst with dir tmp.
+func swapDir(tmp, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return WrapFileOperationError(OpCreate, err, dst, "").WithDetails("failed_to_create_migrate_dst_parent")
+	}
+
+	bak := dst + ".migrate-bak"
+	if _, err := os.Stat(dst); err == nil {
+		if err := os.Rename(dst, bak); err != nil {
+			return WrapFileOperationError(OpRename, err, dst, bak).WithDetails("failed_to_backup_migrate_dst")
+		}
+	} else {
+		bak = ""
+	}
+
+	if err := os.Rename(tmp, dst); err != nil {
+		if bak != "" { // rollback
+			if rerr := os.Rename(bak, dst); rerr != nil {
+				l.Errorf("restore %s from %s failed: %s", dst, bak, rerr.Error())
+			}
+		}
+
+		return WrapFileOperationError(OpRename, err, tmp, dst).WithDetails("failed_to_swap_migrate_dst")
+	}
+
+	if bak != "" {
+		if err := os.RemoveAll(bak); err != nil {
+			l.Warnf("remove migration backup %s failed: %s", bak, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func isEmptyDir(dir string) (bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	return len(entries) == 0, nil
+}
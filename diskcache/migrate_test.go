@@ -0,0 +1,193 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	T "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// prepareMigrateCache put n records and consume the first consumed records.
+func prepareMigrateCache(t *T.T, p string, n, consumed int) {
+	t.Helper()
+
+	c, err := Open(WithPath(p), WithBatchSize(1024), WithPosUpdate(0, 0))
+	require.NoError(t, err)
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, c.Put([]byte(fmt.Sprintf("data-%03d", i))))
+	}
+	require.NoError(t, c.Rotate())
+
+	for i := 0; i < consumed; i++ {
+		require.NoError(t, c.Get(nil))
+	}
+
+	require.NoError(t, c.Close())
+	ResetMetrics()
+}
+
+func TestMigrate(t *T.T) {
+	t.Run(`in-place`, func(t *T.T) {
+		p := t.TempDir()
+		prepareMigrateCache(t, p, 100, 30)
+
+		rep, err := Migrate(p, p, WithBatchSize(128))
+		require.NoError(t, err)
+		assert.Equal(t, 70, rep.Records)
+		assert.Equal(t, 30, rep.Skipped)
+		assert.Equal(t, int64(70*len("data-000")), rep.Bytes)
+		assert.True(t, rep.DstFiles > rep.SrcFiles, "report: %+v", rep)
+
+		for _, x := range []string{p + ".migrating", p + ".migrate-bak"} {
+			_, err := os.Stat(x)
+			assert.True(t, os.IsNotExist(err), "%s should be removed", x)
+		}
+
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		res := getAll(t, c)
+		require.Len(t, res, 70)
+		for i, x := range res {
+			assert.Equal(t, fmt.Sprintf("data-%03d", i+30), x)
+		}
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`new-path`, func(t *T.T) {
+		src := t.TempDir()
+		dst := filepath.Join(t.TempDir(), "sub", "new-cache")
+		prepareMigrateCache(t, src, 10, 0)
+
+		rep, err := Migrate(src, dst)
+		require.NoError(t, err)
+		assert.Equal(t, 10, rep.Records)
+		assert.Equal(t, 1, rep.DstFiles)
+
+		// src untouched
+		in, err := OpenInspector(src)
+		require.NoError(t, err)
+		dfis, err := in.DataFiles()
+		require.NoError(t, err)
+		n := 0
+		for _, dfi := range dfis {
+			n += dfi.Records
+		}
+		assert.Equal(t, 10, n)
+		require.NoError(t, in.Close())
+
+		// dst not empty
+		_, err = Migrate(src, dst)
+		assert.Error(t, err)
+
+		c, err := Open(WithPath(dst))
+		require.NoError(t, err)
+		assert.Len(t, getAll(t, c), 10)
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`dst-too-small`, func(t *T.T) {
+		p := t.TempDir()
+		prepareMigrateCache(t, p, 100, 0)
+
+		_, err := Migrate(p, p, WithBatchSize(128), WithCapacity(256))
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrCacheFull)
+
+		_, err = os.Stat(p + ".migrating")
+		assert.True(t, os.IsNotExist(err))
+
+		// nothing changed on src
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+		assert.Len(t, getAll(t, c), 100)
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`src-got-segments`, func(t *T.T) {
+		p := t.TempDir()
+		prepareMigrateCache(t, p, 10, 0)
+
+		seg := filepath.Join(segmentsDir(p), "seg.1")
+		require.NoError(t, os.MkdirAll(segmentsDir(p), 0o755))
+		require.NoError(t, os.WriteFile(seg, []byte("segment"), 0o600))
+
+		_, err := Migrate(p, p)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, errSegmentsNotAdopted)
+
+		// nothing changed on src
+		_, err = os.Stat(seg)
+		assert.NoError(t, err)
+
+		_, err = os.Stat(p + ".migrating")
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run(`lock-held-on-swap`, func(t *T.T) {
+		p := t.TempDir()
+		prepareMigrateCache(t, p, 10, 0)
+
+		swapped := false
+		beforeMigrateSwap = func(src, dst string) {
+			swapped = true
+
+			// both src and the migrated dir can't be opened during the swap
+			_, err := Open(WithPath(src))
+			assert.Error(t, err)
+
+			_, err = Open(WithPath(src + ".migrating"))
+			assert.Error(t, err)
+		}
+		t.Cleanup(func() { beforeMigrateSwap = func(src, dst string) {} })
+
+		_, err := Migrate(p, p)
+		require.NoError(t, err)
+		assert.True(t, swapped)
+
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+		assert.Len(t, getAll(t, c), 10)
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`src-in-use`, func(t *T.T) {
+		p := t.TempDir()
+
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		_, err = Migrate(p, p)
+		assert.Error(t, err)
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+}
@@ -8,7 +8,6 @@ package diskcache
 import (
 	"errors"
 	"fmt"
-	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
@@ -41,6 +40,17 @@ func Open(opts ...CacheOption) (*DiskCache, error) {
 	}
 	c.initMetrics()
 
+	if c.readAheadSize > 0 {
+		c.ra = newReadAhead(c.readAheadSize)
+	}
+
+	if c.groupCommitInterval > 0 {
+		c.gc = newGroupCommitter(c)
+	}
+
+	c.drain = newDrainLimiter(c.drainBytesRate, c.drainRecordsRate)
+	c.growth = newGrowthTracker()
+
 	defer func() {
 		c.labels = append(c.labels,
 			strconv.FormatBool(c.noFallbackOnError),
@@ -60,6 +70,7 @@ func defaultInstance() *DiskCache {
 	return &DiskCache{
 		noSync: false,
 
+		fs:          OSStorage{},
 		batchHeader: make([]byte, dataHeaderLen),
 
 		batchSize:   20 * 1024 * 1024,
@@ -101,13 +112,17 @@ func (c *DiskCache) doOpen() error {
 		c.maxDataSize = int32(c.batchSize / 2)
 	}
 
-	if err := os.MkdirAll(c.path, c.dirPerms); err != nil {
+	if c.fs == nil {
+		c.fs = OSStorage{}
+	}
+
+	if err := c.fs.MkdirAll(c.path, c.dirPerms); err != nil {
 		return NewCacheError(OpCreate, err, fmt.Sprintf("failed_to_create_directory: perms=%o", c.dirPerms)).
 			WithPath(c.path)
 	}
 
-	// disable open multiple times
-	if !c.noLock {
+	// disable open multiple times, the .lock only available on local file system
+	if !c.noLock && isOSStorage(c.fs) {
 		fl := newFlock(c.path)
 		if ok, err := fl.tryLock(); !ok {
 			return WrapLockError(err, c.path, 0).WithDetails("failed_to_acquire_directory_lock")
@@ -120,11 +135,16 @@ func (c *DiskCache) doOpen() error {
 	if !c.noPos {
 		// use `.pos' file to remember the reading position.
 		c.pos.fname = filepath.Join(c.path, ".pos")
+		c.pos.fs = c.fs
 	}
 	c.curWriteFile = filepath.Join(c.path, "data")
 
 	c.syncEnv()
 
+	if c.freeSpaceReserve > 0 && isOSStorage(c.fs) {
+		c.fsw = newFreeSpaceWatcher(c.path, c.freeSpaceReserve, c.freeSpaceCB)
+	}
+
 	// set stable metrics
 	capVec.WithLabelValues(c.path).Set(float64(c.capacity))
 	maxDataVec.WithLabelValues(c.path).Set(float64(c.maxDataSize))
@@ -141,39 +161,59 @@ func (c *DiskCache) doOpen() error {
 		c.rwlock = NewInstrumentedMutex(LockTypeRW, c.path, lockWaitTimeVec, lockContentionVec)
 	}
 
+	if err := c.repairWriteFile(); err != nil {
+		return NewCacheError(OpOpen, err, "failed_to_repair_write_file").
+			WithPath(c.path).WithFile(c.curWriteFile)
+	}
+
 	// write append fd, always write to the same-name file
 	if err := c.openWriteFile(); err != nil {
 		return NewCacheError(OpOpen, err, "failed_to_open_write_file").
 			WithPath(c.path).WithFile(c.curWriteFile)
 	}
 
-	// list files under @path
-	if err := filepath.Walk(c.path,
-		func(path string, fi os.FileInfo, err error) error {
+	if err := recoverCompact(c.fs, c.path); err != nil {
+		return NewCacheError(OpOpen, err, "failed_to_recover_compact").WithPath(c.path)
+	}
+
+	// list files under @path. Only regular files directly under @path are
+	// listed, sub-dirs(such as segments/ of multi-producer mode and lanes of
+	// PriorityCache) are not walked into as the filepath.Walk before.
+	files, err := c.fs.List(c.path)
+	if err != nil {
+		return NewCacheError(OpOpen, err, "failed_to_list_directory").WithPath(c.path)
+	}
+
+	for _, path := range files {
+		switch filepath.Base(path) {
+		case ".lock", ".pos": // ignore them
+		case "data": // not rotated writing file, do not count on sizeVec.
+		default:
+			fi, err := c.fs.Stat(path)
 			if err != nil {
-				return NewCacheError(OpOpen, err, "failed_to_walk_directory").
+				return NewCacheError(OpOpen, err, "failed_to_stat_data_file").
 					WithPath(c.path).WithFile(path)
 			}
 
-			if fi.IsDir() {
-				return nil
-			}
+			c.size.Add(fi.Size())
+			sizeVec.WithLabelValues(c.path).Add(float64(fi.Size()))
+			c.dataFiles = append(c.dataFiles, path)
+		}
+	}
 
-			switch filepath.Base(path) {
-			case ".lock", ".pos": // ignore them
-			case "data": // not rotated writing file, do not count on sizeVec.
-			default:
-				c.size.Add(fi.Size())
-				sizeVec.WithLabelValues(c.path).Add(float64(fi.Size()))
-				c.dataFiles = append(c.dataFiles, path)
-			}
+	sort.Strings(c.dataFiles) // make file-name sorted for FIFO Get()
 
-			return nil
-		}); err != nil {
-		return err
+	if c.multiProducer {
+		if err := c.fs.MkdirAll(segmentsDir(c.path), c.dirPerms); err != nil {
+			return NewCacheError(OpCreate, err, "failed_to_create_segments_directory").
+				WithPath(c.path)
+		}
+
+		if err := c.adoptSegments(); err != nil {
+			return NewCacheError(OpOpen, err, "failed_to_adopt_segments").WithPath(c.path)
+		}
 	}
 
-	sort.Strings(c.dataFiles) // make file-name sorted for FIFO Get()
 	l.Infof("on open loaded %d files", len(c.dataFiles))
 	datafilesVec.WithLabelValues(c.path).Set(float64(len(c.dataFiles)))
 
@@ -192,6 +232,10 @@ func (c *DiskCache) doOpen() error {
 // It waits for in-flight operations, is idempotent, and causes later I/O
 // operations to return ErrClosed.
 func (c *DiskCache) Close() error {
+	if c.drain != nil { // wakeup Get() waiting on drain limit
+		c.drain.cancel()
+	}
+
 	c.lifecycleMu.Lock()
 	defer c.lifecycleMu.Unlock()
 
@@ -204,6 +248,10 @@ func (c *DiskCache) Close() error {
 	}
 	c.closed = true
 
+	if c.gc != nil {
+		c.gc.stop()
+	}
+
 	c.rwlock.Lock()
 	defer c.rwlock.Unlock()
 
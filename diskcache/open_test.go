@@ -378,9 +378,9 @@ func TestCloseReleasesOwnershipAfterDescriptorError(t *T.T) {
 	closeErr := c.Close()
 	require.Error(t, closeErr)
 	require.EqualError(t, c.Close(), closeErr.Error())
-	_, err = writeFD.WriteString("must-be-closed")
+	_, err = writeFD.Write([]byte("must-be-closed"))
 	require.Error(t, err)
-	_, err = posFD.WriteString("must-be-closed")
+	_, err = posFD.Write([]byte("must-be-closed"))
 	require.Error(t, err)
 
 	c2, err := Open(WithPath(p))
@@ -411,3 +411,45 @@ func TestCloseAggregatesDescriptorErrors(t *T.T) {
 	require.NoError(t, c2.Close())
 	ResetMetrics()
 }
+
+func TestOpenRepairWriteFile(t *T.T) {
+	cases := []struct {
+		name string
+		tail []byte
+	}{
+		{"header-without-data", []byte{0x10, 0x00, 0x00, 0x00}},
+		{"partial-header", []byte{0x10, 0x00}},
+		{"partial-data", []byte{0x10, 0x00, 0x00, 0x00, 'x', 'y'}},
+		{"eof-hint-not-rotated", []byte{0xef, 0xbe, 0xad, 0xde}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *T.T) {
+			p := t.TempDir()
+			c, err := Open(WithPath(p))
+			require.NoError(t, err)
+			require.NoError(t, c.Put([]byte("first")))
+			require.NoError(t, c.Close())
+			ResetMetrics()
+
+			// crashed with broken tail on the writing file
+			f, err := os.OpenFile(filepath.Join(p, "data"), os.O_WRONLY|os.O_APPEND, 0o600)
+			require.NoError(t, err)
+			_, err = f.Write(tc.tail)
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+
+			c, err = Open(WithPath(p))
+			require.NoError(t, err)
+			require.NoError(t, c.Put([]byte("second")))
+			require.NoError(t, c.Rotate())
+
+			assert.Equal(t, []string{"first", "second"}, getAll(t, c))
+
+			t.Cleanup(func() {
+				assert.NoError(t, c.Close())
+				ResetMetrics()
+			})
+		})
+	}
+}
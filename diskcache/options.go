@@ -98,6 +98,18 @@ func WithWakeup(wakeup time.Duration) CacheOption {
 	}
 }
 
+// WithMaxAge set max age of cached data, default 0(never expire).
+//
+// Data files rotated before max-age are dropped during Get(), so data that
+// too old to be useful will not be consumed.
+func WithMaxAge(du time.Duration) CacheOption {
+	return func(c *DiskCache) {
+		if du > 0 {
+			c.maxAge = du
+		}
+	}
+}
+
 // WithBatchSize set file size, default 64MB.
 func WithBatchSize(size int64) CacheOption {
 	return func(c *DiskCache) {
@@ -137,6 +149,29 @@ func WithExtraCapacity(size int64) CacheOption {
 	}
 }
 
+// WithFreeSpaceReserve set free space(in bytes) to reserve on the filesystem
+// of the cache, default 0(disabled).
+//
+// When other processes use the disk, the effective capacity of the cache shrink
+// to keep the reserved free space, and old data dropped(or Put() failed on
+// FILO/no-drop policy) early.
+func WithFreeSpaceReserve(reserve int64) CacheOption {
+	return func(c *DiskCache) {
+		if reserve > 0 {
+			c.freeSpaceReserve = reserve
+		}
+	}
+}
+
+// WithFreeSpaceCallback set callback that called when free space of the
+// filesystem crossed the reserve set by WithFreeSpaceReserve. The callback
+// called within Put() after locks of the cache released.
+func WithFreeSpaceCallback(cb FreeSpaceCallback) CacheOption {
+	return func(c *DiskCache) {
+		c.freeSpaceCB = cb
+	}
+}
+
 // WithNoSync enable/disable sync on cache write.
 //
 // NOTE: Without sync, the write performance 60~80 times faster for 512KB/1MB put,
@@ -147,6 +182,59 @@ func WithNoSync(on bool) CacheOption {
 	}
 }
 
+// WithReadAhead set read-ahead buffer size on Get(), default 0(disabled).
+//
+// With read-ahead enabled, many small records are read from file within a
+// single read syscall and served from memory, this reduce CPU usage when
+// records are small.
+func WithReadAhead(size int) CacheOption {
+	return func(c *DiskCache) {
+		if size > 0 {
+			c.readAheadSize = size
+		}
+	}
+}
+
+// WithDrainRate set the max bytes and records per second that Get() from
+// the cache, 0 for no limit. The Get() blocked until the record allowed.
+//
+// The rate can be changed with SetDrainRate() after Open().
+func WithDrainRate(bytesPerSec, recordsPerSec float64) CacheOption {
+	return func(c *DiskCache) {
+		c.drainBytesRate = bytesPerSec
+		c.drainRecordsRate = recordsPerSec
+	}
+}
+
+// WithMultiProducer enable data from producers(see OpenProducer()) on the cache.
+//
+// Sealed segments of producers are adopted as data files on Get(), in the
+// order of the segments created. Writing segments of exited producers are
+// adopted too.
+func WithMultiProducer(on bool) CacheOption {
+	return func(c *DiskCache) {
+		c.multiProducer = on
+	}
+}
+
+// WithGroupCommit enable group commit on Put().
+//
+// Concurrent Put() are queued and written together, the writing file
+// synced once for each group. A group committed when interval elapsed since
+// the first queued Put(), or queued bytes reached maxBytes(0 for no limit).
+// Each Put() returned after its group committed.
+//
+// With group commit, we get nearly the same durability of sync write,
+// and the throughput is close to no-sync write for many small Put().
+func WithGroupCommit(interval time.Duration, maxBytes int) CacheOption {
+	return func(c *DiskCache) {
+		if interval > 0 {
+			c.groupCommitInterval = interval
+			c.groupCommitBytes = maxBytes
+		}
+	}
+}
+
 // WithDirPermission set disk dir permission mode.
 func WithDirPermission(perms os.FileMode) CacheOption {
 	return func(c *DiskCache) {
@@ -161,6 +249,18 @@ func WithFilePermission(perms os.FileMode) CacheOption {
 	}
 }
 
+// WithStorage set storage that data files stored on, default on local file system.
+//
+// The .lock only available on local file system, for other storage(such
+// as MemStorage), the Open() on the same path not excluded.
+func WithStorage(s Storage) CacheOption {
+	return func(c *DiskCache) {
+		if s != nil {
+			c.fs = s
+		}
+	}
+}
+
 // WithPath set disk dirname.
 func WithPath(x string) CacheOption {
 	return func(c *DiskCache) {
@@ -48,5 +48,11 @@ func TestOptions(t *T.T) {
 
 		WithNoSync(false)(c)
 		assert.False(t, c.noSync)
+
+		WithMaxAge(-time.Hour)(c) // invalid
+		assert.Equal(t, time.Duration(0), c.maxAge)
+
+		WithMaxAge(time.Hour)(c)
+		assert.Equal(t, time.Hour, c.maxAge)
 	})
 }
@@ -10,6 +10,7 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"time"
@@ -24,7 +25,8 @@ type pos struct {
 	dumpInterval time.Duration
 	lastDump     time.Time
 
-	fd    *os.File
+	fs    Storage
+	fd    File
 	fname string        // where to dump the binary data
 	buf   *bytes.Buffer // reused buffer to build the binary data
 }
@@ -49,8 +51,8 @@ func (p *pos) String() string {
 	return fmt.Sprintf("%s:%d", string(p.Name), p.Seek)
 }
 
-func posFromFile(fname string) (*pos, error) {
-	bin, err := os.ReadFile(filepath.Clean(fname))
+func posFromFile(fs Storage, fname string) (*pos, error) {
+	bin, err := readFile(fs, filepath.Clean(fname))
 	if err != nil {
 		return nil, WrapFileOperationError(OpRead, err, "", fname).
 			WithDetails("failed_to_read_position_file")
@@ -69,6 +71,24 @@ func posFromFile(fname string) (*pos, error) {
 	return &p, nil
 }
 
+func (p *pos) storage() Storage {
+	if p.fs == nil {
+		return OSStorage{}
+	}
+	return p.fs
+}
+
+func readFile(fs Storage, fname string) ([]byte, error) {
+	fd, err := fs.OpenFile(fname, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	defer fd.Close() //nolint:errcheck,gosec
+
+	return io.ReadAll(fd)
+}
+
 func (p *pos) MarshalBinary() ([]byte, error) {
 	if p.buf == nil {
 		p.buf = new(bytes.Buffer)
@@ -100,7 +120,7 @@ func (p *pos) UnmarshalBinary(bin []byte) error {
 
 func (p *pos) reset() error {
 	if p.fd == nil {
-		if fd, err := os.OpenFile(p.fname, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600); err != nil {
+		if fd, err := p.storage().OpenFile(p.fname, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600); err != nil {
 			return WrapFileOperationError(OpCreate, err, "", p.fname).
 				WithDetails("failed_to_create_position_file_for_reset")
 		} else {
@@ -124,7 +144,7 @@ func (p *pos) reset() error {
 
 func (p *pos) doDumpFile() error {
 	if p.fd == nil {
-		if fd, err := os.OpenFile(p.fname, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600); err != nil {
+		if fd, err := p.storage().OpenFile(p.fname, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600); err != nil {
 			return WrapFileOperationError(OpCreate, err, "", p.fname).
 				WithDetails("failed_to_open_position_file_for_dump")
 		} else {
@@ -0,0 +1,353 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+)
+
+// Priority is the priority of data within a PriorityCache, the larger
+// value the higher priority.
+type Priority int
+
+// ErrInvalidPriority returned when Put with a priority that no lane accept it.
+var ErrInvalidPriority = errors.New("invalid priority")
+
+// PriorityCache is a disk cache with multiple priority lanes under a
+// single path. Each lane is a standalone DiskCache under sub-dir
+// `<path>/p<priority>', and all lanes share the same capacity.
+//
+// On Get(), high priority lanes drained first(strict), or drained in weighted
+// round-robin if drain weights set. When the shared capacity reached, data within
+// lowest priority lane dropped first.
+type PriorityCache struct {
+	path     string
+	capacity int64
+	noDrop,
+	filoDrop bool
+
+	lanes []*DiskCache
+
+	mu sync.Mutex // exclude Put among lanes to keep the shared capacity
+
+	// weighted round-robin on Get()
+	wmu     sync.Mutex
+	weights []int
+	credits []int
+}
+
+// OpenPriority open a PriorityCache with n lanes, priority within [0, n).
+// Options applied to all lanes, and the capacity is shared among them.
+func OpenPriority(n int, opts ...CacheOption) (*PriorityCache, error) {
+	if n <= 0 {
+		return nil, NewCacheError(OpOpen, ErrInvalidPriority,
+			fmt.Sprintf("invalid_lanes=%d", n))
+	}
+
+	// apply options on a dummy instance to get the shared settings.
+	dummy := defaultInstance()
+	for _, x := range opts {
+		if x != nil {
+			x(dummy)
+		}
+	}
+	dummy.syncEnv()
+
+	pc := &PriorityCache{
+		path:     dummy.path,
+		capacity: dummy.capacity,
+		noDrop:   dummy.noDrop,
+		filoDrop: dummy.filoDrop,
+	}
+
+	for i := 0; i < n; i++ {
+		laneOpts := append(opts[:len(opts):len(opts)],
+			WithPath(filepath.Join(pc.path, fmt.Sprintf("p%d", i))),
+			func(c *DiskCache) {
+				// capacity checked on PriorityCache, not on each lane.
+				c.capacity = 0
+			})
+
+		c, err := Open(laneOpts...)
+		if err != nil {
+			if cerr := pc.Close(); cerr != nil {
+				l.Warnf("close priority cache: %s", cerr.Error())
+			}
+			return nil, err
+		}
+
+		// the ENV_DISKCACHE_CAPACITY may override the capacity during Open.
+		c.capacity = 0
+		pc.lanes = append(pc.lanes, c)
+	}
+
+	return pc, nil
+}
+
+// SetDrainWeights set weights of each lane(index as priority) for Get().
+//
+// With weights [1, 4], 4 Get() on priority-1 lane then 1 Get() on
+// priority-0 lane. Set nil weights to fallback to strict priority drain.
+func (pc *PriorityCache) SetDrainWeights(weights ...int) error {
+	if len(weights) > 0 && len(weights) != len(pc.lanes) {
+		return fmt.Errorf("expect %d weights, got %d", len(pc.lanes), len(weights))
+	}
+
+	for _, w := range weights {
+		if w <= 0 {
+			return fmt.Errorf("invalid weight %d", w)
+		}
+	}
+
+	pc.wmu.Lock()
+	defer pc.wmu.Unlock()
+
+	if len(weights) == 0 {
+		pc.weights, pc.credits = nil, nil
+	} else {
+		pc.weights = append([]int{}, weights...)
+		pc.credits = append([]int{}, weights...)
+	}
+
+	return nil
+}
+
+// Lanes return lane count of the cache.
+func (pc *PriorityCache) Lanes() int {
+	return len(pc.lanes)
+}
+
+// Lane return the DiskCache of priority p.
+func (pc *PriorityCache) Lane(p Priority) *DiskCache {
+	if int(p) < 0 || int(p) >= len(pc.lanes) {
+		return nil
+	}
+
+	return pc.lanes[p]
+}
+
+// Path return dir of the cache.
+func (pc *PriorityCache) Path() string {
+	return pc.path
+}
+
+// Capacity return the shared capacity of all lanes.
+func (pc *PriorityCache) Capacity() int64 {
+	return pc.capacity
+}
+
+// RawSize return total size of all lanes.
+func (pc *PriorityCache) RawSize() (n int64) {
+	for _, c := range pc.lanes {
+		n += c.RawSize()
+	}
+	return n
+}
+
+// IsFull test if reach max capacity limit after put newData into cache.
+func (pc *PriorityCache) IsFull(newData []byte) bool {
+	return pc.capacity > 0 && pc.RawSize()+int64(len(newData)) > pc.capacity
+}
+
+// Put write data to the lowest priority lane.
+func (pc *PriorityCache) Put(data []byte) error {
+	return pc.PutWithPriority(data, 0)
+}
+
+// PutWithPriority write data to lane of priority p.
+//
+// If the shared capacity reached, data within lanes that priority not
+// higher than p are dropped, lowest priority first. If there is nothing
+// to drop, ErrCacheFull returned.
+func (pc *PriorityCache) PutWithPriority(data []byte, p Priority) error {
+	lane := pc.Lane(p)
+	if lane == nil {
+		return WrapPutError(ErrInvalidPriority, pc.path, len(data)).
+			WithDetails(fmt.Sprintf("priority=%d, lanes=%d", p, len(pc.lanes)))
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if pc.IsFull(data) {
+		if pc.noDrop {
+			return WrapPutError(ErrCacheFull, pc.path, len(data)).WithDetails("no_drop_enabled")
+		}
+
+		if pc.filoDrop {
+			droppedDataVec.WithLabelValues(lane.path, reasonExceedCapacity).Observe(float64(len(data)))
+			return WrapPutError(ErrCacheFull, pc.path, len(data)).WithDetails("filo_drop_policy")
+		}
+
+		dropped, err := pc.dropLowest(p)
+		if err != nil {
+			return WrapPutError(err, pc.path, len(data)).WithDetails("failed_to_drop_batch")
+		}
+
+		if !dropped {
+			droppedDataVec.WithLabelValues(lane.path, reasonExceedCapacity).Observe(float64(len(data)))
+			return WrapPutError(ErrCacheFull, pc.path, len(data)).
+				WithDetails(fmt.Sprintf("no_lower_priority_data_to_drop: priority=%d", p))
+		}
+	}
+
+	return lane.Put(data)
+}
+
+// dropLowest drop a batch within the lowest non-empty lane that priority not higher than p.
+func (pc *PriorityCache) dropLowest(p Priority) (bool, error) {
+	for i := 0; i <= int(p); i++ {
+		dropped, err := pc.lanes[i].dropOldestBatch()
+		if err != nil {
+			return false, err
+		}
+
+		if dropped {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// dropOldestBatch drop the oldest data file of the cache. If there is no
+// data file but the writing file got data, it's rotated and dropped.
+func (c *DiskCache) dropOldestBatch() (bool, error) {
+	c.lifecycleMu.RLock()
+	defer c.lifecycleMu.RUnlock()
+
+	if c.closed {
+		return false, ErrClosed
+	}
+
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+
+	c.rwlock.Lock()
+	n := len(c.dataFiles)
+	c.rwlock.Unlock()
+
+	if n == 0 {
+		if c.curBatchSize == 0 {
+			return false, nil
+		}
+
+		if err := c.rotate(); err != nil {
+			return false, err
+		}
+	}
+
+	if err := c.dropBatch(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Get fetch data from lanes, high priority lane first.
+func (pc *PriorityCache) Get(fn Fn) error {
+	return pc.doGet(func(c *DiskCache) error { return c.Get(fn) })
+}
+
+// BufGet fetch data from lanes into buf, high priority lane first.
+func (pc *PriorityCache) BufGet(buf []byte, fn Fn) error {
+	return pc.doGet(func(c *DiskCache) error { return c.BufGet(buf, fn) })
+}
+
+func (pc *PriorityCache) doGet(get func(c *DiskCache) error) error {
+	for _, i := range pc.drainOrder() {
+		err := get(pc.lanes[i])
+		if err == nil {
+			pc.consumeCredit(i)
+			return nil
+		}
+
+		if !errors.Is(err, ErrNoData) {
+			return err
+		}
+	}
+
+	return ErrNoData
+}
+
+// drainOrder return lane indexes in Get() order.
+func (pc *PriorityCache) drainOrder() []int {
+	pc.wmu.Lock()
+	defer pc.wmu.Unlock()
+
+	order := make([]int, 0, len(pc.lanes))
+
+	if pc.weights != nil {
+		allZero := true
+		for _, x := range pc.credits {
+			if x > 0 {
+				allZero = false
+				break
+			}
+		}
+
+		if allZero { // new round
+			copy(pc.credits, pc.weights)
+		}
+
+		// lanes with credits first, high priority first
+		for i := len(pc.lanes) - 1; i >= 0; i-- {
+			if pc.credits[i] > 0 {
+				order = append(order, i)
+			}
+		}
+
+		for i := len(pc.lanes) - 1; i >= 0; i-- {
+			if pc.credits[i] <= 0 {
+				order = append(order, i)
+			}
+		}
+
+		return order
+	}
+
+	for i := len(pc.lanes) - 1; i >= 0; i-- {
+		order = append(order, i)
+	}
+
+	return order
+}
+
+func (pc *PriorityCache) consumeCredit(i int) {
+	pc.wmu.Lock()
+	defer pc.wmu.Unlock()
+
+	if pc.credits != nil && pc.credits[i] > 0 {
+		pc.credits[i]--
+	}
+}
+
+// Rotate force rotate all lanes.
+func (pc *PriorityCache) Rotate() error {
+	var errs []error
+	for _, c := range pc.lanes {
+		if err := c.Rotate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Close close all lanes.
+func (pc *PriorityCache) Close() error {
+	var errs []error
+	for _, c := range pc.lanes {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,171 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	T "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriorityCache(t *T.T) {
+	t.Run(`strict-drain`, func(t *T.T) {
+		p := t.TempDir()
+		pc, err := OpenPriority(3, WithPath(p))
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, pc.Lanes())
+		assert.Equal(t, filepath.Join(p, "p2"), pc.Lane(2).Path())
+		assert.Nil(t, pc.Lane(3))
+
+		require.NoError(t, pc.PutWithPriority([]byte("low"), 0))
+		require.NoError(t, pc.PutWithPriority([]byte("mid"), 1))
+		require.NoError(t, pc.PutWithPriority([]byte("high"), 2))
+		require.NoError(t, pc.Rotate())
+
+		var got []string
+		for {
+			if err := pc.Get(func(x []byte) error {
+				got = append(got, string(x))
+				return nil
+			}); err != nil {
+				require.True(t, errors.Is(err, ErrNoData))
+				break
+			}
+		}
+
+		assert.Equal(t, []string{"high", "mid", "low"}, got)
+
+		assert.True(t, errors.Is(pc.PutWithPriority([]byte("x"), 3), ErrInvalidPriority))
+
+		t.Cleanup(func() {
+			assert.NoError(t, pc.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`weighted-drain`, func(t *T.T) {
+		p := t.TempDir()
+		pc, err := OpenPriority(2, WithPath(p))
+		require.NoError(t, err)
+
+		require.NoError(t, pc.SetDrainWeights(1, 2))
+		assert.Error(t, pc.SetDrainWeights(1))
+		assert.Error(t, pc.SetDrainWeights(0, 1))
+
+		for i := 0; i < 4; i++ {
+			require.NoError(t, pc.PutWithPriority([]byte("low"), 0))
+			require.NoError(t, pc.PutWithPriority([]byte("high"), 1))
+		}
+		require.NoError(t, pc.Rotate())
+
+		var got []string
+		for i := 0; i < 6; i++ {
+			require.NoError(t, pc.Get(func(x []byte) error {
+				got = append(got, string(x))
+				return nil
+			}))
+		}
+
+		assert.Equal(t, []string{"high", "high", "low", "high", "high", "low"}, got)
+
+		t.Cleanup(func() {
+			assert.NoError(t, pc.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`drop-lowest-first`, func(t *T.T) {
+		p := t.TempDir()
+		sample := bytes.Repeat([]byte("x"), 1024)
+		pc, err := OpenPriority(2, WithPath(p), WithCapacity(8*1024))
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, pc.PutWithPriority(sample, 1))
+			require.NoError(t, pc.Lane(1).Rotate())
+		}
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, pc.PutWithPriority(sample, 0))
+			require.NoError(t, pc.Lane(0).Rotate())
+		}
+
+		// the shared capacity exceeded: low priority data dropped first
+		for i := 0; i < 3; i++ {
+			require.NoError(t, pc.PutWithPriority(sample, 1), fmt.Sprintf("size: %d", pc.RawSize()))
+			require.NoError(t, pc.Lane(1).Rotate())
+		}
+
+		assert.Equal(t, 6, len(pc.Lane(1).dataFiles))
+		assert.Less(t, len(pc.Lane(0).dataFiles), 3)
+
+		t.Cleanup(func() {
+			assert.NoError(t, pc.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`drop-lower-priority-writing-file`, func(t *T.T) {
+		p := t.TempDir()
+		sample := bytes.Repeat([]byte("x"), 1024)
+		pc, err := OpenPriority(3, WithPath(p), WithCapacity(5*1024))
+		require.NoError(t, err)
+
+		// low priority data only within the writing file, never rotated
+		require.NoError(t, pc.PutWithPriority(sample, 0))
+
+		for i := 0; i < 4; i++ {
+			require.NoError(t, pc.PutWithPriority(sample, 2))
+			require.NoError(t, pc.Lane(2).Rotate())
+		}
+
+		require.Empty(t, pc.Lane(0).dataFiles)
+		require.True(t, pc.IsFull(sample))
+
+		require.NoError(t, pc.PutWithPriority(sample, 1))
+		assert.Empty(t, pc.Lane(0).dataFiles)
+		assert.Zero(t, pc.Lane(0).curBatchSize)
+		assert.Equal(t, 4, len(pc.Lane(2).dataFiles))
+
+		require.NoError(t, pc.Lane(1).Rotate())
+		require.NoError(t, pc.Lane(1).Get(func(x []byte) error {
+			assert.Equal(t, sample, x)
+			return nil
+		}))
+
+		t.Cleanup(func() {
+			assert.NoError(t, pc.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`full-without-lower-priority-data`, func(t *T.T) {
+		p := t.TempDir()
+		sample := bytes.Repeat([]byte("x"), 1024)
+		pc, err := OpenPriority(2, WithPath(p), WithCapacity(5*1024))
+		require.NoError(t, err)
+
+		for i := 0; i < 4; i++ {
+			require.NoError(t, pc.PutWithPriority(sample, 1))
+			require.NoError(t, pc.Lane(1).Rotate())
+		}
+
+		// high priority data never dropped for low priority data
+		assert.True(t, errors.Is(pc.PutWithPriority(sample, 0), ErrCacheFull))
+		assert.Equal(t, 4, len(pc.Lane(1).dataFiles))
+
+		t.Cleanup(func() {
+			assert.NoError(t, pc.Close())
+			ResetMetrics()
+		})
+	})
+}
@@ -0,0 +1,303 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Producer is a writer of a shared cache in other process.
+//
+// The cache opened with WithMultiProducer() is the only consumer, while
+// producers in other processes(or within the same process) append data to
+// their own segment files under the segments dir of the cache. A sealed
+// segment is adopted by the consumer as a data file, so Get() see data
+// from all producers within one stream, ordered by segment.
+type Producer struct {
+	path, dir string
+	fs        Storage
+
+	batchSize   int64
+	maxDataSize int32
+	noSync      bool
+	filePerms   os.FileMode
+	wakeup      time.Duration
+
+	mu        sync.Mutex
+	closed    bool
+	wfd       File
+	flock     *walLock // held during writing the segment
+	id        string   // id of current writing segment
+	seq       int
+	size      int64
+	lastWrite time.Time
+
+	exit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// OpenProducer open a producer on the cache specified by WithPath(). Options
+// WithBatchSize/WithMaxDataSize/WithNoSync/WithDirPermission/WithFilePermission and
+// WithWakeup are applied to the producer, other options are ignored.
+//
+// The segment is sealed when it's size reached the batch size, or no data
+// written for the wakeup duration, or on Rotate()/Close().
+func OpenProducer(opts ...CacheOption) (*Producer, error) {
+	setupLogger()
+
+	c := defaultInstance()
+	for _, x := range opts {
+		if x != nil {
+			x(c)
+		}
+	}
+
+	if c.path == "" {
+		return nil, WrapOpenError(os.ErrInvalid, c.path).WithDetails("cache_path_not_set")
+	}
+
+	if !isOSStorage(c.fs) {
+		return nil, WrapOpenError(errors.ErrUnsupported, c.path).WithDetails("producer_requires_local_file_system")
+	}
+
+	if c.dirPerms == 0 {
+		c.dirPerms = 0o755
+	}
+
+	if c.filePerms == 0 {
+		c.filePerms = 0o640
+	}
+
+	if int64(c.maxDataSize) > c.batchSize {
+		c.maxDataSize = int32(c.batchSize / 2)
+	}
+
+	p := &Producer{
+		path:        c.path,
+		dir:         segmentsDir(c.path),
+		fs:          c.fs,
+		batchSize:   c.batchSize,
+		maxDataSize: c.maxDataSize,
+		noSync:      c.noSync,
+		filePerms:   c.filePerms,
+		wakeup:      c.wakeup,
+		exit:        make(chan struct{}),
+	}
+
+	if err := p.fs.MkdirAll(p.dir, c.dirPerms); err != nil {
+		return nil, NewCacheError(OpCreate, err, fmt.Sprintf("failed_to_create_segments_directory: perms=%o", c.dirPerms)).
+			WithPath(p.dir)
+	}
+
+	if p.wakeup > 0 {
+		p.wg.Add(1)
+		go p.run()
+	}
+
+	return p, nil
+}
+
+// Path return the dir of the shared cache.
+func (p *Producer) Path() string {
+	return p.path
+}
+
+// Put write data into current segment.
+func (p *Producer) Put(data []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return NewCacheError(OpPut, ErrClosed, "producer_closed").WithPath(p.path)
+	}
+
+	if p.maxDataSize > 0 && int32(len(data)) > p.maxDataSize {
+		return WrapPutError(ErrTooLargeData, p.path, len(data)).WithDetails(
+			fmt.Sprintf("max_size=%d, actual_size=%d", p.maxDataSize, len(data)))
+	}
+
+	if p.wfd == nil {
+		if err := p.newSegment(); err != nil {
+			return WrapPutError(err, p.path, len(data)).WithDetails("failed_to_create_segment")
+		}
+	}
+
+	// header and data written within a single write
+	buf := make([]byte, dataHeaderLen+len(data))
+	binary.LittleEndian.PutUint32(buf, uint32(len(data)))
+	copy(buf[dataHeaderLen:], data)
+
+	if _, err := p.wfd.Write(buf); err != nil {
+		return WrapFileOperationError(OpWrite, err, p.dir, p.wfd.Name()).
+			WithDetails("failed_to_write_segment")
+	}
+
+	if !p.noSync {
+		if err := p.wfd.Sync(); err != nil {
+			return WrapFileOperationError(OpSync, err, p.dir, p.wfd.Name()).
+				WithDetails("failed_to_sync_segment")
+		}
+	}
+
+	p.size += int64(len(buf))
+	p.lastWrite = time.Now()
+
+	if p.size >= p.batchSize {
+		if err := p.seal(); err != nil {
+			return WrapPutError(err, p.path, len(data)).WithDetails("failed_to_seal_segment")
+		}
+	}
+
+	return nil
+}
+
+// Rotate seal current segment, make it visible to the consumer.
+func (p *Producer) Rotate() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return NewCacheError(OpRotate, ErrClosed, "producer_closed").WithPath(p.path)
+	}
+
+	return p.seal()
+}
+
+// Close seal current segment and close the producer.
+func (p *Producer) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+
+	p.closed = true
+	err := p.seal()
+	p.mu.Unlock()
+
+	close(p.exit)
+	p.wg.Wait()
+
+	return err
+}
+
+func (p *Producer) run() {
+	defer p.wg.Done()
+
+	tick := time.NewTicker(p.wakeup)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-p.exit:
+			return
+
+		case <-tick.C:
+			p.mu.Lock()
+			if !p.closed && p.size > 0 && time.Since(p.lastWrite) >= p.wakeup {
+				if err := p.seal(); err != nil {
+					l.Errorf("seal idle segment: %s", err.Error())
+				}
+			}
+			p.mu.Unlock()
+		}
+	}
+}
+
+// newSegment create a new writing segment, the segment locked until sealed.
+func (p *Producer) newSegment() error {
+	p.seq++
+	id := fmt.Sprintf("%020d.%d.%06d", time.Now().UnixNano(), os.Getpid(), p.seq)
+	fname := filepath.Join(p.dir, segmentWritingPrefix+id)
+
+	// lock before the segment created, the consumer never see a unlocked writing segment
+	fl := &walLock{file: fname + segmentLockSuffix}
+	if ok, err := fl.tryLock(); !ok {
+		if err == nil {
+			err = errors.New("locked")
+		}
+		return WrapLockError(err, p.dir, os.Getpid()).WithDetails("failed_to_lock_segment")
+	}
+
+	fd, err := p.fs.OpenFile(fname, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_EXCL, p.filePerms)
+	if err != nil {
+		p.releaseLock(fl)
+		return WrapFileOperationError(OpCreate, err, p.dir, fname).WithDetails("failed_to_create_segment")
+	}
+
+	p.id, p.wfd, p.flock, p.size = id, fd, fl, 0
+	return nil
+}
+
+// seal append EOF to current segment and rename it as a sealed segment.
+func (p *Producer) seal() error {
+	if p.wfd == nil {
+		return nil
+	}
+
+	var (
+		fname = p.wfd.Name()
+		fd    = p.wfd
+		fl    = p.flock
+		size  = p.size
+	)
+
+	p.wfd, p.flock, p.size = nil, nil, 0
+	defer p.releaseLock(fl)
+
+	if size == 0 { // nothing written
+		if err := fd.Close(); err != nil {
+			l.Warnf("close empty segment %s: %s", fname, err.Error())
+		}
+		if err := p.fs.Remove(fname); err != nil {
+			return WrapFileOperationError(OpRemove, err, p.dir, fname).WithDetails("failed_to_remove_empty_segment")
+		}
+		return nil
+	}
+
+	eof := make([]byte, dataHeaderLen)
+	binary.LittleEndian.PutUint32(eof, EOFHint)
+	if _, err := fd.Write(eof); err != nil {
+		_ = fd.Close()
+		return WrapFileOperationError(OpWrite, err, p.dir, fname).WithDetails("failed_to_write_segment_eof")
+	}
+
+	if err := fd.Sync(); err != nil {
+		_ = fd.Close()
+		return WrapFileOperationError(OpSync, err, p.dir, fname).WithDetails("failed_to_sync_segment")
+	}
+
+	if err := fd.Close(); err != nil {
+		return WrapFileOperationError(OpClose, err, p.dir, fname).WithDetails("failed_to_close_segment")
+	}
+
+	sealed := filepath.Join(p.dir, segmentSealedPrefix+p.id)
+	if err := p.fs.Rename(fname, sealed); err != nil {
+		return WrapRotateError(err, p.dir, fname, sealed).WithDetails("failed_to_seal_segment")
+	}
+
+	return nil
+}
+
+func (p *Producer) releaseLock(fl *walLock) {
+	if fl == nil {
+		return
+	}
+
+	if err := fl.unlock(); err != nil {
+		l.Warnf("unlock %s: %s", fl.file, err.Error())
+	}
+
+	if err := os.Remove(fl.file); err != nil && !errors.Is(err, os.ErrNotExist) {
+		l.Warnf("remove %s: %s", fl.file, err.Error())
+	}
+}
@@ -0,0 +1,244 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	T "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	envProducerHelper = "DISKCACHE_TEST_PRODUCER_PATH"
+	envProducerID     = "DISKCACHE_TEST_PRODUCER_ID"
+	envProducerCount  = "DISKCACHE_TEST_PRODUCER_COUNT"
+)
+
+// TestProducerHelperProcess is not a real test, it's the producer process
+// started by TestMultiProducer/cross-process.
+func TestProducerHelperProcess(t *T.T) {
+	path := os.Getenv(envProducerHelper)
+	if path == "" {
+		t.Skip("not a producer process")
+	}
+
+	n, err := strconv.Atoi(os.Getenv(envProducerCount))
+	require.NoError(t, err)
+
+	p, err := OpenProducer(WithPath(path), WithBatchSize(512), WithNoSync(true))
+	require.NoError(t, err)
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, p.Put([]byte(fmt.Sprintf("%s:%d", os.Getenv(envProducerID), i))))
+	}
+
+	require.NoError(t, p.Close())
+}
+
+func getAll(t *T.T, c *DiskCache) []string {
+	t.Helper()
+
+	var res []string
+	for {
+		err := c.Get(func(x []byte) error {
+			res = append(res, string(x))
+			return nil
+		})
+
+		if errors.Is(err, ErrNoData) {
+			return res
+		}
+
+		require.NoError(t, err)
+	}
+}
+
+func TestMultiProducer(t *T.T) {
+	t.Run(`segment-order`, func(t *T.T) {
+		p := t.TempDir()
+
+		c, err := Open(WithPath(p), WithMultiProducer(true))
+		require.NoError(t, err)
+
+		p1, err := OpenProducer(WithPath(p))
+		require.NoError(t, err)
+		p2, err := OpenProducer(WithPath(p))
+		require.NoError(t, err)
+
+		require.NoError(t, p1.Put([]byte("p1-0")))
+		require.NoError(t, p2.Put([]byte("p2-0")))
+		require.NoError(t, p1.Put([]byte("p1-1")))
+
+		// writing segments not visible
+		assert.Empty(t, getAll(t, c))
+
+		// p2 sealed first, but p1's segment created earlier
+		require.NoError(t, p2.Close())
+		require.NoError(t, p1.Close())
+
+		assert.Equal(t, []string{"p1-0", "p1-1", "p2-0"}, getAll(t, c))
+
+		files, err := os.ReadDir(segmentsDir(p))
+		require.NoError(t, err)
+		assert.Empty(t, files)
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`adopt-on-open`, func(t *T.T) {
+		p := t.TempDir()
+
+		prod, err := OpenProducer(WithPath(p), WithBatchSize(64))
+		require.NoError(t, err)
+
+		for i := 0; i < 10; i++ {
+			require.NoError(t, prod.Put([]byte(fmt.Sprintf("data-%d", i))))
+		}
+		require.NoError(t, prod.Close())
+
+		c, err := Open(WithPath(p), WithMultiProducer(true))
+		require.NoError(t, err)
+		assert.True(t, len(c.dataFiles) > 1)
+		assert.True(t, c.Size() > 0)
+
+		res := getAll(t, c)
+		require.Len(t, res, 10)
+		for i, x := range res {
+			assert.Equal(t, fmt.Sprintf("data-%d", i), x)
+		}
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`recover-exited-producer`, func(t *T.T) {
+		p := t.TempDir()
+
+		prod, err := OpenProducer(WithPath(p))
+		require.NoError(t, err)
+
+		require.NoError(t, prod.Put([]byte("hello")))
+		require.NoError(t, prod.Put([]byte("world")))
+
+		c, err := Open(WithPath(p), WithMultiProducer(true), WithWakeup(time.Millisecond))
+		require.NoError(t, err)
+
+		// producer alive: the writing segment not adopted
+		assert.Empty(t, getAll(t, c))
+
+		// producer crashed during writing a record: lock released and
+		// the segment left with a partial record
+		fname := prod.wfd.Name()
+		_, err = prod.wfd.Write([]byte{0x10, 0x00, 0x00, 0x00, 'x'})
+		require.NoError(t, err)
+		prod.releaseLock(prod.flock)
+		require.NoError(t, prod.wfd.Close())
+
+		time.Sleep(time.Millisecond)
+		assert.Equal(t, []string{"hello", "world"}, getAll(t, c))
+
+		_, err = os.Stat(fname)
+		assert.True(t, errors.Is(err, os.ErrNotExist))
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`seal-on-idle`, func(t *T.T) {
+		p := t.TempDir()
+
+		prod, err := OpenProducer(WithPath(p), WithWakeup(10*time.Millisecond))
+		require.NoError(t, err)
+		require.NoError(t, prod.Put([]byte("hello")))
+
+		c, err := Open(WithPath(p), WithMultiProducer(true))
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			return c.Get(func(x []byte) error {
+				assert.Equal(t, []byte("hello"), x)
+				return nil
+			}) == nil
+		}, time.Second, 10*time.Millisecond)
+
+		t.Cleanup(func() {
+			assert.NoError(t, prod.Close())
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`cross-process`, func(t *T.T) {
+		p := t.TempDir()
+
+		c, err := Open(WithPath(p), WithMultiProducer(true))
+		require.NoError(t, err)
+
+		const (
+			nproc = 4
+			n     = 200
+		)
+
+		var cmds []*exec.Cmd
+		for i := 0; i < nproc; i++ {
+			cmd := exec.Command(os.Args[0], "-test.run=^TestProducerHelperProcess$") //nolint:gosec
+			cmd.Env = append(os.Environ(),
+				envProducerHelper+"="+p,
+				envProducerID+"="+strconv.Itoa(i),
+				envProducerCount+"="+strconv.Itoa(n),
+			)
+			require.NoError(t, cmd.Start())
+			cmds = append(cmds, cmd)
+		}
+
+		// consume during the producers writing
+		var res []string
+		for _, cmd := range cmds {
+			res = append(res, getAll(t, c)...)
+			require.NoError(t, cmd.Wait())
+		}
+		res = append(res, getAll(t, c)...)
+
+		require.Len(t, res, nproc*n)
+
+		// data from the same producer are in write order
+		next := map[string]int{}
+		for _, x := range res {
+			arr := strings.Split(x, ":")
+			require.Len(t, arr, 2)
+
+			i, err := strconv.Atoi(arr[1])
+			require.NoError(t, err)
+			assert.Equal(t, next[arr[0]], i, "producer %s", arr[0])
+			next[arr[0]] = i + 1
+		}
+
+		matches, err := filepath.Glob(filepath.Join(segmentsDir(p), "*"))
+		require.NoError(t, err)
+		assert.Empty(t, matches)
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+}
@@ -15,13 +15,22 @@ import (
 
 // IsFull test if reach max capacity limit after put newData into cache.
 func (c *DiskCache) IsFull(newData []byte) bool {
-	return c.capacity > 0 && c.size.Load()+int64(len(newData)) > c.capacity
+	return c.isFull(int64(len(newData)))
 }
 
 // Put write @data to disk cache, if reached batch size, a new batch is rotated.
 // Put is safe to call concurrently with other operations and will
 // block until all other operations finish.
 func (c *DiskCache) Put(data []byte) error {
+	err := c.put(data)
+
+	// free space callback may call into the cache, so called without lock
+	c.fsw.notify()
+
+	return err
+}
+
+func (c *DiskCache) put(data []byte) error {
 	start := time.Now() // count time before lock
 
 	c.lifecycleMu.RLock()
@@ -31,13 +40,23 @@ func (c *DiskCache) Put(data []byte) error {
 		return WrapPutError(ErrClosed, c.path, len(data))
 	}
 
-	c.wlock.Lock()
-	defer c.wlock.Unlock()
-
 	defer func() {
 		putLatencyVec.WithLabelValues(c.path).Observe(time.Since(start).Seconds())
 	}()
 
+	if c.gc != nil {
+		return c.gc.put(data)
+	}
+
+	c.wlock.Lock()
+	defer c.wlock.Unlock()
+
+	return c.doPut(data, !c.noSync)
+}
+
+// doPut write data to current writing file, if sync set, the writing file
+// synced after write. The caller should hold the wlock.
+func (c *DiskCache) doPut(data []byte, sync bool) error {
 	if c.IsFull(data) {
 		if c.noDrop {
 			return WrapPutError(ErrCacheFull, c.path, len(data)).WithDetails("no_drop_enabled")
@@ -75,7 +94,7 @@ func (c *DiskCache) Put(data []byte) error {
 			WithDetails("failed_to_write_data")
 	}
 
-	if !c.noSync {
+	if sync {
 		if err := c.wfd.Sync(); err != nil {
 			return WrapFileOperationError(OpSync, err, c.path, c.writeFileName()).
 				WithDetails("failed_to_sync_write")
@@ -87,6 +106,14 @@ func (c *DiskCache) Put(data []byte) error {
 
 	// rotate new file
 	if c.curBatchSize >= c.batchSize {
+		// make sure all data within the file persisted before rotate
+		if !sync && !c.noSync {
+			if err := c.wfd.Sync(); err != nil {
+				return WrapFileOperationError(OpSync, err, c.path, c.writeFileName()).
+					WithDetails("failed_to_sync_before_rotate")
+			}
+		}
+
 		if err := c.rotate(); err != nil {
 			return WrapPutError(err, c.path, len(data)).WithDetails("failed_to_rotate_batch")
 		}
@@ -124,10 +151,10 @@ func (c *DiskCache) StreamPut(r io.Reader, size int) error {
 	c.wlock.Lock()
 	defer c.wlock.Unlock()
 
-	if c.capacity > 0 && c.size.Load()+int64(size) > c.capacity {
+	if c.isFull(int64(size)) {
 		return NewCacheError(OpStreamPut, ErrCacheFull,
 			fmt.Sprintf("capacity_exceeded: current=%d, new=%d, max=%d",
-				c.size.Load(), size, c.capacity)).WithPath(c.path)
+				c.size.Load(), size, c.EffectiveCapacity())).WithPath(c.path)
 	}
 
 	if c.maxDataSize > 0 && size > int(c.maxDataSize) {
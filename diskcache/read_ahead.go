@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// readAhead is a read buffer on current reading file. Small records
+// are served from the buffer to reduce read syscalls.
+//
+// Data within the buffer always copied to the caller, so the caller
+// buffer(such as buffer from BufCallbackGet) never point to the buffer.
+type readAhead struct {
+	fd   File // the file buffered, buffer dropped if reading file changed
+	buf  []byte
+	r, w int // buf[r:w] is the unread data
+}
+
+func newReadAhead(size int) *readAhead {
+	return &readAhead{buf: make([]byte, size)}
+}
+
+func (ra *readAhead) bind(fd File) {
+	if ra.fd != fd {
+		ra.fd = fd
+		ra.r, ra.w = 0, 0
+	}
+}
+
+// read len(p) bytes from fd. Less bytes returned only if end of file reached,
+// and io.EOF returned if nothing read.
+func (ra *readAhead) read(fd File, p []byte) (int, error) {
+	ra.bind(fd)
+
+	n := copy(p, ra.buf[ra.r:ra.w])
+	ra.r += n
+
+	for n < len(p) {
+		// large read: bypass the buffer
+		if len(p)-n >= len(ra.buf) {
+			x, err := io.ReadFull(fd, p[n:])
+			n += x
+
+			if err != nil {
+				if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+					break
+				}
+				return n, err
+			}
+
+			continue
+		}
+
+		x, err := fd.Read(ra.buf)
+		ra.r, ra.w = 0, x
+
+		if x > 0 {
+			c := copy(p[n:], ra.buf[:x])
+			ra.r = c
+			n += c
+		}
+
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return n, err
+		}
+	}
+
+	if n == 0 && len(p) > 0 {
+		return 0, io.EOF
+	}
+
+	return n, nil
+}
+
+// seek move the read position relative to current position, and return
+// the new position within the file.
+func (ra *readAhead) seek(fd File, delta int64) (int64, error) {
+	ra.bind(fd)
+
+	if x := int64(ra.r) + delta; x >= 0 && x <= int64(ra.w) { // seek within buffer
+		ra.r = int(x)
+
+		off, err := fd.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0, err
+		}
+
+		return off - int64(ra.w-ra.r), nil
+	}
+
+	// drop the buffer, and seek on the file
+	unread := int64(ra.w - ra.r)
+	ra.r, ra.w = 0, 0
+
+	return fd.Seek(delta-unread, io.SeekCurrent)
+}
+
+// readData read current reading file into p.
+//
+// The reading file may be closed by concurrent drop during Get(), the same
+// as nil *os.File, os.ErrInvalid returned on nil reading file.
+func (c *DiskCache) readData(p []byte) (int, error) {
+	if c.rfd == nil {
+		return 0, os.ErrInvalid
+	}
+
+	if c.ra != nil {
+		return c.ra.read(c.rfd, p)
+	}
+
+	return c.rfd.Read(p)
+}
+
+// seekRead move the read position of current reading file relative to current position.
+func (c *DiskCache) seekRead(delta int64) (int64, error) {
+	if c.rfd == nil {
+		return 0, os.ErrInvalid
+	}
+
+	if c.ra != nil {
+		return c.ra.seek(c.rfd, delta)
+	}
+
+	return c.rfd.Seek(delta, io.SeekCurrent)
+}
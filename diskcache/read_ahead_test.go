@@ -0,0 +1,198 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	T "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadAhead(t *T.T) {
+	t.Run(`small-and-large-records`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithReadAhead(64))
+		require.NoError(t, err)
+
+		var expect [][]byte
+		for i := 0; i < 100; i++ {
+			data := bytes.Repeat([]byte{byte(i)}, (i%10)*30+1) // some records larger than the buffer
+			expect = append(expect, data)
+			require.NoError(t, c.Put(data))
+		}
+		require.NoError(t, c.Rotate())
+
+		for i := 0; i < 100; i++ {
+			require.NoError(t, c.Get(func(x []byte) error {
+				assert.Equal(t, expect[i], x, "index %d", i)
+				return nil
+			}))
+		}
+
+		assert.True(t, errors.Is(c.Get(nil), ErrNoData))
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`fallback-on-error`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithReadAhead(1024))
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, c.Put([]byte(fmt.Sprintf("data-%d", i))))
+		}
+		require.NoError(t, c.Rotate())
+
+		require.NoError(t, c.Get(nil))
+
+		// fn failed, the same data read on next Get()
+		assert.Error(t, c.Get(func(x []byte) error {
+			assert.Equal(t, []byte("data-1"), x)
+			return errors.New("some error")
+		}))
+
+		require.NoError(t, c.Get(func(x []byte) error {
+			assert.Equal(t, []byte("data-1"), x)
+			return nil
+		}))
+
+		require.NoError(t, c.Get(func(x []byte) error {
+			assert.Equal(t, []byte("data-2"), x)
+			return nil
+		}))
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`too-small-buffer`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithReadAhead(1024))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put([]byte("large-data")))
+		require.NoError(t, c.Put([]byte("small")))
+		require.NoError(t, c.Rotate())
+
+		buf := make([]byte, 5)
+		assert.True(t, errors.Is(c.BufGet(buf, nil), ErrTooSmallReadBuf))
+
+		require.NoError(t, c.BufCallbackGet(func() []byte { return buf }, func(x []byte) error {
+			assert.Equal(t, []byte("small"), x)
+			return nil
+		}))
+
+		// buffer owned by the caller
+		assert.Equal(t, []byte("small"), buf)
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`pos-on-reopen`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithReadAhead(1024), WithPosUpdate(0, 0))
+		require.NoError(t, err)
+
+		for i := 0; i < 10; i++ {
+			require.NoError(t, c.Put([]byte(fmt.Sprintf("data-%d", i))))
+		}
+		require.NoError(t, c.Rotate())
+
+		for i := 0; i < 4; i++ {
+			require.NoError(t, c.Get(nil))
+		}
+
+		require.NoError(t, c.Close())
+
+		c, err = Open(WithPath(p), WithReadAhead(1024))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Get(func(x []byte) error {
+			assert.Equal(t, []byte("data-4"), x)
+			return nil
+		}))
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`skip-bad-file`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithReadAhead(1024))
+		require.NoError(t, err)
+
+		require.NoError(t, c.Put([]byte("data-0")))
+		require.NoError(t, c.Rotate())
+		require.NoError(t, c.Put([]byte("data-1")))
+		require.NoError(t, c.Rotate())
+
+		// broken header within the first file
+		require.NoError(t, os.Truncate(c.dataFiles[0], 2))
+
+		require.NoError(t, c.Get(func(x []byte) error {
+			assert.Equal(t, []byte("data-1"), x)
+			return nil
+		}))
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+}
+
+func BenchmarkReadAhead(b *T.B) {
+	data := bytes.Repeat([]byte("x"), 128)
+
+	cases := []struct {
+		name string
+		opts []CacheOption
+	}{
+		{name: "direct-read", opts: nil},
+		{name: "read-ahead-64KB", opts: []CacheOption{WithReadAhead(64 * 1024)}},
+	}
+
+	for _, bc := range cases {
+		b.Run(bc.name, func(b *T.B) {
+			c, err := Open(append(bc.opts, WithPath(b.TempDir()), WithNoSync(true), WithNoPos(true))...)
+			require.NoError(b, err)
+
+			for i := 0; i < b.N; i++ {
+				require.NoError(b, c.Put(data))
+			}
+			require.NoError(b, c.Rotate())
+
+			buf := make([]byte, 1024)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := c.BufGet(buf, nil); err != nil {
+					b.Error(err)
+				}
+			}
+			b.StopTimer()
+
+			require.NoError(b, c.Close())
+			ResetMetrics()
+		})
+	}
+}
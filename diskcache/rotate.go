@@ -8,7 +8,6 @@ package diskcache
 import (
 	"encoding/binary"
 	"fmt"
-	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
@@ -60,27 +59,9 @@ func (c *DiskCache) rotate() error {
 	// NOTE: EOF bytes do not count to size
 
 	// rotate file
-	var newfile string
-	if len(c.dataFiles) == 0 {
-		newfile = filepath.Join(c.path, fmt.Sprintf("data.%032d", 0)) // first rotate file
-	} else {
-		// parse last file's name, such as `data.000003', the new rotate file is `data.000004`
-		last := c.dataFiles[len(c.dataFiles)-1]
-		arr := strings.Split(filepath.Base(last), ".")
-		if len(arr) != 2 {
-			return NewCacheError(OpRotate, ErrInvalidDataFileName,
-				fmt.Sprintf("invalid_filename_format: %s", last)).
-				WithPath(c.path).WithFile(last)
-		}
-		x, err := strconv.ParseInt(arr[1], 10, 64)
-		if err != nil {
-			return NewCacheError(OpRotate, ErrInvalidDataFileNameSuffix,
-				fmt.Sprintf("failed_to_parse_sequence_from_filename: %s, error: %v", arr[1], err)).
-				WithPath(c.path).WithFile(last)
-		}
-
-		// data.0003 -> data.0004
-		newfile = filepath.Join(c.path, fmt.Sprintf("data.%032d", x+1))
+	newfile, err := c.nextDataFile()
+	if err != nil {
+		return err
 	}
 
 	// close current writing file
@@ -93,13 +74,13 @@ func (c *DiskCache) rotate() error {
 	// rename data -> data.0004
 	renamed := true
 	var rotateErr error
-	if err := os.Rename(c.curWriteFile, newfile); err != nil {
+	if err := c.fs.Rename(c.curWriteFile, newfile); err != nil {
 		renamed = false
 		rotateErr = WrapRotateError(err, c.path, c.curWriteFile, newfile).
 			WithDetails("failed_to_rename_file_during_rotate")
 
-		if fi, statErr := os.Stat(c.curWriteFile); statErr == nil && !fi.IsDir() {
-			if truncErr := os.Truncate(c.curWriteFile, batchSizeBeforeEOF); truncErr != nil {
+		if fi, statErr := c.fs.Stat(c.curWriteFile); statErr == nil && !fi.IsDir() {
+			if truncErr := c.fs.Truncate(c.curWriteFile, batchSizeBeforeEOF); truncErr != nil {
 				rotateErr = NewCacheError(OpRotate, rotateErr,
 					fmt.Sprintf("failed_to_restore_write_file_size_after_rename_failure: size=%d, error=%v",
 						batchSizeBeforeEOF, truncErr)).
@@ -110,7 +91,7 @@ func (c *DiskCache) rotate() error {
 
 	// new file added, add it's size to cache size
 	if renamed {
-		if fi, err := os.Stat(newfile); err == nil {
+		if fi, err := c.fs.Stat(newfile); err == nil {
 			if fi.Size() > dataHeaderLen {
 				c.size.Add(fi.Size())
 				sizeVec.WithLabelValues(c.path).Add(float64(fi.Size()))
@@ -142,6 +123,31 @@ func (c *DiskCache) rotate() error {
 	return rotateErr
 }
 
+// nextDataFile return the name of next rotated data file.
+func (c *DiskCache) nextDataFile() (string, error) {
+	if len(c.dataFiles) == 0 {
+		return filepath.Join(c.path, fmt.Sprintf("data.%032d", 0)), nil // first rotate file
+	}
+
+	// parse last file's name, such as `data.000003', the new rotate file is `data.000004`
+	last := c.dataFiles[len(c.dataFiles)-1]
+	arr := strings.Split(filepath.Base(last), ".")
+	if len(arr) != 2 {
+		return "", NewCacheError(OpRotate, ErrInvalidDataFileName,
+			fmt.Sprintf("invalid_filename_format: %s", last)).
+			WithPath(c.path).WithFile(last)
+	}
+	x, err := strconv.ParseInt(arr[1], 10, 64)
+	if err != nil {
+		return "", NewCacheError(OpRotate, ErrInvalidDataFileNameSuffix,
+			fmt.Sprintf("failed_to_parse_sequence_from_filename: %s, error: %v", arr[1], err)).
+			WithPath(c.path).WithFile(last)
+	}
+
+	// data.0003 -> data.0004
+	return filepath.Join(c.path, fmt.Sprintf("data.%032d", x+1)), nil
+}
+
 // after file read on EOF, remove the file.
 func (c *DiskCache) removeCurrentReadingFile() error {
 	c.rwlock.Lock()
@@ -160,7 +166,7 @@ func (c *DiskCache) removeCurrentReadingFile() error {
 		c.rfd = nil
 	}
 
-	if fi, err := os.Stat(c.curReadfile); err == nil { // file exist
+	if fi, err := c.fs.Stat(c.curReadfile); err == nil { // file exist
 		if fi.Size() > dataHeaderLen {
 			c.size.Add(-fi.Size())
 			sizeVec.WithLabelValues(c.path).Sub(float64(fi.Size()))
@@ -168,7 +174,7 @@ func (c *DiskCache) removeCurrentReadingFile() error {
 
 		getBytesVec.WithLabelValues(c.path).Observe(float64(fi.Size()))
 
-		if err := os.Remove(c.curReadfile); err != nil {
+		if err := c.fs.Remove(c.curReadfile); err != nil {
 			return WrapFileOperationError(OpRemove, err, c.path, c.curReadfile).
 				WithDetails("failed_to_remove_consumed_file")
 		}
@@ -53,7 +53,7 @@ func TestRotate(t *T.T) {
 		// rotate it
 		c.rotate()
 
-		pos, err := posFromFile(c.pos.fname)
+		pos, err := posFromFile(c.fs, c.pos.fname)
 		assert.NoError(t, err)
 		assert.Nil(t, pos)
 
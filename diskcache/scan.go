@@ -0,0 +1,120 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// errStopScan used within scan callback to stop the scan without error.
+var errStopScan = errors.New("stop scan")
+
+// Record is a single piece of data within the cache.
+type Record struct {
+	File   string // data file of the record
+	Offset int64  // offset of the record(header included) within the data file
+	Data   []byte
+}
+
+// scanDataFile read records from offset off within fname, each record passed to fn.
+//
+// The scan stopped on EOF hint or end of file, and returned the offset of
+// the end position, and whether the EOF hint reached. If fn return errStopScan,
+// the scan stopped with nil error.
+func scanDataFile(fs Storage, fname string, off int64, fn func(off int64, data []byte) error) (int64, bool, error) {
+	fd, err := fs.OpenFile(fname, os.O_RDONLY, 0)
+	if err != nil {
+		return off, false, err
+	}
+
+	defer fd.Close() //nolint:errcheck,gosec
+
+	fi, err := fd.Stat()
+	if err != nil {
+		return off, false, err
+	}
+
+	if off > 0 {
+		if _, err := fd.Seek(off, io.SeekStart); err != nil {
+			return off, false, err
+		}
+	}
+
+	var (
+		r   = bufio.NewReaderSize(fd, 256*1024)
+		hdr = make([]byte, dataHeaderLen)
+	)
+
+	for {
+		n, err := io.ReadFull(r, hdr)
+		if err != nil {
+			if errors.Is(err, io.EOF) { // end of file without EOF hint
+				return off, false, nil
+			}
+
+			return off, false, NewCacheError(OpRead, ErrBadHeader,
+				fmt.Sprintf("truncated_header: offset=%d, header_size=%d", off, n)).WithFile(fname)
+		}
+
+		nbytes := binary.LittleEndian.Uint32(hdr)
+		if nbytes == EOFHint {
+			return off + dataHeaderLen, true, nil
+		}
+
+		if left := fi.Size() - off - dataHeaderLen; int64(nbytes) > left {
+			return off, false, NewCacheError(OpRead, ErrBadHeader,
+				fmt.Sprintf("data_size_exceed_file: offset=%d, data_size=%d, left=%d", off, nbytes, left)).WithFile(fname)
+		}
+
+		data := make([]byte, nbytes)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return off, false, NewCacheError(OpRead, err,
+				fmt.Sprintf("truncated_data: offset=%d, data_size=%d", off, nbytes)).WithFile(fname)
+		}
+
+		if fn != nil {
+			if err := fn(off, data); err != nil {
+				if errors.Is(err, errStopScan) {
+					return off, false, nil
+				}
+				return off, false, err
+			}
+		}
+
+		off += int64(dataHeaderLen) + int64(nbytes)
+	}
+}
+
+// listDataFiles list rotated data files under path, sorted in Get() order.
+func listDataFiles(fs Storage, path string) ([]string, error) {
+	all, err := fs.List(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, f := range all {
+		if isDataFile(f) {
+			files = append(files, f)
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// isDataFile test if fname is a rotated data file.
+func isDataFile(fname string) bool {
+	base := filepath.Base(fname)
+	return len(base) > len("data.") && base[:len("data.")] == "data."
+}
@@ -0,0 +1,201 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const reasonSeekSkipped = "seek-skipped"
+
+// ErrInvalidSeek returned if seek to a position that not exist or not at the start of a record.
+var ErrInvalidSeek = errors.New("invalid seek position")
+
+// Seek move the read position to offset within data file, the file can be
+// full path or base name(such as data.00000000000000000000000000000003).
+// The offset should be the start of a record(or end of the last record).
+//
+// Data files before the file are dropped. We can seek backward within current
+// reading file to re-Get() data that already consumed, but data files that
+// already consumed are removed, they are not seekable.
+func (c *DiskCache) Seek(file string, offset int64) error {
+	c.lifecycleMu.RLock()
+	defer c.lifecycleMu.RUnlock()
+
+	if c.closed {
+		return NewCacheError(OpSeek, ErrClosed, "cache_closed").WithPath(c.path)
+	}
+
+	c.rlock.Lock()
+	defer c.rlock.Unlock()
+
+	c.rwlock.Lock()
+	defer c.rwlock.Unlock()
+
+	return c.doSeek(file, offset)
+}
+
+// SeekToTime move the read position to the start of the first data file
+// that written at or after t.
+//
+// The write time of a data file is the time of its last Put(), so records
+// written a bit before t within the same file are Get() again.
+func (c *DiskCache) SeekToTime(t time.Time) error {
+	c.lifecycleMu.RLock()
+	defer c.lifecycleMu.RUnlock()
+
+	if c.closed {
+		return NewCacheError(OpSeek, ErrClosed, "cache_closed").WithPath(c.path)
+	}
+
+	c.rlock.Lock()
+	defer c.rlock.Unlock()
+
+	c.rwlock.Lock()
+	defer c.rwlock.Unlock()
+
+	for _, f := range c.dataFiles {
+		fi, err := c.fs.Stat(f)
+		if err != nil {
+			return WrapFileOperationError(OpStat, err, c.path, f).WithDetails("failed_to_stat_file_during_seek")
+		}
+
+		if !fi.ModTime().Before(t) {
+			return c.doSeek(f, 0)
+		}
+	}
+
+	return NewCacheError(OpSeek, ErrInvalidSeek,
+		fmt.Sprintf("no_data_file_written_after: %s", t)).WithPath(c.path)
+}
+
+// doSeek seek to offset within fname, the caller should hold the rlock and rwlock.
+func (c *DiskCache) doSeek(fname string, offset int64) error {
+	if filepath.Base(fname) == fname {
+		fname = filepath.Join(c.path, fname)
+	}
+
+	idx := -1
+	for i, f := range c.dataFiles {
+		if f == fname {
+			idx = i
+			break
+		}
+	}
+
+	if idx == -1 {
+		return NewCacheError(OpSeek, ErrInvalidSeek, "data_file_not_found").
+			WithPath(c.path).WithFile(fname)
+	}
+
+	if err := checkRecordOffset(c.fs, fname, offset); err != nil {
+		return NewCacheError(OpSeek, err, fmt.Sprintf("offset=%d", offset)).
+			WithPath(c.path).WithFile(fname)
+	}
+
+	// drop all files before the file
+	if c.rfd != nil && c.curReadfile != fname {
+		if err := c.rfd.Close(); err != nil {
+			return WrapFileOperationError(OpClose, err, c.path, c.readFileName()).
+				WithDetails("failed_to_close_read_file_during_seek")
+		}
+
+		c.rfd = nil
+	}
+
+	for _, f := range c.dataFiles[:idx] {
+		fi, err := c.fs.Stat(f)
+		if err != nil {
+			return WrapFileOperationError(OpStat, err, c.path, f).WithDetails("failed_to_stat_file_during_seek")
+		}
+
+		if err := c.fs.Remove(f); err != nil {
+			return WrapFileOperationError(OpRemove, err, c.path, f).WithDetails("failed_to_remove_file_during_seek")
+		}
+
+		c.size.Add(-fi.Size())
+		droppedDataVec.WithLabelValues(c.path, reasonSeekSkipped).Observe(float64(fi.Size()))
+		sizeVec.WithLabelValues(c.path).Sub(float64(fi.Size()))
+	}
+
+	c.dataFiles = c.dataFiles[idx:]
+	datafilesVec.WithLabelValues(c.path).Set(float64(len(c.dataFiles)))
+
+	if c.rfd == nil {
+		fd, err := c.fs.OpenFile(fname, os.O_RDONLY, c.filePerms)
+		if err != nil {
+			return WrapFileOperationError(OpOpen, err, c.path, fname).
+				WithDetails("failed_to_open_file_during_seek")
+		}
+
+		c.rfd = fd
+		c.curReadfile = fname
+
+		if fi, err := fd.Stat(); err == nil {
+			c.curReadSize = fi.Size()
+		}
+	}
+
+	if c.ra != nil {
+		c.ra.bind(nil) // drop read-ahead buffer
+	}
+
+	if _, err := c.rfd.Seek(offset, io.SeekStart); err != nil {
+		return WrapFileOperationError(OpSeek, err, c.path, fname).
+			WithDetails(fmt.Sprintf("failed_to_seek_to_offset: offset=%d", offset))
+	}
+
+	if !c.noPos {
+		c.pos.Name = []byte(fname)
+		c.pos.Seek = offset
+		if err := c.pos.doDumpFile(); err != nil {
+			return WrapPosError(err, c.path, offset).WithDetails("failed_to_dump_position_after_seek")
+		}
+
+		posUpdatedVec.WithLabelValues("seek", c.path).Inc()
+	}
+
+	return nil
+}
+
+// checkRecordOffset check if offset is the start of a record within fname.
+func checkRecordOffset(fs Storage, fname string, offset int64) error {
+	if offset == 0 {
+		return nil
+	}
+
+	if offset < 0 {
+		return ErrInvalidSeek
+	}
+
+	found := false
+	if _, _, err := scanDataFile(fs, fname, 0, func(off int64, data []byte) error {
+		// offset at the start of a record, or at the end of the record
+		if off == offset || off+dataHeaderLen+int64(len(data)) == offset {
+			found = true
+			return errStopScan
+		}
+
+		if off > offset {
+			return errStopScan
+		}
+
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if found {
+		return nil
+	}
+
+	return ErrInvalidSeek
+}
@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	T "testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeek(t *T.T) {
+	t.Run(`seek-backward-and-forward`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p), WithReadAhead(1024))
+		require.NoError(t, err)
+
+		for i := 0; i < 6; i++ {
+			require.NoError(t, c.Put([]byte(fmt.Sprintf("data-%d", i))))
+			if i%3 == 2 {
+				require.NoError(t, c.Rotate())
+			}
+		}
+
+		first, second := c.dataFiles[0], c.dataFiles[1]
+
+		for i := 0; i < 2; i++ {
+			require.NoError(t, c.Get(nil))
+		}
+
+		// replay consumed data within current reading file
+		require.NoError(t, c.Seek(filepath.Base(first), 0))
+		require.NoError(t, c.Get(func(x []byte) error {
+			assert.Equal(t, []byte("data-0"), x)
+			return nil
+		}))
+
+		// not at the start of a record
+		assert.True(t, errors.Is(c.Seek(first, 3), ErrInvalidSeek))
+		assert.True(t, errors.Is(c.Seek("data.no-such-file", 0), ErrInvalidSeek))
+
+		// seek to the second record of next file, the first file dropped
+		rec := int64(dataHeaderLen + len("data-3"))
+		require.NoError(t, c.Seek(second, rec))
+		require.NoError(t, c.Get(func(x []byte) error {
+			assert.Equal(t, []byte("data-4"), x)
+			return nil
+		}))
+
+		_, err = os.Stat(first)
+		assert.True(t, errors.Is(err, os.ErrNotExist))
+
+		// .pos updated
+		assert.Equal(t, second, string(c.pos.Name))
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`seek-to-time`, func(t *T.T) {
+		p := t.TempDir()
+		c, err := Open(WithPath(p))
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, c.Put([]byte(fmt.Sprintf("data-%d", i))))
+			require.NoError(t, c.Rotate())
+		}
+
+		now := time.Now()
+		for i, f := range c.dataFiles {
+			x := now.Add(time.Duration(i-3) * time.Hour)
+			require.NoError(t, os.Chtimes(f, x, x))
+		}
+
+		require.NoError(t, c.SeekToTime(now.Add(-90*time.Minute)))
+		require.NoError(t, c.Get(func(x []byte) error {
+			assert.Equal(t, []byte("data-2"), x)
+			return nil
+		}))
+
+		assert.True(t, errors.Is(c.SeekToTime(now), ErrInvalidSeek))
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+}
@@ -0,0 +1,172 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Segment files under <path>/segments:
+//
+//	.w.<id>       segment that a producer writing on
+//	.w.<id>.lock  lock held by the producer during writing
+//	seg.<id>      sealed segment, ready to adopt by the consumer
+//
+// The id is <create-unix-nano>.<pid>.<seq>, so segments are sorted in
+// create order.
+const (
+	segmentsDirName      = "segments"
+	segmentWritingPrefix = ".w."
+	segmentSealedPrefix  = "seg."
+	segmentLockSuffix    = ".lock"
+)
+
+func segmentsDir(path string) string {
+	return filepath.Join(path, segmentsDirName)
+}
+
+// adoptSegments move sealed segments into data files of the cache. Writing
+// segments of dead producers are sealed and adopted too. The caller should
+// hold the rwlock.
+func (c *DiskCache) adoptSegments() error {
+	dir := segmentsDir(c.path)
+
+	files, err := c.fs.List(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return NewCacheError(OpSwitch, err, "failed_to_list_segments").WithPath(dir)
+	}
+
+	doRecover := isOSStorage(c.fs) && time.Since(c.segmentRecoverAt) >= c.wakeup
+	if doRecover {
+		c.segmentRecoverAt = time.Now()
+	}
+
+	var sealed []string
+	for _, f := range files {
+		base := filepath.Base(f)
+
+		switch {
+		case strings.HasPrefix(base, segmentSealedPrefix):
+			sealed = append(sealed, f)
+
+		case doRecover &&
+			strings.HasPrefix(base, segmentWritingPrefix) &&
+			!strings.HasSuffix(base, segmentLockSuffix):
+			if x := c.recoverSegment(f); x != "" {
+				sealed = append(sealed, x)
+			}
+		}
+	}
+
+	sort.Strings(sealed)
+
+	for _, f := range sealed {
+		newfile, err := c.nextDataFile()
+		if err != nil {
+			return err
+		}
+
+		if err := c.fs.Rename(f, newfile); err != nil {
+			return WrapRotateError(err, c.path, f, newfile).WithDetails("failed_to_adopt_segment")
+		}
+
+		if fi, err := c.fs.Stat(newfile); err == nil {
+			c.size.Add(fi.Size())
+			sizeVec.WithLabelValues(c.path).Add(float64(fi.Size()))
+		}
+
+		c.dataFiles = append(c.dataFiles, newfile)
+		adoptedSegmentsVec.WithLabelValues(c.path).Inc()
+	}
+
+	if len(sealed) > 0 {
+		sort.Strings(c.dataFiles)
+		datafilesVec.WithLabelValues(c.path).Set(float64(len(c.dataFiles)))
+	}
+
+	return nil
+}
+
+// recoverSegment seal writing segment f if it's producer exited, and return
+// the sealed segment. Broken records at the tail of the segment are dropped.
+func (c *DiskCache) recoverSegment(f string) string {
+	fl := &walLock{file: f + segmentLockSuffix}
+	if ok, _ := fl.tryLock(); !ok { // producer still writing
+		return ""
+	}
+
+	defer func() {
+		if err := fl.unlock(); err != nil {
+			l.Warnf("unlock %s: %s", fl.file, err.Error())
+		}
+		_ = os.Remove(fl.file)
+	}()
+
+	fi, err := c.fs.Stat(f)
+	if err != nil { // sealed during the lock
+		return ""
+	}
+
+	end, eof, err := scanDataFile(c.fs, f, 0, nil)
+	if err != nil {
+		l.Warnf("drop %d bytes at tail of segment %s: %s", fi.Size()-end, f, err.Error())
+		droppedDataVec.WithLabelValues(c.path, reasonBadDataFile).Observe(float64(fi.Size() - end))
+	}
+
+	if end == 0 { // nothing written
+		if err := c.fs.Remove(f); err != nil {
+			l.Warnf("remove empty segment %s: %s", f, err.Error())
+		}
+		return ""
+	}
+
+	if !eof {
+		if err := c.fs.Truncate(f, end); err != nil {
+			l.Errorf("truncate segment %s: %s", f, err.Error())
+			return ""
+		}
+
+		fd, err := c.fs.OpenFile(f, os.O_WRONLY|os.O_APPEND, c.filePerms)
+		if err != nil {
+			l.Errorf("open segment %s: %s", f, err.Error())
+			return ""
+		}
+
+		hdr := make([]byte, dataHeaderLen)
+		binary.LittleEndian.PutUint32(hdr, EOFHint)
+		_, err = fd.Write(hdr)
+		if err == nil {
+			err = fd.Sync()
+		}
+
+		if cerr := fd.Close(); err == nil {
+			err = cerr
+		}
+
+		if err != nil {
+			l.Errorf("seal segment %s: %s", f, err.Error())
+			return ""
+		}
+	}
+
+	sealed := filepath.Join(filepath.Dir(f), segmentSealedPrefix+strings.TrimPrefix(filepath.Base(f), segmentWritingPrefix))
+	if err := c.fs.Rename(f, sealed); err != nil {
+		l.Errorf("rename segment %s: %s", f, err.Error())
+		return ""
+	}
+
+	l.Infof("recovered segment %s of exited producer", f)
+	return sealed
+}
@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// File is a opened file within Storage.
+type File interface {
+	io.Reader
+	io.Writer
+	io.Seeker
+	io.Closer
+
+	Name() string
+	Sync() error
+	Stat() (os.FileInfo, error)
+	Truncate(size int64) error
+}
+
+// Storage is the file operations that diskcache stored data on.
+//
+// Names passed to Storage are full paths that joined with the cache path.
+type Storage interface {
+	// OpenFile open file with flags(os.O_xxx), append-only write is opened with os.O_APPEND.
+	OpenFile(name string, flag int, perm os.FileMode) (File, error)
+	Stat(name string) (os.FileInfo, error)
+	Rename(oldpath, newpath string) error
+	Remove(name string) error
+	Truncate(name string, size int64) error
+	MkdirAll(path string, perm os.FileMode) error
+
+	// List return full paths of regular files directly under dir, sorted by name.
+	List(dir string) ([]string, error)
+}
+
+// OSStorage is the Storage on local file system.
+type OSStorage struct{}
+
+var _ Storage = OSStorage{}
+
+// OpenFile implements Storage.
+func (OSStorage) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	return os.OpenFile(filepath.Clean(name), flag, perm)
+}
+
+// Stat implements Storage.
+func (OSStorage) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+// Rename implements Storage.
+func (OSStorage) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+// Remove implements Storage.
+func (OSStorage) Remove(name string) error {
+	return os.Remove(name)
+}
+
+// Truncate implements Storage.
+func (OSStorage) Truncate(name string, size int64) error {
+	return os.Truncate(name, size)
+}
+
+// MkdirAll implements Storage.
+func (OSStorage) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+// List implements Storage.
+func (OSStorage) List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// isOSStorage test if s is(or wrapped) the local file system.
+func isOSStorage(s Storage) bool {
+	for {
+		switch x := s.(type) {
+		case OSStorage, *OSStorage:
+			return true
+		case interface{ Unwrap() Storage }:
+			s = x.Unwrap()
+		default:
+			return false
+		}
+	}
+}
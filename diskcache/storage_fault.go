@@ -0,0 +1,211 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Fault is a error injected into FaultStorage.
+type Fault struct {
+	// Operation to inject, one of OpOpen/OpStat/OpRename/OpRemove/OpTruncate/
+	// OpMkdir/OpList/OpWrite/OpRead/OpSync.
+	Op Operation
+
+	// File(or dir on OpMkdir/OpList) base name contains Match are injected,
+	// empty for all files.
+	Match string
+
+	// Err returned on the operation, such as syscall.ENOSPC or syscall.EIO.
+	Err error
+
+	// ShortWrite only half of the data written before Err(default io.ErrShortWrite) returned.
+	ShortWrite bool
+
+	// Times is how many times the fault injected, 0 for always.
+	Times int
+}
+
+// FaultStorage wraps a Storage and inject faults on its operations, it's
+// used to test error paths.
+type FaultStorage struct {
+	Storage
+
+	mu     sync.Mutex
+	faults []*Fault
+}
+
+var _ Storage = (*FaultStorage)(nil)
+
+// NewFaultStorage wraps s with fault injection.
+func NewFaultStorage(s Storage) *FaultStorage {
+	return &FaultStorage{Storage: s}
+}
+
+// Unwrap return the wrapped Storage.
+func (s *FaultStorage) Unwrap() Storage {
+	return s.Storage
+}
+
+// Inject add a fault.
+func (s *FaultStorage) Inject(f *Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, f)
+}
+
+// Reset remove all faults.
+func (s *FaultStorage) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// fault return the fault matched on op and name.
+func (s *FaultStorage) fault(op Operation, name string) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, f := range s.faults {
+		if f.Op != op || !strings.Contains(filepath.Base(name), f.Match) {
+			continue
+		}
+
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+
+		return f
+	}
+
+	return nil
+}
+
+func (s *FaultStorage) err(op Operation, name string) error {
+	if f := s.fault(op, name); f != nil {
+		return pathError(strings.ToLower(string(op)), name, f.Err)
+	}
+	return nil
+}
+
+// OpenFile implements Storage.
+func (s *FaultStorage) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	if err := s.err(OpOpen, name); err != nil {
+		return nil, err
+	}
+
+	f, err := s.Storage.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &faultFile{File: f, s: s}, nil
+}
+
+// Stat implements Storage.
+func (s *FaultStorage) Stat(name string) (os.FileInfo, error) {
+	if err := s.err(OpStat, name); err != nil {
+		return nil, err
+	}
+	return s.Storage.Stat(name)
+}
+
+// Rename implements Storage.
+func (s *FaultStorage) Rename(oldpath, newpath string) error {
+	if err := s.err(OpRename, oldpath); err != nil {
+		return err
+	}
+	return s.Storage.Rename(oldpath, newpath)
+}
+
+// Remove implements Storage.
+func (s *FaultStorage) Remove(name string) error {
+	if err := s.err(OpRemove, name); err != nil {
+		return err
+	}
+	return s.Storage.Remove(name)
+}
+
+// Truncate implements Storage.
+func (s *FaultStorage) Truncate(name string, size int64) error {
+	if err := s.err(OpTruncate, name); err != nil {
+		return err
+	}
+	return s.Storage.Truncate(name, size)
+}
+
+// MkdirAll implements Storage.
+func (s *FaultStorage) MkdirAll(path string, perm os.FileMode) error {
+	if err := s.err(OpMkdir, path); err != nil {
+		return err
+	}
+	return s.Storage.MkdirAll(path, perm)
+}
+
+// List implements Storage.
+func (s *FaultStorage) List(dir string) ([]string, error) {
+	if err := s.err(OpList, dir); err != nil {
+		return nil, err
+	}
+	return s.Storage.List(dir)
+}
+
+type faultFile struct {
+	File
+	s *FaultStorage
+}
+
+func (f *faultFile) Write(p []byte) (int, error) {
+	x := f.s.fault(OpWrite, f.Name())
+	if x == nil {
+		return f.File.Write(p)
+	}
+
+	if x.ShortWrite {
+		n, err := f.File.Write(p[:len(p)/2])
+		if err != nil {
+			return n, err
+		}
+
+		if x.Err == nil {
+			return n, io.ErrShortWrite
+		}
+
+		return n, pathError("write", f.Name(), x.Err)
+	}
+
+	return 0, pathError("write", f.Name(), x.Err)
+}
+
+func (f *faultFile) Read(p []byte) (int, error) {
+	if err := f.s.err(OpRead, f.Name()); err != nil {
+		return 0, err
+	}
+	return f.File.Read(p)
+}
+
+func (f *faultFile) Sync() error {
+	if err := f.s.err(OpSync, f.Name()); err != nil {
+		return err
+	}
+	return f.File.Sync()
+}
+
+func (f *faultFile) Truncate(size int64) error {
+	if err := f.s.err(OpTruncate, f.Name()); err != nil {
+		return err
+	}
+	return f.File.Truncate(size)
+}
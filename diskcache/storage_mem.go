@@ -0,0 +1,332 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemStorage is a in-memory Storage, all data lost after process exit.
+// It's mainly used for testing.
+type MemStorage struct {
+	mu    sync.Mutex
+	files map[string]*memData
+	dirs  map[string]bool
+}
+
+var _ Storage = (*MemStorage)(nil)
+
+// NewMemStorage create a empty in-memory Storage.
+func NewMemStorage() *MemStorage {
+	return &MemStorage{
+		files: map[string]*memData{},
+		dirs:  map[string]bool{},
+	}
+}
+
+type memData struct {
+	mu      sync.Mutex
+	data    []byte
+	mode    os.FileMode
+	modTime time.Time
+}
+
+type memFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+	dir     bool
+}
+
+func (fi *memFileInfo) Name() string       { return fi.name }
+func (fi *memFileInfo) Size() int64        { return fi.size }
+func (fi *memFileInfo) Mode() os.FileMode  { return fi.mode }
+func (fi *memFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *memFileInfo) IsDir() bool        { return fi.dir }
+func (fi *memFileInfo) Sys() any           { return nil }
+
+func (d *memData) stat(name string) *memFileInfo {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return &memFileInfo{
+		name:    filepath.Base(name),
+		size:    int64(len(d.data)),
+		mode:    d.mode,
+		modTime: d.modTime,
+	}
+}
+
+func pathError(op, name string, err error) error {
+	return &os.PathError{Op: op, Path: name, Err: err}
+}
+
+// OpenFile implements Storage.
+func (s *MemStorage) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	name = filepath.Clean(name)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.dirs[name] {
+		return nil, pathError("open", name, os.ErrInvalid)
+	}
+
+	d, ok := s.files[name]
+	switch {
+	case !ok && flag&os.O_CREATE == 0:
+		return nil, pathError("open", name, os.ErrNotExist)
+	case ok && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, pathError("open", name, os.ErrExist)
+	case !ok:
+		d = &memData{mode: perm, modTime: time.Now()}
+		s.files[name] = d
+	}
+
+	if flag&os.O_TRUNC != 0 {
+		d.mu.Lock()
+		d.data = d.data[:0]
+		d.modTime = time.Now()
+		d.mu.Unlock()
+	}
+
+	return &memFile{name: name, d: d, flag: flag}, nil
+}
+
+// Stat implements Storage.
+func (s *MemStorage) Stat(name string) (os.FileInfo, error) {
+	name = filepath.Clean(name)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.dirs[name] {
+		return &memFileInfo{name: filepath.Base(name), mode: os.ModeDir | 0o750, dir: true}, nil
+	}
+
+	if d, ok := s.files[name]; ok {
+		return d.stat(name), nil
+	}
+
+	return nil, pathError("stat", name, os.ErrNotExist)
+}
+
+// Rename implements Storage.
+func (s *MemStorage) Rename(oldpath, newpath string) error {
+	oldpath, newpath = filepath.Clean(oldpath), filepath.Clean(newpath)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.files[oldpath]
+	if !ok {
+		return pathError("rename", oldpath, os.ErrNotExist)
+	}
+
+	delete(s.files, oldpath)
+	s.files[newpath] = d
+	return nil
+}
+
+// Remove implements Storage.
+func (s *MemStorage) Remove(name string) error {
+	name = filepath.Clean(name)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.files[name]; !ok {
+		return pathError("remove", name, os.ErrNotExist)
+	}
+
+	delete(s.files, name)
+	return nil
+}
+
+// Truncate implements Storage.
+func (s *MemStorage) Truncate(name string, size int64) error {
+	name = filepath.Clean(name)
+
+	s.mu.Lock()
+	d, ok := s.files[name]
+	s.mu.Unlock()
+
+	if !ok {
+		return pathError("truncate", name, os.ErrNotExist)
+	}
+
+	return d.truncate(size)
+}
+
+// MkdirAll implements Storage.
+func (s *MemStorage) MkdirAll(path string, perm os.FileMode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for p := filepath.Clean(path); ; p = filepath.Dir(p) {
+		s.dirs[p] = true
+		if filepath.Dir(p) == p {
+			return nil
+		}
+	}
+}
+
+// List implements Storage.
+func (s *MemStorage) List(dir string) ([]string, error) {
+	dir = filepath.Clean(dir)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.dirs[dir] {
+		return nil, pathError("readdir", dir, os.ErrNotExist)
+	}
+
+	var files []string
+	for name := range s.files {
+		if filepath.Dir(name) == dir {
+			files = append(files, name)
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+func (d *memData) truncate(size int64) error {
+	if size < 0 {
+		return os.ErrInvalid
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if int64(len(d.data)) >= size {
+		d.data = d.data[:size]
+	} else {
+		d.data = append(d.data, make([]byte, size-int64(len(d.data)))...)
+	}
+
+	d.modTime = time.Now()
+	return nil
+}
+
+type memFile struct {
+	name   string
+	d      *memData
+	flag   int
+	off    int64
+	closed bool
+}
+
+func (f *memFile) Name() string { return f.name }
+
+func (f *memFile) Read(p []byte) (int, error) {
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+
+	f.d.mu.Lock()
+	defer f.d.mu.Unlock()
+
+	if f.off >= int64(len(f.d.data)) {
+		return 0, io.EOF
+	}
+
+	n := copy(p, f.d.data[f.off:])
+	f.off += int64(n)
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+
+	if f.flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return 0, pathError("write", f.name, os.ErrPermission)
+	}
+
+	f.d.mu.Lock()
+	defer f.d.mu.Unlock()
+
+	if f.flag&os.O_APPEND != 0 {
+		f.off = int64(len(f.d.data))
+	}
+
+	if end := f.off + int64(len(p)); end > int64(len(f.d.data)) {
+		f.d.data = append(f.d.data, make([]byte, end-int64(len(f.d.data)))...)
+	}
+
+	n := copy(f.d.data[f.off:], p)
+	f.off += int64(n)
+	f.d.modTime = time.Now()
+	return n, nil
+}
+
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+
+	f.d.mu.Lock()
+	defer f.d.mu.Unlock()
+
+	var off int64
+	switch whence {
+	case io.SeekStart:
+		off = offset
+	case io.SeekCurrent:
+		off = f.off + offset
+	case io.SeekEnd:
+		off = int64(len(f.d.data)) + offset
+	default:
+		return 0, os.ErrInvalid
+	}
+
+	if off < 0 {
+		return 0, pathError("seek", f.name, os.ErrInvalid)
+	}
+
+	f.off = off
+	return off, nil
+}
+
+func (f *memFile) Close() error {
+	if f.closed {
+		return os.ErrClosed
+	}
+
+	f.closed = true
+	return nil
+}
+
+func (f *memFile) Sync() error {
+	if f.closed {
+		return os.ErrClosed
+	}
+	return nil
+}
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	if f.closed {
+		return nil, os.ErrClosed
+	}
+	return f.d.stat(f.name), nil
+}
+
+func (f *memFile) Truncate(size int64) error {
+	if f.closed {
+		return os.ErrClosed
+	}
+	return f.d.truncate(size)
+}
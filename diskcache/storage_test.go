@@ -0,0 +1,279 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the MIT License.
+// This product includes software developed at Guance Cloud (https://www.guance.com/).
+// Copyright 2021-present Guance, Inc.
+
+package diskcache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	T "testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemStorage(t *T.T) {
+	t.Run(`put-get-reopen`, func(t *T.T) {
+		ms := NewMemStorage()
+		p := "/mem/cache"
+
+		c, err := Open(WithPath(p), WithStorage(ms), WithBatchSize(1024), WithPosUpdate(0, 0))
+		require.NoError(t, err)
+
+		for i := 0; i < 100; i++ {
+			require.NoError(t, c.Put([]byte(fmt.Sprintf("data-%03d", i))))
+		}
+		require.NoError(t, c.Rotate())
+		assert.True(t, len(c.dataFiles) > 1)
+
+		// consume half of the data
+		for i := 0; i < 50; i++ {
+			require.NoError(t, c.Get(func(x []byte) error {
+				assert.Equal(t, fmt.Sprintf("data-%03d", i), string(x))
+				return nil
+			}))
+		}
+
+		require.NoError(t, c.Close())
+		ResetMetrics()
+
+		files, err := ms.List(p)
+		require.NoError(t, err)
+		assert.Contains(t, files, "/mem/cache/.pos")
+
+		c, err = Open(WithPath(p), WithStorage(ms), WithBatchSize(1024), WithPosUpdate(0, 0))
+		require.NoError(t, err)
+
+		for i := 50; i < 100; i++ {
+			require.NoError(t, c.Get(func(x []byte) error {
+				assert.Equal(t, fmt.Sprintf("data-%03d", i), string(x))
+				return nil
+			}))
+		}
+
+		assert.True(t, errors.Is(c.Get(nil), ErrNoData))
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+
+	t.Run(`list-and-rename`, func(t *T.T) {
+		ms := NewMemStorage()
+		require.NoError(t, ms.MkdirAll("/a/b", 0o750))
+
+		for _, name := range []string{"/a/b/2", "/a/b/1", "/a/x"} {
+			f, err := ms.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+			require.NoError(t, err)
+			_, err = f.Write([]byte("hello"))
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+		}
+
+		files, err := ms.List("/a/b")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/a/b/1", "/a/b/2"}, files)
+
+		require.NoError(t, ms.Rename("/a/b/1", "/a/b/3"))
+		_, err = ms.Stat("/a/b/1")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+
+		fi, err := ms.Stat("/a/b/3")
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), fi.Size())
+
+		require.NoError(t, ms.Truncate("/a/b/3", 2))
+		fi, err = ms.Stat("/a/b/3")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), fi.Size())
+
+		require.NoError(t, ms.Remove("/a/b/3"))
+		assert.Error(t, ms.Remove("/a/b/3"))
+	})
+}
+
+func TestFaultStorage(t *T.T) {
+	open := func(t *T.T, fs *FaultStorage, opts ...CacheOption) *DiskCache {
+		t.Helper()
+
+		c, err := Open(append([]CacheOption{WithPath("/fault/cache"), WithStorage(fs)}, opts...)...)
+		require.NoError(t, err)
+
+		t.Cleanup(func() {
+			fs.Reset()
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+
+		return c
+	}
+
+	t.Run(`enospc-on-write`, func(t *T.T) {
+		fs := NewFaultStorage(NewMemStorage())
+		c := open(t, fs)
+
+		fs.Inject(&Fault{Op: OpWrite, Err: syscall.ENOSPC, Times: 1})
+
+		err := c.Put([]byte("hello"))
+		require.Error(t, err)
+
+		var ce *CacheError
+		require.True(t, errors.As(err, &ce))
+		assert.Equal(t, OpWrite, ce.Operation)
+		assert.ErrorIs(t, err, syscall.ENOSPC)
+		assert.True(t, IsRetryable(err))
+
+		// fault gone
+		require.NoError(t, c.Put([]byte("hello")))
+	})
+
+	t.Run(`short-write`, func(t *T.T) {
+		fs := NewFaultStorage(NewMemStorage())
+		c := open(t, fs)
+
+		fs.Inject(&Fault{Op: OpWrite, Match: "data", ShortWrite: true, Times: 1})
+
+		err := c.Put([]byte("hello"))
+		require.Error(t, err)
+		assert.ErrorIs(t, err, io.ErrShortWrite)
+		assert.False(t, IsRetryable(err))
+
+		fi, err := fs.Stat(c.curWriteFile)
+		require.NoError(t, err)
+		assert.Equal(t, int64(dataHeaderLen/2), fi.Size())
+	})
+
+	t.Run(`eio-on-sync`, func(t *T.T) {
+		fs := NewFaultStorage(NewMemStorage())
+		c := open(t, fs)
+
+		fs.Inject(&Fault{Op: OpSync, Err: syscall.EIO})
+
+		err := c.Put([]byte("hello"))
+		require.Error(t, err)
+
+		var ce *CacheError
+		require.True(t, errors.As(err, &ce))
+		assert.Equal(t, OpSync, ce.Operation)
+		assert.ErrorIs(t, err, syscall.EIO)
+		assert.False(t, IsRetryable(err))
+	})
+
+	t.Run(`eio-on-read`, func(t *T.T) {
+		fs := NewFaultStorage(NewMemStorage())
+		c := open(t, fs)
+
+		require.NoError(t, c.Put([]byte("hello")))
+		require.NoError(t, c.Rotate())
+
+		fs.Inject(&Fault{Op: OpRead, Match: "data.", Err: syscall.EIO, Times: 1})
+
+		// bad file dropped on read error
+		assert.ErrorIs(t, c.Get(nil), ErrNoData)
+		assert.Len(t, c.dataFiles, 0)
+	})
+
+	t.Run(`rename-fail-on-rotate`, func(t *T.T) {
+		fs := NewFaultStorage(NewMemStorage())
+		c := open(t, fs)
+
+		require.NoError(t, c.Put([]byte("hello")))
+
+		fs.Inject(&Fault{Op: OpRename, Err: syscall.EIO, Times: 1})
+
+		err := c.Rotate()
+		require.Error(t, err)
+		assert.ErrorIs(t, err, syscall.EIO)
+		assert.Len(t, c.dataFiles, 0)
+
+		// EOF hint removed from the write file
+		fi, err := fs.Stat(c.curWriteFile)
+		require.NoError(t, err)
+		assert.Equal(t, int64(dataHeaderLen+len("hello")), fi.Size())
+
+		// data still readable after successive rotate
+		require.NoError(t, c.Rotate())
+		require.NoError(t, c.Get(func(x []byte) error {
+			assert.Equal(t, []byte("hello"), x)
+			return nil
+		}))
+	})
+
+	t.Run(`remove-fail-on-switch`, func(t *T.T) {
+		fs := NewFaultStorage(NewMemStorage())
+		c := open(t, fs)
+
+		require.NoError(t, c.Put([]byte("hello")))
+		require.NoError(t, c.Rotate())
+
+		require.NoError(t, c.Get(nil))
+
+		fs.Inject(&Fault{Op: OpRemove, Match: "data.", Err: syscall.EIO, Times: 1})
+
+		err := c.Get(nil)
+		require.Error(t, err)
+
+		var ce *CacheError
+		require.True(t, errors.As(err, &ce))
+		assert.ErrorIs(t, err, syscall.EIO)
+	})
+
+	t.Run(`fail-on-open`, func(t *T.T) {
+		for _, f := range []*Fault{
+			{Op: OpMkdir, Match: "cache", Err: syscall.EACCES},
+			{Op: OpList, Match: "cache", Err: syscall.EIO},
+		} {
+			fs := NewFaultStorage(NewMemStorage())
+			fs.Inject(f)
+
+			_, err := Open(WithPath("/fault/cache"), WithStorage(fs))
+			assert.ErrorIs(t, err, f.Err, "%s", f.Op)
+			ResetMetrics()
+		}
+	})
+
+	t.Run(`truncate-fail-on-repair`, func(t *T.T) {
+		fs := NewFaultStorage(NewMemStorage())
+		c, err := Open(WithPath("/fault/cache"), WithStorage(fs))
+		require.NoError(t, err)
+		require.NoError(t, c.Put([]byte("hello")))
+		require.NoError(t, c.Close())
+		ResetMetrics()
+
+		// crashed with only the header written
+		f, err := fs.OpenFile(c.curWriteFile, os.O_WRONLY|os.O_APPEND, 0o600)
+		require.NoError(t, err)
+		_, err = f.Write([]byte{0x10, 0x00, 0x00, 0x00})
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		fs.Inject(&Fault{Op: OpTruncate, Match: "data", Err: syscall.EIO, Times: 1})
+		_, err = Open(WithPath("/fault/cache"), WithStorage(fs))
+		assert.ErrorIs(t, err, syscall.EIO)
+		ResetMetrics()
+
+		// repaired on next open
+		c, err = Open(WithPath("/fault/cache"), WithStorage(fs))
+		require.NoError(t, err)
+		require.NoError(t, c.Rotate())
+		assert.Equal(t, []string{"hello"}, getAll(t, c))
+
+		t.Cleanup(func() {
+			assert.NoError(t, c.Close())
+			ResetMetrics()
+		})
+	})
+}
+
+func TestIsOSStorage(t *T.T) {
+	assert.True(t, isOSStorage(OSStorage{}))
+	assert.True(t, isOSStorage(NewFaultStorage(OSStorage{})))
+	assert.False(t, isOSStorage(NewMemStorage()))
+	assert.False(t, isOSStorage(NewFaultStorage(NewMemStorage())))
+}
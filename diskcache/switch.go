@@ -15,11 +15,11 @@ import (
 
 // switch to next file remembered in .pos file.
 func (c *DiskCache) loadUnfinishedFile() error {
-	if _, err := os.Stat(c.pos.fname); err != nil {
+	if _, err := c.fs.Stat(c.pos.fname); err != nil {
 		return nil // .pos file not exist
 	}
 
-	pos, err := posFromFile(c.pos.fname)
+	pos, err := posFromFile(c.fs, c.pos.fname)
 	if err != nil {
 		return NewCacheError(OpPos, err, "failed_to_load_position_file").
 			WithPath(c.path).WithFile(c.pos.fname)
@@ -30,7 +30,7 @@ func (c *DiskCache) loadUnfinishedFile() error {
 	}
 
 	// check file's healty
-	if _, err := os.Stat(string(pos.Name)); err != nil { // not exist
+	if _, err := c.fs.Stat(string(pos.Name)); err != nil { // not exist
 		if err := c.pos.reset(); err != nil {
 			return NewCacheError(OpPos, err, "failed_to_reset_position_after_missing_file").
 				WithPath(c.path).WithFile(c.pos.fname)
@@ -44,7 +44,7 @@ func (c *DiskCache) loadUnfinishedFile() error {
 		return nil
 	}
 
-	fd, err := os.OpenFile(string(pos.Name), os.O_RDONLY, c.filePerms)
+	fd, err := c.fs.OpenFile(string(pos.Name), os.O_RDONLY, c.filePerms)
 	if err != nil {
 		return WrapFileOperationError(OpOpen, err, c.path, string(pos.Name)).
 			WithDetails(fmt.Sprintf("failed_to_open_position_file: seek=%d", pos.Seek))
@@ -76,13 +76,25 @@ func (c *DiskCache) doSwitchNextFile() error {
 		}
 	}
 
+	if c.multiProducer {
+		if err := c.adoptSegments(); err != nil {
+			return NewCacheError(OpSwitch, err, "failed_to_adopt_segments").
+				WithPath(c.path)
+		}
+	}
+
+	if err := c.dropExpiredFiles(); err != nil {
+		return NewCacheError(OpSwitch, err, "failed_to_drop_expired_files").
+			WithPath(c.path)
+	}
+
 	if len(c.dataFiles) == 0 {
 		return nil
 	} else {
 		c.curReadfile = c.dataFiles[0]
 	}
 
-	fd, err := os.OpenFile(c.curReadfile, os.O_RDONLY, c.filePerms)
+	fd, err := c.fs.OpenFile(c.curReadfile, os.O_RDONLY, c.filePerms)
 	if err != nil {
 		return WrapFileOperationError(OpOpen, err, c.path, c.curReadfile).
 			WithDetails(fmt.Sprintf("failed_to_open_next_read_file: available_files=%v", c.dataFiles))
@@ -111,9 +123,39 @@ func (c *DiskCache) doSwitchNextFile() error {
 	return nil
 }
 
+// repairWriteFile truncate broken tail of the writing file left by a crash:
+// a header without data(crashed within Put()), or the EOF hint of an
+// unfinished rotate. Data appended after the broken tail can not be read.
+func (c *DiskCache) repairWriteFile() error {
+	fi, err := c.fs.Stat(c.curWriteFile)
+	if err != nil || fi.IsDir() || fi.Size() == 0 {
+		return nil
+	}
+
+	end, eof, err := scanDataFile(c.fs, c.curWriteFile, 0, nil)
+	if err != nil {
+		l.Warnf("drop %d bytes at tail of %s: %s", fi.Size()-end, c.curWriteFile, err.Error())
+		droppedDataVec.WithLabelValues(c.path, reasonBadDataFile).Observe(float64(fi.Size() - end))
+	}
+
+	if eof {
+		if end != fi.Size() { // data after EOF hint, leave it to Get()
+			return nil
+		}
+		end -= dataHeaderLen
+	}
+
+	if end == fi.Size() {
+		return nil
+	}
+
+	l.Infof("truncate %s from %d to %d bytes", c.curWriteFile, fi.Size(), end)
+	return c.fs.Truncate(c.curWriteFile, end)
+}
+
 // open write file.
 func (c *DiskCache) openWriteFile() error {
-	if fi, err := os.Stat(c.curWriteFile); err == nil { // file exists
+	if fi, err := c.fs.Stat(c.curWriteFile); err == nil { // file exists
 		if fi.IsDir() {
 			return NewCacheError(OpCreate, errors.New("data file should not be dir"), "").
 				WithPath(c.path).WithFile(c.curWriteFile)
@@ -126,7 +168,7 @@ func (c *DiskCache) openWriteFile() error {
 	}
 
 	// write append fd, always write to the same-name file
-	wfd, err := os.OpenFile(c.curWriteFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, c.filePerms)
+	wfd, err := c.fs.OpenFile(c.curWriteFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, c.filePerms)
 	if err != nil {
 		return WrapFileOperationError(OpCreate, err, c.path, c.curWriteFile).
 			WithDetails("failed_to_open_write_file")